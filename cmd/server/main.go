@@ -15,6 +15,7 @@ import (
 	"DF-PLCH/internal"
 	"DF-PLCH/internal/config"
 	"DF-PLCH/internal/handlers"
+	"DF-PLCH/internal/models"
 	"DF-PLCH/internal/services"
 	"DF-PLCH/internal/storage"
 
@@ -99,6 +100,9 @@ func main() {
 		documentService.SetLibreOfficeConfig(cfg.LibreOffice.Enabled, cfg.LibreOffice.Path)
 	}
 
+	// Overlay templates embed this font instead of relying on LibreOffice fonts
+	documentService.SetOverlayFontPath(cfg.Overlay.FontPath)
+
 	activityLogService := services.NewActivityLogService()
 	fieldRuleService := services.NewFieldRuleService()
 	entityRuleService := services.NewEntityRuleService()
@@ -106,6 +110,7 @@ func main() {
 	inputTypeService := services.NewInputTypeService()
 	statisticsService := services.NewStatisticsService()
 	filterService := services.NewFilterService()
+	tagService := services.NewTagService()
 
 	// Initialize default field rules if none exist
 	if err := fieldRuleService.InitializeDefaultRules(); err != nil {
@@ -126,6 +131,29 @@ func main() {
 		log.Printf("Warning: Failed to initialize default filters: %v", err)
 	}
 
+	// Start scheduled purge of templates whose trash retention has expired
+	trashRetention := time.Duration(cfg.Trash.RetentionDays) * 24 * time.Hour
+	purgeInterval, err := time.ParseDuration(cfg.Trash.PurgeInterval)
+	if err != nil || purgeInterval <= 0 {
+		log.Printf("Warning: Invalid TEMPLATE_TRASH_PURGE_INTERVAL %q, using 1h", cfg.Trash.PurgeInterval)
+		purgeInterval = time.Hour
+	}
+	trashPurgeScheduler := services.NewTrashPurgeScheduler(templateService, trashRetention, purgeInterval)
+	trashPurgeScheduler.Start()
+
+	// Start scheduled template integrity check (storage objects, placeholders, field definitions)
+	integrityService := services.NewIntegrityService(templateService, storageClient)
+	var integrityScheduler *services.IntegrityCheckScheduler
+	if cfg.Integrity.Enabled {
+		checkInterval, err := time.ParseDuration(cfg.Integrity.CheckInterval)
+		if err != nil || checkInterval <= 0 {
+			log.Printf("Warning: Invalid TEMPLATE_INTEGRITY_CHECK_INTERVAL %q, using 24h", cfg.Integrity.CheckInterval)
+			checkInterval = 24 * time.Hour
+		}
+		integrityScheduler = services.NewIntegrityCheckScheduler(integrityService, checkInterval)
+		integrityScheduler.Start()
+	}
+
 	// Initialize handlers
 	docxHandler := handlers.NewDocxHandler(templateService, documentService, statisticsService)
 	// Set storage info based on storage type
@@ -136,6 +164,65 @@ func main() {
 	}
 	// Set base URL for generating public API URLs
 	docxHandler.SetBaseURL(cfg.Server.BaseURL)
+	docxHandler.SetTrashRetention(trashRetention)
+
+	// Configure template tier enforcement if enabled
+	if cfg.Entitlement.Enabled {
+		var entitlementProvider services.EntitlementProvider = services.NewHeaderEntitlementProvider(models.Tier(cfg.Entitlement.DefaultTier))
+		if cfg.Entitlement.ServiceURL != "" {
+			entitlementProvider = services.NewHTTPEntitlementProvider(cfg.Entitlement.ServiceURL, entitlementProvider)
+			log.Printf("Tier enforcement enabled (entitlement service: %s)", cfg.Entitlement.ServiceURL)
+		} else {
+			log.Printf("Tier enforcement enabled (gateway header: %s)", cfg.Entitlement.TierHeader)
+		}
+		docxHandler.SetEntitlementService(services.NewEntitlementService(entitlementProvider, cfg.Entitlement.UpgradeURL), cfg.Entitlement.TierHeader)
+	}
+	docxHandler.SetUserTemplateService(services.NewUserTemplateService())
+
+	// Mail-merge batch jobs run in memory on a bounded worker pool; jobs interrupted by a restart are marked failed
+	batchService := services.NewBatchService(documentService, storageClient, cfg.Batch.Workers, cfg.Batch.MaxQueued)
+	batchService.FailInterruptedJobs()
+	batchService.Start()
+	docxHandler.SetBatchService(batchService)
+
+	// Outbound webhooks: events are stored as deliveries and sent with retries
+	webhookTimeout, err := time.ParseDuration(cfg.Webhook.Timeout)
+	if err != nil || webhookTimeout <= 0 {
+		log.Printf("Warning: Invalid WEBHOOK_TIMEOUT %q, using 10s", cfg.Webhook.Timeout)
+		webhookTimeout = 10 * time.Second
+	}
+	webhookService := services.NewWebhookService(webhookTimeout, cfg.Webhook.MaxAttempts)
+	webhookService.SetBaseURL(cfg.Server.BaseURL)
+	webhookService.Start()
+	templateService.SetWebhookService(webhookService)
+	docxHandler.SetWebhookService(webhookService)
+
+	// Async document processing: persisted queue drained by a bounded worker pool
+	queuePollInterval, err := time.ParseDuration(cfg.Queue.PollInterval)
+	if err != nil || queuePollInterval <= 0 {
+		log.Printf("Warning: Invalid PROCESS_QUEUE_POLL_INTERVAL %q, using 2s", cfg.Queue.PollInterval)
+		queuePollInterval = 2 * time.Second
+	}
+	documentQueue := services.NewDocumentQueue(documentService, cfg.Queue.Workers, queuePollInterval, cfg.Queue.MaxAttempts)
+	documentQueue.SetWebhookService(webhookService)
+	documentQueue.Start()
+	docxHandler.SetDocumentQueue(documentQueue)
+
+	// Saved form drafts expire after a period without updates
+	draftTTL, err := time.ParseDuration(cfg.Draft.TTL)
+	if err != nil || draftTTL <= 0 {
+		log.Printf("Warning: Invalid FORM_DRAFT_TTL %q, using 720h", cfg.Draft.TTL)
+		draftTTL = 720 * time.Hour
+	}
+	draftPurgeInterval, err := time.ParseDuration(cfg.Draft.PurgeInterval)
+	if err != nil || draftPurgeInterval <= 0 {
+		log.Printf("Warning: Invalid FORM_DRAFT_PURGE_INTERVAL %q, using 1h", cfg.Draft.PurgeInterval)
+		draftPurgeInterval = time.Hour
+	}
+	draftService := services.NewFormDraftService(draftTTL)
+	draftPurgeScheduler := services.NewDraftPurgeScheduler(draftService, draftPurgeInterval)
+	draftPurgeScheduler.Start()
+	docxHandler.SetFormDraftService(draftService)
 	logsHandler := handlers.NewLogsHandler(activityLogService)
 	fieldRuleHandler := handlers.NewFieldRuleHandler(fieldRuleService)
 	entityRuleHandler := handlers.NewEntityRuleHandler(entityRuleService)
@@ -144,6 +231,12 @@ func main() {
 	statisticsHandler := handlers.NewStatisticsHandler(statisticsService)
 	documentTypeHandler := handlers.NewDocumentTypeHandler(documentTypeService)
 	filterHandler := handlers.NewFilterHandler(filterService)
+	tagHandler := handlers.NewTagHandler(tagService)
+	integrityHandler := handlers.NewIntegrityHandler(integrityService)
+	ratingHandler := handlers.NewRatingHandler(services.NewRatingService())
+	fixtureHandler := handlers.NewFixtureHandler(templateService)
+	overlayHandler := handlers.NewOverlayHandler(templateService)
+	webhookHandler := handlers.NewWebhookHandler(webhookService)
 
 	// Initialize Gin router
 	r := gin.Default()
@@ -232,27 +325,89 @@ func main() {
 	{
 		// Template management
 		v1.POST("/upload", docxHandler.UploadTemplate)
+		v1.POST("/upload/overlay", overlayHandler.UploadOverlayTemplate) // Scanned form (PDF/PNG/JPEG background)
 		v1.GET("/templates", docxHandler.GetAllTemplates)
 
 		// Thumbnail regeneration (must be BEFORE :templateId routes)
 		v1.POST("/templates/regenerate-thumbnails", docxHandler.RegenerateThumbnails)
 
+		// Full-text search index rebuild (must be BEFORE :templateId routes)
+		v1.POST("/templates/search/reindex", docxHandler.RebuildSearchIndex)
+
+		// Trash (must be BEFORE :templateId routes)
+		v1.GET("/templates/trash", docxHandler.GetTrash)
+		v1.DELETE("/templates/trash/:templateId", docxHandler.PurgeTemplate)
+
+		// Integrity check (must be BEFORE :templateId routes)
+		v1.POST("/templates/integrity/check", integrityHandler.StartCheck)
+		v1.GET("/templates/integrity/reports", integrityHandler.GetReports)
+		v1.GET("/templates/integrity/reports/:id", integrityHandler.GetReport) // :id may be "latest"
+		v1.POST("/templates/integrity/reports/:id/repair", integrityHandler.RepairReport)
+		v1.GET("/templates/:templateId/integrity", integrityHandler.CheckTemplate)
+		v1.POST("/templates/:templateId/integrity/repair", integrityHandler.RepairTemplate)
+
+		// Regression fixtures (sample data + golden output text)
+		v1.GET("/templates/:templateId/fixtures", fixtureHandler.GetFixtures)
+		v1.POST("/templates/:templateId/fixtures", fixtureHandler.CreateFixture)
+		v1.PUT("/templates/:templateId/fixtures/:fixtureId", fixtureHandler.UpdateFixture)
+		v1.DELETE("/templates/:templateId/fixtures/:fixtureId", fixtureHandler.DeleteFixture)
+		v1.POST("/templates/:templateId/test", fixtureHandler.RunFixtures)
+
+		// Ratings, feedback and issue reports
+		v1.GET("/templates/:templateId/ratings", ratingHandler.GetRatings)
+		v1.PUT("/templates/:templateId/rating", ratingHandler.RateTemplate)
+		v1.DELETE("/templates/:templateId/rating", ratingHandler.DeleteRating)
+		v1.GET("/templates/:templateId/feedback", ratingHandler.GetFeedback)
+		v1.POST("/templates/:templateId/feedback", ratingHandler.SubmitFeedback)
+
+		// Moderation queue of reported templates
+		v1.GET("/moderation/reports", ratingHandler.GetModerationQueue)
+		v1.PATCH("/moderation/reports/:id", ratingHandler.ModerateFeedback)
+
 		v1.GET("/templates/:templateId/placeholders", docxHandler.GetPlaceholders)
+		v1.POST("/templates/:templateId/placeholders/rename", docxHandler.RenamePlaceholders)
+		v1.GET("/templates/:templateId/placeholders/locations", docxHandler.GetPlaceholderLocations)
+		v1.GET("/templates/:templateId/form-fields", docxHandler.GetPDFFormFields)
+		v1.GET("/templates/:templateId/overlay", overlayHandler.GetOverlayLayout)    // Positioning editor: page sizes + field positions
+		v1.PUT("/templates/:templateId/overlay", overlayHandler.UpdateOverlayLayout) // Positioning editor: save all field positions
 		v1.GET("/templates/:templateId/preview", docxHandler.GetHTMLPreview)           // HTML preview (auto-generated from DOCX)
 		v1.GET("/templates/:templateId/preview/pdf", docxHandler.GetPDFPreview)        // PDF preview (auto-generated from DOCX)
 		v1.GET("/templates/:templateId/thumbnail", docxHandler.GetThumbnail)           // Thumbnail image (auto-generated from PDF)
 		v1.PUT("/templates/:templateId", docxHandler.UpdateTemplate)
 		v1.DELETE("/templates/:templateId", docxHandler.DeleteTemplate)
+		v1.POST("/templates/:templateId/restore", docxHandler.RestoreTemplate)
 		v1.POST("/templates/:templateId/files", docxHandler.ReplaceTemplateFiles)
 
 		// Field definitions (auto-detected from placeholders)
 		v1.GET("/templates/:templateId/field-definitions", docxHandler.GetFieldDefinitions)
 		v1.PUT("/templates/:templateId/field-definitions", docxHandler.UpdateFieldDefinitions)
 		v1.POST("/templates/:templateId/field-definitions/regenerate", docxHandler.RegenerateFieldDefinitions)
+		v1.POST("/templates/:templateId/expressions/validate", docxHandler.ValidateExpression) // Computed field expressions
 
 		// Document processing and download
 		v1.POST("/templates/:templateId/process", docxHandler.ProcessDocument)
+		v1.POST("/templates/:templateId/render-preview", docxHandler.RenderPreview) // Filled preview (html, pdf or png), nothing stored
 		v1.GET("/documents/:documentId/download", docxHandler.DownloadDocument)
+		v1.GET("/documents/:documentId/status", docxHandler.GetDocumentStatus) // Async processing status ("async": true on /process)
+
+		// Mail-merge batch generation from CSV/XLSX (202 + job polling)
+		v1.POST("/templates/:templateId/batch", docxHandler.StartBatch)
+		v1.GET("/batch-jobs/:jobId", docxHandler.GetBatchJob)
+		v1.GET("/batch-jobs/:jobId/download", docxHandler.DownloadBatchResult)
+
+		// Per-user favorites and recently used templates
+		v1.GET("/me/templates/favorites", docxHandler.GetFavoriteTemplates)
+		v1.POST("/me/templates/favorites/:templateId", docxHandler.AddFavoriteTemplate)
+		v1.DELETE("/me/templates/favorites/:templateId", docxHandler.RemoveFavoriteTemplate)
+		v1.GET("/me/templates/recent", docxHandler.GetRecentTemplates)
+
+		// Saved form drafts (per user)
+		v1.GET("/me/drafts", docxHandler.GetFormDrafts)
+		v1.GET("/me/drafts/:draftId", docxHandler.GetFormDraft)
+		v1.PUT("/me/drafts/:draftId", docxHandler.UpdateFormDraft)
+		v1.DELETE("/me/drafts/:draftId", docxHandler.DeleteFormDraft)
+		v1.POST("/me/drafts/:draftId/submit", docxHandler.SubmitFormDraft)
+		v1.POST("/templates/:templateId/drafts", docxHandler.CreateFormDraft)
 
 		// User document history
 		v1.GET("/documents/history", docxHandler.GetUserDocumentHistory)
@@ -261,6 +416,22 @@ func main() {
 		v1.POST("/documents/:documentId/regenerate", docxHandler.RegenerateDocument)
 		v1.POST("/regenerate/:documentId", docxHandler.RegenerateDocument) // Alternative path to avoid gateway route conflict
 
+		// Document revisions (partial data patch -> new linked document)
+		v1.POST("/documents/:documentId/revise", docxHandler.ReviseDocument)
+		v1.GET("/documents/:documentId/revisions", docxHandler.GetDocumentRevisions)
+		v1.GET("/documents/:documentId/diff", docxHandler.GetDocumentDiff) // ?against=<documentId>, defaults to the parent
+
+		// Outbound webhooks (HMAC-signed event deliveries with retries and a delivery log)
+		v1.GET("/webhooks", webhookHandler.GetWebhooks)
+		v1.POST("/webhooks", webhookHandler.CreateWebhook)
+		v1.GET("/webhooks/:id", webhookHandler.GetWebhook)
+		v1.PUT("/webhooks/:id", webhookHandler.UpdateWebhook)
+		v1.DELETE("/webhooks/:id", webhookHandler.DeleteWebhook)
+		v1.POST("/webhooks/:id/rotate-secret", webhookHandler.RotateWebhookSecret)
+		v1.POST("/webhooks/:id/ping", webhookHandler.PingWebhook)
+		v1.GET("/webhooks/:id/deliveries", webhookHandler.GetWebhookDeliveries)
+		v1.POST("/webhooks/:id/deliveries/:deliveryId/redeliver", webhookHandler.RedeliverWebhook)
+
 		// Activity logs
 		v1.GET("/logs", logsHandler.GetAllLogs)
 		v1.GET("/logs/stats", logsHandler.GetLogStats)
@@ -363,6 +534,16 @@ func main() {
 		v1.PUT("/filters/options/:id", filterHandler.UpdateOption)
 		v1.DELETE("/filters/options/:id", filterHandler.DeleteOption)
 		v1.POST("/filters/initialize", filterHandler.InitializeDefaultFilters)
+
+		// Tags (cross-cutting template labels)
+		v1.GET("/tags", tagHandler.GetAllTags)
+		v1.POST("/tags", tagHandler.CreateTag)
+		v1.POST("/tags/bulk", tagHandler.BulkTag)
+		v1.GET("/tags/:id", tagHandler.GetTag)
+		v1.PUT("/tags/:id", tagHandler.UpdateTag)
+		v1.DELETE("/tags/:id", tagHandler.DeleteTag)
+		v1.GET("/templates/:templateId/tags", tagHandler.GetTemplateTags)
+		v1.PUT("/templates/:templateId/tags", tagHandler.SetTemplateTags)
 	}
 
 	// Create HTTP server with increased timeouts for document processing
@@ -398,6 +579,16 @@ func main() {
 		log.Printf("Server forced to shutdown: %v", err)
 	}
 
+	// Stop background jobs
+	documentQueue.Stop()
+	batchService.Stop()
+	webhookService.Stop()
+	trashPurgeScheduler.Stop()
+	draftPurgeScheduler.Stop()
+	if integrityScheduler != nil {
+		integrityScheduler.Stop()
+	}
+
 	// Close database connection
 	if err := internal.CloseDB(); err != nil {
 		log.Printf("Error closing database: %v", err)
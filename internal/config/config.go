@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strconv"
 
 	"github.com/joho/godotenv"
 )
@@ -15,6 +16,57 @@ type Config struct {
 	GCS         GCSConfig         `json:"gcs"`
 	Gotenberg   GotenbergConfig   `json:"gotenberg"`
 	LibreOffice LibreOfficeConfig `json:"libreoffice"`
+	Entitlement EntitlementConfig `json:"entitlement"`
+	Trash       TrashConfig       `json:"trash"`
+	Integrity   IntegrityConfig   `json:"integrity"`
+	Overlay     OverlayConfig     `json:"overlay"`
+	Queue       QueueConfig       `json:"queue"`
+	Batch       BatchConfig       `json:"batch"`
+	Webhook     WebhookConfig     `json:"webhook"`
+	Draft       DraftConfig       `json:"draft"`
+}
+
+type TrashConfig struct {
+	RetentionDays int    `json:"retention_days"` // Days a deleted template stays in the trash before it is purged
+	PurgeInterval string `json:"purge_interval"` // How often the purge job runs (Go duration, e.g., "1h")
+}
+
+type IntegrityConfig struct {
+	Enabled       bool   `json:"enabled"`        // Run the template integrity check on a schedule
+	CheckInterval string `json:"check_interval"` // How often the check runs (Go duration, e.g., "24h")
+}
+
+type EntitlementConfig struct {
+	Enabled     bool   `json:"enabled"`      // Enforce template tier access on processing, download and preview
+	TierHeader  string `json:"tier_header"`  // Gateway header carrying the user's tier (e.g., "X-User-Tier")
+	DefaultTier string `json:"default_tier"` // Tier assumed when the gateway does not send one
+	ServiceURL  string `json:"service_url"`  // Optional external entitlement service (overrides the header when set)
+	UpgradeURL  string `json:"upgrade_url"`  // URL returned in upgrade hints
+}
+
+type OverlayConfig struct {
+	FontPath string `json:"font_path"` // TrueType font for overlay templates (the compiled-in Thai font if empty)
+}
+
+type QueueConfig struct {
+	Workers      int    `json:"workers"`       // Documents processed concurrently by the async queue
+	PollInterval string `json:"poll_interval"` // How often idle workers check for due jobs (Go duration, e.g., "2s")
+	MaxAttempts  int    `json:"max_attempts"`  // Attempts before an async document is marked failed
+}
+
+type BatchConfig struct {
+	Workers   int `json:"workers"`    // Mail-merge batch jobs run concurrently
+	MaxQueued int `json:"max_queued"` // Batch jobs waiting for a worker before new uploads are refused
+}
+
+type WebhookConfig struct {
+	Timeout     string `json:"timeout"`      // Request timeout of a delivery attempt (Go duration, e.g., "10s")
+	MaxAttempts int    `json:"max_attempts"` // Attempts before a delivery is marked failed
+}
+
+type DraftConfig struct {
+	TTL           string `json:"ttl"`            // How long a form draft is kept after its last update (Go duration, e.g., "720h")
+	PurgeInterval string `json:"purge_interval"` // How often expired drafts are deleted (Go duration, e.g., "1h")
 }
 
 type LibreOfficeConfig struct {
@@ -139,6 +191,41 @@ func Load() (*Config, error) {
 			Enabled: getEnv("LIBREOFFICE_ENABLED", "false") == "true",
 			Path:    getEnv("LIBREOFFICE_PATH", ""), // Auto-detected if empty
 		},
+		Entitlement: EntitlementConfig{
+			Enabled:     getEnv("TIER_ENFORCEMENT_ENABLED", "false") == "true",
+			TierHeader:  getEnv("TIER_HEADER", "X-User-Tier"),
+			DefaultTier: getEnv("TIER_DEFAULT", "free"),
+			ServiceURL:  getEnv("ENTITLEMENT_SERVICE_URL", ""),
+			UpgradeURL:  getEnv("TIER_UPGRADE_URL", ""),
+		},
+		Trash: TrashConfig{
+			RetentionDays: getEnvInt("TEMPLATE_TRASH_RETENTION_DAYS", 30),
+			PurgeInterval: getEnv("TEMPLATE_TRASH_PURGE_INTERVAL", "1h"),
+		},
+		Integrity: IntegrityConfig{
+			Enabled:       getEnv("TEMPLATE_INTEGRITY_CHECK_ENABLED", "true") == "true",
+			CheckInterval: getEnv("TEMPLATE_INTEGRITY_CHECK_INTERVAL", "24h"),
+		},
+		Overlay: OverlayConfig{
+			FontPath: getEnv("OVERLAY_FONT_PATH", ""),
+		},
+		Queue: QueueConfig{
+			Workers:      getEnvInt("PROCESS_QUEUE_WORKERS", 4),
+			PollInterval: getEnv("PROCESS_QUEUE_POLL_INTERVAL", "2s"),
+			MaxAttempts:  getEnvInt("PROCESS_QUEUE_MAX_ATTEMPTS", 3),
+		},
+		Batch: BatchConfig{
+			Workers:   getEnvInt("BATCH_WORKERS", 1),
+			MaxQueued: getEnvInt("BATCH_MAX_QUEUED", 20),
+		},
+		Webhook: WebhookConfig{
+			Timeout:     getEnv("WEBHOOK_TIMEOUT", "10s"),
+			MaxAttempts: getEnvInt("WEBHOOK_MAX_ATTEMPTS", 8),
+		},
+		Draft: DraftConfig{
+			TTL:           getEnv("FORM_DRAFT_TTL", "720h"),
+			PurgeInterval: getEnv("FORM_DRAFT_PURGE_INTERVAL", "1h"),
+		},
 	}
 
 	return config, nil
@@ -150,3 +237,10 @@ func getEnv(key, defaultValue string) string {
 	}
 	return defaultValue
 }
+
+func getEnvInt(key string, defaultValue int) int {
+	if value, err := strconv.Atoi(os.Getenv(key)); err == nil {
+		return value
+	}
+	return defaultValue
+}
@@ -113,6 +113,7 @@ func autoMigrate() error {
 		"placeholders":      "ALTER TABLE document_templates ADD COLUMN placeholders jsonb",
 		"aliases":           "ALTER TABLE document_templates ADD COLUMN aliases jsonb",
 		"field_definitions": "ALTER TABLE document_templates ADD COLUMN field_definitions jsonb",
+		"translations":      "ALTER TABLE document_templates ADD COLUMN translations jsonb",
 		"original_source":   "ALTER TABLE document_templates ADD COLUMN original_source text",
 		"remarks":           "ALTER TABLE document_templates ADD COLUMN remarks text",
 		"is_verified":       "ALTER TABLE document_templates ADD COLUMN is_verified boolean DEFAULT false",
@@ -121,6 +122,9 @@ func autoMigrate() error {
 		"tier":              "ALTER TABLE document_templates ADD COLUMN tier varchar(20)",
 		"group":             "ALTER TABLE document_templates ADD COLUMN \"group\" text",
 		"page_orientation":  "ALTER TABLE document_templates ADD COLUMN page_orientation varchar(20) DEFAULT 'portrait'",
+		"format":            "ALTER TABLE document_templates ADD COLUMN format varchar(20) DEFAULT 'docx'",
+		"rating_average":    "ALTER TABLE document_templates ADD COLUMN rating_average double precision DEFAULT 0",
+		"rating_count":      "ALTER TABLE document_templates ADD COLUMN rating_count int DEFAULT 0",
 		"created_at":        "ALTER TABLE document_templates ADD COLUMN created_at timestamp(3) NULL",
 		"updated_at":        "ALTER TABLE document_templates ADD COLUMN updated_at timestamp(3) NULL",
 		"deleted_at":        "ALTER TABLE document_templates ADD COLUMN deleted_at timestamp(3) NULL",
@@ -132,6 +136,11 @@ func autoMigrate() error {
 		}
 	}
 
+	// PDF form templates were identified by MIME type before the format column existed
+	if err := DB.Exec(`UPDATE document_templates SET format = 'pdf_form' WHERE mime_type = 'application/pdf' AND (format IS NULL OR format = 'docx')`).Error; err != nil {
+		return fmt.Errorf("failed to backfill template formats: %w", err)
+	}
+
 	fmt.Println("Creating documents table if not exists...")
 	result = DB.Exec(`
         CREATE TABLE IF NOT EXISTS documents (
@@ -190,6 +199,10 @@ func autoMigrate() error {
 		"mime_type":     "ALTER TABLE documents ADD COLUMN mime_type text",
 		"data":          "ALTER TABLE documents ADD COLUMN data jsonb",
 		"status":        "ALTER TABLE documents ADD COLUMN status varchar(191) DEFAULT 'completed'",
+		"flattened":     "ALTER TABLE documents ADD COLUMN flattened boolean DEFAULT false",
+		"parent_id":     "ALTER TABLE documents ADD COLUMN parent_id varchar(191)",
+		"root_id":       "ALTER TABLE documents ADD COLUMN root_id varchar(191)",
+		"revision":      "ALTER TABLE documents ADD COLUMN revision int DEFAULT 1",
 		"created_at":    "ALTER TABLE documents ADD COLUMN created_at timestamp(3) NULL",
 		"updated_at":    "ALTER TABLE documents ADD COLUMN updated_at timestamp(3) NULL",
 		"deleted_at":    "ALTER TABLE documents ADD COLUMN deleted_at timestamp(3) NULL",
@@ -200,6 +213,8 @@ func autoMigrate() error {
 			return err
 		}
 	}
+	DB.Exec("CREATE INDEX IF NOT EXISTS idx_documents_parent_id ON documents(parent_id)")
+	DB.Exec("CREATE INDEX IF NOT EXISTS idx_documents_root_id ON documents(root_id)")
 
 	fmt.Println("Creating activity_logs table if not exists...")
 	result = DB.Exec(`
@@ -504,6 +519,313 @@ func autoMigrate() error {
 	DB.Exec("CREATE INDEX IF NOT EXISTS idx_filter_options_is_active ON filter_options(is_active)")
 	DB.Exec("CREATE INDEX IF NOT EXISTS idx_filter_options_sort_order ON filter_options(sort_order)")
 
+	// Create template_search_documents table for full-text search over template content
+	fmt.Println("Creating template_search_documents table if not exists...")
+	result = DB.Exec(`
+        CREATE TABLE IF NOT EXISTS template_search_documents (
+            template_id varchar(191) PRIMARY KEY,
+            body_text text,
+            search_vector tsvector,
+            indexed_at timestamp(3) NULL
+        )
+    `)
+	if result.Error != nil {
+		return fmt.Errorf("failed to create template_search_documents table: %w", result.Error)
+	}
+
+	// GIN index for tsvector matching
+	DB.Exec("CREATE INDEX IF NOT EXISTS idx_template_search_documents_vector ON template_search_documents USING GIN(search_vector)")
+
+	// Create tags table for cross-cutting template labels
+	fmt.Println("Creating tags table if not exists...")
+	result = DB.Exec(`
+        CREATE TABLE IF NOT EXISTS tags (
+            id varchar(191) PRIMARY KEY,
+            slug varchar(100) NOT NULL,
+            name text NOT NULL,
+            name_en text,
+            description text,
+            color varchar(20),
+            sort_order int DEFAULT 0,
+            created_at timestamp(3) NULL,
+            updated_at timestamp(3) NULL,
+            deleted_at timestamp(3) NULL
+        )
+    `)
+	if result.Error != nil {
+		return fmt.Errorf("failed to create tags table: %w", result.Error)
+	}
+
+	DB.Exec("CREATE INDEX IF NOT EXISTS idx_tags_deleted_at ON tags(deleted_at)")
+
+	// Slugs are unique among live tags only, so a deleted tag's slug can be reused
+	DB.Exec("ALTER TABLE tags DROP CONSTRAINT IF EXISTS tags_slug_key")
+	DB.Exec("CREATE UNIQUE INDEX IF NOT EXISTS idx_tags_slug ON tags(slug) WHERE deleted_at IS NULL")
+	DB.Exec("CREATE INDEX IF NOT EXISTS idx_tags_sort_order ON tags(sort_order)")
+
+	// Create template_tags join table (many-to-many between templates and tags)
+	fmt.Println("Creating template_tags table if not exists...")
+	result = DB.Exec(`
+        CREATE TABLE IF NOT EXISTS template_tags (
+            template_id varchar(191) NOT NULL,
+            tag_id varchar(191) NOT NULL,
+            created_at timestamp(3) NULL,
+            PRIMARY KEY (template_id, tag_id)
+        )
+    `)
+	if result.Error != nil {
+		return fmt.Errorf("failed to create template_tags table: %w", result.Error)
+	}
+
+	DB.Exec("CREATE INDEX IF NOT EXISTS idx_template_tags_tag_id ON template_tags(tag_id)")
+
+	// Create template_integrity_reports table for integrity check runs
+	fmt.Println("Creating template_integrity_reports table if not exists...")
+	result = DB.Exec(`
+        CREATE TABLE IF NOT EXISTS template_integrity_reports (
+            id varchar(191) PRIMARY KEY,
+            status varchar(20) DEFAULT 'running',
+            triggered_by varchar(20),
+            templates_checked int DEFAULT 0,
+            issue_count int DEFAULT 0,
+            issues jsonb,
+            error text,
+            started_at timestamp(3) NULL,
+            finished_at timestamp(3) NULL
+        )
+    `)
+	if result.Error != nil {
+		return fmt.Errorf("failed to create template_integrity_reports table: %w", result.Error)
+	}
+
+	DB.Exec("CREATE INDEX IF NOT EXISTS idx_template_integrity_reports_started_at ON template_integrity_reports(started_at)")
+
+	// Create template_favorites table for per-user starred templates
+	fmt.Println("Creating template_favorites table if not exists...")
+	result = DB.Exec(`
+        CREATE TABLE IF NOT EXISTS template_favorites (
+            user_id varchar(191) NOT NULL,
+            template_id varchar(191) NOT NULL,
+            created_at timestamp(3) NULL,
+            PRIMARY KEY (user_id, template_id)
+        )
+    `)
+	if result.Error != nil {
+		return fmt.Errorf("failed to create template_favorites table: %w", result.Error)
+	}
+
+	DB.Exec("CREATE INDEX IF NOT EXISTS idx_template_favorites_template_id ON template_favorites(template_id)")
+
+	// Create user_template_usage table for per-user form submit counts
+	fmt.Println("Creating user_template_usage table if not exists...")
+	result = DB.Exec(`
+        CREATE TABLE IF NOT EXISTS user_template_usage (
+            user_id varchar(191) NOT NULL,
+            template_id varchar(191) NOT NULL,
+            use_count bigint DEFAULT 0,
+            last_used_at timestamp(3) NULL,
+            PRIMARY KEY (user_id, template_id)
+        )
+    `)
+	if result.Error != nil {
+		return fmt.Errorf("failed to create user_template_usage table: %w", result.Error)
+	}
+
+	DB.Exec("CREATE INDEX IF NOT EXISTS idx_user_template_usage_last_used ON user_template_usage(user_id, last_used_at)")
+
+	// Create template_ratings table for per-user star ratings
+	fmt.Println("Creating template_ratings table if not exists...")
+	result = DB.Exec(`
+        CREATE TABLE IF NOT EXISTS template_ratings (
+            id varchar(191) PRIMARY KEY,
+            template_id varchar(191) NOT NULL,
+            user_id varchar(191) NOT NULL,
+            rating int NOT NULL,
+            comment text,
+            created_at timestamp(3) NULL,
+            updated_at timestamp(3) NULL,
+            UNIQUE (template_id, user_id)
+        )
+    `)
+	if result.Error != nil {
+		return fmt.Errorf("failed to create template_ratings table: %w", result.Error)
+	}
+
+	// Create template_feedback table for text feedback and issue reports
+	fmt.Println("Creating template_feedback table if not exists...")
+	result = DB.Exec(`
+        CREATE TABLE IF NOT EXISTS template_feedback (
+            id varchar(191) PRIMARY KEY,
+            template_id varchar(191) NOT NULL,
+            user_id varchar(191),
+            kind varchar(20) DEFAULT 'feedback',
+            field_key text,
+            message text NOT NULL,
+            status varchar(20) DEFAULT 'open',
+            moderator_id varchar(191),
+            moderator_note text,
+            resolved_at timestamp(3) NULL,
+            created_at timestamp(3) NULL,
+            updated_at timestamp(3) NULL
+        )
+    `)
+	if result.Error != nil {
+		return fmt.Errorf("failed to create template_feedback table: %w", result.Error)
+	}
+
+	DB.Exec("CREATE INDEX IF NOT EXISTS idx_template_feedback_template_id ON template_feedback(template_id)")
+	DB.Exec("CREATE INDEX IF NOT EXISTS idx_template_feedback_status ON template_feedback(kind, status)")
+
+	// Create template_fixtures table for regression test datasets
+	fmt.Println("Creating template_fixtures table if not exists...")
+	result = DB.Exec(`
+        CREATE TABLE IF NOT EXISTS template_fixtures (
+            id varchar(191) PRIMARY KEY,
+            template_id varchar(191) NOT NULL,
+            name varchar(191) NOT NULL,
+            data jsonb,
+            expected_text text,
+            created_by varchar(191),
+            last_run_at timestamp(3) NULL,
+            last_passed boolean,
+            created_at timestamp(3) NULL,
+            updated_at timestamp(3) NULL,
+            UNIQUE (template_id, name)
+        )
+    `)
+	if result.Error != nil {
+		return fmt.Errorf("failed to create template_fixtures table: %w", result.Error)
+	}
+
+	// Create batch_jobs table for mail-merge runs
+	fmt.Println("Creating batch_jobs table if not exists...")
+	result = DB.Exec(`
+        CREATE TABLE IF NOT EXISTS batch_jobs (
+            id varchar(191) PRIMARY KEY,
+            template_id varchar(191) NOT NULL,
+            user_id varchar(191),
+            status varchar(20) DEFAULT 'queued',
+            output varchar(10),
+            format varchar(10),
+            filename_pattern text,
+            source_filename text,
+            total_rows int DEFAULT 0,
+            processed_rows int DEFAULT 0,
+            succeeded_rows int DEFAULT 0,
+            failed_rows int DEFAULT 0,
+            column_map jsonb,
+            row_errors jsonb,
+            gcs_path_result text,
+            result_size bigint DEFAULT 0,
+            error text,
+            created_at timestamp(3) NULL,
+            started_at timestamp(3) NULL,
+            finished_at timestamp(3) NULL
+        )
+    `)
+	if result.Error != nil {
+		return fmt.Errorf("failed to create batch_jobs table: %w", result.Error)
+	}
+
+	DB.Exec("CREATE INDEX IF NOT EXISTS idx_batch_jobs_template_id ON batch_jobs(template_id)")
+	DB.Exec("CREATE INDEX IF NOT EXISTS idx_batch_jobs_user_id ON batch_jobs(user_id)")
+
+	// Create document_jobs table, the persisted queue of async document processing
+	fmt.Println("Creating document_jobs table if not exists...")
+	result = DB.Exec(`
+        CREATE TABLE IF NOT EXISTS document_jobs (
+            document_id varchar(191) PRIMARY KEY,
+            template_id varchar(191) NOT NULL,
+            user_id varchar(191),
+            status varchar(20) DEFAULT 'queued',
+            attempts int DEFAULT 0,
+            flatten boolean DEFAULT false,
+            warnings jsonb,
+            error text,
+            available_at timestamp(3) NOT NULL DEFAULT now(),
+            locked_at timestamp(3) NULL,
+            finished_at timestamp(3) NULL,
+            created_at timestamp(3) NULL,
+            updated_at timestamp(3) NULL
+        )
+    `)
+	if result.Error != nil {
+		return fmt.Errorf("failed to create document_jobs table: %w", result.Error)
+	}
+
+	DB.Exec("ALTER TABLE document_jobs ADD COLUMN IF NOT EXISTS organization_id varchar(191)")
+	DB.Exec("CREATE INDEX IF NOT EXISTS idx_document_jobs_status_available_at ON document_jobs(status, available_at)")
+	DB.Exec("CREATE INDEX IF NOT EXISTS idx_document_jobs_user_id ON document_jobs(user_id)")
+
+	// Create webhooks and webhook_deliveries tables for outbound event subscriptions
+	fmt.Println("Creating webhooks tables if not exists...")
+	result = DB.Exec(`
+        CREATE TABLE IF NOT EXISTS webhooks (
+            id varchar(191) PRIMARY KEY,
+            name text,
+            url text NOT NULL,
+            secret text NOT NULL,
+            events jsonb,
+            active boolean DEFAULT true,
+            created_by varchar(191),
+            created_at timestamp(3) NULL,
+            updated_at timestamp(3) NULL
+        )
+    `)
+	if result.Error != nil {
+		return fmt.Errorf("failed to create webhooks table: %w", result.Error)
+	}
+
+	result = DB.Exec(`
+        CREATE TABLE IF NOT EXISTS webhook_deliveries (
+            id varchar(191) PRIMARY KEY,
+            webhook_id varchar(191) NOT NULL REFERENCES webhooks(id) ON DELETE CASCADE,
+            event varchar(50),
+            event_id varchar(191),
+            payload jsonb,
+            status varchar(20) DEFAULT 'pending',
+            attempts int DEFAULT 0,
+            response_status int DEFAULT 0,
+            response_body text,
+            error text,
+            duration_ms bigint DEFAULT 0,
+            next_attempt_at timestamp(3) NULL,
+            locked_at timestamp(3) NULL,
+            delivered_at timestamp(3) NULL,
+            created_at timestamp(3) NULL,
+            updated_at timestamp(3) NULL
+        )
+    `)
+	if result.Error != nil {
+		return fmt.Errorf("failed to create webhook_deliveries table: %w", result.Error)
+	}
+
+	DB.Exec("CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_webhook_id ON webhook_deliveries(webhook_id, created_at DESC)")
+	DB.Exec("CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_pending ON webhook_deliveries(next_attempt_at) WHERE status = 'pending'")
+	DB.Exec("CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_event_id ON webhook_deliveries(event_id)")
+
+	// Create form_drafts table for users' saved, partially filled forms
+	fmt.Println("Creating form_drafts table if not exists...")
+	result = DB.Exec(`
+        CREATE TABLE IF NOT EXISTS form_drafts (
+            id varchar(191) PRIMARY KEY,
+            user_id varchar(191) NOT NULL,
+            template_id varchar(191) NOT NULL,
+            name text,
+            data jsonb,
+            template_version varchar(64),
+            expires_at timestamp(3) NOT NULL,
+            created_at timestamp(3) NULL,
+            updated_at timestamp(3) NULL
+        )
+    `)
+	if result.Error != nil {
+		return fmt.Errorf("failed to create form_drafts table: %w", result.Error)
+	}
+
+	DB.Exec("CREATE INDEX IF NOT EXISTS idx_form_drafts_user_id ON form_drafts(user_id, updated_at DESC)")
+	DB.Exec("CREATE INDEX IF NOT EXISTS idx_form_drafts_expires_at ON form_drafts(expires_at)")
+
 	fmt.Println("Tables created/verified successfully")
 	return nil
 }
@@ -0,0 +1,128 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"DF-PLCH/internal/models"
+	"DF-PLCH/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SetBatchService enables mail-merge batch generation
+func (h *DocxHandler) SetBatchService(batchService *services.BatchService) {
+	h.batchService = batchService
+}
+
+// batchErrorStatus maps batch service errors to HTTP status codes
+func batchErrorStatus(err error) int {
+	if errors.Is(err, services.ErrInvalidBatch) {
+		return http.StatusBadRequest
+	}
+	if errors.Is(err, services.ErrBatchQueueFull) {
+		return http.StatusServiceUnavailable
+	}
+	if strings.Contains(err.Error(), "unauthorized") {
+		return http.StatusForbidden
+	}
+	if strings.Contains(err.Error(), "not found") {
+		return http.StatusNotFound
+	}
+	return http.StatusInternalServerError
+}
+
+// batchJobResponse adds the download URL of a completed job
+func batchJobResponse(job *models.BatchJob) models.BatchJobResponse {
+	response := job.ToResponse()
+	if job.Status == models.BatchStatusCompleted {
+		response.DownloadURL = fmt.Sprintf("/api/v1/batch-jobs/%s/download", job.ID)
+	}
+	return response
+}
+
+// StartBatch generates one document per row of an uploaded CSV/XLSX (multipart field "file")
+// Form fields: output (zip|pdf), format (docx|pdf, for ZIP entries), filenamePattern (e.g. "cert_{{name}}")
+// Returns 202 with the job; poll GET /api/v1/batch-jobs/:jobId for progress
+// POST /api/v1/templates/:templateId/batch
+func (h *DocxHandler) StartBatch(c *gin.Context) {
+	if h.batchService == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Batch generation is not available"})
+		return
+	}
+
+	template, err := h.templateService.GetTemplate(c.Param("templateId"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Template not found"})
+		return
+	}
+	if !h.enforceTierAccess(c, template) {
+		return
+	}
+
+	file, header, err := c.Request.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "No spreadsheet uploaded (expected a .csv or .xlsx in 'file')"})
+		return
+	}
+	defer file.Close()
+
+	opts := services.BatchOptions{
+		Output:          strings.ToLower(c.PostForm("output")),
+		Format:          strings.ToLower(c.PostForm("format")),
+		FilenamePattern: c.PostForm("filenamePattern"),
+	}
+
+	job, err := h.batchService.StartBatch(c.Request.Context(), template, file, header, c.GetHeader("X-User-ID"), opts)
+	if err != nil {
+		c.JSON(batchErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, batchJobResponse(job))
+}
+
+// GetBatchJob returns a batch job's status, progress and per-row errors
+// GET /api/v1/batch-jobs/:jobId
+func (h *DocxHandler) GetBatchJob(c *gin.Context) {
+	if h.batchService == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Batch generation is not available"})
+		return
+	}
+
+	job, err := h.batchService.GetJob(c.Param("jobId"), c.GetHeader("X-User-ID"))
+	if err != nil {
+		c.JSON(batchErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, batchJobResponse(job))
+}
+
+// DownloadBatchResult streams the ZIP or merged PDF of a completed batch job
+// GET /api/v1/batch-jobs/:jobId/download
+func (h *DocxHandler) DownloadBatchResult(c *gin.Context) {
+	if h.batchService == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Batch generation is not available"})
+		return
+	}
+
+	reader, filename, mimeType, err := h.batchService.GetResultReader(c.Request.Context(), c.Param("jobId"), c.GetHeader("X-User-ID"))
+	if err != nil {
+		c.JSON(batchErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	defer reader.Close()
+
+	c.Header("Content-Description", "File Transfer")
+	c.Header("Content-Transfer-Encoding", "binary")
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s", filename))
+	c.Header("Content-Type", mimeType)
+
+	if _, err := io.Copy(c.Writer, reader); err != nil {
+		fmt.Printf("Error streaming batch result: %v\n", err)
+	}
+}
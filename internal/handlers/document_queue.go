@@ -0,0 +1,145 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"DF-PLCH/internal/models"
+	"DF-PLCH/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SetDocumentQueue enables asynchronous processing ("async": true on /process)
+func (h *DocxHandler) SetDocumentQueue(documentQueue *services.DocumentQueue) {
+	h.documentQueue = documentQueue
+}
+
+// AsyncProcessResponse is returned with 202 when a document is queued
+type AsyncProcessResponse struct {
+	DocumentID string   `json:"document_id"`
+	Status     string   `json:"status"`
+	StatusURL  string   `json:"status_url"`
+	Message    string   `json:"message"`
+	Warnings   []string `json:"warnings,omitempty"`  // Fields filled in, cleared or ignored before processing
+	ParentID   string   `json:"parent_id,omitempty"` // Revisions only: the revised document
+	Revision   int      `json:"revision,omitempty"`  // Revisions only: number in the revision chain
+}
+
+// DocumentStatusResponse is the processing state of a document
+type DocumentStatusResponse struct {
+	DocumentID     string     `json:"document_id"`
+	TemplateID     string     `json:"template_id"`
+	Status         string     `json:"status"` // queued, processing, completed, failed or expired
+	Error          string     `json:"error,omitempty"`
+	Attempts       int        `json:"attempts,omitempty"`
+	Warnings       []string   `json:"warnings,omitempty"`
+	DownloadURL    string     `json:"download_url,omitempty"`
+	DownloadPDFURL string     `json:"download_pdf_url,omitempty"`
+	ExpiresAt      string     `json:"expires_at,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+	FinishedAt     *time.Time `json:"finished_at,omitempty"`
+}
+
+// processDocumentAsync queues a validated process request and responds with 202
+// Returns the queued document's ID, or "" after an error response
+func (h *DocxHandler) processDocumentAsync(c *gin.Context, template *models.Template, req ProcessRequest, parentID string, data map[string]string, userID string, warnings []string) string {
+	if h.documentQueue == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Asynchronous processing is not available"})
+		return ""
+	}
+
+	document, err := h.documentQueue.Enqueue(template, data, userID, services.ProcessOptions{Flatten: req.Flatten, OrganizationID: req.OrganizationID, ParentID: parentID})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Failed to queue document: %v", err)})
+		return ""
+	}
+
+	h.recordFormSubmit(template.ID, userID)
+
+	statusURL := fmt.Sprintf("/api/v1/documents/%s/status", document.ID)
+	c.Header("Location", statusURL)
+	response := AsyncProcessResponse{
+		DocumentID: document.ID,
+		Status:     document.Status,
+		StatusURL:  statusURL,
+		Message:    "Document queued for processing. Poll the status URL until it is completed or failed.",
+		Warnings:   warnings,
+	}
+	if document.ParentID != "" {
+		response.ParentID = document.ParentID
+		response.Revision = document.Revision
+	}
+	c.JSON(http.StatusAccepted, response)
+	return document.ID
+}
+
+// GetDocumentStatus returns the processing status of a document
+// Download URLs are included once the document is completed
+// GET /api/v1/documents/:documentId/status
+func (h *DocxHandler) GetDocumentStatus(c *gin.Context) {
+	documentID := c.Param("documentId")
+	if documentID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Document ID is required"})
+		return
+	}
+
+	// Security: Verify ownership when the user is authenticated
+	var document *models.Document
+	var err error
+	if userID := c.GetHeader("X-User-ID"); userID != "" {
+		document, err = h.documentService.GetDocumentWithAuth(documentID, userID)
+	} else {
+		document, err = h.documentService.GetDocument(documentID)
+	}
+	if err != nil {
+		if strings.Contains(err.Error(), "unauthorized") {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Access denied"})
+			return
+		}
+		c.JSON(http.StatusNotFound, gin.H{"error": "Document not found"})
+		return
+	}
+
+	response := DocumentStatusResponse{
+		DocumentID: document.ID,
+		TemplateID: document.TemplateID,
+		Status:     document.Status,
+		CreatedAt:  document.CreatedAt,
+	}
+
+	// Documents processed synchronously have no job
+	finishedAt := document.UpdatedAt
+	if h.documentQueue != nil {
+		job, err := h.documentQueue.GetJob(document.ID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if job != nil {
+			response.Attempts = job.Attempts
+			response.Warnings = job.GetWarnings()
+			response.FinishedAt = job.FinishedAt
+			if job.FinishedAt != nil {
+				finishedAt = *job.FinishedAt
+			}
+			if document.Status != models.DocumentStatusCompleted {
+				response.Error = job.Error
+			}
+		}
+	}
+
+	if document.Status == models.DocumentStatusCompleted {
+		if document.GCSPathDocx != "" {
+			response.DownloadURL = fmt.Sprintf("/api/v1/documents/%s/download", document.ID)
+		}
+		if document.GCSPathPdf != "" {
+			response.DownloadPDFURL = fmt.Sprintf("/api/v1/documents/%s/download?format=pdf", document.ID)
+		}
+		response.ExpiresAt = finishedAt.Add(10 * time.Minute).Format(time.RFC3339)
+	}
+
+	c.JSON(http.StatusOK, response)
+}
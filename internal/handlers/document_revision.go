@@ -0,0 +1,147 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"DF-PLCH/internal/models"
+	"DF-PLCH/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// revisionErrorStatus maps revision errors to HTTP status codes
+func revisionErrorStatus(err error) int {
+	if errors.Is(err, services.ErrInvalidRevision) {
+		return http.StatusBadRequest
+	}
+	if errors.Is(err, services.ErrDocumentPending) {
+		return http.StatusConflict
+	}
+	if strings.Contains(err.Error(), "unauthorized") {
+		return http.StatusForbidden
+	}
+	if strings.Contains(err.Error(), "not found") {
+		return http.StatusNotFound
+	}
+	return http.StatusInternalServerError
+}
+
+// ReviseDocument produces a new revision of a document from its stored data with a partial patch
+// applied. The body takes the /process options; its data (in any form /process accepts) only needs
+// the fields that change. The new document links to the revised one by parent_id and gets the next
+// revision number of the chain. Flattened documents stay flattened
+// POST /api/v1/documents/:documentId/revise
+func (h *DocxHandler) ReviseDocument(c *gin.Context) {
+	documentID := c.Param("documentId")
+	if documentID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Document ID is required"})
+		return
+	}
+
+	userID := c.GetHeader("X-User-ID")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+		return
+	}
+
+	var req ProcessRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid JSON"})
+		return
+	}
+	if len(req.Data) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Data is required: send the fields to change"})
+		return
+	}
+
+	document, err := h.documentService.GetRevisableDocument(documentID, userID)
+	if err != nil {
+		c.JSON(revisionErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	template, err := h.templateService.GetTemplate(document.TemplateID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Template not found"})
+		return
+	}
+
+	if !h.enforceTierAccess(c, template) {
+		return
+	}
+
+	patch, err := services.ResolveProcessData(template, req.Data)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidProcessData) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse template placeholders"})
+		return
+	}
+
+	data, err := services.ReviseData(template, document, patch)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	req.Data = make(map[string]interface{}, len(data))
+	for placeholder, value := range data {
+		req.Data[placeholder] = value
+	}
+	req.Flatten = req.Flatten || document.Flattened
+
+	h.processRequest(c, template, req, document.ID)
+}
+
+// GetDocumentRevisions lists the revision chain a document belongs to, oldest first
+// GET /api/v1/documents/:documentId/revisions
+func (h *DocxHandler) GetDocumentRevisions(c *gin.Context) {
+	documentID := c.Param("documentId")
+	if documentID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Document ID is required"})
+		return
+	}
+
+	userID := c.GetHeader("X-User-ID")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+		return
+	}
+
+	revisions, err := h.documentService.GetRevisions(documentID, userID)
+	if err != nil {
+		c.JSON(revisionErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"revisions": revisions, "total": len(revisions)})
+}
+
+// GetDocumentDiff returns the field-level changes from another revision of the same chain to a document
+// Query: against (document ID, defaults to the document's parent), lang
+// GET /api/v1/documents/:documentId/diff
+func (h *DocxHandler) GetDocumentDiff(c *gin.Context) {
+	documentID := c.Param("documentId")
+	if documentID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Document ID is required"})
+		return
+	}
+
+	userID := c.GetHeader("X-User-ID")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+		return
+	}
+
+	lang := models.ResolveLanguage(c.Query("lang"), c.GetHeader("Accept-Language"))
+	diff, err := h.documentService.DiffRevisions(documentID, c.Query("against"), userID, lang)
+	if err != nil {
+		c.JSON(revisionErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, diff)
+}
@@ -3,6 +3,7 @@ package handlers
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"mime/multipart"
@@ -20,11 +21,19 @@ import (
 )
 
 type DocxHandler struct {
-	templateService   *services.TemplateService
-	documentService   *services.DocumentService
-	statisticsService *services.StatisticsService
-	storageInfo       string // Storage identifier (bucket name for GCS, path for local)
-	baseURL           string // Base URL for generating public URLs
+	templateService    *services.TemplateService
+	documentService    *services.DocumentService
+	statisticsService  *services.StatisticsService
+	userTemplates      *services.UserTemplateService
+	batchService       *services.BatchService
+	draftService       *services.FormDraftService
+	documentQueue      *services.DocumentQueue      // Optional: enables async processing
+	webhookService     *services.WebhookService     // Optional: publishes document.* events
+	entitlementService *services.EntitlementService // Optional: enforces template tiers when set
+	tierHeader         string                       // Gateway header carrying the user's tier
+	storageInfo        string                       // Storage identifier (bucket name for GCS, path for local)
+	baseURL            string                       // Base URL for generating public URLs
+	trashRetention     time.Duration                // How long deleted templates stay in the trash
 }
 
 func NewDocxHandler(templateService *services.TemplateService, documentService *services.DocumentService, statisticsService *services.StatisticsService) *DocxHandler {
@@ -45,13 +54,124 @@ func (h *DocxHandler) SetBaseURL(url string) {
 	h.baseURL = url
 }
 
+// SetTrashRetention sets how long deleted templates stay in the trash (used to report purge dates)
+func (h *DocxHandler) SetTrashRetention(retention time.Duration) {
+	h.trashRetention = retention
+}
+
+// SetUserTemplateService enables per-user favorites and recent template tracking
+func (h *DocxHandler) SetUserTemplateService(userTemplates *services.UserTemplateService) {
+	h.userTemplates = userTemplates
+}
+
+// SetEntitlementService enables tier enforcement using the given service and gateway header
+func (h *DocxHandler) SetEntitlementService(entitlementService *services.EntitlementService, tierHeader string) {
+	h.entitlementService = entitlementService
+	h.tierHeader = tierHeader
+}
+
+// resolveUserTier returns the caller's tier from the entitlement provider
+func (h *DocxHandler) resolveUserTier(c *gin.Context) (models.Tier, error) {
+	return h.entitlementService.ResolveTier(c.Request.Context(), c.GetHeader("X-User-ID"), c.GetHeader(h.tierHeader))
+}
+
+// enforceTierAccess checks that the caller's tier allows the template
+// Returns false after writing a 402/403 response if access is denied
+func (h *DocxHandler) enforceTierAccess(c *gin.Context, template *models.Template) bool {
+	if h.entitlementService == nil {
+		return true
+	}
+
+	userTier, err := h.resolveUserTier(c)
+	if errors.Is(err, services.ErrEntitlementUnavailable) {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Unable to verify your tier, try again later"})
+		return false
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Failed to resolve user tier: %v", err)})
+		return false
+	}
+
+	if accessErr := h.entitlementService.CheckAccess(template, userTier); accessErr != nil {
+		c.JSON(accessErr.StatusCode, accessErr)
+		return false
+	}
+
+	return true
+}
+
+// enforceDocumentTierAccess checks the tier of the template a document was generated from
+// Access is denied when the check cannot be made (document or template not found)
+func (h *DocxHandler) enforceDocumentTierAccess(c *gin.Context, documentID string) bool {
+	if h.entitlementService == nil {
+		return true
+	}
+
+	document, err := h.documentService.GetDocument(documentID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Document not found"})
+		return false
+	}
+
+	// Trashed templates still decide access to documents generated from them
+	template, err := h.templateService.GetTemplateUnscoped(document.TemplateID)
+	if err != nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Access denied: the document's template could not be verified"})
+		return false
+	}
+
+	return h.enforceTierAccess(c, template)
+}
+
+// markLockedTemplates flags templates the caller cannot use instead of hiding them
+func (h *DocxHandler) markLockedTemplates(c *gin.Context, responses []models.TemplateResponse) {
+	if h.entitlementService == nil {
+		return
+	}
+
+	userTier, err := h.resolveUserTier(c)
+	if err != nil {
+		// Show templates as they would appear to the lowest tier rather than unlocking them
+		fmt.Printf("Warning: failed to resolve user tier for template list: %v\n", err)
+		userTier = models.TierFree
+	}
+
+	for i := range responses {
+		responses[i].IsLocked = !userTier.Allows(responses[i].Tier)
+	}
+}
+
+// markFavoriteTemplates sets IsFavorite on list responses for the calling user
+func (h *DocxHandler) markFavoriteTemplates(c *gin.Context, responses []models.TemplateResponse) {
+	userID := c.GetHeader("X-User-ID")
+	if h.userTemplates == nil || userID == "" || len(responses) == 0 {
+		return
+	}
+
+	templateIDs := make([]string, len(responses))
+	for i := range responses {
+		templateIDs[i] = responses[i].ID
+	}
+	favorites, err := h.userTemplates.FavoriteTemplateIDs(userID, templateIDs)
+	if err != nil {
+		fmt.Printf("Warning: failed to load favorites for template list: %v\n", err)
+		return
+	}
+	for i := range responses {
+		responses[i].IsFavorite = favorites[responses[i].ID]
+	}
+}
+
 type PlaceholderResponse struct {
 	Placeholders []string `json:"placeholders"`
 }
 
 type ProcessRequest struct {
-	Data           map[string]string `json:"data"`
-	OrganizationID string            `json:"organization_id,omitempty"`
+	Data           map[string]interface{} `json:"data"` // Keyed by placeholder, key or alias, or nested by entity/group; see services.ResolveProcessData
+	OrganizationID string                 `json:"organization_id,omitempty"`
+	Flatten        bool                   `json:"flatten,omitempty"` // Flatten filled PDF form fields (PDF form templates only)
+	Async          bool                   `json:"async,omitempty"`   // Queue the document and return 202; poll GET /documents/:id/status
+	Mode           string                 `json:"mode,omitempty"`    // strict (default), defaults or lenient; see services.ApplyProcessingMode
 }
 
 type UploadResponse struct {
@@ -65,20 +185,24 @@ type UploadResponse struct {
 }
 
 type ProcessResponse struct {
-	DocumentID     string `json:"document_id"`
-	DownloadURL    string `json:"download_url"`
-	DownloadPDFURL string `json:"download_pdf_url,omitempty"`
-	ExpiresAt      string `json:"expires_at"`
-	Message        string `json:"message"`
+	DocumentID     string   `json:"document_id"`
+	DownloadURL    string   `json:"download_url"`
+	DownloadPDFURL string   `json:"download_pdf_url,omitempty"`
+	ExpiresAt      string   `json:"expires_at"`
+	Message        string   `json:"message"`
+	Warnings       []string `json:"warnings,omitempty"`
+	ParentID       string   `json:"parent_id,omitempty"` // Revisions only: the revised document
+	Revision       int      `json:"revision,omitempty"`  // Revisions only: number in the revision chain
 }
 
 type ValidationError struct {
-	Missing []string `json:"missing,omitempty"`
-	Extra   []string `json:"extra,omitempty"`
+	Missing []string              `json:"missing,omitempty"`
+	Extra   []string              `json:"extra,omitempty"`
+	Fields  []services.FieldError `json:"fields,omitempty"` // Values that break their field definition
 }
 
 func (e *ValidationError) HasErrors() bool {
-	return len(e.Missing) > 0 || len(e.Extra) > 0
+	return len(e.Missing) > 0 || len(e.Extra) > 0 || len(e.Fields) > 0
 }
 
 func (e *ValidationError) Error() string {
@@ -89,34 +213,10 @@ func (e *ValidationError) Error() string {
 	if len(e.Extra) > 0 {
 		parts = append(parts, fmt.Sprintf("unknown placeholders: %v", e.Extra))
 	}
-	return strings.Join(parts, "; ")
-}
-
-// validateRequestData checks if the request data matches the template placeholders
-func (h *DocxHandler) validateRequestData(templatePlaceholders []string, requestData map[string]string) *ValidationError {
-	validationErr := &ValidationError{}
-
-	// Create a set of template placeholders for fast lookup
-	placeholderSet := make(map[string]bool)
-	for _, p := range templatePlaceholders {
-		placeholderSet[p] = true
-	}
-
-	// Check for missing placeholders (in template but not in request)
-	for _, placeholder := range templatePlaceholders {
-		if _, exists := requestData[placeholder]; !exists {
-			validationErr.Missing = append(validationErr.Missing, placeholder)
-		}
-	}
-
-	// Check for extra keys (in request but not in template)
-	for key := range requestData {
-		if !placeholderSet[key] {
-			validationErr.Extra = append(validationErr.Extra, key)
-		}
+	if len(e.Fields) > 0 {
+		parts = append(parts, fmt.Sprintf("invalid fields: %s", services.FieldErrorsSummary(e.Fields)))
 	}
-
-	return validationErr
+	return strings.Join(parts, "; ")
 }
 
 func (h *DocxHandler) UploadTemplate(c *gin.Context) {
@@ -127,8 +227,10 @@ func (h *DocxHandler) UploadTemplate(c *gin.Context) {
 	}
 	defer file.Close()
 
-	if filepath.Ext(header.Filename) != ".docx" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Only .docx files are supported"})
+	// Fillable PDF forms are accepted alongside DOCX templates
+	isPDFForm := strings.ToLower(filepath.Ext(header.Filename)) == ".pdf"
+	if filepath.Ext(header.Filename) != ".docx" && !isPDFForm {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Only .docx files and fillable .pdf forms are supported"})
 		return
 	}
 
@@ -177,8 +279,17 @@ func (h *DocxHandler) UploadTemplate(c *gin.Context) {
 		}
 	}
 
-	template, err := h.templateService.UploadTemplateWithHTMLPreview(c.Request.Context(), file, header, htmlFile, htmlHeader, fileName, description, author, aliases)
+	var template *models.Template
+	if isPDFForm {
+		template, err = h.templateService.UploadPDFFormTemplate(c.Request.Context(), file, header, fileName, description, author, aliases)
+	} else {
+		template, err = h.templateService.UploadTemplateWithHTMLPreview(c.Request.Context(), file, header, htmlFile, htmlHeader, fileName, description, author, aliases)
+	}
 	if err != nil {
+		if errors.Is(err, services.ErrInvalidPDFForm) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Failed to upload template: %v", err)})
 		return
 	}
@@ -222,8 +333,16 @@ func (h *DocxHandler) GetAllTemplates(c *gin.Context) {
 	isVerifiedStr := c.Query("is_verified")
 	includeDocumentType := c.Query("include_document_type") == "true"
 	grouped := c.Query("grouped") == "true"
-	sort := c.Query("sort")       // "popular", "recent", "name"
+	sort := c.Query("sort")       // "popular", "recent", "name", "top_rated"
 	limitStr := c.Query("limit")  // Limit number of results
+	tagsParam := c.Query("tags")  // Comma-separated tag slugs
+	matchAllTags := c.Query("tag_match") == "all"
+	cursor := c.Query("cursor")                   // Cursor from the previous page's next_cursor
+	pageSizeStr := c.Query("page_size")           // Enables cursor pagination
+	fields := splitQueryList(c.Query("fields"))   // Sparse fieldset: only these fields
+	exclude := splitQueryList(c.Query("exclude")) // Sparse fieldset: all fields except these
+	lang := requestLanguage(c)                    // From ?lang= or Accept-Language
+	boostRecent := c.Query("boost_recent") == "true"
 
 	// If grouped view is requested, return templates grouped by document type
 	if grouped {
@@ -234,10 +353,16 @@ func (h *DocxHandler) GetAllTemplates(c *gin.Context) {
 		}
 
 		// Convert to clean response format
-		c.JSON(http.StatusOK, models.GroupedTemplatesResponse{
-			DocumentTypes:   models.ToResponseListDocTypes(documentTypes),
-			OrphanTemplates: models.ToResponseList(orphanTemplates),
-		})
+		response := models.GroupedTemplatesResponse{
+			DocumentTypes:   models.ToLocalizedResponseListDocTypes(documentTypes, lang),
+			OrphanTemplates: models.ToLocalizedResponseList(orphanTemplates, lang),
+		}
+		for i := range response.DocumentTypes {
+			h.markLockedTemplates(c, response.DocumentTypes[i].Templates)
+		}
+		h.markLockedTemplates(c, response.OrphanTemplates)
+
+		c.JSON(http.StatusOK, response)
 		return
 	}
 
@@ -247,17 +372,39 @@ func (h *DocxHandler) GetAllTemplates(c *gin.Context) {
 		fmt.Sscanf(limitStr, "%d", &limit)
 	}
 
-	// If any filter/sort is specified, use filtered query
-	if documentTypeID != "" || templateType != "" || tier != "" || category != "" || search != "" || isVerifiedStr != "" || includeDocumentType || sort != "" || limit > 0 {
+	// Parse tag slugs
+	tags := splitQueryList(tagsParam)
+
+	// Parse page size (cursor without page_size uses the default page size)
+	var pageSize int
+	if pageSizeStr != "" {
+		fmt.Sscanf(pageSizeStr, "%d", &pageSize)
+	}
+	if cursor != "" && pageSize <= 0 {
+		pageSize = defaultTemplatePageSize
+	}
+	if pageSize > maxTemplatePageSize {
+		pageSize = maxTemplatePageSize
+	}
+
+	// If any filter/sort/pagination is specified, use filtered query
+	if documentTypeID != "" || templateType != "" || tier != "" || category != "" || search != "" || len(tags) > 0 || isVerifiedStr != "" || includeDocumentType || sort != "" || limit > 0 || pageSize > 0 || boostRecent {
 		filter := &services.TemplateFilter{
 			DocumentTypeID:      documentTypeID,
 			Type:                templateType,
 			Tier:                tier,
 			Category:            category,
 			Search:              search,
+			Tags:                tags,
+			MatchAllTags:        matchAllTags,
 			IncludeDocumentType: includeDocumentType,
 			Sort:                sort,
 			Limit:               limit,
+			PageSize:            pageSize,
+			Cursor:              cursor,
+		}
+		if boostRecent {
+			filter.BoostUserID = c.GetHeader("X-User-ID")
 		}
 
 		// Parse is_verified boolean
@@ -266,16 +413,29 @@ func (h *DocxHandler) GetAllTemplates(c *gin.Context) {
 			filter.IsVerified = &isVerified
 		}
 
-		templates, err := h.templateService.GetTemplatesWithFilter(filter)
+		page, err := h.templateService.GetTemplatesWithFilter(filter)
 		if err != nil {
+			if errors.Is(err, services.ErrInvalidCursor) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
 			c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Failed to get templates: %v", err)})
 			return
 		}
 
 		// Convert to clean response format
-		c.JSON(http.StatusOK, models.TemplateListResponse{
-			Templates: models.ToResponseList(templates),
-		})
+		responses := models.ToLocalizedResponseList(page.Templates, lang)
+		h.markLockedTemplates(c, responses)
+		h.markFavoriteTemplates(c, responses)
+		if search != "" {
+			h.attachSearchHighlights(responses, search)
+		}
+		writeTemplateList(c, models.TemplateListResponse{
+			Templates:  responses,
+			Total:      page.Total,
+			NextCursor: page.NextCursor,
+			HasMore:    page.NextCursor != "",
+		}, fields, exclude)
 		return
 	}
 
@@ -287,11 +447,102 @@ func (h *DocxHandler) GetAllTemplates(c *gin.Context) {
 	}
 
 	// Convert to clean response format
-	c.JSON(http.StatusOK, models.TemplateListResponse{
-		Templates: models.ToResponseList(templates),
+	responses := models.ToLocalizedResponseList(templates, lang)
+	h.markLockedTemplates(c, responses)
+	h.markFavoriteTemplates(c, responses)
+	writeTemplateList(c, models.TemplateListResponse{
+		Templates: responses,
+		Total:     int64(len(responses)),
+	}, fields, exclude)
+}
+
+const (
+	defaultTemplatePageSize = 20
+	maxTemplatePageSize     = 100
+)
+
+// requestLanguage picks the response language from ?lang= or the Accept-Language header
+// and reports it back in Content-Language
+func requestLanguage(c *gin.Context) string {
+	lang := models.ResolveLanguage(c.Query("lang"), c.GetHeader("Accept-Language"))
+	c.Header("Content-Language", lang)
+	return lang
+}
+
+// splitQueryList parses a comma-separated query parameter, dropping empty entries
+func splitQueryList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// writeTemplateList writes a template list, applying a sparse fieldset when fields or exclude is set
+// "id" is always included so clients can fetch the full template later
+func writeTemplateList(c *gin.Context, list models.TemplateListResponse, fields, exclude []string) {
+	if len(fields) == 0 && len(exclude) == 0 {
+		c.JSON(http.StatusOK, list)
+		return
+	}
+
+	keep := make(map[string]bool, len(fields))
+	for _, field := range fields {
+		keep[field] = true
+	}
+	drop := make(map[string]bool, len(exclude))
+	for _, field := range exclude {
+		drop[field] = true
+	}
+
+	templates := make([]map[string]interface{}, len(list.Templates))
+	for i := range list.Templates {
+		data, err := json.Marshal(list.Templates[i])
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Failed to encode template: %v", err)})
+			return
+		}
+		var full map[string]interface{}
+		json.Unmarshal(data, &full)
+
+		sparse := make(map[string]interface{}, len(full))
+		for key, value := range full {
+			if key != "id" && ((len(keep) > 0 && !keep[key]) || drop[key]) {
+				continue
+			}
+			sparse[key] = value
+		}
+		templates[i] = sparse
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"templates":   templates,
+		"total":       list.Total,
+		"next_cursor": list.NextCursor,
+		"has_more":    list.HasMore,
 	})
 }
 
+// attachSearchHighlights adds body text snippets matching the search term to each template
+func (h *DocxHandler) attachSearchHighlights(responses []models.TemplateResponse, search string) {
+	templateIDs := make([]string, len(responses))
+	for i := range responses {
+		templateIDs[i] = responses[i].ID
+	}
+
+	highlights, err := h.templateService.GetSearchHighlights(templateIDs, search)
+	if err != nil {
+		fmt.Printf("[WARNING] Failed to get search highlights: %v\n", err)
+		return
+	}
+
+	for i := range responses {
+		responses[i].Highlights = highlights[responses[i].ID]
+	}
+}
+
 func (h *DocxHandler) GetPlaceholders(c *gin.Context) {
 	templateID := c.Param("templateId")
 	if templateID == "" {
@@ -312,6 +563,49 @@ func (h *DocxHandler) GetPlaceholders(c *gin.Context) {
 	c.JSON(http.StatusOK, response)
 }
 
+// GetPlaceholderLocations returns where each placeholder occurrence is in the DOCX (part, paragraph,
+// table cell, page) and its bounding box in the PDF preview, plus the fields in layout order
+// GET /api/v1/templates/:templateId/placeholders/locations?pdf=false
+func (h *DocxHandler) GetPlaceholderLocations(c *gin.Context) {
+	withPDF := c.DefaultQuery("pdf", "true") != "false"
+
+	locations, err := h.templateService.GetPlaceholderLocations(c.Request.Context(), c.Param("templateId"), withPDF)
+	if err != nil {
+		if errors.Is(err, services.ErrDocxOnly) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if strings.Contains(err.Error(), "not found") {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Template not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Failed to locate placeholders: %v", err)})
+		return
+	}
+
+	c.JSON(http.StatusOK, locations)
+}
+
+// GetPDFFormFields returns the AcroForm fields of a PDF form template (names, types, options, positions)
+// GET /api/v1/templates/:templateId/form-fields
+func (h *DocxHandler) GetPDFFormFields(c *gin.Context) {
+	fields, err := h.templateService.GetPDFFormFields(c.Request.Context(), c.Param("templateId"))
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidPDFForm) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if strings.Contains(err.Error(), "not found") {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Template not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Failed to read form fields: %v", err)})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"fields": fields})
+}
+
 func (h *DocxHandler) GetHTMLPreview(c *gin.Context) {
 	templateID := c.Param("templateId")
 	if templateID == "" {
@@ -325,6 +619,10 @@ func (h *DocxHandler) GetHTMLPreview(c *gin.Context) {
 		return
 	}
 
+	if !h.enforceTierAccess(c, template) {
+		return
+	}
+
 	if template.GCSPathHTML == "" {
 		c.JSON(http.StatusNotFound, gin.H{"error": "No HTML preview available for this template"})
 		return
@@ -355,6 +653,10 @@ func (h *DocxHandler) GetPDFPreview(c *gin.Context) {
 		return
 	}
 
+	if !h.enforceTierAccess(c, template) {
+		return
+	}
+
 	// If PDF doesn't exist, try to generate it on-the-fly
 	if template.GCSPathPDF == "" {
 		fmt.Printf("[INFO] PDF preview not found for template %s, generating on-the-fly...\n", templateID)
@@ -396,7 +698,7 @@ func (h *DocxHandler) GetPDFPreview(c *gin.Context) {
 // GetThumbnail returns the thumbnail image for a template
 // Query params:
 //   - quality: "normal" (default) or "hd" for pixel-perfect rendering
-//   - width: pixel width (default 300 for normal, 800 for HD)
+//   - width: pixel width (default 300 for normal, 800 for HD, at most 2000)
 func (h *DocxHandler) GetThumbnail(c *gin.Context) {
 	templateID := c.Param("templateId")
 	if templateID == "" {
@@ -415,6 +717,11 @@ func (h *DocxHandler) GetThumbnail(c *gin.Context) {
 
 	// For HD quality, generate on-demand from PDF
 	if quality == "hd" {
+		// HD thumbnails render the full PDF, so they are gated like the other previews
+		if !h.enforceTierAccess(c, template) {
+			return
+		}
+
 		// Check if PDF exists
 		if template.GCSPathPDF == "" {
 			c.JSON(http.StatusNotFound, gin.H{"error": "PDF not found for HD thumbnail generation"})
@@ -425,7 +732,7 @@ func (h *DocxHandler) GetThumbnail(c *gin.Context) {
 		width := 800
 		if widthStr := c.Query("width"); widthStr != "" {
 			if w, err := strconv.Atoi(widthStr); err == nil && w > 0 {
-				width = w
+				width = min(w, maxPreviewWidth)
 			}
 		}
 
@@ -486,38 +793,68 @@ func (h *DocxHandler) ProcessDocument(c *gin.Context) {
 		return
 	}
 
-	// Get template placeholders for validation
-	placeholders, err := h.templateService.GetPlaceholders(templateID)
+	template, err := h.templateService.GetTemplate(templateID)
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Template not found"})
 		return
 	}
 
-	// Validate request data against template placeholders
-	if validationErr := h.validateRequestData(placeholders, req.Data); validationErr.HasErrors() {
+	if !h.enforceTierAccess(c, template) {
+		return
+	}
+
+	h.processRequest(c, template, req, "")
+}
+
+// processRequest resolves, expands, checks and processes request data for a template and writes
+// the response. parentID is the document being revised, or "" for a new document.
+// Returns the ID of the processed or queued document, or "" after an error response
+func (h *DocxHandler) processRequest(c *gin.Context, template *models.Template, req ProcessRequest, parentID string) string {
+	// Resolve, expand, apply the processing mode, compute and validate the request data
+	lang := models.ResolveLanguage(c.Query("lang"), c.GetHeader("Accept-Language"))
+	prepared, err := services.PrepareProcessData(template, req.Data, req.Mode, lang, time.Now())
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidProcessData) || errors.Is(err, services.ErrInvalidProcessingMode) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return ""
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse template placeholders"})
+		return ""
+	}
+	if len(prepared.Missing) > 0 || len(prepared.Extra) > 0 {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"error":   "Request data does not match template placeholders",
-			"details": validationErr,
+			"details": &ValidationError{Missing: prepared.Missing, Extra: prepared.Extra},
 		})
-		return
+		return ""
+	}
+	if len(prepared.FieldErrors) > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Request data failed field validation",
+			"details": &ValidationError{Fields: prepared.FieldErrors},
+		})
+		return ""
 	}
+	data, warnings := prepared.Data, prepared.Warnings
 
 	// Get user ID from X-User-ID header (set by API gateway)
 	userID := c.GetHeader("X-User-ID")
 
-	document, err := h.documentService.ProcessDocument(c.Request.Context(), templateID, req.Data, userID)
+	if req.Async {
+		return h.processDocumentAsync(c, template, req, parentID, data, userID, warnings)
+	}
+
+	document, err := h.documentService.ProcessDocumentWithOptions(c.Request.Context(), template.ID, data, userID, services.ProcessOptions{Flatten: req.Flatten, ParentID: parentID})
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Failed to process document: %v", err)})
-		return
+		return ""
 	}
 
-	// Record form submission statistics
-	if h.statisticsService != nil {
-		go func() {
-			if err := h.statisticsService.RecordFormSubmit(templateID); err != nil {
-				fmt.Printf("Warning: failed to record form submit statistics: %v\n", err)
-			}
-		}()
+	h.recordFormSubmit(template.ID, userID)
+
+	// Notify subscribed webhooks (e.g. the organization service and DMS)
+	if h.webhookService != nil {
+		go h.webhookService.Publish(models.WebhookEventDocumentCompleted, h.webhookService.DocumentEventData(document, req.OrganizationID))
 	}
 
 	// Create temporary download link that expires in 10 minutes
@@ -527,6 +864,11 @@ func (h *DocxHandler) ProcessDocument(c *gin.Context) {
 		DownloadURL: fmt.Sprintf("/api/v1/documents/%s/download", document.ID),
 		ExpiresAt:   expiresAt.Format(time.RFC3339),
 		Message:     "Document processed successfully. File will be deleted after 10 minutes. You can regenerate from history anytime.",
+		Warnings:    append(warnings, document.Warnings...),
+	}
+	if document.ParentID != "" {
+		response.ParentID = document.ParentID
+		response.Revision = document.Revision
 	}
 
 	// Schedule file deletion after 10 minutes
@@ -546,66 +888,94 @@ func (h *DocxHandler) ProcessDocument(c *gin.Context) {
 	}
 
 	c.JSON(http.StatusOK, response)
+	return document.ID
 }
 
-// registerDocumentWithOrganization calls the organization service to register the document
-func (h *DocxHandler) registerDocumentWithOrganization(ctx context.Context, orgID string, document *models.Document, userID string, authToken string) {
-	// This will be called asynchronously to not block the response
-
-	// Prepare request body
-	requestBody := map[string]interface{}{
-		"document_id":  document.ID,
-		"template_id":  document.TemplateID,
-		"file_name":    document.Filename,
-		"file_size":    document.FileSize,
-		"mime_type":    document.MimeType,
-		"storage":      h.storageInfo,
-		"storage_path": document.GCSPathDocx,
-		"pdf_path":     document.GCSPathPdf,
-		"form_data":    map[string]interface{}{}, // Parse from document.Data if needed
+// recordFormSubmit records form submission statistics and the user's own template usage
+func (h *DocxHandler) recordFormSubmit(templateID, userID string) {
+	// Record form submission statistics
+	if h.statisticsService != nil {
+		go func() {
+			if err := h.statisticsService.RecordFormSubmit(templateID); err != nil {
+				fmt.Printf("Warning: failed to record form submit statistics: %v\n", err)
+			}
+		}()
 	}
 
-	jsonData, err := json.Marshal(requestBody)
-	if err != nil {
-		fmt.Printf("[ERROR] Failed to marshal request body for document registration: %v\n", err)
-		return
+	// Record the user's own usage for their recent templates
+	if h.userTemplates != nil && userID != "" {
+		go func() {
+			if err := h.userTemplates.RecordUsage(userID, templateID); err != nil {
+				fmt.Printf("Warning: failed to record template usage: %v\n", err)
+			}
+		}()
 	}
+}
 
-	// Make HTTP request to organization service
-	// Use localhost:8085 for direct service-to-service communication
-	url := fmt.Sprintf("http://localhost:8085/api/v1/organizations/%s/documents/register", orgID)
+// maxPreviewWidth caps the pixel width of rendered previews and HD thumbnails
+const maxPreviewWidth = 2000
 
-	req, err := http.NewRequestWithContext(ctx, "POST", url, strings.NewReader(string(jsonData)))
-	if err != nil {
-		fmt.Printf("[ERROR] Failed to create request for document registration: %v\n", err)
+// RenderPreviewRequest represents the request body for a filled template preview
+type RenderPreviewRequest struct {
+	Data   map[string]interface{} `json:"data"`   // Optional values, in the same forms /process accepts
+	Format string                 `json:"format"` // "html" (default), "pdf" or "png"
+	Width  int                    `json:"width"`  // PNG width in pixels (default 800, at most maxPreviewWidth)
+}
+
+// RenderPreview fills a template with supplied or sample values and returns the rendered file
+// Nothing is stored and no document record is created
+// POST /api/v1/templates/:templateId/render-preview
+func (h *DocxHandler) RenderPreview(c *gin.Context) {
+	templateID := c.Param("templateId")
+	if templateID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Template ID is required"})
 		return
 	}
 
-	// Set headers - forward the user's JWT token for authentication
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", authToken)
-
-	fmt.Printf("[INFO] Registering document %s with organization %s for user %v at %s\n",
-		document.ID, orgID, userID, url)
-
-	client := &http.Client{
-		Timeout: 10 * time.Second,
+	var req RenderPreviewRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid JSON"})
+			return
+		}
+	}
+	if format := c.Query("format"); format != "" {
+		req.Format = format
+	}
+	if req.Format == "" {
+		req.Format = services.PreviewFormatHTML
+	}
+	if req.Width <= 0 {
+		req.Width = 800
 	}
+	req.Width = min(req.Width, maxPreviewWidth)
 
-	resp, err := client.Do(req)
+	template, err := h.templateService.GetTemplate(templateID)
 	if err != nil {
-		fmt.Printf("[ERROR] Failed to call organization service: %v\n", err)
+		c.JSON(http.StatusNotFound, gin.H{"error": "Template not found"})
+		return
+	}
+
+	if !h.enforceTierAccess(c, template) {
 		return
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		fmt.Printf("[ERROR] Organization service returned status %d: %s\n", resp.StatusCode, string(body))
+	preview, err := h.documentService.RenderPreview(c.Request.Context(), template, req.Data, strings.ToLower(req.Format), req.Width)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if strings.Contains(err.Error(), "unsupported preview format") || errors.Is(err, services.ErrDocxOnly) || errors.Is(err, services.ErrInvalidProcessData) {
+			status = http.StatusBadRequest
+		} else if strings.Contains(err.Error(), "not available") {
+			status = http.StatusServiceUnavailable
+		}
+		c.JSON(status, gin.H{"error": fmt.Sprintf("Failed to render preview: %v", err)})
 		return
 	}
 
-	fmt.Printf("[SUCCESS] Document %s registered with organization %s\n", document.ID, orgID)
+	c.Header("Content-Disposition", fmt.Sprintf("inline; filename=%s", preview.Filename))
+	c.Header("Cache-Control", "no-store")
+	c.Header("X-Preview-Sample-Fields", strconv.Itoa(len(preview.SampleFields)))
+	c.Data(http.StatusOK, preview.ContentType, preview.Content)
 }
 
 func (h *DocxHandler) DownloadDocument(c *gin.Context) {
@@ -620,10 +990,16 @@ func (h *DocxHandler) DownloadDocument(c *gin.Context) {
 
 	format := c.DefaultQuery("format", "docx")
 
+	// Check the template tier before streaming the file
+	if !h.enforceDocumentTierAccess(c, documentID) {
+		return
+	}
+
 	var reader io.ReadCloser
 	var filename, mimeType string
 	var err error
 	var templateID string
+	var document *models.Document
 
 	// Security: Use authorized reader if user is authenticated
 	if userID != "" {
@@ -640,6 +1016,7 @@ func (h *DocxHandler) DownloadDocument(c *gin.Context) {
 		// Get template ID for statistics
 		if doc, docErr := h.documentService.GetDocumentWithAuth(documentID, userID); docErr == nil {
 			templateID = doc.TemplateID
+			document = doc
 		}
 	} else {
 		// For backwards compatibility, allow unauthenticated access if no user header
@@ -652,6 +1029,7 @@ func (h *DocxHandler) DownloadDocument(c *gin.Context) {
 		// Get template ID for statistics
 		if doc, docErr := h.documentService.GetDocument(documentID); docErr == nil {
 			templateID = doc.TemplateID
+			document = doc
 		}
 	}
 	defer reader.Close()
@@ -679,6 +1057,16 @@ func (h *DocxHandler) DownloadDocument(c *gin.Context) {
 		}()
 	}
 
+	// Notify subscribed webhooks
+	if h.webhookService != nil && document != nil {
+		go func() {
+			data := h.webhookService.DocumentEventData(document, "")
+			data["format"] = format
+			data["downloaded_by"] = userID
+			h.webhookService.Publish(models.WebhookEventDocumentDownloaded, data)
+		}()
+	}
+
 	// After successful download, delete the processed DOCX file from GCS
 	// but keep the document record in database with user data
 	go func() {
@@ -695,13 +1083,87 @@ func (h *DocxHandler) DeleteTemplate(c *gin.Context) {
 		return
 	}
 
-	err := h.templateService.DeleteTemplate(c.Request.Context(), templateID)
+	force := c.Query("force") == "true"
+
+	err := h.templateService.DeleteTemplate(c.Request.Context(), templateID, force)
 	if err != nil {
+		var inUseErr *services.TemplateInUseError
+		if errors.As(err, &inUseErr) {
+			c.JSON(http.StatusConflict, gin.H{
+				"error":          "Template is referenced by existing documents. Use force=true to delete anyway",
+				"document_count": inUseErr.DocumentCount,
+			})
+			return
+		}
+		if strings.Contains(err.Error(), "not found") {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Template not found"})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Failed to delete template: %v", err)})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Template deleted successfully"})
+	c.JSON(http.StatusOK, gin.H{
+		"message":  "Template moved to trash",
+		"purge_at": time.Now().Add(h.trashRetention),
+	})
+}
+
+// GetTrash lists deleted templates that can still be restored
+// GET /api/v1/templates/trash
+func (h *DocxHandler) GetTrash(c *gin.Context) {
+	templates, err := h.templateService.GetTrashedTemplates()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Failed to get trash: %v", err)})
+		return
+	}
+
+	lang := requestLanguage(c)
+	responses := make([]models.TrashedTemplateResponse, len(templates))
+	for i := range templates {
+		deletedAt := templates[i].DeletedAt.Time
+		responses[i] = models.TrashedTemplateResponse{
+			TemplateResponse: templates[i].ToLocalizedResponse(lang),
+			DeletedAt:        deletedAt,
+			PurgeAt:          deletedAt.Add(h.trashRetention),
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"templates": responses})
+}
+
+// RestoreTemplate moves a template out of the trash
+// POST /api/v1/templates/:templateId/restore
+func (h *DocxHandler) RestoreTemplate(c *gin.Context) {
+	template, err := h.templateService.RestoreTemplate(c.Param("templateId"))
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Template not found in trash"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Failed to restore template: %v", err)})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":  "Template restored successfully",
+		"template": template.ToLocalizedResponse(requestLanguage(c)),
+	})
+}
+
+// PurgeTemplate permanently deletes a template from the trash, including its stored files
+// DELETE /api/v1/templates/trash/:templateId
+func (h *DocxHandler) PurgeTemplate(c *gin.Context) {
+	if err := h.templateService.PurgeTemplate(c.Request.Context(), c.Param("templateId")); err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Template not found in trash"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Failed to purge template: %v", err)})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Template permanently deleted"})
 }
 
 type UpdateTemplateRequest struct {
@@ -718,6 +1180,9 @@ type UpdateTemplateRequest struct {
 	Tier           string            `json:"tier"`
 	Group          string            `json:"group"`
 	Aliases        map[string]string `json:"aliases,omitempty"`
+
+	// Translations keyed by language code, e.g. {"en": {"name": "...", "description": "..."}}
+	Translations map[string]models.TemplateTranslation `json:"translations,omitempty"`
 }
 
 func (h *DocxHandler) UpdateTemplate(c *gin.Context) {
@@ -765,10 +1230,15 @@ func (h *DocxHandler) UpdateTemplate(c *gin.Context) {
 			Tier:           req.Tier,
 			Group:          req.Group,
 			Aliases:        req.Aliases,
+			Translations:   req.Translations,
 		}
 
 		template, err := h.templateService.UpdateTemplate(c.Request.Context(), templateID, updateReq, nil, nil)
 		if err != nil {
+			if strings.Contains(err.Error(), "unsupported language") {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
 			c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Failed to update template: %v", err)})
 			return
 		}
@@ -792,6 +1262,7 @@ func (h *DocxHandler) UpdateTemplate(c *gin.Context) {
 		tier := c.PostForm("tier")
 		group := c.PostForm("group")
 		aliasesJSON := c.PostForm("aliases")
+		translationsJSON := c.PostForm("translations")
 
 		if displayName == "" {
 			c.JSON(http.StatusBadRequest, gin.H{"error": "displayName is required"})
@@ -826,6 +1297,15 @@ func (h *DocxHandler) UpdateTemplate(c *gin.Context) {
 			}
 		}
 
+		// Parse translations if provided
+		var translations map[string]models.TemplateTranslation
+		if translationsJSON != "" {
+			if err := json.Unmarshal([]byte(translationsJSON), &translations); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid translations JSON format"})
+				return
+			}
+		}
+
 		// Get optional HTML file
 		var htmlFile multipart.File
 		var htmlHeader *multipart.FileHeader
@@ -855,10 +1335,15 @@ func (h *DocxHandler) UpdateTemplate(c *gin.Context) {
 			Tier:           tier,
 			Group:          group,
 			Aliases:        aliases,
+			Translations:   translations,
 		}
 
 		template, err := h.templateService.UpdateTemplate(c.Request.Context(), templateID, updateReq, htmlFile, htmlHeader)
 		if err != nil {
+			if strings.Contains(err.Error(), "unsupported language") {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
 			c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Failed to update template: %v", err)})
 			return
 		}
@@ -940,9 +1425,24 @@ func (h *DocxHandler) ReplaceTemplateFiles(c *gin.Context) {
 	// Check if field definitions should be regenerated
 	regenerateFields := c.PostForm("regenerate_fields") == "true"
 
+	// Fixtures run against a new DOCX unless explicitly skipped
+	skipFixtures := c.PostForm("skip_fixtures") == "true"
+
 	// Replace files
-	template, err := h.templateService.ReplaceTemplateFiles(c.Request.Context(), templateID, docxFile, docxHeader, htmlFile, htmlHeader, thumbnailFile, thumbnailHeader, regenerateFields)
+	template, fixtureReport, err := h.templateService.ReplaceTemplateFiles(c.Request.Context(), templateID, docxFile, docxHeader, htmlFile, htmlHeader, thumbnailFile, thumbnailHeader, regenerateFields, skipFixtures)
 	if err != nil {
+		var fixtureErr *services.FixtureFailureError
+		if errors.As(err, &fixtureErr) {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{
+				"error":    fmt.Sprintf("New DOCX rejected: %v", err),
+				"fixtures": fixtureErr.Report,
+			})
+			return
+		}
+		if errors.Is(err, services.ErrDocxOnly) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Failed to replace files: %v", err)})
 		return
 	}
@@ -959,6 +1459,7 @@ func (h *DocxHandler) ReplaceTemplateFiles(c *gin.Context) {
 		"filename":     template.Filename,
 		"placeholders": placeholders,
 		"template":     template,
+		"fixtures":     fixtureReport,
 	})
 }
 
@@ -1005,6 +1506,57 @@ func (h *DocxHandler) GetUserDocumentHistory(c *gin.Context) {
 	})
 }
 
+// RenamePlaceholdersRequest represents the request body for renaming placeholders
+type RenamePlaceholdersRequest struct {
+	Renames          map[string]string `json:"renames"`           // Old key -> new key, braces optional
+	RewriteDocuments bool              `json:"rewrite_documents"` // Also rewrite the data of generated documents
+}
+
+// RenamePlaceholders renames placeholders in the DOCX, field definitions, aliases and placeholders list
+// POST /api/v1/templates/:templateId/placeholders/rename
+func (h *DocxHandler) RenamePlaceholders(c *gin.Context) {
+	templateID := c.Param("templateId")
+	if templateID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Template ID is required"})
+		return
+	}
+
+	var req RenamePlaceholdersRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid JSON"})
+		return
+	}
+	if len(req.Renames) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "renames is required"})
+		return
+	}
+
+	result, err := h.templateService.RenamePlaceholders(c.Request.Context(), templateID, req.Renames, req.RewriteDocuments)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidRename) || errors.Is(err, services.ErrDocxOnly) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if strings.Contains(err.Error(), "template not found") {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Template not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Failed to rename placeholders: %v", err)})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":           "Placeholders renamed successfully",
+		"template":          result.Template.ToLocalizedResponse(requestLanguage(c)),
+		"renamed":           result.Renamed,
+		"occurrences":       result.Occurrences,
+		"documents_updated": result.DocumentsUpdated,
+		"fixtures_updated":  result.FixturesUpdated,
+		"drafts_updated":    result.DraftsUpdated,
+		"warnings":          result.Warnings,
+	})
+}
+
 // GetFieldDefinitions returns the field definitions for a template
 func (h *DocxHandler) GetFieldDefinitions(c *gin.Context) {
 	templateID := c.Param("templateId")
@@ -1050,6 +1602,14 @@ func (h *DocxHandler) UpdateFieldDefinitions(c *gin.Context) {
 
 	template, err := h.templateService.UpdateFieldDefinitions(templateID, req.FieldDefinitions)
 	if err != nil {
+		var expressionErrors services.ExpressionErrors
+		if errors.As(err, &expressionErrors) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Invalid computed field expressions",
+				"details": expressionErrors,
+			})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Failed to update field definitions: %v", err)})
 		return
 	}
@@ -1075,8 +1635,16 @@ func (h *DocxHandler) RegenerateDocument(c *gin.Context) {
 		return
 	}
 
+	if !h.enforceDocumentTierAccess(c, documentID) {
+		return
+	}
+
 	document, err := h.documentService.RegenerateDocument(c.Request.Context(), documentID, userID)
 	if err != nil {
+		if errors.Is(err, services.ErrDocumentPending) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
 		if strings.Contains(err.Error(), "unauthorized") {
 			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
 			return
@@ -1185,3 +1753,137 @@ func (h *DocxHandler) RegenerateThumbnails(c *gin.Context) {
 		"force":         forceRegenerate,
 	})
 }
+
+// RebuildSearchIndex re-extracts body text from all template DOCX files and rebuilds the full-text search index
+// POST /api/v1/templates/search/reindex
+func (h *DocxHandler) RebuildSearchIndex(c *gin.Context) {
+	successCount, failCount, errors := h.templateService.RebuildSearchIndex(c.Request.Context())
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":       "Search index rebuild completed",
+		"success_count": successCount,
+		"fail_count":    failCount,
+		"errors":        errors,
+	})
+}
+
+// GetFavoriteTemplates lists the calling user's favorite templates, most recently starred first
+// GET /api/v1/me/templates/favorites
+func (h *DocxHandler) GetFavoriteTemplates(c *gin.Context) {
+	userID := c.GetHeader("X-User-ID")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+		return
+	}
+
+	favorites, err := h.userTemplates.GetFavorites(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Failed to get favorites: %v", err)})
+		return
+	}
+
+	lang := requestLanguage(c)
+	templates := make([]models.TemplateResponse, len(favorites))
+	for i := range favorites {
+		templates[i] = favorites[i].Template.ToLocalizedResponse(lang)
+		templates[i].IsFavorite = true
+	}
+	h.markLockedTemplates(c, templates)
+
+	responses := make([]models.FavoriteTemplateResponse, len(favorites))
+	for i := range favorites {
+		responses[i] = models.FavoriteTemplateResponse{
+			TemplateResponse: templates[i],
+			FavoritedAt:      favorites[i].FavoritedAt,
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"templates": responses, "total": len(responses)})
+}
+
+// AddFavoriteTemplate stars a template for the calling user
+// POST /api/v1/me/templates/favorites/:templateId
+func (h *DocxHandler) AddFavoriteTemplate(c *gin.Context) {
+	userID := c.GetHeader("X-User-ID")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+		return
+	}
+
+	templateID := c.Param("templateId")
+	if err := h.userTemplates.AddFavorite(userID, templateID); err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Template not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Failed to add favorite: %v", err)})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":     "Template added to favorites",
+		"template_id": templateID,
+	})
+}
+
+// RemoveFavoriteTemplate un-stars a template for the calling user
+// DELETE /api/v1/me/templates/favorites/:templateId
+func (h *DocxHandler) RemoveFavoriteTemplate(c *gin.Context) {
+	userID := c.GetHeader("X-User-ID")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+		return
+	}
+
+	templateID := c.Param("templateId")
+	if err := h.userTemplates.RemoveFavorite(userID, templateID); err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Template is not a favorite"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Failed to remove favorite: %v", err)})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":     "Template removed from favorites",
+		"template_id": templateID,
+	})
+}
+
+// GetRecentTemplates lists the templates the calling user used most recently
+// Usage comes from the user's generated documents and form submissions
+// GET /api/v1/me/templates/recent?limit=20
+func (h *DocxHandler) GetRecentTemplates(c *gin.Context) {
+	userID := c.GetHeader("X-User-ID")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+		return
+	}
+
+	limit, _ := strconv.Atoi(c.Query("limit"))
+	recent, err := h.userTemplates.GetRecent(userID, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Failed to get recent templates: %v", err)})
+		return
+	}
+
+	lang := requestLanguage(c)
+	templates := make([]models.TemplateResponse, len(recent))
+	for i := range recent {
+		templates[i] = recent[i].Template.ToLocalizedResponse(lang)
+	}
+	h.markLockedTemplates(c, templates)
+	h.markFavoriteTemplates(c, templates)
+
+	responses := make([]models.RecentTemplateResponse, len(recent))
+	for i := range recent {
+		responses[i] = models.RecentTemplateResponse{
+			TemplateResponse: templates[i],
+			LastUsedAt:       recent[i].LastUsedAt,
+			UseCount:         recent[i].UseCount,
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"templates": responses, "total": len(responses)})
+}
@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"DF-PLCH/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ValidateExpressionRequest checks one expression, or all computed fields of the template when empty
+type ValidateExpressionRequest struct {
+	Expression string            `json:"expression"`
+	Field      string            `json:"field,omitempty"` // Key of the field being edited, to detect cycles
+	Data       map[string]string `json:"data,omitempty"`  // Sample values; other fields use preview sample values
+}
+
+// ValidateExpression checks a computed field expression against a template and evaluates it
+// with sample data. Without an expression, the template's saved computed fields are checked
+// POST /api/v1/templates/:templateId/expressions/validate
+func (h *DocxHandler) ValidateExpression(c *gin.Context) {
+	templateID := c.Param("templateId")
+	if templateID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Template ID is required"})
+		return
+	}
+
+	var req ValidateExpressionRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid JSON"})
+			return
+		}
+	}
+
+	template, err := h.templateService.GetTemplate(templateID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Template not found"})
+		return
+	}
+
+	if req.Expression == "" {
+		definitions, err := h.templateService.GetFieldDefinitions(templateID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		var placeholders []string
+		json.Unmarshal([]byte(template.Placeholders), &placeholders)
+
+		expressionErrors := services.CheckFieldExpressions(placeholders, definitions)
+		c.JSON(http.StatusOK, gin.H{
+			"valid":  len(expressionErrors) == 0,
+			"errors": expressionErrors,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, services.CheckExpression(template, req.Expression, req.Field, req.Data, time.Now()))
+}
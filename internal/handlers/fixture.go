@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"DF-PLCH/internal/models"
+	"DF-PLCH/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type FixtureHandler struct {
+	templateService *services.TemplateService
+}
+
+func NewFixtureHandler(templateService *services.TemplateService) *FixtureHandler {
+	return &FixtureHandler{
+		templateService: templateService,
+	}
+}
+
+// fixtureErrorStatus maps fixture service errors to HTTP status codes
+func fixtureErrorStatus(err error) int {
+	if errors.Is(err, services.ErrInvalidFixture) || errors.Is(err, services.ErrDocxOnly) {
+		return http.StatusBadRequest
+	}
+	if strings.Contains(err.Error(), "not found") {
+		return http.StatusNotFound
+	}
+	return http.StatusInternalServerError
+}
+
+// GetFixtures lists a template's regression fixtures
+// GET /api/v1/templates/:templateId/fixtures
+func (h *FixtureHandler) GetFixtures(c *gin.Context) {
+	fixtures, err := h.templateService.GetFixtures(c.Param("templateId"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	responses := make([]models.TemplateFixtureResponse, len(fixtures))
+	for i := range fixtures {
+		responses[i] = fixtures[i].ToResponse()
+	}
+	c.JSON(http.StatusOK, gin.H{"fixtures": responses})
+}
+
+// CreateFixture adds a named sample dataset to a template
+// The golden snapshot is recorded from the current DOCX unless expected_text is given
+// POST /api/v1/templates/:templateId/fixtures
+func (h *FixtureHandler) CreateFixture(c *gin.Context) {
+	var req services.TemplateFixtureRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Invalid request body: %v", err)})
+		return
+	}
+
+	fixture, err := h.templateService.CreateFixture(c.Request.Context(), c.Param("templateId"), c.GetHeader("X-User-ID"), &req)
+	if err != nil {
+		c.JSON(fixtureErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, fixture.ToResponse())
+}
+
+// UpdateFixture changes a fixture's name, data or expected text
+// Set "snapshot": true to re-record the golden snapshot from the current DOCX
+// PUT /api/v1/templates/:templateId/fixtures/:fixtureId
+func (h *FixtureHandler) UpdateFixture(c *gin.Context) {
+	var req services.TemplateFixtureRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Invalid request body: %v", err)})
+		return
+	}
+
+	fixture, err := h.templateService.UpdateFixture(c.Request.Context(), c.Param("templateId"), c.Param("fixtureId"), &req)
+	if err != nil {
+		c.JSON(fixtureErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, fixture.ToResponse())
+}
+
+// DeleteFixture removes a fixture from a template
+// DELETE /api/v1/templates/:templateId/fixtures/:fixtureId
+func (h *FixtureHandler) DeleteFixture(c *gin.Context) {
+	if err := h.templateService.DeleteFixture(c.Param("templateId"), c.Param("fixtureId")); err != nil {
+		c.JSON(fixtureErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Fixture deleted successfully"})
+}
+
+// RunFixtures processes every fixture against the current DOCX and reports diffs,
+// missing placeholders and leftover {{ tokens
+// POST /api/v1/templates/:templateId/test
+func (h *FixtureHandler) RunFixtures(c *gin.Context) {
+	report, err := h.templateService.RunFixtures(c.Request.Context(), c.Param("templateId"))
+	if err != nil {
+		c.JSON(fixtureErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
@@ -0,0 +1,212 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"DF-PLCH/internal/models"
+	"DF-PLCH/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SetFormDraftService enables saved form drafts
+func (h *DocxHandler) SetFormDraftService(draftService *services.FormDraftService) {
+	h.draftService = draftService
+}
+
+// FormDraftRequest creates or updates a draft
+type FormDraftRequest struct {
+	Name *string                `json:"name"`
+	Data map[string]interface{} `json:"data"` // Partial data in any form /process accepts; replaces the saved data
+}
+
+// draftErrorStatus maps draft service errors to HTTP status codes
+func draftErrorStatus(err error) int {
+	if errors.Is(err, services.ErrInvalidDraft) {
+		return http.StatusBadRequest
+	}
+	if strings.Contains(err.Error(), "unauthorized") {
+		return http.StatusForbidden
+	}
+	if strings.Contains(err.Error(), "not found") {
+		return http.StatusNotFound
+	}
+	return http.StatusInternalServerError
+}
+
+// writeDraft responds with a single draft including its data
+func (h *DocxHandler) writeDraft(c *gin.Context, status int, draft *models.FormDraft) {
+	responses, err := h.draftService.DraftResponses([]models.FormDraft{*draft}, true)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(status, gin.H{"draft": responses[0]})
+}
+
+// CreateFormDraft saves a partially filled form of a template for the calling user
+// POST /api/v1/templates/:templateId/drafts
+func (h *DocxHandler) CreateFormDraft(c *gin.Context) {
+	userID := c.GetHeader("X-User-ID")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+		return
+	}
+
+	var req FormDraftRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid JSON"})
+		return
+	}
+
+	template, err := h.templateService.GetTemplate(c.Param("templateId"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Template not found"})
+		return
+	}
+
+	name := ""
+	if req.Name != nil {
+		name = *req.Name
+	}
+	draft, err := h.draftService.CreateDraft(template, userID, name, req.Data)
+	if err != nil {
+		c.JSON(draftErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	h.writeDraft(c, http.StatusCreated, draft)
+}
+
+// GetFormDrafts lists the calling user's drafts without their data, most recently updated first
+// Query: template_id
+// GET /api/v1/me/drafts
+func (h *DocxHandler) GetFormDrafts(c *gin.Context) {
+	userID := c.GetHeader("X-User-ID")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+		return
+	}
+
+	drafts, err := h.draftService.ListDrafts(userID, c.Query("template_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	responses, err := h.draftService.DraftResponses(drafts, false)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"drafts": responses, "total": len(responses)})
+}
+
+// GetFormDraft returns one of the calling user's drafts with its data
+// GET /api/v1/me/drafts/:draftId
+func (h *DocxHandler) GetFormDraft(c *gin.Context) {
+	userID := c.GetHeader("X-User-ID")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+		return
+	}
+
+	draft, err := h.draftService.GetDraft(c.Param("draftId"), userID)
+	if err != nil {
+		c.JSON(draftErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	h.writeDraft(c, http.StatusOK, draft)
+}
+
+// UpdateFormDraft replaces a draft's data and/or name and extends its expiry
+// PUT /api/v1/me/drafts/:draftId
+func (h *DocxHandler) UpdateFormDraft(c *gin.Context) {
+	userID := c.GetHeader("X-User-ID")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+		return
+	}
+
+	var req FormDraftRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid JSON"})
+		return
+	}
+
+	draft, err := h.draftService.UpdateDraft(c.Param("draftId"), userID, req.Name, req.Data)
+	if err != nil {
+		c.JSON(draftErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	h.writeDraft(c, http.StatusOK, draft)
+}
+
+// DeleteFormDraft deletes one of the calling user's drafts
+// DELETE /api/v1/me/drafts/:draftId
+func (h *DocxHandler) DeleteFormDraft(c *gin.Context) {
+	userID := c.GetHeader("X-User-ID")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+		return
+	}
+
+	if err := h.draftService.DeleteDraft(c.Param("draftId"), userID); err != nil {
+		c.JSON(draftErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Draft deleted successfully"})
+}
+
+// SubmitFormDraft processes a draft like POST /templates/:templateId/process and deletes it
+// on success. The body takes the /process options; its data, if any, is applied over the draft's
+// POST /api/v1/me/drafts/:draftId/submit
+func (h *DocxHandler) SubmitFormDraft(c *gin.Context) {
+	userID := c.GetHeader("X-User-ID")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+		return
+	}
+
+	var req ProcessRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid JSON"})
+			return
+		}
+	}
+
+	draft, err := h.draftService.GetDraft(c.Param("draftId"), userID)
+	if err != nil {
+		c.JSON(draftErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	template, err := h.templateService.GetTemplate(draft.TemplateID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Template not found"})
+		return
+	}
+
+	if !h.enforceTierAccess(c, template) {
+		return
+	}
+
+	data := draft.GetData()
+	for key, value := range req.Data {
+		data[key] = value
+	}
+	req.Data = data
+
+	if documentID := h.processRequest(c, template, req, ""); documentID != "" {
+		if err := h.draftService.DeleteDraft(draft.ID, userID); err != nil {
+			fmt.Printf("Warning: failed to delete submitted draft %s: %v\n", draft.ID, err)
+		}
+	}
+}
@@ -0,0 +1,148 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"DF-PLCH/internal/models"
+	"DF-PLCH/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type IntegrityHandler struct {
+	integrityService *services.IntegrityService
+}
+
+func NewIntegrityHandler(integrityService *services.IntegrityService) *IntegrityHandler {
+	return &IntegrityHandler{
+		integrityService: integrityService,
+	}
+}
+
+// StartCheck starts an integrity check over all templates in the background
+// POST /api/v1/templates/integrity/check
+func (h *IntegrityHandler) StartCheck(c *gin.Context) {
+	report, err := h.integrityService.StartCheck("manual")
+	if err != nil {
+		status := http.StatusInternalServerError
+		if strings.Contains(err.Error(), "already running") {
+			status = http.StatusConflict
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"message": "Integrity check started",
+		"report":  report,
+	})
+}
+
+// GetReports lists recent integrity reports (without issues)
+// GET /api/v1/templates/integrity/reports
+func (h *IntegrityHandler) GetReports(c *gin.Context) {
+	limit := 20
+	if l := c.Query("limit"); l != "" {
+		if parsed, err := strconv.Atoi(l); err == nil && parsed > 0 && parsed <= 100 {
+			limit = parsed
+		}
+	}
+
+	reports, err := h.integrityService.GetReports(limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"reports": reports})
+}
+
+// GetReport returns an integrity report with its issues ("latest" for the most recent run)
+// GET /api/v1/templates/integrity/reports/:id
+func (h *IntegrityHandler) GetReport(c *gin.Context) {
+	var report *models.IntegrityReport
+	var err error
+	if id := c.Param("id"); id == "latest" {
+		report, err = h.integrityService.GetLatestReport()
+	} else {
+		report, err = h.integrityService.GetReport(id)
+	}
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Report not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"report": report.ToResponse()})
+}
+
+// RepairReport applies every repair action listed in a report
+// POST /api/v1/templates/integrity/reports/:id/repair
+func (h *IntegrityHandler) RepairReport(c *gin.Context) {
+	repaired, errors, err := h.integrityService.RepairReport(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		status := http.StatusInternalServerError
+		if strings.Contains(err.Error(), "not found") {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, gin.H{"error": fmt.Sprintf("Failed to repair: %v", err)})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":  fmt.Sprintf("Applied %d repairs", repaired),
+		"repaired": repaired,
+		"failed":   len(errors),
+		"errors":   errors,
+	})
+}
+
+// CheckTemplate checks a single template now
+// GET /api/v1/templates/:templateId/integrity
+func (h *IntegrityHandler) CheckTemplate(c *gin.Context) {
+	issues, err := h.integrityService.CheckTemplateByID(c.Request.Context(), c.Param("templateId"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Template not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"issues": issues})
+}
+
+// RepairTemplate applies one repair action to a template and returns its remaining issues
+// POST /api/v1/templates/:templateId/integrity/repair
+func (h *IntegrityHandler) RepairTemplate(c *gin.Context) {
+	var req struct {
+		Action models.IntegrityRepairAction `json:"action"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil || req.Action == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "action is required"})
+		return
+	}
+
+	templateID := c.Param("templateId")
+	if err := h.integrityService.Repair(c.Request.Context(), templateID, req.Action); err != nil {
+		status := http.StatusInternalServerError
+		if strings.Contains(err.Error(), "template not found") {
+			status = http.StatusNotFound
+		} else if strings.Contains(err.Error(), "unknown repair action") {
+			status = http.StatusBadRequest
+		}
+		c.JSON(status, gin.H{"error": fmt.Sprintf("Failed to repair template: %v", err)})
+		return
+	}
+
+	issues, err := h.integrityService.CheckTemplateByID(c.Request.Context(), templateID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Repair applied successfully",
+		"action":  req.Action,
+		"issues":  issues,
+	})
+}
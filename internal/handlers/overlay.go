@@ -0,0 +1,113 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"DF-PLCH/internal/services"
+	"DF-PLCH/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+type OverlayHandler struct {
+	templateService *services.TemplateService
+}
+
+func NewOverlayHandler(templateService *services.TemplateService) *OverlayHandler {
+	return &OverlayHandler{
+		templateService: templateService,
+	}
+}
+
+// UpdateOverlayLayoutRequest is the body of UpdateOverlayLayout
+type UpdateOverlayLayoutRequest struct {
+	Positions map[string]utils.FieldPosition `json:"positions"`
+}
+
+// overlayErrorStatus maps overlay service errors to HTTP status codes
+func overlayErrorStatus(err error) int {
+	if errors.Is(err, services.ErrInvalidOverlayBackground) || errors.Is(err, services.ErrInvalidOverlayLayout) || errors.Is(err, services.ErrNotOverlay) {
+		return http.StatusBadRequest
+	}
+	if strings.Contains(err.Error(), "not found") {
+		return http.StatusNotFound
+	}
+	return http.StatusInternalServerError
+}
+
+// UploadOverlayTemplate creates an overlay template from a scanned form (PDF, PNG or JPEG background)
+// POST /api/v1/upload/overlay
+func (h *OverlayHandler) UploadOverlayTemplate(c *gin.Context) {
+	file, header, err := c.Request.FormFile("background")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "No background file uploaded"})
+		return
+	}
+	defer file.Close()
+
+	fileName := c.PostForm("fileName")
+	description := c.PostForm("description")
+	author := c.PostForm("author")
+
+	if fileName == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "fileName is required"})
+		return
+	}
+	if description == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "description is required"})
+		return
+	}
+	if author == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "author is required"})
+		return
+	}
+
+	template, err := h.templateService.UploadOverlayTemplate(c.Request.Context(), file, header, fileName, description, author)
+	if err != nil {
+		c.JSON(overlayErrorStatus(err), gin.H{"error": fmt.Sprintf("Failed to upload overlay template: %v", err)})
+		return
+	}
+
+	c.JSON(http.StatusOK, UploadResponse{
+		TemplateID:   template.ID,
+		FileName:     template.DisplayName,
+		Description:  template.Description,
+		Author:       template.Author,
+		Placeholders: []string{},
+		Message:      "Overlay template uploaded successfully, add fields by saving their positions",
+	})
+}
+
+// GetOverlayLayout returns the page sizes and field positions of an overlay template
+// GET /api/v1/templates/:templateId/overlay
+func (h *OverlayHandler) GetOverlayLayout(c *gin.Context) {
+	layout, err := h.templateService.GetOverlayLayout(c.Request.Context(), c.Param("templateId"))
+	if err != nil {
+		c.JSON(overlayErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, layout)
+}
+
+// UpdateOverlayLayout saves the positions of all fields of an overlay template
+// Fields left out of the request are removed from the template
+// PUT /api/v1/templates/:templateId/overlay
+func (h *OverlayHandler) UpdateOverlayLayout(c *gin.Context) {
+	var req UpdateOverlayLayoutRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Invalid request: %v", err)})
+		return
+	}
+
+	layout, err := h.templateService.UpdateOverlayLayout(c.Request.Context(), c.Param("templateId"), req.Positions)
+	if err != nil {
+		c.JSON(overlayErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, layout)
+}
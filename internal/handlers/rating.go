@@ -0,0 +1,218 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"DF-PLCH/internal/models"
+	"DF-PLCH/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type RatingHandler struct {
+	ratingService *services.RatingService
+}
+
+func NewRatingHandler(ratingService *services.RatingService) *RatingHandler {
+	return &RatingHandler{
+		ratingService: ratingService,
+	}
+}
+
+// RateTemplateRequest is the body of PUT /api/v1/templates/:templateId/rating
+type RateTemplateRequest struct {
+	Rating  int    `json:"rating" binding:"required"` // 1-5 stars
+	Comment string `json:"comment"`
+}
+
+// FeedbackRequest is the body of POST /api/v1/templates/:templateId/feedback
+type FeedbackRequest struct {
+	Kind     models.FeedbackKind `json:"kind"`  // "feedback" (default) or "issue"
+	FieldKey string              `json:"field"` // Placeholder the feedback is about (optional)
+	Message  string              `json:"message" binding:"required"`
+}
+
+// ModerateFeedbackRequest is the body of PATCH /api/v1/moderation/reports/:id
+type ModerateFeedbackRequest struct {
+	Status models.FeedbackStatus `json:"status" binding:"required"` // open, resolved, dismissed
+	Note   string                `json:"note"`
+}
+
+// feedbackErrorStatus maps rating service errors to HTTP status codes
+func feedbackErrorStatus(err error) int {
+	if errors.Is(err, services.ErrInvalidFeedback) {
+		return http.StatusBadRequest
+	}
+	if strings.Contains(err.Error(), "not found") {
+		return http.StatusNotFound
+	}
+	return http.StatusInternalServerError
+}
+
+// RateTemplate creates or replaces the caller's rating of a community template
+// PUT /api/v1/templates/:templateId/rating
+func (h *RatingHandler) RateTemplate(c *gin.Context) {
+	userID := c.GetHeader("X-User-ID")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+		return
+	}
+
+	var req RateTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Invalid request body: %v", err)})
+		return
+	}
+
+	templateID := c.Param("templateId")
+	rating, err := h.ratingService.RateTemplate(templateID, userID, req.Rating, req.Comment)
+	if err != nil {
+		c.JSON(feedbackErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	summary, _, err := h.ratingService.GetRatings(templateID, 0)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Rating saved",
+		"rating":  rating,
+		"summary": summary,
+	})
+}
+
+// DeleteRating removes the caller's rating of a template
+// DELETE /api/v1/templates/:templateId/rating
+func (h *RatingHandler) DeleteRating(c *gin.Context) {
+	userID := c.GetHeader("X-User-ID")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+		return
+	}
+
+	if err := h.ratingService.DeleteRating(c.Param("templateId"), userID); err != nil {
+		c.JSON(feedbackErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Rating deleted"})
+}
+
+// GetRatings returns a template's rating summary (average, count, distribution) and recent ratings
+// GET /api/v1/templates/:templateId/ratings?limit=20
+func (h *RatingHandler) GetRatings(c *gin.Context) {
+	limit := 20
+	if limitStr := c.Query("limit"); limitStr != "" {
+		if parsed, err := strconv.Atoi(limitStr); err == nil && parsed > 0 {
+			limit = parsed
+		}
+	}
+
+	summary, ratings, err := h.ratingService.GetRatings(c.Param("templateId"), limit)
+	if err != nil {
+		c.JSON(feedbackErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"summary": summary,
+		"ratings": ratings,
+	})
+}
+
+// SubmitFeedback records text feedback or an issue report on a template
+// POST /api/v1/templates/:templateId/feedback
+func (h *RatingHandler) SubmitFeedback(c *gin.Context) {
+	userID := c.GetHeader("X-User-ID")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+		return
+	}
+
+	var req FeedbackRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Invalid request body: %v", err)})
+		return
+	}
+
+	feedback, err := h.ratingService.SubmitFeedback(c.Param("templateId"), userID, req.Kind, req.FieldKey, req.Message)
+	if err != nil {
+		c.JSON(feedbackErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message":  "Feedback submitted",
+		"feedback": feedback,
+	})
+}
+
+// GetFeedback lists a template's feedback and issue reports
+// GET /api/v1/templates/:templateId/feedback?kind=issue&status=open
+func (h *RatingHandler) GetFeedback(c *gin.Context) {
+	feedback, err := h.ratingService.GetFeedback(
+		c.Param("templateId"),
+		models.FeedbackKind(c.Query("kind")),
+		models.FeedbackStatus(c.Query("status")),
+	)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"feedback": feedback})
+}
+
+// GetModerationQueue lists templates with open issue reports, most reported first
+// GET /api/v1/moderation/reports
+func (h *RatingHandler) GetModerationQueue(c *gin.Context) {
+	queue, err := h.ratingService.GetModerationQueue()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	lang := requestLanguage(c)
+	responses := make([]models.ReportedTemplateResponse, len(queue))
+	for i := range queue {
+		responses[i] = models.ReportedTemplateResponse{
+			Template:       queue[i].Template.ToLocalizedResponse(lang),
+			OpenReports:    queue[i].OpenReports,
+			LatestReportAt: queue[i].LatestReportAt,
+			Reports:        queue[i].Reports,
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"templates": responses,
+		"total":     len(responses),
+	})
+}
+
+// ModerateFeedback resolves, dismisses or reopens a report
+// PATCH /api/v1/moderation/reports/:id
+func (h *RatingHandler) ModerateFeedback(c *gin.Context) {
+	var req ModerateFeedbackRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Invalid request body: %v", err)})
+		return
+	}
+
+	feedback, err := h.ratingService.ModerateFeedback(c.Param("id"), c.GetHeader("X-User-ID"), req.Status, req.Note)
+	if err != nil {
+		c.JSON(feedbackErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":  "Report updated",
+		"feedback": feedback,
+	})
+}
@@ -0,0 +1,204 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"DF-PLCH/internal/models"
+	"DF-PLCH/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type TagHandler struct {
+	tagService *services.TagService
+}
+
+func NewTagHandler(tagService *services.TagService) *TagHandler {
+	return &TagHandler{
+		tagService: tagService,
+	}
+}
+
+// GetAllTags returns all tags with template counts
+// GET /api/v1/tags
+func (h *TagHandler) GetAllTags(c *gin.Context) {
+	tags, err := h.tagService.GetAll()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"tags": tags})
+}
+
+// GetTag returns a single tag
+// GET /api/v1/tags/:id
+func (h *TagHandler) GetTag(c *gin.Context) {
+	tag, err := h.tagService.GetByID(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Tag not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"tag": tag})
+}
+
+// CreateTag creates a new tag
+// POST /api/v1/tags
+func (h *TagHandler) CreateTag(c *gin.Context) {
+	var req services.CreateTagRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid JSON"})
+		return
+	}
+
+	if strings.TrimSpace(req.Name) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "name is required"})
+		return
+	}
+
+	tag, err := h.tagService.Create(&req)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if strings.Contains(err.Error(), "already exists") || strings.Contains(err.Error(), "could not derive") {
+			status = http.StatusBadRequest
+		}
+		c.JSON(status, gin.H{"error": fmt.Sprintf("Failed to create tag: %v", err)})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Tag created successfully",
+		"tag":     tag,
+	})
+}
+
+// UpdateTag updates an existing tag
+// PUT /api/v1/tags/:id
+func (h *TagHandler) UpdateTag(c *gin.Context) {
+	var req services.UpdateTagRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid JSON"})
+		return
+	}
+
+	tag, err := h.tagService.Update(c.Param("id"), &req)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if strings.Contains(err.Error(), "not found") {
+			status = http.StatusNotFound
+		} else if strings.Contains(err.Error(), "already exists") {
+			status = http.StatusBadRequest
+		}
+		c.JSON(status, gin.H{"error": fmt.Sprintf("Failed to update tag: %v", err)})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Tag updated successfully",
+		"tag":     tag,
+	})
+}
+
+// DeleteTag deletes a tag and removes it from all templates
+// DELETE /api/v1/tags/:id
+func (h *TagHandler) DeleteTag(c *gin.Context) {
+	if err := h.tagService.Delete(c.Param("id")); err != nil {
+		status := http.StatusInternalServerError
+		if strings.Contains(err.Error(), "not found") {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, gin.H{"error": fmt.Sprintf("Failed to delete tag: %v", err)})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Tag deleted successfully"})
+}
+
+// BulkTagRequest is the request body for bulk tagging
+type BulkTagRequest struct {
+	TemplateIDs []string `json:"template_ids"`
+	TagIDs      []string `json:"tag_ids"`
+	Action      string   `json:"action"` // "add" (default) or "remove"
+}
+
+// BulkTag adds or removes tags on many templates at once
+// POST /api/v1/tags/bulk
+func (h *TagHandler) BulkTag(c *gin.Context) {
+	var req BulkTagRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid JSON"})
+		return
+	}
+
+	if req.Action == "" {
+		req.Action = "add"
+	}
+
+	if err := h.tagService.BulkTag(req.TemplateIDs, req.TagIDs, req.Action); err != nil {
+		status := http.StatusInternalServerError
+		if strings.Contains(err.Error(), "not found") {
+			status = http.StatusNotFound
+		} else if strings.Contains(err.Error(), "required") || strings.Contains(err.Error(), "invalid action") {
+			status = http.StatusBadRequest
+		}
+		c.JSON(status, gin.H{"error": fmt.Sprintf("Failed to update tags: %v", err)})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":        "Tags updated successfully",
+		"action":         req.Action,
+		"template_count": len(req.TemplateIDs),
+		"tag_count":      len(req.TagIDs),
+	})
+}
+
+// GetTemplateTags returns the tags attached to a template
+// GET /api/v1/templates/:templateId/tags
+func (h *TagHandler) GetTemplateTags(c *gin.Context) {
+	tags, err := h.tagService.GetTemplateTags(c.Param("templateId"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"tags": toTagResponses(tags, requestLanguage(c))})
+}
+
+// SetTemplateTags replaces all tags on a template
+// PUT /api/v1/templates/:templateId/tags
+func (h *TagHandler) SetTemplateTags(c *gin.Context) {
+	var req struct {
+		TagIDs []string `json:"tag_ids"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid JSON"})
+		return
+	}
+
+	tags, err := h.tagService.SetTemplateTags(c.Param("templateId"), req.TagIDs)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if strings.Contains(err.Error(), "not found") {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, gin.H{"error": fmt.Sprintf("Failed to set template tags: %v", err)})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Template tags updated successfully",
+		"tags":    toTagResponses(tags, requestLanguage(c)),
+	})
+}
+
+func toTagResponses(tags []models.Tag, lang string) []models.TagResponse {
+	responses := make([]models.TagResponse, len(tags))
+	for i := range tags {
+		responses[i] = tags[i].ToLocalizedResponse(lang)
+	}
+	return responses
+}
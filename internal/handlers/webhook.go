@@ -0,0 +1,199 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"DF-PLCH/internal/models"
+	"DF-PLCH/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type WebhookHandler struct {
+	webhookService *services.WebhookService
+}
+
+func NewWebhookHandler(webhookService *services.WebhookService) *WebhookHandler {
+	return &WebhookHandler{
+		webhookService: webhookService,
+	}
+}
+
+// SetWebhookService publishes document.completed and document.downloaded events
+func (h *DocxHandler) SetWebhookService(webhookService *services.WebhookService) {
+	h.webhookService = webhookService
+}
+
+// webhookErrorStatus maps webhook service errors to HTTP status codes
+func webhookErrorStatus(err error) int {
+	if errors.Is(err, services.ErrInvalidWebhook) {
+		return http.StatusBadRequest
+	}
+	if strings.Contains(err.Error(), "not found") {
+		return http.StatusNotFound
+	}
+	return http.StatusInternalServerError
+}
+
+// GetWebhooks returns all webhook subscriptions and the events they can subscribe to
+// GET /api/v1/webhooks
+func (h *WebhookHandler) GetWebhooks(c *gin.Context) {
+	webhooks, err := h.webhookService.GetWebhooks()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	responses := make([]models.WebhookResponse, 0, len(webhooks))
+	for i := range webhooks {
+		responses = append(responses, webhooks[i].ToResponse())
+	}
+	c.JSON(http.StatusOK, gin.H{"webhooks": responses, "events": models.WebhookEvents})
+}
+
+// GetWebhook returns a webhook subscription
+// GET /api/v1/webhooks/:id
+func (h *WebhookHandler) GetWebhook(c *gin.Context) {
+	webhook, err := h.webhookService.GetWebhook(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Webhook not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"webhook": webhook.ToResponse()})
+}
+
+// CreateWebhook creates a webhook subscription
+// The signing secret is generated when not given and is only returned in this response
+// POST /api/v1/webhooks
+func (h *WebhookHandler) CreateWebhook(c *gin.Context) {
+	var req services.WebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid JSON"})
+		return
+	}
+
+	webhook, err := h.webhookService.CreateWebhook(&req, c.GetHeader("X-User-ID"))
+	if err != nil {
+		c.JSON(webhookErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Webhook created successfully. Store the secret now, it is not shown again.",
+		"webhook": webhook,
+	})
+}
+
+// UpdateWebhook replaces a webhook's name, URL, events and active flag
+// PUT /api/v1/webhooks/:id
+func (h *WebhookHandler) UpdateWebhook(c *gin.Context) {
+	var req services.WebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid JSON"})
+		return
+	}
+
+	webhook, err := h.webhookService.UpdateWebhook(c.Param("id"), &req)
+	if err != nil {
+		c.JSON(webhookErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Webhook updated successfully",
+		"webhook": webhook.ToResponse(),
+	})
+}
+
+// DeleteWebhook deletes a webhook subscription and its delivery log
+// DELETE /api/v1/webhooks/:id
+func (h *WebhookHandler) DeleteWebhook(c *gin.Context) {
+	if err := h.webhookService.DeleteWebhook(c.Param("id")); err != nil {
+		c.JSON(webhookErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Webhook deleted successfully"})
+}
+
+// RotateWebhookSecret replaces a webhook's signing secret and returns the new one
+// POST /api/v1/webhooks/:id/rotate-secret
+func (h *WebhookHandler) RotateWebhookSecret(c *gin.Context) {
+	webhook, err := h.webhookService.RotateSecret(c.Param("id"))
+	if err != nil {
+		c.JSON(webhookErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Webhook secret rotated. Store the secret now, it is not shown again.",
+		"webhook": webhook,
+	})
+}
+
+// PingWebhook queues a "ping" event to check the endpoint and its signature verification
+// POST /api/v1/webhooks/:id/ping
+func (h *WebhookHandler) PingWebhook(c *gin.Context) {
+	delivery, err := h.webhookService.Ping(c.Param("id"))
+	if err != nil {
+		c.JSON(webhookErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{"delivery": delivery.ToResponse()})
+}
+
+// GetWebhookDeliveries returns a webhook's delivery log, newest first
+// Query: status (pending|succeeded|failed), page, limit
+// GET /api/v1/webhooks/:id/deliveries
+func (h *WebhookHandler) GetWebhookDeliveries(c *gin.Context) {
+	page := 1
+	limit := 20
+	if p := c.Query("page"); p != "" {
+		if parsed, err := strconv.Atoi(p); err == nil && parsed > 0 {
+			page = parsed
+		}
+	}
+	if l := c.Query("limit"); l != "" {
+		if parsed, err := strconv.Atoi(l); err == nil && parsed > 0 && parsed <= 100 {
+			limit = parsed
+		}
+	}
+
+	deliveries, total, err := h.webhookService.GetDeliveries(c.Param("id"), c.Query("status"), page, limit)
+	if err != nil {
+		c.JSON(webhookErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	responses := make([]models.WebhookDeliveryResponse, 0, len(deliveries))
+	for i := range deliveries {
+		responses = append(responses, deliveries[i].ToResponse())
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"deliveries": responses,
+		"pagination": gin.H{
+			"page":  page,
+			"limit": limit,
+			"total": total,
+			"pages": (total + int64(limit) - 1) / int64(limit),
+		},
+	})
+}
+
+// RedeliverWebhook queues a new delivery of a logged event (same event ID and payload)
+// POST /api/v1/webhooks/:id/deliveries/:deliveryId/redeliver
+func (h *WebhookHandler) RedeliverWebhook(c *gin.Context) {
+	delivery, err := h.webhookService.Redeliver(c.Param("id"), c.Param("deliveryId"))
+	if err != nil {
+		c.JSON(webhookErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{"delivery": delivery.ToResponse()})
+}
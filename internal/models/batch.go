@@ -0,0 +1,90 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Batch job statuses
+const (
+	BatchStatusQueued     = "queued"
+	BatchStatusProcessing = "processing"
+	BatchStatusCompleted  = "completed"
+	BatchStatusFailed     = "failed"
+)
+
+// Batch job outputs
+const (
+	BatchOutputZip = "zip" // One file per row in a ZIP archive
+	BatchOutputPDF = "pdf" // All rows merged into a single PDF
+)
+
+// BatchJob is a mail-merge run that generates one document per spreadsheet row
+type BatchJob struct {
+	ID              string     `gorm:"primaryKey" json:"id"`
+	TemplateID      string     `gorm:"not null;index" json:"template_id"`
+	UserID          string     `gorm:"index" json:"user_id"`
+	Status          string     `gorm:"type:varchar(20);default:'queued'" json:"status"` // queued, processing, completed, failed
+	Output          string     `gorm:"type:varchar(10)" json:"output"`                  // zip or pdf
+	Format          string     `gorm:"type:varchar(10)" json:"format"`                  // File format inside a ZIP (docx or pdf)
+	FilenamePattern string     `json:"filename_pattern,omitempty"`                      // e.g. "certificate_{{name}}"
+	SourceFilename  string     `json:"source_filename"`                                 // Uploaded CSV/XLSX name
+	TotalRows       int        `json:"total_rows"`
+	ProcessedRows   int        `json:"processed_rows"`
+	SucceededRows   int        `json:"succeeded_rows"`
+	FailedRows      int        `json:"failed_rows"`
+	ColumnMap       string     `gorm:"type:json" json:"-"` // JSON object of column header -> placeholder
+	RowErrors       string     `gorm:"type:json" json:"-"` // JSON array of BatchRowError
+	GCSPathResult   string     `json:"-"`                  // ZIP or merged PDF
+	ResultSize      int64      `json:"result_size,omitempty"`
+	Error           string     `json:"error,omitempty"` // Why the whole job failed
+	CreatedAt       time.Time  `json:"created_at"`
+	StartedAt       *time.Time `json:"started_at,omitempty"`
+	FinishedAt      *time.Time `json:"finished_at,omitempty"`
+}
+
+func (BatchJob) TableName() string {
+	return "batch_jobs"
+}
+
+// BatchRowError is why one spreadsheet row could not be generated
+type BatchRowError struct {
+	Row   int    `json:"row"` // Spreadsheet row number (the header is row 1)
+	Error string `json:"error"`
+}
+
+// GetColumns parses the column header -> placeholder mapping
+func (j *BatchJob) GetColumns() map[string]string {
+	columns := make(map[string]string)
+	if j.ColumnMap != "" {
+		json.Unmarshal([]byte(j.ColumnMap), &columns)
+	}
+	return columns
+}
+
+// GetRowErrors parses the per-row errors
+func (j *BatchJob) GetRowErrors() []BatchRowError {
+	rowErrors := []BatchRowError{}
+	if j.RowErrors != "" {
+		json.Unmarshal([]byte(j.RowErrors), &rowErrors)
+	}
+	return rowErrors
+}
+
+// BatchJobResponse is a batch job with its progress, mapping and row errors parsed
+type BatchJobResponse struct {
+	BatchJob
+	Progress    float64           `json:"progress"` // 0-100
+	Columns     map[string]string `json:"columns"`
+	RowErrors   []BatchRowError   `json:"row_errors"`
+	DownloadURL string            `json:"download_url,omitempty"`
+}
+
+// ToResponse converts a BatchJob to a response
+func (j *BatchJob) ToResponse() BatchJobResponse {
+	response := BatchJobResponse{BatchJob: *j, Columns: j.GetColumns(), RowErrors: j.GetRowErrors()}
+	if j.TotalRows > 0 {
+		response.Progress = float64(j.ProcessedRows) * 100 / float64(j.TotalRows)
+	}
+	return response
+}
@@ -0,0 +1,38 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// DocumentJob is the persisted queue entry of an asynchronously processed document
+// The document row exists from the moment the job is queued; the job tracks attempts and locking
+type DocumentJob struct {
+	DocumentID     string     `gorm:"primaryKey" json:"document_id"`
+	TemplateID     string     `gorm:"not null;index" json:"template_id"`
+	UserID         string     `gorm:"index" json:"user_id"`
+	Status         string     `gorm:"type:varchar(20);default:'queued'" json:"status"` // queued, processing, completed, failed
+	Attempts       int        `json:"attempts"`
+	Flatten        bool       `json:"flatten"`                   // ProcessOptions.Flatten
+	OrganizationID string     `json:"organization_id,omitempty"` // ProcessOptions.OrganizationID
+	Warnings       string     `gorm:"type:json" json:"-"`        // JSON array of processing warnings
+	Error          string     `json:"error,omitempty"`           // Last processing error
+	AvailableAt    time.Time  `json:"available_at"`              // Not picked up before this time (retry backoff)
+	LockedAt       *time.Time `json:"locked_at,omitempty"`       // When a worker claimed the job
+	FinishedAt     *time.Time `json:"finished_at,omitempty"`     // When the job completed or failed for good
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+func (DocumentJob) TableName() string {
+	return "document_jobs"
+}
+
+// GetWarnings parses the stored processing warnings
+func (j *DocumentJob) GetWarnings() []string {
+	var warnings []string
+	if j.Warnings != "" {
+		json.Unmarshal([]byte(j.Warnings), &warnings)
+	}
+	return warnings
+}
@@ -0,0 +1,73 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// TemplateFixture is a named sample dataset for a template with the expected output text (golden snapshot)
+type TemplateFixture struct {
+	ID           string     `gorm:"primaryKey" json:"id"`
+	TemplateID   string     `gorm:"not null;index" json:"template_id"`
+	Name         string     `gorm:"not null" json:"name"` // Unique per template
+	Data         string     `gorm:"type:json" json:"-"`   // JSON object of placeholder ("{{key}}") -> value
+	ExpectedText string     `json:"expected_text"`        // Golden text of the filled document (empty = no snapshot check)
+	CreatedBy    string     `json:"created_by,omitempty"`
+	LastRunAt    *time.Time `json:"last_run_at,omitempty"`
+	LastPassed   *bool      `json:"last_passed,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+}
+
+func (TemplateFixture) TableName() string {
+	return "template_fixtures"
+}
+
+// GetData parses the fixture's placeholder values
+func (f *TemplateFixture) GetData() map[string]string {
+	data := make(map[string]string)
+	if f.Data != "" {
+		json.Unmarshal([]byte(f.Data), &data)
+	}
+	return data
+}
+
+// TemplateFixtureResponse is a fixture with its data parsed
+type TemplateFixtureResponse struct {
+	TemplateFixture
+	Data map[string]string `json:"data"`
+}
+
+// ToResponse converts a TemplateFixture to a response with parsed data
+func (f *TemplateFixture) ToResponse() TemplateFixtureResponse {
+	return TemplateFixtureResponse{TemplateFixture: *f, Data: f.GetData()}
+}
+
+// FixtureDiffLine is one changed line between the golden snapshot and the actual output
+type FixtureDiffLine struct {
+	Op   string `json:"op"`   // "-" only in expected, "+" only in actual
+	Line int    `json:"line"` // 1-based line number in expected ("-") or actual ("+")
+	Text string `json:"text"`
+}
+
+// FixtureResult is the outcome of running one fixture
+type FixtureResult struct {
+	FixtureID           string            `json:"fixture_id"`
+	Name                string            `json:"name"`
+	Passed              bool              `json:"passed"`
+	MissingPlaceholders []string          `json:"missing_placeholders,omitempty"` // Fixture fields the template no longer has
+	LeftoverTokens      []string          `json:"leftover_tokens,omitempty"`      // "{{" tokens still in the output
+	Diff                []FixtureDiffLine `json:"diff,omitempty"`                 // Differences from ExpectedText
+	Snapshot            bool              `json:"snapshot"`                       // Whether the output was compared to a golden snapshot
+	Error               string            `json:"error,omitempty"`
+}
+
+// FixtureRunReport is the outcome of running all fixtures of a template
+type FixtureRunReport struct {
+	TemplateID string          `json:"template_id"`
+	Passed     bool            `json:"passed"`
+	Total      int             `json:"total"`
+	Failed     int             `json:"failed"`
+	RanAt      time.Time       `json:"ran_at"`
+	Results    []FixtureResult `json:"results"`
+}
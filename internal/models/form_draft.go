@@ -0,0 +1,48 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// FormDraft is a user's partially filled form for a template, saved so it can be resumed and
+// later submitted to /process. Drafts expire after a configurable period without updates
+type FormDraft struct {
+	ID              string    `gorm:"primaryKey" json:"id"`
+	UserID          string    `gorm:"not null;index" json:"user_id"`
+	TemplateID      string    `gorm:"not null;index" json:"template_id"`
+	Name            string    `json:"name"`
+	Data            string    `gorm:"type:json" json:"-"` // JSON object in any form /process accepts
+	TemplateVersion string    `json:"template_version"`   // Version of the template's fields the data was saved against
+	ExpiresAt       time.Time `gorm:"index" json:"expires_at"`
+	CreatedAt       time.Time `json:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at"`
+}
+
+func (FormDraft) TableName() string {
+	return "form_drafts"
+}
+
+// GetData parses the stored draft data
+func (d *FormDraft) GetData() map[string]interface{} {
+	data := make(map[string]interface{})
+	if d.Data != "" {
+		json.Unmarshal([]byte(d.Data), &data)
+	}
+	return data
+}
+
+// FormDraftResponse is a draft in API responses
+type FormDraftResponse struct {
+	ID              string                 `json:"id"`
+	TemplateID      string                 `json:"template_id"`
+	TemplateName    string                 `json:"template_name,omitempty"`
+	Name            string                 `json:"name"`
+	Data            map[string]interface{} `json:"data,omitempty"` // Omitted in lists
+	FieldCount      int                    `json:"field_count"`    // Top-level keys in the data
+	TemplateVersion string                 `json:"template_version"`
+	TemplateChanged bool                   `json:"template_changed"` // The template's fields changed (or it was deleted) since the draft was saved
+	ExpiresAt       time.Time              `json:"expires_at"`
+	CreatedAt       time.Time              `json:"created_at"`
+	UpdatedAt       time.Time              `json:"updated_at"`
+}
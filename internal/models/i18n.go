@@ -0,0 +1,90 @@
+package models
+
+import (
+	"strconv"
+	"strings"
+)
+
+const (
+	LanguageThai    = "th"
+	LanguageEnglish = "en"
+
+	// DefaultLanguage is the language of the base (untranslated) template fields
+	DefaultLanguage = LanguageThai
+)
+
+// SupportedLanguages lists the languages the catalog can be served in
+// Add a code here to accept it from clients; translations are stored per code
+var SupportedLanguages = []string{LanguageThai, LanguageEnglish}
+
+// TemplateTranslation holds a template's metadata in another language
+type TemplateTranslation struct {
+	Name        string `json:"name,omitempty"`
+	Description string `json:"description,omitempty"`
+	Remarks     string `json:"remarks,omitempty"`
+}
+
+// IsSupportedLanguage reports whether lang is one of SupportedLanguages
+func IsSupportedLanguage(lang string) bool {
+	for _, supported := range SupportedLanguages {
+		if lang == supported {
+			return true
+		}
+	}
+	return false
+}
+
+// ResolveLanguage picks the response language from an explicit lang parameter,
+// then the Accept-Language header (in the client's order of preference), then the default
+func ResolveLanguage(langParam, acceptLanguage string) string {
+	if lang := normalizeLanguage(langParam); IsSupportedLanguage(lang) {
+		return lang
+	}
+
+	bestLang := ""
+	bestQuality := -1.0
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		fields := strings.Split(strings.TrimSpace(part), ";")
+		lang := normalizeLanguage(fields[0])
+		if !IsSupportedLanguage(lang) {
+			continue
+		}
+
+		quality := 1.0
+		for _, param := range fields[1:] {
+			param = strings.TrimSpace(param)
+			if strings.HasPrefix(param, "q=") {
+				if q, ok := parseQuality(strings.TrimPrefix(param, "q=")); ok {
+					quality = q
+				}
+			}
+		}
+		if quality > bestQuality {
+			bestLang = lang
+			bestQuality = quality
+		}
+	}
+	if bestLang != "" && bestQuality > 0 {
+		return bestLang
+	}
+
+	return DefaultLanguage
+}
+
+// normalizeLanguage reduces a language tag to its primary subtag (e.g., "en-US" -> "en")
+func normalizeLanguage(tag string) string {
+	tag = strings.ToLower(strings.TrimSpace(tag))
+	if i := strings.IndexAny(tag, "-_"); i >= 0 {
+		tag = tag[:i]
+	}
+	return tag
+}
+
+// parseQuality parses an Accept-Language q value (0 to 1)
+func parseQuality(value string) (float64, bool) {
+	q, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+	if err != nil || q < 0 || q > 1 {
+		return 0, false
+	}
+	return q, true
+}
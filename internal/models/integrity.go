@@ -0,0 +1,78 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// IntegrityIssueType identifies a kind of template integrity problem
+type IntegrityIssueType string
+
+const (
+	IntegrityMissingDocx             IntegrityIssueType = "missing_docx"              // GCSPath object does not exist
+	IntegrityMissingHTML             IntegrityIssueType = "missing_html"              // GCSPathHTML is empty or the object does not exist
+	IntegrityMissingPDF              IntegrityIssueType = "missing_pdf"               // GCSPathPDF is empty or the object does not exist
+	IntegrityMissingThumbnail        IntegrityIssueType = "missing_thumbnail"         // GCSPathThumbnail is empty or the object does not exist
+	IntegrityUnreadableDocx          IntegrityIssueType = "unreadable_docx"           // DOCX exists but placeholders could not be extracted
+	IntegrityStalePlaceholders       IntegrityIssueType = "stale_placeholders"        // Stored placeholders differ from the DOCX
+	IntegrityMissingFieldDefinitions IntegrityIssueType = "missing_field_definitions" // Placeholders without a field definition
+	IntegrityOrphanFieldDefinitions  IntegrityIssueType = "orphan_field_definitions"  // Field definitions for placeholders not in the DOCX
+)
+
+// IntegrityRepairAction is a repair that can be applied to a template issue
+type IntegrityRepairAction string
+
+const (
+	RepairRegenerateHTML               IntegrityRepairAction = "regenerate_html"
+	RepairRegeneratePDF                IntegrityRepairAction = "regenerate_pdf"
+	RepairRegenerateThumbnail          IntegrityRepairAction = "regenerate_thumbnail"
+	RepairSyncPlaceholders             IntegrityRepairAction = "sync_placeholders"
+	RepairAddFieldDefinitions          IntegrityRepairAction = "add_field_definitions"
+	RepairRemoveOrphanFieldDefinitions IntegrityRepairAction = "remove_orphan_field_definitions"
+)
+
+// IntegrityIssue is a single problem found on a template
+type IntegrityIssue struct {
+	TemplateID   string                `json:"template_id"`
+	TemplateName string                `json:"template_name"`
+	Type         IntegrityIssueType    `json:"type"`
+	Detail       string                `json:"detail"`
+	Path         string                `json:"path,omitempty"`          // Storage path for missing file issues
+	Items        []string              `json:"items,omitempty"`         // Affected placeholders
+	RepairAction IntegrityRepairAction `json:"repair_action,omitempty"` // Empty when the issue needs manual action (e.g., re-upload)
+}
+
+// IntegrityReport is the result of one integrity check run
+type IntegrityReport struct {
+	ID               string     `gorm:"primaryKey" json:"id"`
+	Status           string     `gorm:"default:'running'" json:"status"` // running, completed, failed
+	TriggeredBy      string     `json:"triggered_by"`                    // scheduled, manual
+	TemplatesChecked int        `json:"templates_checked"`
+	IssueCount       int        `json:"issue_count"`
+	Issues           string     `gorm:"type:json" json:"-"` // JSON array of IntegrityIssue
+	Error            string     `json:"error,omitempty"`
+	StartedAt        time.Time  `json:"started_at"`
+	FinishedAt       *time.Time `json:"finished_at,omitempty"`
+}
+
+func (IntegrityReport) TableName() string {
+	return "template_integrity_reports"
+}
+
+// IntegrityReportResponse is an integrity report with its issues parsed
+type IntegrityReportResponse struct {
+	IntegrityReport
+	Issues []IntegrityIssue `json:"issues"`
+}
+
+// ToResponse converts an IntegrityReport to a response with parsed issues
+func (r *IntegrityReport) ToResponse() IntegrityReportResponse {
+	resp := IntegrityReportResponse{IntegrityReport: *r}
+	if r.Issues != "" {
+		json.Unmarshal([]byte(r.Issues), &resp.Issues)
+	}
+	if resp.Issues == nil {
+		resp.Issues = []IntegrityIssue{}
+	}
+	return resp
+}
@@ -0,0 +1,81 @@
+package models
+
+import "time"
+
+// Rating bounds for TemplateRating.Rating
+const (
+	MinTemplateRating = 1
+	MaxTemplateRating = 5
+)
+
+// TemplateRating is one user's 1-5 star rating of a community template
+type TemplateRating struct {
+	ID         string    `gorm:"primaryKey" json:"id"`
+	TemplateID string    `gorm:"not null;index" json:"template_id"`
+	UserID     string    `gorm:"not null" json:"user_id"` // One rating per user and template
+	Rating     int       `gorm:"not null" json:"rating"`
+	Comment    string    `json:"comment,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+func (TemplateRating) TableName() string {
+	return "template_ratings"
+}
+
+// RatingSummary aggregates the ratings of a template
+type RatingSummary struct {
+	Average      float64     `json:"average"`
+	Count        int         `json:"count"`
+	Distribution map[int]int `json:"distribution"` // Stars (1-5) -> number of ratings
+}
+
+// FeedbackKind distinguishes free-text feedback from issue reports
+type FeedbackKind string
+
+const (
+	FeedbackKindGeneral FeedbackKind = "feedback" // General feedback
+	FeedbackKindIssue   FeedbackKind = "issue"    // Problem report (e.g., "field X misplaced"), goes to the moderation queue
+)
+
+// FeedbackStatus is the moderation state of a feedback entry
+type FeedbackStatus string
+
+const (
+	FeedbackStatusOpen      FeedbackStatus = "open"
+	FeedbackStatusResolved  FeedbackStatus = "resolved"
+	FeedbackStatusDismissed FeedbackStatus = "dismissed"
+)
+
+// IsValid reports whether the status is one of the known statuses
+func (s FeedbackStatus) IsValid() bool {
+	return s == FeedbackStatusOpen || s == FeedbackStatusResolved || s == FeedbackStatusDismissed
+}
+
+// TemplateFeedback is a user's text feedback or issue report on a template
+type TemplateFeedback struct {
+	ID            string         `gorm:"primaryKey" json:"id"`
+	TemplateID    string         `gorm:"not null;index" json:"template_id"`
+	UserID        string         `json:"user_id"`
+	Kind          FeedbackKind   `gorm:"type:varchar(20)" json:"kind"`
+	FieldKey      string         `json:"field_key,omitempty"` // Placeholder key the feedback is about (optional)
+	Message       string         `gorm:"not null" json:"message"`
+	Status        FeedbackStatus `gorm:"type:varchar(20);default:'open'" json:"status"`
+	ModeratorID   string         `json:"moderator_id,omitempty"`
+	ModeratorNote string         `json:"moderator_note,omitempty"`
+	ResolvedAt    *time.Time     `json:"resolved_at,omitempty"`
+	CreatedAt     time.Time      `json:"created_at"`
+	UpdatedAt     time.Time      `json:"updated_at"`
+}
+
+func (TemplateFeedback) TableName() string {
+	return "template_feedback"
+}
+
+// ReportedTemplateResponse is a template in the moderation queue with its open issue reports
+type ReportedTemplateResponse struct {
+	Template       TemplateResponse   `json:"template"`
+	OpenReports    int                `json:"open_reports"`
+	LatestReportAt time.Time          `json:"latest_report_at"`
+	Reports        []TemplateFeedback `json:"reports"`
+}
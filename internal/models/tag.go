@@ -0,0 +1,66 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Tag is a cross-cutting label that can be attached to any number of templates
+// (e.g., "requires witness", "bilingual", "court filing")
+type Tag struct {
+	ID          string         `gorm:"primaryKey" json:"id"`
+	Slug        string         `gorm:"not null" json:"slug"` // URL-safe identifier used for filtering (e.g., "court-filing"), unique among live tags
+	Name        string         `gorm:"not null" json:"name"` // Display name in Thai
+	NameEN      string         `json:"name_en"`              // Display name in English
+	Description string         `json:"description"`
+	Color       string         `json:"color"` // Optional color for badge
+	SortOrder   int            `gorm:"default:0" json:"sort_order"`
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+	DeletedAt   gorm.DeletedAt `gorm:"index" json:"-"`
+}
+
+func (Tag) TableName() string {
+	return "tags"
+}
+
+// TemplateTag links a template to a tag
+type TemplateTag struct {
+	TemplateID string    `gorm:"primaryKey" json:"template_id"`
+	TagID      string    `gorm:"primaryKey" json:"tag_id"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+func (TemplateTag) TableName() string {
+	return "template_tags"
+}
+
+// TagResponse is a clean tag for API responses
+type TagResponse struct {
+	ID     string `json:"id"`
+	Slug   string `json:"slug"`
+	Name   string `json:"name"`
+	NameEn string `json:"name_en,omitempty"`
+	Color  string `json:"color,omitempty"`
+}
+
+// ToResponse converts a Tag model to a TagResponse
+func (t *Tag) ToResponse() TagResponse {
+	return t.ToLocalizedResponse(DefaultLanguage)
+}
+
+// ToLocalizedResponse converts a Tag model to a TagResponse in lang
+func (t *Tag) ToLocalizedResponse(lang string) TagResponse {
+	resp := TagResponse{
+		ID:     t.ID,
+		Slug:   t.Slug,
+		Name:   t.Name,
+		NameEn: t.NameEN,
+		Color:  t.Color,
+	}
+	if lang == LanguageEnglish {
+		resp.Name = localizedText(t.NameEN, t.Name)
+	}
+	return resp
+}
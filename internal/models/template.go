@@ -34,6 +34,30 @@ const (
 	// Add more tiers as needed
 )
 
+// tierRanks orders tiers from lowest to highest access level
+var tierRanks = map[Tier]int{
+	TierFree:       0,
+	TierBasic:      1,
+	TierPremium:    2,
+	TierEnterprise: 3,
+}
+
+// IsValid reports whether the tier is one of the known tiers
+func (t Tier) IsValid() bool {
+	_, ok := tierRanks[t]
+	return ok
+}
+
+// Rank returns the access level of the tier (empty or unknown tiers rank as free)
+func (t Tier) Rank() int {
+	return tierRanks[t]
+}
+
+// Allows reports whether a user on this tier may access content requiring the given tier
+func (t Tier) Allows(required Tier) bool {
+	return t.Rank() >= required.Rank()
+}
+
 // PageOrientation represents the document page orientation
 type PageOrientation string
 
@@ -60,6 +84,7 @@ type Template struct {
 	Placeholders string         `gorm:"type:json" json:"placeholders"` // JSON array of placeholder strings
 	Aliases      string         `gorm:"type:json" json:"aliases"`      // JSON object mapping placeholders to aliases
 	FieldDefinitions string     `gorm:"type:json" json:"field_definitions"` // JSON object of field type definitions
+	Translations     string     `gorm:"type:json" json:"translations"`      // JSON object of language code -> TemplateTranslation
 	CreatedAt    time.Time      `json:"created_at"`
 	UpdatedAt    time.Time      `json:"updated_at"`
 	DeletedAt    gorm.DeletedAt `gorm:"index" json:"deleted_at,omitempty"`
@@ -78,18 +103,63 @@ type Template struct {
 	VariantName    string        `json:"variant_name"`                   // Name of this variant (e.g., "ด้านหน้า", "ด้านหลัง")
 	VariantOrder   int           `gorm:"default:0" json:"variant_order"` // Display order within document type
 
+	// Rating aggregates, maintained by the rating service (read-only here so template saves never overwrite them)
+	RatingAverage float64 `gorm:"->" json:"rating_average"`
+	RatingCount   int     `gorm:"->" json:"rating_count"`
+
 	// Page orientation (detected from DOCX)
 	PageOrientation PageOrientation `gorm:"type:varchar(20);default:'portrait'" json:"page_orientation"`
 
+	// Template source format (docx, pdf_form or overlay)
+	Format TemplateFormat `gorm:"type:varchar(20);default:'docx'" json:"format"`
+
 	// Relations
 	DocumentType *DocumentType `gorm:"foreignKey:DocumentTypeID" json:"document_type,omitempty"`
 	Documents    []Document    `gorm:"foreignKey:TemplateID" json:"documents,omitempty"`
+	Tags         []Tag         `gorm:"many2many:template_tags;joinForeignKey:TemplateID;joinReferences:TagID" json:"tags,omitempty"`
 }
 
 func (Template) TableName() string {
 	return "document_templates"
 }
 
+// MimeTypePDF is the MIME type of PDF templates (forms and overlay backgrounds) and of PDF documents
+const MimeTypePDF = "application/pdf"
+
+// TemplateFormat represents how a template is filled
+type TemplateFormat string
+
+const (
+	FormatDocx    TemplateFormat = "docx"     // Word document with {{placeholders}}
+	FormatPDFForm TemplateFormat = "pdf_form" // Fillable PDF (AcroForm)
+	FormatOverlay TemplateFormat = "overlay"  // Scanned background with positioned fields
+)
+
+// IsDocx reports whether the template is a DOCX (templates created before formats existed are DOCX)
+func (t *Template) IsDocx() bool {
+	return t.Format == FormatDocx || t.Format == ""
+}
+
+// IsPDFForm reports whether the template is a fillable PDF form
+func (t *Template) IsPDFForm() bool {
+	return t.Format == FormatPDFForm
+}
+
+// IsOverlay reports whether the template is a background PDF with positioned fields
+func (t *Template) IsOverlay() bool {
+	return t.Format == FormatOverlay
+}
+
+// Document statuses. Async documents go through queued -> processing -> completed or failed;
+// completed documents become expired once their files are deleted
+const (
+	DocumentStatusQueued     = "queued"
+	DocumentStatusProcessing = "processing"
+	DocumentStatusCompleted  = "completed"
+	DocumentStatusFailed     = "failed"
+	DocumentStatusExpired    = "expired"
+)
+
 type Document struct {
 	ID          string         `gorm:"primaryKey" json:"id"`
 	TemplateID  string         `gorm:"not null;index" json:"template_id"`
@@ -103,6 +173,11 @@ type Document struct {
 	MimeType    string         `json:"mime_type"`
 	Data        string         `gorm:"type:json" json:"data"` // JSON object of placeholder data used
 	Status      string         `gorm:"default:'completed'" json:"status"`
+	Flattened   bool           `gorm:"default:false" json:"flattened,omitempty"` // PDF form fields were flattened into page content
+	Warnings    []string       `gorm:"-" json:"warnings,omitempty"`              // Non-fatal processing warnings (not stored in DB)
+	ParentID    string         `gorm:"index" json:"parent_id,omitempty"`         // Document this one is a revision of
+	RootID      string         `gorm:"index" json:"root_id,omitempty"`           // First document of the revision chain
+	Revision    int            `gorm:"default:1" json:"revision"`                // 1 for the first document, counting up along the chain
 	CreatedAt   time.Time      `json:"created_at"`
 	UpdatedAt   time.Time      `json:"updated_at"`
 	DeletedAt   gorm.DeletedAt `gorm:"index" json:"deleted_at,omitempty"`
@@ -18,6 +18,8 @@ type TemplateResponse struct {
 	Tier          Tier         `json:"tier"`
 	IsVerified    bool         `json:"is_verified"`
 	IsAIAvailable bool         `json:"is_ai_available"`
+	IsLocked      bool         `json:"is_locked"`             // True when the caller's tier is below Tier (set by handlers)
+	IsFavorite    bool         `json:"is_favorite,omitempty"` // True when the caller has starred the template (set by handlers)
 
 	// Parsed template data (as proper objects, not JSON strings)
 	Placeholders     []string               `json:"placeholders"`
@@ -36,11 +38,33 @@ type TemplateResponse struct {
 	VariantOrder   int                   `json:"variant_order,omitempty"`
 	DocumentType   *DocumentTypeResponse `json:"document_type,omitempty"`
 
+	// Tags (cross-cutting labels)
+	Tags []TagResponse `json:"tags"`
+
+	// Community quality signal (average of 1-5 star ratings)
+	RatingAverage float64 `json:"rating_average"`
+	RatingCount   int     `json:"rating_count"`
+
+	// Localization: Name/Description/Remarks and field labels are in Language
+	// Translations carries all stored languages for editing
+	Language     string                         `json:"language"`
+	Translations map[string]TemplateTranslation `json:"translations,omitempty"`
+
+	// Search results only: body text snippets with matches wrapped in <mark></mark>
+	Highlights []string `json:"highlights,omitempty"`
+
 	// Timestamps
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
 }
 
+// TrashedTemplateResponse is a deleted template in GET /api/v1/templates/trash
+type TrashedTemplateResponse struct {
+	TemplateResponse
+	DeletedAt time.Time `json:"deleted_at"`
+	PurgeAt   time.Time `json:"purge_at"` // When the template will be permanently deleted
+}
+
 // DocumentTypeResponse is a clean document type for API response
 type DocumentTypeResponse struct {
 	ID          string             `json:"id"`
@@ -54,7 +78,10 @@ type DocumentTypeResponse struct {
 
 // TemplateListResponse is the response for GET /api/v1/templates
 type TemplateListResponse struct {
-	Templates []TemplateResponse `json:"templates"`
+	Templates  []TemplateResponse `json:"templates"`
+	Total      int64              `json:"total"`                 // Templates matching the filter across all pages
+	NextCursor string             `json:"next_cursor,omitempty"` // Pass as ?cursor= to get the next page
+	HasMore    bool               `json:"has_more"`
 }
 
 // GroupedTemplatesResponse is the response for GET /api/v1/templates?grouped=true
@@ -63,8 +90,14 @@ type GroupedTemplatesResponse struct {
 	OrphanTemplates []TemplateResponse     `json:"orphan_templates"`
 }
 
-// ToResponse converts a Template model to a clean TemplateResponse
+// ToResponse converts a Template model to a clean TemplateResponse in the default language
 func (t *Template) ToResponse() TemplateResponse {
+	return t.ToLocalizedResponse(DefaultLanguage)
+}
+
+// ToLocalizedResponse converts a Template model to a clean TemplateResponse in lang
+// Missing translations fall back to the default-language fields
+func (t *Template) ToLocalizedResponse(lang string) TemplateResponse {
 	resp := TemplateResponse{
 		ID:             t.ID,
 		Name:           t.getDisplayName(),
@@ -82,6 +115,8 @@ func (t *Template) ToResponse() TemplateResponse {
 		DocumentTypeID: t.DocumentTypeID,
 		VariantName:    t.VariantName,
 		VariantOrder:   t.VariantOrder,
+		RatingAverage:  t.RatingAverage,
+		RatingCount:    t.RatingCount,
 		CreatedAt:      t.CreatedAt,
 		UpdatedAt:      t.UpdatedAt,
 	}
@@ -121,7 +156,30 @@ func (t *Template) ToResponse() TemplateResponse {
 
 	// Add document type if loaded
 	if t.DocumentType != nil {
-		resp.DocumentType = t.DocumentType.ToResponse()
+		resp.DocumentType = t.DocumentType.ToLocalizedResponse(lang)
+	}
+
+	// Convert tags if loaded
+	resp.Tags = make([]TagResponse, len(t.Tags))
+	for i := range t.Tags {
+		resp.Tags[i] = t.Tags[i].ToLocalizedResponse(lang)
+	}
+
+	// Apply translations for the requested language
+	resp.Language = lang
+	if t.Translations != "" {
+		var translations map[string]TemplateTranslation
+		if err := json.Unmarshal([]byte(t.Translations), &translations); err == nil && len(translations) > 0 {
+			resp.Translations = translations
+		}
+	}
+	if lang != DefaultLanguage {
+		if translation, ok := resp.Translations[lang]; ok {
+			resp.Name = localizedText(translation.Name, resp.Name)
+			resp.Description = localizedText(translation.Description, resp.Description)
+			resp.Remarks = localizedText(translation.Remarks, resp.Remarks)
+		}
+		localizeFieldDefinitions(resp.FieldDefinitions, lang)
 	}
 
 	return resp
@@ -138,17 +196,59 @@ func (t *Template) getDisplayName() string {
 	return t.OriginalName
 }
 
+// localizedText returns the translated text, or the fallback if there is no translation
+func localizedText(translated, fallback string) string {
+	if translated != "" {
+		return translated
+	}
+	return fallback
+}
+
+// localizeFieldDefinitions replaces each field's label and description with its translation in lang
+func localizeFieldDefinitions(fieldDefs map[string]interface{}, lang string) {
+	for _, raw := range fieldDefs {
+		def, ok := raw.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		translations, ok := def["translations"].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		translation, ok := translations[lang].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		for _, key := range []string{"label", "description"} {
+			if text, ok := translation[key].(string); ok && text != "" {
+				def[key] = text
+			}
+		}
+	}
+}
+
 // ToResponseList converts a slice of Templates to TemplateResponses
 func ToResponseList(templates []Template) []TemplateResponse {
+	return ToLocalizedResponseList(templates, DefaultLanguage)
+}
+
+// ToLocalizedResponseList converts a slice of Templates to TemplateResponses in lang
+func ToLocalizedResponseList(templates []Template, lang string) []TemplateResponse {
 	responses := make([]TemplateResponse, len(templates))
 	for i := range templates {
-		responses[i] = templates[i].ToResponse()
+		responses[i] = templates[i].ToLocalizedResponse(lang)
 	}
 	return responses
 }
 
 // ToResponse converts a DocumentType model to a clean DocumentTypeResponse
 func (dt *DocumentType) ToResponse() *DocumentTypeResponse {
+	return dt.ToLocalizedResponse(DefaultLanguage)
+}
+
+// ToLocalizedResponse converts a DocumentType model to a DocumentTypeResponse in lang
+// Name is NameEN for English when set; NameEn is always included
+func (dt *DocumentType) ToLocalizedResponse(lang string) *DocumentTypeResponse {
 	if dt == nil {
 		return nil
 	}
@@ -160,10 +260,13 @@ func (dt *DocumentType) ToResponse() *DocumentTypeResponse {
 		Category:    dt.Category,
 		Description: dt.Description,
 	}
+	if lang == LanguageEnglish {
+		resp.Name = localizedText(dt.NameEN, dt.Name)
+	}
 
 	// Convert templates if present
 	if dt.Templates != nil {
-		resp.Templates = ToResponseList(dt.Templates)
+		resp.Templates = ToLocalizedResponseList(dt.Templates, lang)
 	}
 
 	return resp
@@ -171,9 +274,14 @@ func (dt *DocumentType) ToResponse() *DocumentTypeResponse {
 
 // ToResponseListDocTypes converts a slice of DocumentTypes to DocumentTypeResponses
 func ToResponseListDocTypes(docTypes []DocumentType) []DocumentTypeResponse {
+	return ToLocalizedResponseListDocTypes(docTypes, DefaultLanguage)
+}
+
+// ToLocalizedResponseListDocTypes converts a slice of DocumentTypes to DocumentTypeResponses in lang
+func ToLocalizedResponseListDocTypes(docTypes []DocumentType, lang string) []DocumentTypeResponse {
 	responses := make([]DocumentTypeResponse, len(docTypes))
 	for i := range docTypes {
-		resp := docTypes[i].ToResponse()
+		resp := docTypes[i].ToLocalizedResponse(lang)
 		if resp != nil {
 			responses[i] = *resp
 		}
@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+// TemplateSearchDocument holds the full-text search index for a template
+// BodyText is the plain text extracted from the DOCX, kept so the index can be
+// rebuilt from metadata changes and so search results can show highlighted snippets
+// The search_vector column is maintained with raw SQL (see services/template_search.go)
+type TemplateSearchDocument struct {
+	TemplateID string    `gorm:"primaryKey" json:"template_id"`
+	BodyText   string    `json:"body_text"`
+	IndexedAt  time.Time `json:"indexed_at"`
+}
+
+func (TemplateSearchDocument) TableName() string {
+	return "template_search_documents"
+}
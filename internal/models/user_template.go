@@ -0,0 +1,39 @@
+package models
+
+import "time"
+
+// TemplateFavorite is a template starred by a user (user ID from the X-User-ID gateway header)
+type TemplateFavorite struct {
+	UserID     string    `gorm:"primaryKey" json:"user_id"`
+	TemplateID string    `gorm:"primaryKey" json:"template_id"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+func (TemplateFavorite) TableName() string {
+	return "template_favorites"
+}
+
+// UserTemplateUsage counts a user's form submissions per template
+type UserTemplateUsage struct {
+	UserID     string    `gorm:"primaryKey" json:"user_id"`
+	TemplateID string    `gorm:"primaryKey" json:"template_id"`
+	UseCount   int64     `json:"use_count"`
+	LastUsedAt time.Time `json:"last_used_at"`
+}
+
+func (UserTemplateUsage) TableName() string {
+	return "user_template_usage"
+}
+
+// FavoriteTemplateResponse is a template in GET /api/v1/me/templates/favorites
+type FavoriteTemplateResponse struct {
+	TemplateResponse
+	FavoritedAt time.Time `json:"favorited_at"`
+}
+
+// RecentTemplateResponse is a template in GET /api/v1/me/templates/recent
+type RecentTemplateResponse struct {
+	TemplateResponse
+	LastUsedAt time.Time `json:"last_used_at"`
+	UseCount   int64     `json:"use_count"`
+}
@@ -0,0 +1,121 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Webhook events
+const (
+	WebhookEventDocumentCompleted  = "document.completed"  // A document was processed (sync or async)
+	WebhookEventDocumentFailed     = "document.failed"     // An async document failed for good
+	WebhookEventDocumentDownloaded = "document.downloaded" // A processed document was downloaded
+	WebhookEventTemplatePublished  = "template.published"  // A template was uploaded and is available for processing
+	WebhookEventTemplateUpdated    = "template.updated"    // Template metadata, files, fields or layout changed
+	WebhookEventPing               = "ping"                // Test delivery, only sent on request
+	WebhookEventAll                = "*"
+)
+
+// WebhookEvents lists the events a webhook can subscribe to
+var WebhookEvents = []string{
+	WebhookEventDocumentCompleted,
+	WebhookEventDocumentFailed,
+	WebhookEventDocumentDownloaded,
+	WebhookEventTemplatePublished,
+	WebhookEventTemplateUpdated,
+}
+
+// Webhook delivery statuses
+const (
+	WebhookDeliveryPending   = "pending"
+	WebhookDeliverySucceeded = "succeeded"
+	WebhookDeliveryFailed    = "failed"
+)
+
+// Webhook is an outbound subscription: matching events are POSTed to URL, signed with Secret
+type Webhook struct {
+	ID        string    `gorm:"primaryKey" json:"id"`
+	Name      string    `json:"name"`
+	URL       string    `gorm:"not null" json:"url"`
+	Secret    string    `gorm:"not null" json:"-"`  // HMAC-SHA256 signing key, only returned on creation
+	Events    string    `gorm:"type:json" json:"-"` // JSON array of subscribed events ("*" for all)
+	Active    bool      `gorm:"default:true" json:"active"`
+	CreatedBy string    `json:"created_by,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func (Webhook) TableName() string {
+	return "webhooks"
+}
+
+// GetEvents parses the subscribed events
+func (w *Webhook) GetEvents() []string {
+	events := []string{}
+	if w.Events != "" {
+		json.Unmarshal([]byte(w.Events), &events)
+	}
+	return events
+}
+
+// Subscribes reports whether the webhook receives the event
+func (w *Webhook) Subscribes(event string) bool {
+	for _, subscribed := range w.GetEvents() {
+		if subscribed == event || subscribed == WebhookEventAll {
+			return true
+		}
+	}
+	return false
+}
+
+// WebhookResponse is a webhook with its events parsed
+// Secret is only set in the response to creation and secret rotation
+type WebhookResponse struct {
+	Webhook
+	Events []string `json:"events"`
+	Secret string   `json:"secret,omitempty"`
+}
+
+// ToResponse converts a Webhook to a response without its secret
+func (w *Webhook) ToResponse() WebhookResponse {
+	return WebhookResponse{Webhook: *w, Events: w.GetEvents()}
+}
+
+// WebhookDelivery is one event sent (or to be sent) to one webhook, kept as the delivery log
+type WebhookDelivery struct {
+	ID             string     `gorm:"primaryKey" json:"id"`
+	WebhookID      string     `gorm:"not null;index" json:"webhook_id"`
+	Event          string     `gorm:"type:varchar(50)" json:"event"`
+	EventID        string     `gorm:"index" json:"event_id"`                            // Same for every webhook receiving the event
+	Payload        string     `gorm:"type:json" json:"-"`                               // Request body sent to the webhook
+	Status         string     `gorm:"type:varchar(20);default:'pending'" json:"status"` // pending, succeeded, failed
+	Attempts       int        `json:"attempts"`
+	ResponseStatus int        `json:"response_status,omitempty"` // HTTP status of the last attempt
+	ResponseBody   string     `json:"response_body,omitempty"`   // Start of the last response body
+	Error          string     `json:"error,omitempty"`           // Why the last attempt failed
+	DurationMs     int64      `json:"duration_ms,omitempty"`     // Duration of the last attempt
+	NextAttemptAt  *time.Time `json:"next_attempt_at,omitempty"` // When a pending delivery is (re)tried
+	LockedAt       *time.Time `json:"-"`
+	DeliveredAt    *time.Time `json:"delivered_at,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+func (WebhookDelivery) TableName() string {
+	return "webhook_deliveries"
+}
+
+// WebhookDeliveryResponse is a delivery with its payload as JSON
+type WebhookDeliveryResponse struct {
+	WebhookDelivery
+	Payload json.RawMessage `json:"payload"`
+}
+
+// ToResponse converts a WebhookDelivery to a response
+func (d *WebhookDelivery) ToResponse() WebhookDeliveryResponse {
+	response := WebhookDeliveryResponse{WebhookDelivery: *d}
+	if json.Valid([]byte(d.Payload)) {
+		response.Payload = json.RawMessage(d.Payload)
+	}
+	return response
+}
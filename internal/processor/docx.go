@@ -300,6 +300,49 @@ func (dp *DocxProcessor) replaceXMLSplit(content, placeholder, value string) (st
 	return resultStr, true
 }
 
+// RenamePlaceholders rewrites placeholder tokens in document.xml (e.g., "{{old}}" -> "{{new}}")
+// Tokens split across runs are merged into the first run, keeping its formatting
+// Renames go through temporary tokens so swaps (a -> b, b -> a) work
+// Returns the number of tokens renamed per old placeholder
+func (dp *DocxProcessor) RenamePlaceholders(renames map[string]string) (map[string]int, error) {
+	documentPath := filepath.Join(dp.tempDir, "word", "document.xml")
+	content, err := os.ReadFile(documentPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read document.xml: %w", err)
+	}
+
+	contentStr := string(content)
+	counts := make(map[string]int, len(renames))
+	temporary := make(map[string]string, len(renames))
+
+	i := 0
+	for oldToken, newToken := range renames {
+		i++
+		tempToken := fmt.Sprintf("{{__rename_%d_%d__}}", time.Now().UnixNano(), i)
+		counts[oldToken] = strings.Count(dp.removeXMLTags(contentStr), oldToken)
+		contentStr = dp.replaceAllWithXMLHandling(contentStr, oldToken, tempToken)
+		temporary[tempToken] = escapeXML(newToken)
+	}
+	for tempToken, newToken := range temporary {
+		contentStr = strings.ReplaceAll(contentStr, tempToken, newToken)
+	}
+
+	if err := os.WriteFile(documentPath, []byte(contentStr), 0644); err != nil {
+		return nil, fmt.Errorf("failed to write document.xml: %w", err)
+	}
+
+	return counts, nil
+}
+
+// replaceAllWithXMLHandling replaces every occurrence of placeholder, both whole and split across runs
+func (dp *DocxProcessor) replaceAllWithXMLHandling(content, placeholder, value string) string {
+	content = strings.ReplaceAll(content, placeholder, value)
+	if strings.Contains(dp.removeXMLTags(content), placeholder) {
+		content, _ = dp.replaceXMLSplit(content, placeholder, value)
+	}
+	return content
+}
+
 func (dp *DocxProcessor) checkPlaceholderMatch(content string, startPos int, placeholder string) (bool, int) {
 	placeholderChars := []rune(placeholder)
 	contentRunes := []rune(content)
@@ -416,6 +459,29 @@ func (dp *DocxProcessor) ExtractPlaceholders() ([]string, error) {
 	return placeholders, nil
 }
 
+// ExtractText returns the plain body text of the document, one line per paragraph
+// XML entities are decoded so the text can be indexed and searched
+func (dp *DocxProcessor) ExtractText() (string, error) {
+	documentPath := filepath.Join(dp.tempDir, "word", "document.xml")
+	content, err := os.ReadFile(documentPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to read document.xml: %w", err)
+	}
+
+	// Keep paragraph and tab boundaries before stripping tags
+	contentStr := strings.ReplaceAll(string(content), "</w:p>", "</w:p>\n")
+	contentStr = strings.ReplaceAll(contentStr, "<w:tab/>", "<w:tab/> ")
+
+	var lines []string
+	for _, line := range strings.Split(html.UnescapeString(dp.removeXMLTags(contentStr)), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+
+	return strings.Join(lines, "\n"), nil
+}
+
 func (dp *DocxProcessor) DetectOrientation() (bool, error) {
 	contentPath := filepath.Join(dp.tempDir, "word", "document.xml")
 	content, err := os.ReadFile(contentPath)
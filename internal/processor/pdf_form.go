@@ -0,0 +1,410 @@
+package processor
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// PDF form field types
+const (
+	PDFFieldText      = "text"
+	PDFFieldCheckbox  = "checkbox"
+	PDFFieldRadio     = "radio"
+	PDFFieldChoice    = "choice"
+	PDFFieldSignature = "signature"
+)
+
+// Field flags (PDF 32000-1, 12.7.3.1 and 12.7.4)
+const (
+	pdfFlagReadOnly   = 1 << 0
+	pdfFlagRequired   = 1 << 1
+	pdfFlagMultiline  = 1 << 12
+	pdfFlagRadio      = 1 << 15
+	pdfFlagPushButton = 1 << 16
+	pdfFlagCombo      = 1 << 17
+)
+
+// PDFFormField is one fillable AcroForm field
+type PDFFormField struct {
+	Name      string     `json:"name"`            // Fully qualified name (parent.child)
+	Label     string     `json:"label,omitempty"` // Tooltip (/TU), shown to users instead of the name
+	Type      string     `json:"type"`            // text, checkbox, radio, choice or signature
+	Multiline bool       `json:"multiline,omitempty"`
+	Combo     bool       `json:"combo,omitempty"` // Drop-down choice (otherwise a list box)
+	Required  bool       `json:"required,omitempty"`
+	ReadOnly  bool       `json:"read_only,omitempty"`
+	MaxLength int        `json:"max_length,omitempty"`
+	Options   []string   `json:"options,omitempty"`  // Choice export values, or radio button states
+	OnValue   string     `json:"on_value,omitempty"` // Checked state of a checkbox
+	Value     string     `json:"value,omitempty"`    // Current value
+	Page      int        `json:"page,omitempty"`     // 1-based page of the first widget
+	Rect      [4]float64 `json:"rect"`               // First widget rectangle (x1, y1, x2, y2 in PDF points)
+}
+
+// pdfFieldNode is a terminal field with its widget annotations
+type pdfFieldNode struct {
+	field   PDFFormField
+	dict    pdfDict
+	widgets []pdfDict
+	states  [][]string // "On" appearance states per widget (nil when the widget has no appearance)
+}
+
+// ExtractPDFFormFields reads the AcroForm fields of a PDF
+// Push buttons are skipped since they carry no data
+func ExtractPDFFormFields(pdfPath string) ([]PDFFormField, error) {
+	data, err := os.ReadFile(pdfPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read PDF: %w", err)
+	}
+	doc, err := parsePDF(data)
+	if err != nil {
+		return nil, err
+	}
+
+	nodes := collectPDFFields(doc)
+	fields := make([]PDFFormField, len(nodes))
+	for i, node := range nodes {
+		fields[i] = node.field
+	}
+	return fields, nil
+}
+
+// HasPDFForm reports whether a PDF contains at least one fillable field
+func HasPDFForm(pdfPath string) (bool, error) {
+	fields, err := ExtractPDFFormFields(pdfPath)
+	if err != nil {
+		return false, err
+	}
+	return len(fields) > 0, nil
+}
+
+// FillPDFForm fills the AcroForm fields of inputPath with values (keyed by field name)
+// and writes the result to outputPath
+// Text and choice appearances are dropped and /NeedAppearances is set so viewers (and
+// flattening) regenerate them with the new values; checkbox and radio states are switched
+// directly. Values that do not fit a field are reported as warnings, not errors
+func FillPDFForm(inputPath, outputPath string, values map[string]string) ([]string, error) {
+	data, err := os.ReadFile(inputPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read PDF: %w", err)
+	}
+	doc, err := parsePDF(data)
+	if err != nil {
+		return nil, err
+	}
+
+	acroForm := doc.dict(doc.catalog()["AcroForm"])
+	if acroForm == nil {
+		return nil, fmt.Errorf("PDF has no form fields")
+	}
+
+	var warnings []string
+	for _, node := range collectPDFFields(doc) {
+		value, ok := values[node.field.Name]
+		if !ok {
+			continue
+		}
+
+		switch node.field.Type {
+		case PDFFieldText:
+			if node.field.MaxLength > 0 && len([]rune(value)) > node.field.MaxLength {
+				warnings = append(warnings, fmt.Sprintf("%s: value truncated to %d characters", node.field.Name, node.field.MaxLength))
+				value = string([]rune(value)[:node.field.MaxLength])
+			}
+			node.setTextValue(value)
+		case PDFFieldChoice:
+			if value != "" && len(node.field.Options) > 0 && !containsString(node.field.Options, value) {
+				warnings = append(warnings, fmt.Sprintf("%s: %q is not one of the field options", node.field.Name, value))
+			}
+			node.setTextValue(value)
+			delete(node.dict, "I")
+		case PDFFieldCheckbox:
+			state := pdfName("Off")
+			if isCheckedValue(value, node.field.OnValue) {
+				state = pdfName(node.field.OnValue)
+			}
+			node.setState(state)
+		case PDFFieldRadio:
+			if value == "" {
+				node.setState("Off")
+				continue
+			}
+			state, found := matchRadioState(node.field.Options, value)
+			if !found {
+				warnings = append(warnings, fmt.Sprintf("%s: %q is not one of the radio options", node.field.Name, value))
+				continue
+			}
+			node.setState(pdfName(state))
+		case PDFFieldSignature:
+			if value != "" {
+				warnings = append(warnings, fmt.Sprintf("%s: signature fields cannot be filled", node.field.Name))
+			}
+		}
+	}
+
+	acroForm["NeedAppearances"] = pdfBool(true)
+	// An XFA form would take precedence over the AcroForm values in XFA-aware viewers
+	delete(acroForm, "XFA")
+
+	output, err := os.Create(outputPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create output PDF: %w", err)
+	}
+	defer output.Close()
+
+	if err := doc.write(output); err != nil {
+		return nil, fmt.Errorf("failed to write output PDF: %w", err)
+	}
+	return warnings, nil
+}
+
+// setTextValue sets /V and removes the stale widget appearances
+func (n *pdfFieldNode) setTextValue(value string) {
+	n.dict["V"] = encodePDFText(value)
+	for _, widget := range n.widgets {
+		delete(widget, "AP")
+	}
+}
+
+// setState sets the field value and switches each widget to the matching appearance
+func (n *pdfFieldNode) setState(state pdfName) {
+	n.dict["V"] = state
+	for i, widget := range n.widgets {
+		if state != "Off" && (n.states[i] == nil || containsString(n.states[i], string(state))) {
+			widget["AS"] = state
+		} else {
+			widget["AS"] = pdfName("Off")
+		}
+	}
+}
+
+// widgetStates returns the "on" appearance states of a widget (every /AP /N key except Off)
+func widgetStates(doc *pdfDocument, widget pdfDict) []string {
+	ap := doc.dict(widget["AP"])
+	if ap == nil {
+		return nil
+	}
+	normal := doc.dict(ap["N"])
+	var states []string
+	for key := range normal {
+		if key != "Off" {
+			states = append(states, string(key))
+		}
+	}
+	sort.Strings(states)
+	return states
+}
+
+// collectPDFFields walks the AcroForm field tree and returns the terminal fields in order
+// FT, Ff, Opt and V are inherited from parent fields as the specification requires
+func collectPDFFields(doc *pdfDocument) []*pdfFieldNode {
+	acroForm := doc.dict(doc.catalog()["AcroForm"])
+	if acroForm == nil {
+		return nil
+	}
+
+	// Map widget annotations to pages through the page /Annots arrays
+	annotPages := make(map[int]int)
+	for i, page := range doc.pages() {
+		for _, annot := range doc.array(doc.dict(pdfRef{num: page})["Annots"]) {
+			if ref, ok := annot.(pdfRef); ok {
+				annotPages[ref.num] = i + 1
+			}
+		}
+	}
+
+	type inherited struct {
+		name string
+		ft   pdfName
+		ff   int
+		opt  pdfObject
+		v    pdfObject
+	}
+
+	var nodes []*pdfFieldNode
+	visited := make(map[int]bool)
+	var walk func(obj pdfObject, parent inherited)
+	walk = func(obj pdfObject, parent inherited) {
+		if ref, ok := obj.(pdfRef); ok {
+			if visited[ref.num] {
+				return
+			}
+			visited[ref.num] = true
+		}
+		dict := doc.dict(obj)
+		if dict == nil {
+			return
+		}
+
+		current := parent
+		if partial := pdfText(doc.resolve(dict["T"])); partial != "" {
+			if current.name != "" {
+				current.name += "." + partial
+			} else {
+				current.name = partial
+			}
+		}
+		if ft, ok := doc.resolve(dict["FT"]).(pdfName); ok {
+			current.ft = ft
+		}
+		if ff, ok := doc.resolve(dict["Ff"]).(pdfNumber); ok {
+			current.ff = ff.int()
+		}
+		if opt, ok := dict["Opt"]; ok {
+			current.opt = opt
+		}
+		if v, ok := dict["V"]; ok {
+			current.v = v
+		}
+
+		// Kids with a partial name are child fields; kids without one are widgets
+		var childFields, widgets []pdfObject
+		for _, kid := range doc.array(dict["Kids"]) {
+			kidDict := doc.dict(kid)
+			if kidDict == nil {
+				continue
+			}
+			if _, named := kidDict["T"]; named {
+				childFields = append(childFields, kid)
+			} else {
+				widgets = append(widgets, kid)
+			}
+		}
+		if len(childFields) > 0 {
+			for _, child := range childFields {
+				walk(child, current)
+			}
+			return
+		}
+		if current.name == "" || current.ft == "" {
+			return
+		}
+		if len(widgets) == 0 {
+			widgets = []pdfObject{obj} // Field and widget merged in one dictionary
+		}
+
+		node := &pdfFieldNode{dict: dict}
+		field := PDFFormField{
+			Name:     current.name,
+			Label:    pdfText(doc.resolve(dict["TU"])),
+			Required: current.ff&pdfFlagRequired != 0,
+			ReadOnly: current.ff&pdfFlagReadOnly != 0,
+			Value:    pdfText(doc.resolve(current.v)),
+		}
+		if maxLen, ok := doc.resolve(dict["MaxLen"]).(pdfNumber); ok {
+			field.MaxLength = maxLen.int()
+		}
+
+		for _, widget := range widgets {
+			widgetDict := doc.dict(widget)
+			node.widgets = append(node.widgets, widgetDict)
+			node.states = append(node.states, widgetStates(doc, widgetDict))
+			ref, _ := widget.(pdfRef)
+			if field.Page == 0 {
+				field.Page = annotPages[ref.num]
+			}
+		}
+		if rect := doc.array(node.widgets[0]["Rect"]); len(rect) == 4 {
+			for i, item := range rect {
+				if number, ok := doc.resolve(item).(pdfNumber); ok {
+					field.Rect[i] = number.float()
+				}
+			}
+		}
+
+		switch current.ft {
+		case "Tx":
+			field.Type = PDFFieldText
+			field.Multiline = current.ff&pdfFlagMultiline != 0
+		case "Ch":
+			field.Type = PDFFieldChoice
+			field.Combo = current.ff&pdfFlagCombo != 0
+			for _, option := range doc.array(current.opt) {
+				// An option is either a text or an [export value, display text] pair
+				if pair := doc.array(option); len(pair) > 0 {
+					field.Options = append(field.Options, pdfText(doc.resolve(pair[0])))
+				} else {
+					field.Options = append(field.Options, pdfText(doc.resolve(option)))
+				}
+			}
+		case "Btn":
+			if current.ff&pdfFlagPushButton != 0 {
+				return
+			}
+			var states []string
+			for _, widgetStates := range node.states {
+				for _, state := range widgetStates {
+					if !containsString(states, state) {
+						states = append(states, state)
+					}
+				}
+			}
+			if current.ff&pdfFlagRadio != 0 {
+				field.Type = PDFFieldRadio
+				field.Options = states
+			} else {
+				field.Type = PDFFieldCheckbox
+				field.OnValue = "Yes"
+				if len(states) > 0 {
+					field.OnValue = states[0]
+				}
+			}
+		case "Sig":
+			field.Type = PDFFieldSignature
+		default:
+			return
+		}
+
+		node.field = field
+		nodes = append(nodes, node)
+	}
+
+	for _, field := range doc.array(acroForm["Fields"]) {
+		walk(field, inherited{})
+	}
+	return nodes
+}
+
+// isCheckedValue reports whether a submitted value checks a checkbox
+func isCheckedValue(value, onValue string) bool {
+	value = strings.TrimSpace(value)
+	if onValue != "" && strings.EqualFold(value, onValue) {
+		return true
+	}
+	switch strings.ToLower(value) {
+	case "true", "yes", "on", "x", "✓", "✔", "/", "checked", "ใช่":
+		return true
+	}
+	if n, err := strconv.ParseFloat(value, 64); err == nil {
+		return n != 0
+	}
+	return false
+}
+
+// matchRadioState finds the radio state for a value, ignoring case and surrounding spaces
+func matchRadioState(states []string, value string) (string, bool) {
+	value = strings.TrimSpace(value)
+	for _, state := range states {
+		if state == value {
+			return state, true
+		}
+	}
+	for _, state := range states {
+		if strings.EqualFold(state, value) {
+			return state, true
+		}
+	}
+	return "", false
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
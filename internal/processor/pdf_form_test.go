@@ -0,0 +1,133 @@
+package processor
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// formPDF has a text field (max 5 characters), a checkbox and a radio group on one page
+func formPDF() []byte {
+	return buildPDF(
+		"<< /Type /Catalog /Pages 2 0 R /AcroForm << /Fields [4 0 R 5 0 R 6 0 R] >> >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] /Annots [4 0 R 5 0 R 7 0 R 8 0 R] >>",
+		"<< /Type /Annot /Subtype /Widget /FT /Tx /T (name) /TU (Full name) /MaxLen 5 /Ff 2 /Rect [10 20 110 40] >>",
+		"<< /Type /Annot /Subtype /Widget /FT /Btn /T (agree) /AP << /N << /On 9 0 R /Off 9 0 R >> >> /Rect [0 0 10 10] >>",
+		"<< /FT /Btn /Ff 32768 /T (size) /Kids [7 0 R 8 0 R] >>",
+		"<< /Type /Annot /Subtype /Widget /Parent 6 0 R /AP << /N << /S 9 0 R /Off 9 0 R >> >> /Rect [0 0 10 10] >>",
+		"<< /Type /Annot /Subtype /Widget /Parent 6 0 R /AP << /N << /L 9 0 R /Off 9 0 R >> >> /Rect [20 0 30 10] >>",
+		"<< /Length 0 >>\nstream\n\nendstream",
+	)
+}
+
+func writeTestPDF(t *testing.T, data []byte) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "form.pdf")
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatalf("write PDF: %v", err)
+	}
+	return path
+}
+
+func fieldsByName(fields []PDFFormField) map[string]PDFFormField {
+	byName := make(map[string]PDFFormField, len(fields))
+	for _, field := range fields {
+		byName[field.Name] = field
+	}
+	return byName
+}
+
+func TestExtractPDFFormFields(t *testing.T) {
+	fields, err := ExtractPDFFormFields(writeTestPDF(t, formPDF()))
+	if err != nil {
+		t.Fatalf("ExtractPDFFormFields: %v", err)
+	}
+	byName := fieldsByName(fields)
+	if len(byName) != 3 {
+		t.Fatalf("fields = %+v, want name, agree and size", fields)
+	}
+
+	name := byName["name"]
+	if name.Type != PDFFieldText || name.Label != "Full name" || name.MaxLength != 5 || !name.Required || name.Page != 1 {
+		t.Errorf("name = %+v", name)
+	}
+	if name.Rect != [4]float64{10, 20, 110, 40} {
+		t.Errorf("name.Rect = %v", name.Rect)
+	}
+	if agree := byName["agree"]; agree.Type != PDFFieldCheckbox || agree.OnValue != "On" {
+		t.Errorf("agree = %+v", agree)
+	}
+	if size := byName["size"]; size.Type != PDFFieldRadio || strings.Join(size.Options, ",") != "S,L" {
+		t.Errorf("size = %+v", size)
+	}
+}
+
+func TestFillPDFForm(t *testing.T) {
+	input := writeTestPDF(t, formPDF())
+	output := filepath.Join(t.TempDir(), "filled.pdf")
+
+	warnings, err := FillPDFForm(input, output, map[string]string{
+		"name":  "Somchai",
+		"agree": "yes",
+		"size":  "XL",
+	})
+	if err != nil {
+		t.Fatalf("FillPDFForm: %v", err)
+	}
+	if len(warnings) != 2 {
+		t.Fatalf("warnings = %q, want a truncation and an unknown radio option", warnings)
+	}
+
+	fields, err := ExtractPDFFormFields(output)
+	if err != nil {
+		t.Fatalf("ExtractPDFFormFields(filled): %v", err)
+	}
+	byName := fieldsByName(fields)
+	if got := byName["name"].Value; got != "Somch" {
+		t.Errorf("name = %q, want the value truncated to 5 characters", got)
+	}
+	if got := byName["agree"].Value; got != "On" {
+		t.Errorf("agree = %q, want On", got)
+	}
+}
+
+func TestFillPDFFormWithoutForm(t *testing.T) {
+	input := writeTestPDF(t, minimalPDF())
+	if _, err := FillPDFForm(input, filepath.Join(t.TempDir(), "out.pdf"), map[string]string{"a": "b"}); err == nil {
+		t.Fatal("FillPDFForm succeeded on a PDF without a form")
+	}
+	if hasForm, err := HasPDFForm(input); err != nil || hasForm {
+		t.Fatalf("HasPDFForm = %v, %v; want false", hasForm, err)
+	}
+}
+
+func TestExtractPDFFormFieldsCyclicKids(t *testing.T) {
+	data := buildPDF(
+		"<< /Type /Catalog /Pages 2 0 R /AcroForm << /Fields [3 0 R] >> >>",
+		"<< /Type /Pages /Kids [] /Count 0 >>",
+		"<< /T (a) /FT /Tx /Kids [4 0 R] >>",
+		"<< /T (b) /Kids [3 0 R 4 0 R] >>",
+	)
+	fields, err := ExtractPDFFormFields(writeTestPDF(t, data))
+	if err != nil {
+		t.Fatalf("ExtractPDFFormFields: %v", err)
+	}
+	if len(fields) != 0 {
+		t.Fatalf("fields = %+v, want none", fields)
+	}
+}
+
+func TestExtractPDFFormFieldsTruncated(t *testing.T) {
+	data := formPDF()
+	dir := t.TempDir()
+	// Every prefix must either parse or fail cleanly
+	for n := 0; n < len(data); n += 7 {
+		path := filepath.Join(dir, "truncated.pdf")
+		if err := os.WriteFile(path, data[:n], 0o644); err != nil {
+			t.Fatalf("write PDF: %v", err)
+		}
+		ExtractPDFFormFields(path)
+	}
+}
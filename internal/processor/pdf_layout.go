@@ -0,0 +1,190 @@
+package processor
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os/exec"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// IsPDFTextLayoutAvailable checks if poppler's pdftotext is available for text bounding boxes
+func IsPDFTextLayoutAvailable() bool {
+	_, err := exec.LookPath("pdftotext")
+	return err == nil
+}
+
+// pdfWord is a word on a PDF page with its bounding box
+type pdfWord struct {
+	text           string
+	x0, y0, x1, y1 float64
+}
+
+// LocatePlaceholdersInPDF finds the bounding box of every "{{...}}" token in a PDF
+// Returns boxes per placeholder in reading order (page, then pdftotext word order)
+// Tokens split across words (e.g., wrapped lines) are matched and their boxes merged
+func LocatePlaceholdersInPDF(pdfPath string) (map[string][]PDFBox, error) {
+	cmd := exec.Command("pdftotext", "-bbox", "-enc", "UTF-8", pdfPath, "-")
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("pdftotext failed: %w, stderr: %s", err, stderr.String())
+	}
+
+	boxes := make(map[string][]PDFBox)
+	decoder := xml.NewDecoder(bytes.NewReader(output))
+	decoder.Strict = false
+	decoder.AutoClose = xml.HTMLAutoClose
+	decoder.Entity = xml.HTMLEntity
+
+	var (
+		pageNumber            int
+		pageWidth, pageHeight float64
+		words                 []pdfWord
+		current               *pdfWord
+	)
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse pdftotext output: %w", err)
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "page":
+				pageNumber++
+				pageWidth = xmlFloatAttr(t, "width")
+				pageHeight = xmlFloatAttr(t, "height")
+				words = words[:0]
+			case "word":
+				current = &pdfWord{
+					x0: xmlFloatAttr(t, "xMin"),
+					y0: xmlFloatAttr(t, "yMin"),
+					x1: xmlFloatAttr(t, "xMax"),
+					y1: xmlFloatAttr(t, "yMax"),
+				}
+			}
+		case xml.CharData:
+			if current != nil {
+				current.text += string(t)
+			}
+		case xml.EndElement:
+			switch t.Name.Local {
+			case "word":
+				if current != nil {
+					words = append(words, *current)
+					current = nil
+				}
+			case "page":
+				for placeholder, pageBoxes := range locateTokensOnPage(words, pageNumber, pageWidth, pageHeight) {
+					boxes[placeholder] = append(boxes[placeholder], pageBoxes...)
+				}
+			}
+		}
+	}
+
+	return boxes, nil
+}
+
+// locateTokensOnPage matches "{{...}}" tokens in a page's words
+// Words are joined with spaces; a token's box is the union of the (partial) words it covers
+func locateTokensOnPage(words []pdfWord, page int, pageWidth, pageHeight float64) map[string][]PDFBox {
+	boxes := make(map[string][]PDFBox)
+
+	// Rune offsets of each word in the joined page text
+	var text []rune
+	starts := make([]int, len(words))
+	for i, word := range words {
+		if i > 0 {
+			text = append(text, ' ')
+		}
+		starts[i] = len(text)
+		text = append(text, []rune(word.text)...)
+	}
+
+	joined := string(text)
+	for pos := 0; ; {
+		start := strings.Index(joined[pos:], "{{")
+		if start == -1 {
+			break
+		}
+		start += pos
+		end := strings.Index(joined[start:], "}}")
+		if end == -1 {
+			break
+		}
+		end += start + 2
+
+		runeStart := len([]rune(joined[:start]))
+		runeEnd := runeStart + len([]rune(joined[start:end]))
+		if box, ok := unionWordBoxes(words, starts, runeStart, runeEnd); ok {
+			box.Page = page
+			box.PageWidth = pageWidth
+			box.PageHeight = pageHeight
+			placeholder := NormalizePlaceholderToken(joined[start:end])
+			boxes[placeholder] = append(boxes[placeholder], box)
+		}
+		pos = end
+	}
+
+	return boxes
+}
+
+// unionWordBoxes returns the box covering runes [start, end) of the joined page text
+// Partially covered words are cut proportionally to the covered characters
+func unionWordBoxes(words []pdfWord, starts []int, start, end int) (PDFBox, bool) {
+	var box PDFBox
+	found := false
+	for i, word := range words {
+		length := len([]rune(word.text))
+		wordStart, wordEnd := starts[i], starts[i]+length
+		if wordEnd <= start || wordStart >= end || length == 0 {
+			continue
+		}
+
+		from := max(start, wordStart) - wordStart
+		to := min(end, wordEnd) - wordStart
+		width := word.x1 - word.x0
+		part := PDFBox{
+			X0: word.x0 + width*float64(from)/float64(length),
+			Y0: word.y0,
+			X1: word.x0 + width*float64(to)/float64(length),
+			Y1: word.y1,
+		}
+		if !found {
+			box = part
+			found = true
+			continue
+		}
+		box.X0 = min(box.X0, part.X0)
+		box.Y0 = min(box.Y0, part.Y0)
+		box.X1 = max(box.X1, part.X1)
+		box.Y1 = max(box.Y1, part.Y1)
+	}
+	return box, found
+}
+
+// NormalizePlaceholderToken removes whitespace from a token so "{{first_ name}}" split
+// across PDF words matches "{{first_name}}" from the DOCX
+func NormalizePlaceholderToken(token string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsSpace(r) {
+			return -1
+		}
+		return r
+	}, token)
+}
+
+// xmlFloatAttr parses a numeric attribute by local name (0 when missing)
+func xmlFloatAttr(element xml.StartElement, name string) float64 {
+	value, _ := strconv.ParseFloat(xmlAttr(element, name), 64)
+	return value
+}
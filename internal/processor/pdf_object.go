@@ -0,0 +1,875 @@
+package processor
+
+import (
+	"bytes"
+	"compress/zlib"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// ErrPDFEncrypted is returned for password-protected PDFs, which cannot be read or filled
+var ErrPDFEncrypted = errors.New("encrypted PDFs are not supported")
+
+// maxPDFStreamSize caps the decoded size of a single stream (decompression bombs)
+const maxPDFStreamSize = 64 << 20
+
+// Minimal PDF object model, enough to fill AcroForm dictionaries and draw overlays on pages
+// Content streams are never decoded (except object streams) and are written back unchanged
+type pdfObject interface{}
+
+type pdfName string
+
+type pdfString struct {
+	value []byte
+	hex   bool
+}
+
+// pdfNumber keeps the number as written so that it round-trips unchanged
+type pdfNumber string
+
+type pdfRef struct {
+	num int
+	gen int
+}
+
+type pdfDict map[pdfName]pdfObject
+
+type pdfArray []pdfObject
+
+type pdfStream struct {
+	dict pdfDict
+	data []byte // Raw (still encoded) stream data
+}
+
+type pdfBool bool
+
+type pdfNull struct{}
+
+// pdfKeyword is an unquoted token that is not a value (obj, endobj, R, stream, ...)
+type pdfKeyword string
+
+func (n pdfNumber) float() float64 {
+	f, _ := strconv.ParseFloat(string(n), 64)
+	return f
+}
+
+// int truncates the number; values outside the int32 range (and NaN) are clamped so that
+// offsets and lengths computed from a malformed file cannot overflow
+func (n pdfNumber) int() int {
+	f := n.float()
+	switch {
+	case math.IsNaN(f):
+		return 0
+	case f > math.MaxInt32:
+		return math.MaxInt32
+	case f < math.MinInt32:
+		return math.MinInt32
+	}
+	return int(f)
+}
+
+// pdfIndirect is one indirect object of the document
+type pdfIndirect struct {
+	gen    int
+	value  pdfObject
+	source int // Offset of the definition (or of its object stream); later definitions win
+}
+
+// pdfDocument is a parsed PDF; every indirect object is loaded in memory
+type pdfDocument struct {
+	objects map[int]*pdfIndirect
+	trailer pdfDict
+	version string
+}
+
+var pdfObjectHeader = regexp.MustCompile(`(\d+)\s+(\d+)\s+obj\b`)
+
+// parsePDF loads every indirect object of a PDF, including objects in object streams
+// Objects are found by scanning the file rather than through the xref table, which also
+// makes damaged or incrementally updated files readable
+func parsePDF(data []byte) (*pdfDocument, error) {
+	if !bytes.HasPrefix(bytes.TrimLeft(data, "\x00\t\r\n "), []byte("%PDF-")) {
+		return nil, fmt.Errorf("not a PDF file")
+	}
+
+	doc := &pdfDocument{objects: make(map[int]*pdfIndirect), version: "1.7"}
+	if start := bytes.Index(data, []byte("%PDF-")); start >= 0 && start+8 <= len(data) {
+		doc.version = string(data[start+5 : start+8])
+	}
+
+	trailerSource := -1
+	for pos := 0; pos < len(data); {
+		loc := pdfObjectHeader.FindSubmatchIndex(data[pos:])
+		if loc == nil {
+			break
+		}
+		start := pos + loc[0]
+		if start > 0 && !isPDFWhitespace(data[start-1]) && !isPDFDelimiter(data[start-1]) {
+			pos = pos + loc[1]
+			continue
+		}
+		num, _ := strconv.Atoi(string(data[pos+loc[2] : pos+loc[3]]))
+		gen, _ := strconv.Atoi(string(data[pos+loc[4] : pos+loc[5]]))
+
+		p := &pdfParser{data: data, pos: pos + loc[1]}
+		value, err := p.parseObject()
+		if err != nil {
+			pos = pos + loc[1]
+			continue
+		}
+		doc.define(num, gen, value, start)
+
+		if stream, ok := value.(*pdfStream); ok {
+			if name, _ := stream.dict["Type"].(pdfName); name == "XRef" && start > trailerSource {
+				doc.trailer = stream.dict
+				trailerSource = start
+			}
+		}
+		pos = p.pos
+	}
+
+	// Classic trailers; the last one (of the latest update) wins
+	for pos := 0; ; {
+		idx := bytes.Index(data[pos:], []byte("trailer"))
+		if idx < 0 {
+			break
+		}
+		start := pos + idx
+		p := &pdfParser{data: data, pos: start + len("trailer")}
+		if value, err := p.parseObject(); err == nil {
+			if dict, ok := value.(pdfDict); ok && start > trailerSource {
+				doc.trailer = dict
+				trailerSource = start
+			}
+		}
+		pos = start + len("trailer")
+	}
+
+	if doc.trailer == nil {
+		return nil, fmt.Errorf("PDF trailer not found")
+	}
+	if _, encrypted := doc.trailer["Encrypt"]; encrypted {
+		return nil, ErrPDFEncrypted
+	}
+
+	if err := doc.expandObjectStreams(); err != nil {
+		return nil, err
+	}
+	if doc.trailer["Root"] == nil {
+		return nil, fmt.Errorf("PDF catalog not found")
+	}
+
+	return doc, nil
+}
+
+// define records an object definition unless a later one is already known
+func (d *pdfDocument) define(num, gen int, value pdfObject, source int) {
+	if existing, ok := d.objects[num]; ok && existing.source > source {
+		return
+	}
+	d.objects[num] = &pdfIndirect{gen: gen, value: value, source: source}
+}
+
+// expandObjectStreams loads the objects compressed in /Type /ObjStm streams
+func (d *pdfDocument) expandObjectStreams() error {
+	var streams []int
+	for num, obj := range d.objects {
+		if stream, ok := obj.value.(*pdfStream); ok {
+			if name, _ := stream.dict["Type"].(pdfName); name == "ObjStm" {
+				streams = append(streams, num)
+			}
+		}
+	}
+	sort.Ints(streams)
+
+	for _, num := range streams {
+		holder := d.objects[num]
+		stream := holder.value.(*pdfStream)
+		content, err := d.decodeStream(stream)
+		if err != nil {
+			return fmt.Errorf("failed to decode object stream %d: %w", num, err)
+		}
+
+		count, _ := d.resolve(stream.dict["N"]).(pdfNumber)
+		first, _ := d.resolve(stream.dict["First"]).(pdfNumber)
+		if first.int() < 0 || first.int() > len(content) {
+			continue
+		}
+		header := &pdfParser{data: content[:first.int()]}
+		for i := 0; i < count.int() && header.pos < len(header.data); i++ {
+			objNum, err1 := header.parseObject()
+			offset, err2 := header.parseObject()
+			if err1 != nil || err2 != nil {
+				break
+			}
+			n, ok1 := objNum.(pdfNumber)
+			o, ok2 := offset.(pdfNumber)
+			if !ok1 || !ok2 || n.int() < 0 || o.int() < 0 || first.int()+o.int() >= len(content) {
+				continue
+			}
+			p := &pdfParser{data: content, pos: first.int() + o.int()}
+			value, err := p.parseObject()
+			if err != nil {
+				continue
+			}
+			d.define(n.int(), 0, value, holder.source)
+		}
+	}
+
+	return nil
+}
+
+// decodeStream returns the decoded data of a stream; only FlateDecode is supported
+func (d *pdfDocument) decodeStream(stream *pdfStream) ([]byte, error) {
+	var filters []pdfObject
+	switch filter := d.resolve(stream.dict["Filter"]).(type) {
+	case nil:
+		return stream.data, nil
+	case pdfName:
+		filters = []pdfObject{filter}
+	case pdfArray:
+		filters = filter
+	}
+
+	data := stream.data
+	for _, filter := range filters {
+		if name, _ := d.resolve(filter).(pdfName); name != "FlateDecode" {
+			return nil, fmt.Errorf("unsupported stream filter %v", filter)
+		}
+		reader, err := zlib.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		decoded, err := io.ReadAll(io.LimitReader(reader, maxPDFStreamSize+1))
+		reader.Close()
+		if len(decoded) > maxPDFStreamSize {
+			return nil, fmt.Errorf("decoded stream exceeds %d bytes", maxPDFStreamSize)
+		}
+		if err != nil && len(decoded) == 0 {
+			return nil, err
+		}
+		data = decoded
+	}
+	return data, nil
+}
+
+// add stores a new indirect object and returns its reference
+func (d *pdfDocument) add(obj pdfObject) pdfRef {
+	num := 1
+	for existing := range d.objects {
+		if existing >= num {
+			num = existing + 1
+		}
+	}
+	d.objects[num] = &pdfIndirect{value: obj, source: -1}
+	return pdfRef{num: num}
+}
+
+// inherited returns a page attribute, looking up the page tree when the page does not set it
+func (d *pdfDocument) inherited(page pdfDict, key pdfName) pdfObject {
+	for i := 0; page != nil && i < 32; i++ {
+		if value, ok := page[key]; ok {
+			return d.resolve(value)
+		}
+		page = d.dict(page["Parent"])
+	}
+	return nil
+}
+
+// resolve follows indirect references
+func (d *pdfDocument) resolve(obj pdfObject) pdfObject {
+	for i := 0; i < 32; i++ {
+		ref, ok := obj.(pdfRef)
+		if !ok {
+			return obj
+		}
+		target, ok := d.objects[ref.num]
+		if !ok {
+			return nil
+		}
+		obj = target.value
+	}
+	return nil
+}
+
+// dict resolves an object to a dictionary (the dictionary of a stream included)
+func (d *pdfDocument) dict(obj pdfObject) pdfDict {
+	switch value := d.resolve(obj).(type) {
+	case pdfDict:
+		return value
+	case *pdfStream:
+		return value.dict
+	}
+	return nil
+}
+
+func (d *pdfDocument) array(obj pdfObject) pdfArray {
+	value, _ := d.resolve(obj).(pdfArray)
+	return value
+}
+
+// catalog returns the document catalog (/Root)
+func (d *pdfDocument) catalog() pdfDict {
+	return d.dict(d.trailer["Root"])
+}
+
+// pages returns the page dictionaries' object numbers in page order
+func (d *pdfDocument) pages() []int {
+	var pages []int
+	visited := make(map[int]bool)
+	var walk func(obj pdfObject)
+	walk = func(obj pdfObject) {
+		ref, ok := obj.(pdfRef)
+		if !ok || visited[ref.num] {
+			return
+		}
+		visited[ref.num] = true
+		node := d.dict(ref)
+		if node == nil {
+			return
+		}
+		if kids := d.array(node["Kids"]); kids != nil {
+			for _, kid := range kids {
+				walk(kid)
+			}
+			return
+		}
+		pages = append(pages, ref.num)
+	}
+	walk(d.catalog()["Pages"])
+	return pages
+}
+
+// write serializes the whole document as a new file with a classic xref table
+// Object and xref streams are dropped since their objects are written individually
+func (d *pdfDocument) write(w io.Writer) error {
+	var buf bytes.Buffer
+	buf.WriteString("%PDF-" + d.version + "\n%\xe2\xe3\xcf\xd3\n")
+
+	nums := make([]int, 0, len(d.objects))
+	maxNum := 0
+	for num, obj := range d.objects {
+		if stream, ok := obj.value.(*pdfStream); ok {
+			if name, _ := stream.dict["Type"].(pdfName); name == "ObjStm" || name == "XRef" {
+				continue
+			}
+		}
+		nums = append(nums, num)
+		if num > maxNum {
+			maxNum = num
+		}
+	}
+	sort.Ints(nums)
+
+	offsets := make(map[int]int, len(nums))
+	for _, num := range nums {
+		offsets[num] = buf.Len()
+		fmt.Fprintf(&buf, "%d %d obj\n", num, d.objects[num].gen)
+		writePDFObject(&buf, d.objects[num].value)
+		buf.WriteString("\nendobj\n")
+	}
+
+	xrefOffset := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n", maxNum+1)
+	buf.WriteString("0000000000 65535 f\r\n")
+	for num := 1; num <= maxNum; num++ {
+		if offset, ok := offsets[num]; ok {
+			fmt.Fprintf(&buf, "%010d %05d n\r\n", offset, d.objects[num].gen)
+		} else {
+			buf.WriteString("0000000000 65535 f\r\n")
+		}
+	}
+
+	trailer := pdfDict{"Size": pdfNumber(strconv.Itoa(maxNum + 1)), "Root": d.trailer["Root"]}
+	for _, key := range []pdfName{"Info", "ID"} {
+		if value, ok := d.trailer[key]; ok {
+			trailer[key] = value
+		}
+	}
+	buf.WriteString("trailer\n")
+	writePDFObject(&buf, trailer)
+	fmt.Fprintf(&buf, "\nstartxref\n%d\n%%%%EOF\n", xrefOffset)
+
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// writePDFObject serializes one object; dictionary keys are sorted for stable output
+func writePDFObject(buf *bytes.Buffer, obj pdfObject) {
+	switch value := obj.(type) {
+	case nil, pdfNull:
+		buf.WriteString("null")
+	case pdfBool:
+		if value {
+			buf.WriteString("true")
+		} else {
+			buf.WriteString("false")
+		}
+	case pdfNumber:
+		buf.WriteString(string(value))
+	case pdfName:
+		buf.WriteString(encodePDFName(value))
+	case pdfString:
+		if value.hex {
+			fmt.Fprintf(buf, "<%X>", value.value)
+			return
+		}
+		buf.WriteByte('(')
+		for _, c := range value.value {
+			switch c {
+			case '(', ')', '\\':
+				buf.WriteByte('\\')
+				buf.WriteByte(c)
+			case '\r':
+				buf.WriteString("\\r")
+			case '\n':
+				buf.WriteString("\\n")
+			default:
+				buf.WriteByte(c)
+			}
+		}
+		buf.WriteByte(')')
+	case pdfRef:
+		fmt.Fprintf(buf, "%d %d R", value.num, value.gen)
+	case pdfArray:
+		buf.WriteByte('[')
+		for i, item := range value {
+			if i > 0 {
+				buf.WriteByte(' ')
+			}
+			writePDFObject(buf, item)
+		}
+		buf.WriteByte(']')
+	case pdfDict:
+		keys := make([]string, 0, len(value))
+		for key := range value {
+			keys = append(keys, string(key))
+		}
+		sort.Strings(keys)
+		buf.WriteString("<<")
+		for _, key := range keys {
+			buf.WriteString(encodePDFName(pdfName(key)))
+			buf.WriteByte(' ')
+			writePDFObject(buf, value[pdfName(key)])
+		}
+		buf.WriteString(">>")
+	case *pdfStream:
+		value.dict["Length"] = pdfNumber(strconv.Itoa(len(value.data)))
+		writePDFObject(buf, value.dict)
+		buf.WriteString("\nstream\n")
+		buf.Write(value.data)
+		buf.WriteString("\nendstream")
+	case pdfKeyword:
+		buf.WriteString(string(value))
+	}
+}
+
+func encodePDFName(name pdfName) string {
+	var sb strings.Builder
+	sb.WriteByte('/')
+	for i := 0; i < len(name); i++ {
+		c := name[i]
+		if c < 0x21 || c > 0x7e || c == '#' || isPDFDelimiter(c) {
+			fmt.Fprintf(&sb, "#%02X", c)
+		} else {
+			sb.WriteByte(c)
+		}
+	}
+	return sb.String()
+}
+
+// decodePDFText decodes a PDF text string (UTF-16BE with BOM, UTF-8 with BOM or PDFDocEncoding)
+func decodePDFText(value []byte) string {
+	if len(value) >= 2 && value[0] == 0xfe && value[1] == 0xff {
+		units := make([]uint16, 0, (len(value)-2)/2)
+		for i := 2; i+1 < len(value); i += 2 {
+			units = append(units, uint16(value[i])<<8|uint16(value[i+1]))
+		}
+		return string(utf16.Decode(units))
+	}
+	if bytes.HasPrefix(value, []byte{0xef, 0xbb, 0xbf}) {
+		return string(value[3:])
+	}
+	if utf8.Valid(value) {
+		return string(value)
+	}
+	// PDFDocEncoding matches Latin-1 for the characters forms use in practice
+	runes := make([]rune, len(value))
+	for i, c := range value {
+		runes[i] = rune(c)
+	}
+	return string(runes)
+}
+
+// encodePDFText encodes a text string: ASCII as a literal string, anything else as UTF-16BE
+func encodePDFText(text string) pdfString {
+	ascii := true
+	for i := 0; i < len(text); i++ {
+		if text[i] >= 0x80 {
+			ascii = false
+			break
+		}
+	}
+	if ascii {
+		return pdfString{value: []byte(text)}
+	}
+
+	encoded := []byte{0xfe, 0xff}
+	for _, unit := range utf16.Encode([]rune(text)) {
+		encoded = append(encoded, byte(unit>>8), byte(unit))
+	}
+	return pdfString{value: encoded, hex: true}
+}
+
+// pdfText returns a string or name object as text
+func pdfText(obj pdfObject) string {
+	switch value := obj.(type) {
+	case pdfString:
+		return decodePDFText(value.value)
+	case pdfName:
+		return string(value)
+	}
+	return ""
+}
+
+func isPDFWhitespace(c byte) bool {
+	return c == ' ' || c == '\n' || c == '\r' || c == '\t' || c == '\f' || c == 0
+}
+
+func isPDFDelimiter(c byte) bool {
+	return strings.IndexByte("()<>[]{}/%", c) >= 0
+}
+
+// pdfParser reads PDF objects from a byte slice
+type pdfParser struct {
+	data  []byte
+	pos   int
+	depth int // Nesting of arrays and dictionaries being parsed
+}
+
+// maxPDFNesting bounds array and dictionary nesting so malformed files cannot exhaust the stack
+const maxPDFNesting = 100
+
+// enter counts one more level of nesting; leave must be called when the level is done
+func (p *pdfParser) enter() error {
+	if p.depth >= maxPDFNesting {
+		return fmt.Errorf("objects nested deeper than %d levels at offset %d", maxPDFNesting, p.pos)
+	}
+	p.depth++
+	return nil
+}
+
+func (p *pdfParser) leave() {
+	p.depth--
+}
+
+func (p *pdfParser) skipWhitespace() {
+	for p.pos < len(p.data) {
+		c := p.data[p.pos]
+		if isPDFWhitespace(c) {
+			p.pos++
+		} else if c == '%' {
+			for p.pos < len(p.data) && p.data[p.pos] != '\n' && p.data[p.pos] != '\r' {
+				p.pos++
+			}
+		} else {
+			return
+		}
+	}
+}
+
+// parseObject reads one object; "N G R" references and streams are recognized
+func (p *pdfParser) parseObject() (pdfObject, error) {
+	obj, err := p.parseToken()
+	if err != nil {
+		return nil, err
+	}
+
+	switch value := obj.(type) {
+	case pdfNumber:
+		// Look ahead for "gen R"
+		if !isPDFInteger(value) {
+			return value, nil
+		}
+		saved := p.pos
+		gen, err := p.parseToken()
+		if genNum, ok := gen.(pdfNumber); err == nil && ok && isPDFInteger(genNum) {
+			keyword, err := p.parseToken()
+			if kw, ok := keyword.(pdfKeyword); err == nil && ok && kw == "R" {
+				return pdfRef{num: value.int(), gen: genNum.int()}, nil
+			}
+		}
+		p.pos = saved
+		return value, nil
+	case pdfDict:
+		saved := p.pos
+		p.skipWhitespace()
+		if bytes.HasPrefix(p.data[p.pos:], []byte("stream")) {
+			return p.parseStreamData(value)
+		}
+		p.pos = saved
+		return value, nil
+	}
+
+	return obj, nil
+}
+
+func isPDFInteger(n pdfNumber) bool {
+	_, err := strconv.Atoi(string(n))
+	return err == nil
+}
+
+// parseStreamData reads the data following a stream dictionary
+func (p *pdfParser) parseStreamData(dict pdfDict) (pdfObject, error) {
+	p.pos += len("stream")
+	if p.pos < len(p.data) && p.data[p.pos] == '\r' {
+		p.pos++
+	}
+	if p.pos < len(p.data) && p.data[p.pos] == '\n' {
+		p.pos++
+	}
+	start := p.pos
+
+	// Trust a direct /Length when "endstream" follows it, otherwise search for the keyword
+	if length, ok := dict["Length"].(pdfNumber); ok {
+		end := start + length.int()
+		if length.int() >= 0 && length.int() <= len(p.data)-start {
+			rest := bytes.TrimLeft(p.data[end:min(end+16, len(p.data))], "\r\n\t ")
+			if bytes.HasPrefix(rest, []byte("endstream")) {
+				p.pos = end
+				p.skipWhitespace()
+				p.pos += len("endstream")
+				return &pdfStream{dict: dict, data: p.data[start:end]}, nil
+			}
+		}
+	}
+
+	idx := bytes.Index(p.data[start:], []byte("endstream"))
+	if idx < 0 {
+		return nil, fmt.Errorf("unterminated stream")
+	}
+	end := start + idx
+	if end > start && p.data[end-1] == '\n' {
+		end--
+	}
+	if end > start && p.data[end-1] == '\r' {
+		end--
+	}
+	p.pos = start + idx + len("endstream")
+	return &pdfStream{dict: dict, data: p.data[start:end]}, nil
+}
+
+// parseToken reads one token; arrays and dictionaries are read as a whole
+func (p *pdfParser) parseToken() (pdfObject, error) {
+	p.skipWhitespace()
+	if p.pos >= len(p.data) {
+		return nil, io.ErrUnexpectedEOF
+	}
+
+	c := p.data[p.pos]
+	switch {
+	case c == '/':
+		return p.parseName(), nil
+	case c == '(':
+		return p.parseLiteralString()
+	case c == '<' && p.pos+1 < len(p.data) && p.data[p.pos+1] == '<':
+		return p.parseDict()
+	case c == '<':
+		return p.parseHexString()
+	case c == '[':
+		return p.parseArray()
+	case c == '+' || c == '-' || c == '.' || (c >= '0' && c <= '9'):
+		start := p.pos
+		p.pos++
+		for p.pos < len(p.data) && (p.data[p.pos] == '.' || (p.data[p.pos] >= '0' && p.data[p.pos] <= '9')) {
+			p.pos++
+		}
+		return pdfNumber(p.data[start:p.pos]), nil
+	case c == ']' || c == '>' || c == ')' || c == '{' || c == '}':
+		p.pos++
+		return pdfKeyword(string(c)), nil
+	}
+
+	start := p.pos
+	for p.pos < len(p.data) && !isPDFWhitespace(p.data[p.pos]) && !isPDFDelimiter(p.data[p.pos]) {
+		p.pos++
+	}
+	switch word := string(p.data[start:p.pos]); word {
+	case "true":
+		return pdfBool(true), nil
+	case "false":
+		return pdfBool(false), nil
+	case "null":
+		return pdfNull{}, nil
+	default:
+		return pdfKeyword(word), nil
+	}
+}
+
+func (p *pdfParser) parseName() pdfName {
+	p.pos++ // "/"
+	var sb strings.Builder
+	for p.pos < len(p.data) && !isPDFWhitespace(p.data[p.pos]) && !isPDFDelimiter(p.data[p.pos]) {
+		c := p.data[p.pos]
+		if c == '#' && p.pos+2 < len(p.data) {
+			if b, err := strconv.ParseUint(string(p.data[p.pos+1:p.pos+3]), 16, 8); err == nil {
+				sb.WriteByte(byte(b))
+				p.pos += 3
+				continue
+			}
+		}
+		sb.WriteByte(c)
+		p.pos++
+	}
+	return pdfName(sb.String())
+}
+
+func (p *pdfParser) parseLiteralString() (pdfObject, error) {
+	p.pos++ // "("
+	var out []byte
+	depth := 1
+	for p.pos < len(p.data) {
+		c := p.data[p.pos]
+		p.pos++
+		switch c {
+		case '(':
+			depth++
+			out = append(out, c)
+		case ')':
+			depth--
+			if depth == 0 {
+				return pdfString{value: out}, nil
+			}
+			out = append(out, c)
+		case '\\':
+			if p.pos >= len(p.data) {
+				break
+			}
+			e := p.data[p.pos]
+			p.pos++
+			switch e {
+			case 'n':
+				out = append(out, '\n')
+			case 'r':
+				out = append(out, '\r')
+			case 't':
+				out = append(out, '\t')
+			case 'b':
+				out = append(out, '\b')
+			case 'f':
+				out = append(out, '\f')
+			case '\r':
+				// Line continuation
+				if p.pos < len(p.data) && p.data[p.pos] == '\n' {
+					p.pos++
+				}
+			case '\n':
+			default:
+				if e >= '0' && e <= '7' {
+					value := int(e - '0')
+					for i := 0; i < 2 && p.pos < len(p.data) && p.data[p.pos] >= '0' && p.data[p.pos] <= '7'; i++ {
+						value = value*8 + int(p.data[p.pos]-'0')
+						p.pos++
+					}
+					out = append(out, byte(value))
+				} else {
+					out = append(out, e)
+				}
+			}
+		default:
+			out = append(out, c)
+		}
+	}
+	return nil, fmt.Errorf("unterminated string")
+}
+
+func (p *pdfParser) parseHexString() (pdfObject, error) {
+	p.pos++ // "<"
+	var digits []byte
+	for p.pos < len(p.data) && p.data[p.pos] != '>' {
+		c := p.data[p.pos]
+		if (c >= '0' && c <= '9') || (c >= 'a' && c <= 'f') || (c >= 'A' && c <= 'F') {
+			digits = append(digits, c)
+		}
+		p.pos++
+	}
+	if p.pos >= len(p.data) {
+		return nil, fmt.Errorf("unterminated hex string")
+	}
+	p.pos++ // ">"
+	if len(digits)%2 == 1 {
+		digits = append(digits, '0')
+	}
+	out := make([]byte, len(digits)/2)
+	for i := range out {
+		b, _ := strconv.ParseUint(string(digits[2*i:2*i+2]), 16, 8)
+		out[i] = byte(b)
+	}
+	return pdfString{value: out, hex: true}, nil
+}
+
+func (p *pdfParser) parseArray() (pdfObject, error) {
+	if err := p.enter(); err != nil {
+		return nil, err
+	}
+	defer p.leave()
+	p.pos++ // "["
+	var out pdfArray
+	for {
+		p.skipWhitespace()
+		if p.pos >= len(p.data) {
+			return nil, fmt.Errorf("unterminated array")
+		}
+		if p.data[p.pos] == ']' {
+			p.pos++
+			return out, nil
+		}
+		item, err := p.parseObject()
+		if err != nil {
+			return nil, err
+		}
+		if kw, ok := item.(pdfKeyword); ok {
+			return nil, fmt.Errorf("unexpected %q in array", string(kw))
+		}
+		out = append(out, item)
+	}
+}
+
+func (p *pdfParser) parseDict() (pdfObject, error) {
+	if err := p.enter(); err != nil {
+		return nil, err
+	}
+	defer p.leave()
+	p.pos += 2 // "<<"
+	out := make(pdfDict)
+	for {
+		p.skipWhitespace()
+		if p.pos+1 < len(p.data) && p.data[p.pos] == '>' && p.data[p.pos+1] == '>' {
+			p.pos += 2
+			return out, nil
+		}
+		if p.pos >= len(p.data) {
+			return nil, fmt.Errorf("unterminated dictionary")
+		}
+		if p.data[p.pos] != '/' {
+			return nil, fmt.Errorf("expected name in dictionary at offset %d", p.pos)
+		}
+		key := p.parseName()
+		value, err := p.parseObject()
+		if err != nil {
+			return nil, err
+		}
+		if kw, ok := value.(pdfKeyword); ok {
+			return nil, fmt.Errorf("unexpected %q in dictionary", string(kw))
+		}
+		out[key] = value
+	}
+}
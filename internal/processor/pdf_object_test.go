@@ -0,0 +1,285 @@
+package processor
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// buildPDF assembles a PDF from object bodies numbered from 1; object 1 is the catalog
+func buildPDF(objects ...string) []byte {
+	var buf bytes.Buffer
+	buf.WriteString("%PDF-1.7\n")
+	for i, body := range objects {
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", i+1, body)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R >>\n%%%%EOF\n", len(objects)+1)
+	return buf.Bytes()
+}
+
+func minimalPDF() []byte {
+	return buildPDF(
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 612 792] >>",
+	)
+}
+
+func TestParsePDFMinimal(t *testing.T) {
+	doc, err := parsePDF(minimalPDF())
+	if err != nil {
+		t.Fatalf("parsePDF: %v", err)
+	}
+	if pages := doc.pages(); len(pages) != 1 || pages[0] != 3 {
+		t.Fatalf("pages = %v, want [3]", pages)
+	}
+}
+
+func TestParsePDFRejectsMalformedInput(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"empty", nil},
+		{"not a PDF", []byte("hello world")},
+		{"header only", []byte("%PDF-1.7\n")},
+		{"no trailer", []byte("%PDF-1.7\n1 0 obj\n<< /Type /Catalog >>\nendobj\n")},
+		{"trailer without root", []byte("%PDF-1.7\n1 0 obj\n<< >>\nendobj\ntrailer\n<< /Size 2 >>\n")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := parsePDF(tt.data); err == nil {
+				t.Fatal("parsePDF succeeded, want an error")
+			}
+		})
+	}
+}
+
+func TestParsePDFRejectsEncrypted(t *testing.T) {
+	data := bytes.Replace(minimalPDF(), []byte("/Root 1 0 R"), []byte("/Root 1 0 R /Encrypt << /Filter /Standard >>"), 1)
+	if _, err := parsePDF(data); !errors.Is(err, ErrPDFEncrypted) {
+		t.Fatalf("parsePDF error = %v, want ErrPDFEncrypted", err)
+	}
+}
+
+func TestParsePDFTruncated(t *testing.T) {
+	data := minimalPDF()
+	// Every prefix must either parse or fail cleanly
+	for n := 0; n < len(data); n++ {
+		parsePDF(data[:n])
+	}
+}
+
+func TestParsePDFSkipsDeeplyNestedObjects(t *testing.T) {
+	depth := maxPDFNesting + 1
+	nested := strings.Repeat("[", depth) + strings.Repeat("]", depth)
+	data := buildPDF(
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [] /Count 0 >>",
+		nested,
+	)
+	doc, err := parsePDF(data)
+	if err != nil {
+		t.Fatalf("parsePDF: %v", err)
+	}
+	if _, ok := doc.objects[3]; ok {
+		t.Fatal("object nested beyond the limit was loaded")
+	}
+}
+
+func TestPDFParserNesting(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		wantErr bool
+	}{
+		{"arrays at the limit", strings.Repeat("[", maxPDFNesting) + strings.Repeat("]", maxPDFNesting), false},
+		{"arrays beyond the limit", strings.Repeat("[", maxPDFNesting+1) + strings.Repeat("]", maxPDFNesting+1), true},
+		{"dictionaries beyond the limit", strings.Repeat("<< /A ", maxPDFNesting+1) + strings.Repeat(">> ", maxPDFNesting+1), true},
+		{"unbalanced deep arrays", strings.Repeat("[", 100000), true},
+		{"unbalanced deep dictionaries", strings.Repeat("<</A", 100000), true},
+		{"mixed beyond the limit", strings.Repeat("[<< /A ", maxPDFNesting/2+1) + strings.Repeat(">>]", maxPDFNesting/2+1), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &pdfParser{data: []byte(tt.input)}
+			_, err := p.parseObject()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseObject error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if p.depth != 0 {
+				t.Fatalf("depth = %d after parsing, want 0", p.depth)
+			}
+		})
+	}
+}
+
+func TestPDFParserMalformedTokens(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{"empty", ""},
+		{"unterminated array", "[1 2 3"},
+		{"unterminated dictionary", "<< /A 1"},
+		{"unterminated literal string", "(abc"},
+		{"unterminated escape", `(abc\`},
+		{"unterminated hex string", "<4142"},
+		{"dictionary key is not a name", "<< 1 2 >>"},
+		{"keyword in array", "[1 obj]"},
+		{"keyword in dictionary", "<< /A endobj >>"},
+		{"unterminated stream", "<< /Length 5 >>\nstream\nabc"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &pdfParser{data: []byte(tt.input)}
+			if _, err := p.parseObject(); err == nil {
+				t.Fatalf("parseObject(%q) succeeded, want an error", tt.input)
+			}
+		})
+	}
+}
+
+func TestPDFParserStreamLength(t *testing.T) {
+	tests := []struct {
+		name   string
+		length string
+		want   string
+	}{
+		{"exact", "5", "hello"},
+		{"negative", "-5", "hello"},
+		{"beyond the data", "99999999999", "hello"},
+		{"wrong", "2", "hello"},
+		{"not a number", "(x)", "hello"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			input := fmt.Sprintf("<< /Length %s >>\nstream\nhello\nendstream", tt.length)
+			p := &pdfParser{data: []byte(input)}
+			obj, err := p.parseObject()
+			if err != nil {
+				t.Fatalf("parseObject: %v", err)
+			}
+			stream, ok := obj.(*pdfStream)
+			if !ok {
+				t.Fatalf("parseObject = %T, want *pdfStream", obj)
+			}
+			if string(stream.data) != tt.want {
+				t.Fatalf("stream data = %q, want %q", stream.data, tt.want)
+			}
+		})
+	}
+}
+
+func TestPDFNumberIntClamps(t *testing.T) {
+	tests := []struct {
+		number pdfNumber
+		want   int
+	}{
+		{"12", 12},
+		{"-3.9", -3},
+		{"99999999999999999999", 1<<31 - 1},
+		{"-99999999999999999999", -1 << 31},
+		{"--", 0},
+		{".", 0},
+	}
+	for _, tt := range tests {
+		if got := tt.number.int(); got != tt.want {
+			t.Errorf("pdfNumber(%q).int() = %d, want %d", tt.number, got, tt.want)
+		}
+	}
+}
+
+func TestParsePDFObjectStreamOffsets(t *testing.T) {
+	// Header pairs: a valid object, a negative offset, an offset past the end and a huge number
+	content := "4 0 5 -7 6 9999 7 99999999999999 << /Marker true >>"
+	first := strings.Index(content, "<<")
+	header := content[:first]
+	data := buildPDF(
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [] /Count 0 >>",
+		fmt.Sprintf("<< /Type /ObjStm /N 4 /First %d /Length %d >>\nstream\n%s\nendstream", len(header), len(content), content),
+	)
+
+	doc, err := parsePDF(data)
+	if err != nil {
+		t.Fatalf("parsePDF: %v", err)
+	}
+	if marker := doc.dict(pdfRef{num: 4})["Marker"]; marker != pdfBool(true) {
+		t.Fatalf("object 4 = %v, want the marker dictionary", doc.objects[4])
+	}
+	for _, num := range []int{5, 6, 7} {
+		if _, ok := doc.objects[num]; ok {
+			t.Errorf("object %d with an invalid offset was loaded", num)
+		}
+	}
+}
+
+func TestParsePDFObjectStreamBadFirst(t *testing.T) {
+	for _, first := range []string{"-1", "99999", "99999999999999"} {
+		data := buildPDF(
+			"<< /Type /Catalog /Pages 2 0 R >>",
+			"<< /Type /Pages /Kids [] /Count 0 >>",
+			fmt.Sprintf("<< /Type /ObjStm /N 1 /First %s /Length 8 >>\nstream\n4 0 true\nendstream", first),
+		)
+		if _, err := parsePDF(data); err != nil {
+			t.Fatalf("parsePDF with /First %s: %v", first, err)
+		}
+	}
+}
+
+func TestDecodeStream(t *testing.T) {
+	doc := &pdfDocument{objects: make(map[int]*pdfIndirect)}
+
+	plain := &pdfStream{dict: pdfDict{}, data: []byte("raw")}
+	if got, err := doc.decodeStream(plain); err != nil || string(got) != "raw" {
+		t.Fatalf("decodeStream(plain) = %q, %v", got, err)
+	}
+
+	flate := &pdfStream{dict: pdfDict{"Filter": pdfName("FlateDecode")}, data: deflate([]byte("hello"))}
+	if got, err := doc.decodeStream(flate); err != nil || string(got) != "hello" {
+		t.Fatalf("decodeStream(flate) = %q, %v", got, err)
+	}
+
+	unsupported := &pdfStream{dict: pdfDict{"Filter": pdfName("LZWDecode")}, data: []byte("x")}
+	if _, err := doc.decodeStream(unsupported); err == nil {
+		t.Fatal("decodeStream(LZWDecode) succeeded, want an error")
+	}
+
+	corrupt := &pdfStream{dict: pdfDict{"Filter": pdfName("FlateDecode")}, data: []byte("not zlib")}
+	if _, err := doc.decodeStream(corrupt); err == nil {
+		t.Fatal("decodeStream(corrupt) succeeded, want an error")
+	}
+}
+
+func TestDecodeStreamSizeLimit(t *testing.T) {
+	doc := &pdfDocument{objects: make(map[int]*pdfIndirect)}
+	bomb := &pdfStream{
+		dict: pdfDict{"Filter": pdfName("FlateDecode")},
+		data: deflate(make([]byte, maxPDFStreamSize+1)),
+	}
+	if _, err := doc.decodeStream(bomb); err == nil {
+		t.Fatal("decodeStream succeeded on a stream larger than the limit")
+	}
+}
+
+func TestPDFDocumentPagesCycle(t *testing.T) {
+	data := buildPDF(
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [2 0 R 3 0 R] /Count 1 >>",
+		"<< /Type /Page /Parent 2 0 R >>",
+	)
+	doc, err := parsePDF(data)
+	if err != nil {
+		t.Fatalf("parsePDF: %v", err)
+	}
+	if pages := doc.pages(); len(pages) != 1 || pages[0] != 3 {
+		t.Fatalf("pages = %v, want [3]", pages)
+	}
+}
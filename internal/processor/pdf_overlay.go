@@ -0,0 +1,604 @@
+package processor
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/base64"
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/jpeg" // JPEG backgrounds and image fields
+	_ "image/png"  // PNG backgrounds and image fields
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Overlay item kinds
+const (
+	OverlayKindText  = "text"
+	OverlayKindCheck = "check"
+	OverlayKindImage = "image"
+)
+
+// Default and minimum overlay font sizes in points
+const (
+	overlayDefaultFontSize = 14
+	overlayMinFontSize     = 6
+)
+
+// maxImagePixels caps the size of embedded images; images are decoded in memory,
+// so the limit is checked against the header before decoding
+const maxImagePixels = 25_000_000
+
+// OverlayItem is one value drawn on a background page
+// Coordinates are PDF points with the origin at the top-left corner of the page, like PDFBox
+type OverlayItem struct {
+	Key       string
+	Page      int // 1-based
+	X         float64
+	Y         float64
+	Width     float64 // 0 = unbounded (text is not shrunk or aligned)
+	Height    float64 // 0 = one line
+	FontSize  float64 // 0 = default (14pt)
+	Align     string  // left (default), center or right
+	Multiline bool    // Wrap text inside Width and Height
+	Comb      int     // Number of character boxes; each character is centered in its box
+	Kind      string  // text (default), check or image
+	Mark      string  // check (default), cross or dot, for check items
+	Color     string  // #RRGGBB, black by default
+	Value     string  // Text, a truthy value for checks, or a base64/data URL PNG or JPEG for images
+}
+
+// PDFPageSize is the size of a page in points
+type PDFPageSize struct {
+	Page   int     `json:"page"`
+	Width  float64 `json:"width"`
+	Height float64 `json:"height"`
+}
+
+// PDFPageSizes returns the size of every page (from the MediaBox)
+func PDFPageSizes(pdfPath string) ([]PDFPageSize, error) {
+	data, err := os.ReadFile(pdfPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read PDF: %w", err)
+	}
+	doc, err := parsePDF(data)
+	if err != nil {
+		return nil, err
+	}
+
+	pages := doc.pages()
+	sizes := make([]PDFPageSize, len(pages))
+	for i, num := range pages {
+		box := doc.mediaBox(doc.dict(pdfRef{num: num}))
+		sizes[i] = PDFPageSize{Page: i + 1, Width: box[2] - box[0], Height: box[3] - box[1]}
+	}
+	return sizes, nil
+}
+
+// mediaBox returns a page's MediaBox (x0, y0, x1, y1), A4 when missing or invalid
+func (d *pdfDocument) mediaBox(page pdfDict) [4]float64 {
+	box := [4]float64{0, 0, 595.28, 841.89}
+	values := d.array(d.inherited(page, "MediaBox"))
+	if len(values) != 4 {
+		return box
+	}
+	for i, value := range values {
+		number, ok := d.resolve(value).(pdfNumber)
+		if !ok {
+			return [4]float64{0, 0, 595.28, 841.89}
+		}
+		box[i] = number.float()
+	}
+	return box
+}
+
+// RenderOverlayPDF draws the items on the pages of a background PDF and writes the result
+// The original page content is wrapped in q/Q so its graphics state cannot leak into the overlay
+// Text uses font, which is embedded in the output; font may be nil when there is no text
+// Items that cannot be drawn are reported as warnings
+func RenderOverlayPDF(backgroundPath, outputPath string, items []OverlayItem, font *TrueTypeFont) ([]string, error) {
+	data, err := os.ReadFile(backgroundPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read background PDF: %w", err)
+	}
+	doc, err := parsePDF(data)
+	if err != nil {
+		return nil, err
+	}
+
+	pages := doc.pages()
+	byPage := make(map[int][]OverlayItem)
+	var warnings []string
+	for _, item := range items {
+		if item.Page < 1 || item.Page > len(pages) {
+			warnings = append(warnings, fmt.Sprintf("%s: page %d does not exist", item.Key, item.Page))
+			continue
+		}
+		byPage[item.Page] = append(byPage[item.Page], item)
+	}
+
+	var fontUsage *pdfFontUsage
+	if font != nil {
+		fontUsage = newPDFFontUsage(font)
+	}
+	var fontRef *pdfRef
+	fontResource := func() pdfRef {
+		if fontRef == nil {
+			// Reserve the reference now; the font is embedded once all glyphs are known
+			ref := doc.add(pdfNull{})
+			fontRef = &ref
+		}
+		return *fontRef
+	}
+
+	imageCount := 0
+	for pageNumber, pageItems := range byPage {
+		page := doc.dict(pdfRef{num: pages[pageNumber-1]})
+		box := doc.mediaBox(page)
+		if rotate, ok := doc.inherited(page, "Rotate").(pdfNumber); ok && rotate.int()%360 != 0 {
+			warnings = append(warnings, fmt.Sprintf("page %d is rotated; overlay coordinates follow the unrotated page", pageNumber))
+		}
+
+		resources := copyPDFDict(doc.dict(doc.inherited(page, "Resources")))
+		fonts := copyPDFDict(doc.dict(resources["Font"]))
+		xobjects := copyPDFDict(doc.dict(resources["XObject"]))
+
+		var ops bytes.Buffer
+		ops.WriteString("Q\n")
+		for _, item := range pageItems {
+			canvas := overlayCanvas{ops: &ops, pageTop: box[3], pageLeft: box[0]}
+			switch item.Kind {
+			case OverlayKindCheck:
+				if isCheckedValue(item.Value, "") {
+					canvas.drawMark(item)
+				}
+			case OverlayKindImage:
+				if strings.TrimSpace(item.Value) == "" {
+					continue
+				}
+				imageStream, width, height, err := overlayImage(item.Value)
+				if err != nil {
+					warnings = append(warnings, fmt.Sprintf("%s: %v", item.Key, err))
+					continue
+				}
+				imageCount++
+				name := pdfName(fmt.Sprintf("ImOv%d", imageCount))
+				xobjects[name] = doc.add(imageStream)
+				canvas.drawImage(item, name, width, height)
+			default:
+				if item.Value == "" {
+					continue
+				}
+				if fontUsage == nil {
+					warnings = append(warnings, fmt.Sprintf("%s: no font available for text", item.Key))
+					continue
+				}
+				fonts["FOv"] = fontResource()
+				if missing := missingGlyphs(font, item.Value); missing != "" {
+					warnings = append(warnings, fmt.Sprintf("%s: font has no glyphs for %q", item.Key, missing))
+				}
+				canvas.drawText(item, fontUsage)
+			}
+		}
+
+		if len(fonts) > 0 {
+			resources["Font"] = fonts
+		}
+		if len(xobjects) > 0 {
+			resources["XObject"] = xobjects
+		}
+		page["Resources"] = resources
+
+		contents := pdfArray{doc.add(&pdfStream{dict: pdfDict{}, data: []byte("q\n")})}
+		switch existing := page["Contents"].(type) {
+		case pdfRef:
+			if array, ok := doc.resolve(existing).(pdfArray); ok {
+				contents = append(contents, array...)
+			} else {
+				contents = append(contents, existing)
+			}
+		case pdfArray:
+			contents = append(contents, existing...)
+		}
+		contents = append(contents, doc.add(&pdfStream{dict: pdfDict{}, data: ops.Bytes()}))
+		page["Contents"] = contents
+	}
+
+	if fontRef != nil {
+		embedded := fontUsage.embed(doc)
+		doc.objects[fontRef.num].value = doc.resolve(embedded)
+		delete(doc.objects, embedded.num)
+	}
+
+	output, err := os.Create(outputPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create output PDF: %w", err)
+	}
+	defer output.Close()
+
+	if err := doc.write(output); err != nil {
+		return nil, fmt.Errorf("failed to write output PDF: %w", err)
+	}
+	return warnings, nil
+}
+
+// ImageToPDF wraps a PNG or JPEG scan in a one-page PDF, scaled to the width of an A4 page
+func ImageToPDF(imageData []byte, outputPath string) error {
+	imageStream, width, height, err := pdfImage(imageData)
+	if err != nil {
+		return err
+	}
+
+	pageWidth := 595.28
+	pageHeight := pageWidth * float64(height) / float64(width)
+
+	doc := &pdfDocument{objects: make(map[int]*pdfIndirect), trailer: pdfDict{}, version: "1.7"}
+	imageRef := doc.add(imageStream)
+	content := doc.add(&pdfStream{
+		dict: pdfDict{},
+		data: []byte(fmt.Sprintf("q %s 0 0 %s 0 0 cm /Im0 Do Q\n", formatPDFFloat(pageWidth), formatPDFFloat(pageHeight))),
+	})
+	pagesRef := doc.add(pdfNull{})
+	pageRef := doc.add(pdfDict{
+		"Type":      pdfName("Page"),
+		"Parent":    pagesRef,
+		"MediaBox":  pdfArray{pdfNumber("0"), pdfNumber("0"), pdfNumber(formatPDFFloat(pageWidth)), pdfNumber(formatPDFFloat(pageHeight))},
+		"Resources": pdfDict{"XObject": pdfDict{"Im0": imageRef}},
+		"Contents":  content,
+	})
+	doc.objects[pagesRef.num].value = pdfDict{"Type": pdfName("Pages"), "Kids": pdfArray{pageRef}, "Count": pdfNumber("1")}
+	doc.trailer["Root"] = doc.add(pdfDict{"Type": pdfName("Catalog"), "Pages": pagesRef})
+
+	output, err := os.Create(outputPath)
+	if err != nil {
+		return fmt.Errorf("failed to create PDF: %w", err)
+	}
+	defer output.Close()
+
+	if err := doc.write(output); err != nil {
+		return fmt.Errorf("failed to write PDF: %w", err)
+	}
+	return nil
+}
+
+// overlayCanvas writes drawing operators for one page, converting top-left coordinates
+type overlayCanvas struct {
+	ops      *bytes.Buffer
+	pageTop  float64
+	pageLeft float64
+}
+
+func (c *overlayCanvas) x(x float64) string {
+	return formatPDFFloat(c.pageLeft + x)
+}
+
+func (c *overlayCanvas) y(y float64) string {
+	return formatPDFFloat(c.pageTop - y)
+}
+
+// drawText draws single-line, comb or wrapped text
+// Single lines are shrunk to fit Width and vertically centered in Height when set
+func (c *overlayCanvas) drawText(item OverlayItem, fontUsage *pdfFontUsage) {
+	font := fontUsage.font
+	size := item.FontSize
+	if size <= 0 {
+		size = overlayDefaultFontSize
+	}
+	ascent := float64(font.ascent) * size / float64(font.unitsPerEm)
+	descent := float64(font.descent) * size / float64(font.unitsPerEm)
+
+	fmt.Fprintf(c.ops, "q %s rg\n", pdfColor(item.Color))
+	defer c.ops.WriteString("Q\n")
+
+	// Comb fields: one character cluster per box
+	if item.Comb > 0 && item.Width > 0 {
+		cell := item.Width / float64(item.Comb)
+		baseline := c.baseline(item, size, ascent, descent)
+		for i, cluster := range textClusters(item.Value) {
+			if i >= item.Comb {
+				break
+			}
+			width := font.TextWidth(cluster, size)
+			c.showText(fontUsage, cluster, size, item.X+float64(i)*cell+(cell-width)/2, baseline)
+		}
+		return
+	}
+
+	if item.Multiline && item.Width > 0 {
+		lineHeight := float64(font.ascent-font.descent+font.lineGap) * size / float64(font.unitsPerEm)
+		baseline := item.Y + ascent
+		for _, line := range wrapText(font, item.Value, size, item.Width) {
+			if item.Height > 0 && baseline-descent > item.Y+item.Height+0.5 {
+				break
+			}
+			c.showText(fontUsage, line, size, alignedX(item, font.TextWidth(line, size)), baseline)
+			baseline += lineHeight
+		}
+		return
+	}
+
+	text := strings.Join(strings.Fields(item.Value), " ")
+	if item.Width > 0 {
+		for size > overlayMinFontSize && font.TextWidth(text, size) > item.Width {
+			size -= 0.5
+		}
+		ascent = float64(font.ascent) * size / float64(font.unitsPerEm)
+		descent = float64(font.descent) * size / float64(font.unitsPerEm)
+	}
+	c.showText(fontUsage, text, size, alignedX(item, font.TextWidth(text, size)), c.baseline(item, size, ascent, descent))
+}
+
+// baseline returns the top-left based baseline of a single line of text
+func (c *overlayCanvas) baseline(item OverlayItem, size, ascent, descent float64) float64 {
+	if item.Height > 0 {
+		return item.Y + item.Height/2 + (ascent+descent)/2
+	}
+	return item.Y + ascent
+}
+
+func (c *overlayCanvas) showText(fontUsage *pdfFontUsage, text string, size, x, baseline float64) {
+	var encoded bytes.Buffer
+	writePDFObject(&encoded, fontUsage.encode(text))
+	fmt.Fprintf(c.ops, "BT /FOv %s Tf %s %s Td %s Tj ET\n", formatPDFFloat(size), c.x(x), c.y(baseline), encoded.String())
+}
+
+// drawMark draws a check mark, cross or dot as vector paths, centered in the item box
+func (c *overlayCanvas) drawMark(item OverlayItem) {
+	size := item.FontSize
+	if item.Width > 0 && item.Height > 0 {
+		size = min(item.Width, item.Height)
+	} else if item.Width > 0 {
+		size = item.Width
+	} else if item.Height > 0 {
+		size = item.Height
+	}
+	if size <= 0 {
+		size = overlayDefaultFontSize
+	}
+	left := item.X + (max(item.Width, size)-size)/2
+	top := item.Y + (max(item.Height, size)-size)/2
+	point := func(fx, fy float64) string {
+		return c.x(left+fx*size) + " " + c.y(top+fy*size)
+	}
+
+	color := pdfColor(item.Color)
+	fmt.Fprintf(c.ops, "q %s RG %s rg 1 J 1 j %s w\n", color, color, formatPDFFloat(size*0.12))
+	switch item.Mark {
+	case "cross":
+		fmt.Fprintf(c.ops, "%s m %s l S %s m %s l S\n", point(0.2, 0.2), point(0.8, 0.8), point(0.8, 0.2), point(0.2, 0.8))
+	case "dot":
+		// Four Bézier arcs approximating a circle
+		r, k := 0.25, 0.25*0.5523
+		fmt.Fprintf(c.ops, "%s m %s %s %s c %s %s %s c %s %s %s c %s %s %s c f\n",
+			point(0.5+r, 0.5),
+			point(0.5+r, 0.5-k), point(0.5+k, 0.5-r), point(0.5, 0.5-r),
+			point(0.5-k, 0.5-r), point(0.5-r, 0.5-k), point(0.5-r, 0.5),
+			point(0.5-r, 0.5+k), point(0.5-k, 0.5+r), point(0.5, 0.5+r),
+			point(0.5+k, 0.5+r), point(0.5+r, 0.5+k), point(0.5+r, 0.5))
+	default:
+		fmt.Fprintf(c.ops, "%s m %s l %s l S\n", point(0.15, 0.55), point(0.4, 0.8), point(0.85, 0.2))
+	}
+	c.ops.WriteString("Q\n")
+}
+
+// drawImage draws an image XObject fitted (aspect ratio kept) and centered in the item box
+func (c *overlayCanvas) drawImage(item OverlayItem, name pdfName, width, height int) {
+	boxWidth, boxHeight := item.Width, item.Height
+	if boxWidth <= 0 && boxHeight <= 0 {
+		boxWidth, boxHeight = float64(width), float64(height)
+	} else if boxWidth <= 0 {
+		boxWidth = boxHeight * float64(width) / float64(height)
+	} else if boxHeight <= 0 {
+		boxHeight = boxWidth * float64(height) / float64(width)
+	}
+
+	scale := min(boxWidth/float64(width), boxHeight/float64(height))
+	drawWidth, drawHeight := float64(width)*scale, float64(height)*scale
+	left := item.X + (boxWidth-drawWidth)/2
+	bottom := item.Y + (boxHeight+drawHeight)/2
+	fmt.Fprintf(c.ops, "q %s 0 0 %s %s %s cm %s Do Q\n",
+		formatPDFFloat(drawWidth), formatPDFFloat(drawHeight), c.x(left), c.y(bottom), encodePDFName(name))
+}
+
+// alignedX returns the left edge of a line of text aligned in the item box
+func alignedX(item OverlayItem, width float64) float64 {
+	if item.Width <= 0 {
+		return item.X
+	}
+	switch item.Align {
+	case "center":
+		return item.X + (item.Width-width)/2
+	case "right":
+		return item.X + item.Width - width
+	}
+	return item.X
+}
+
+// textClusters splits text into base characters with their combining marks
+// (Thai vowels above/below and tone marks stay with their consonant)
+func textClusters(text string) []string {
+	var clusters []string
+	for _, r := range text {
+		if len(clusters) > 0 && unicode.Is(unicode.Mn, r) {
+			clusters[len(clusters)-1] += string(r)
+			continue
+		}
+		clusters = append(clusters, string(r))
+	}
+	return clusters
+}
+
+// wrapText breaks text into lines no wider than width
+// Lines break at spaces; words wider than a line (Thai is written without spaces) are broken
+// between character clusters
+func wrapText(font *TrueTypeFont, text string, size, width float64) []string {
+	var lines []string
+	for _, paragraph := range strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n") {
+		line := ""
+		for _, word := range strings.Fields(paragraph) {
+			candidate := word
+			if line != "" {
+				candidate = line + " " + word
+			}
+			if font.TextWidth(candidate, size) <= width {
+				line = candidate
+				continue
+			}
+			if line != "" {
+				lines = append(lines, line)
+				line = ""
+			}
+			for _, cluster := range textClusters(word) {
+				if line != "" && font.TextWidth(line+cluster, size) > width {
+					lines = append(lines, line)
+					line = ""
+				}
+				line += cluster
+			}
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// missingGlyphs returns the characters of text the font cannot draw
+func missingGlyphs(font *TrueTypeFont, text string) string {
+	var missing []rune
+	for _, r := range text {
+		if !unicode.IsSpace(r) && !font.HasGlyph(r) && !strings.ContainsRune(string(missing), r) {
+			missing = append(missing, r)
+		}
+	}
+	return string(missing)
+}
+
+// pdfColor converts #RRGGBB to PDF RGB components (black when empty or invalid)
+func pdfColor(hex string) string {
+	hex = strings.TrimPrefix(strings.TrimSpace(hex), "#")
+	value, err := strconv.ParseUint(hex, 16, 32)
+	if len(hex) != 6 || err != nil {
+		return "0 0 0"
+	}
+	return fmt.Sprintf("%s %s %s",
+		formatPDFFloat(float64(value>>16&0xff)/255),
+		formatPDFFloat(float64(value>>8&0xff)/255),
+		formatPDFFloat(float64(value&0xff)/255))
+}
+
+// formatPDFFloat formats a number for content streams, rounded to 1/1000 of a point
+func formatPDFFloat(value float64) string {
+	return strconv.FormatFloat(math.Round(value*1000)/1000, 'f', -1, 64)
+}
+
+// overlayImage decodes an image field value: a data URL or plain base64 PNG/JPEG
+func overlayImage(value string) (*pdfStream, int, int, error) {
+	value = strings.TrimSpace(value)
+	if strings.HasPrefix(value, "data:") {
+		comma := strings.IndexByte(value, ',')
+		if comma < 0 {
+			return nil, 0, 0, fmt.Errorf("invalid data URL")
+		}
+		value = value[comma+1:]
+	}
+	data, err := base64.StdEncoding.DecodeString(value)
+	if err != nil {
+		return nil, 0, 0, fmt.Errorf("image is not valid base64")
+	}
+	return pdfImage(data)
+}
+
+// pdfImage converts PNG or JPEG data into an image XObject
+// Baseline RGB and grayscale JPEGs are embedded as is; everything else is re-encoded
+// losslessly, with transparency kept as a soft mask
+func pdfImage(data []byte) (*pdfStream, int, int, error) {
+	config, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, 0, 0, fmt.Errorf("unsupported image: %w", err)
+	}
+	if config.Width <= 0 || config.Height <= 0 {
+		return nil, 0, 0, fmt.Errorf("image is empty")
+	}
+	if int64(config.Width)*int64(config.Height) > maxImagePixels {
+		return nil, 0, 0, fmt.Errorf("image is too large (%dx%d, at most %d pixels)", config.Width, config.Height, maxImagePixels)
+	}
+
+	dict := pdfDict{
+		"Type":             pdfName("XObject"),
+		"Subtype":          pdfName("Image"),
+		"Width":            pdfInt(config.Width),
+		"Height":           pdfInt(config.Height),
+		"BitsPerComponent": pdfNumber("8"),
+	}
+
+	if format == "jpeg" {
+		switch config.ColorModel {
+		case color.YCbCrModel, color.RGBAModel:
+			dict["ColorSpace"] = pdfName("DeviceRGB")
+			dict["Filter"] = pdfName("DCTDecode")
+			return &pdfStream{dict: dict, data: data}, config.Width, config.Height, nil
+		case color.GrayModel:
+			dict["ColorSpace"] = pdfName("DeviceGray")
+			dict["Filter"] = pdfName("DCTDecode")
+			return &pdfStream{dict: dict, data: data}, config.Width, config.Height, nil
+		}
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, 0, 0, fmt.Errorf("failed to decode image: %w", err)
+	}
+	bounds := img.Bounds()
+	rgb := make([]byte, 0, bounds.Dx()*bounds.Dy()*3)
+	alpha := make([]byte, 0, bounds.Dx()*bounds.Dy())
+	opaque := true
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			pixel := color.NRGBAModel.Convert(img.At(x, y)).(color.NRGBA)
+			rgb = append(rgb, pixel.R, pixel.G, pixel.B)
+			alpha = append(alpha, pixel.A)
+			if pixel.A != 0xff {
+				opaque = false
+			}
+		}
+	}
+
+	dict["ColorSpace"] = pdfName("DeviceRGB")
+	dict["Filter"] = pdfName("FlateDecode")
+	stream := &pdfStream{dict: dict, data: deflate(rgb)}
+	if !opaque {
+		dict["SMask"] = &pdfStream{
+			dict: pdfDict{
+				"Type":             pdfName("XObject"),
+				"Subtype":          pdfName("Image"),
+				"Width":            pdfInt(bounds.Dx()),
+				"Height":           pdfInt(bounds.Dy()),
+				"BitsPerComponent": pdfNumber("8"),
+				"ColorSpace":       pdfName("DeviceGray"),
+				"Filter":           pdfName("FlateDecode"),
+			},
+			data: deflate(alpha),
+		}
+	}
+	return stream, bounds.Dx(), bounds.Dy(), nil
+}
+
+func deflate(data []byte) []byte {
+	var buf bytes.Buffer
+	writer := zlib.NewWriter(&buf)
+	writer.Write(data)
+	writer.Close()
+	return buf.Bytes()
+}
+
+// copyPDFDict returns a shallow copy so shared (inherited) dictionaries are not modified
+func copyPDFDict(dict pdfDict) pdfDict {
+	out := make(pdfDict, len(dict))
+	for key, value := range dict {
+		out[key] = value
+	}
+	return out
+}
@@ -0,0 +1,339 @@
+package processor
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// wordNamespace is the WordprocessingML main namespace (the "w:" prefix)
+const wordNamespace = "http://schemas.openxmlformats.org/wordprocessingml/2006/main"
+
+// markupCompatibilityNamespace is the namespace of mc:AlternateContent
+const markupCompatibilityNamespace = "http://schemas.openxmlformats.org/markup-compatibility/2006"
+
+// Document parts a placeholder can be located in
+const (
+	PartBody   = "body"
+	PartHeader = "header"
+	PartFooter = "footer"
+)
+
+// TableCellLocation is the position of a paragraph inside a table (0-based)
+type TableCellLocation struct {
+	Table int `json:"table"` // Table index within the part, in document order (nested tables included)
+	Row   int `json:"row"`
+	Cell  int `json:"cell"`
+	Depth int `json:"depth"` // 1 for a top-level table, 2 for a table inside a cell, ...
+}
+
+// PDFBox is the bounding box of a placeholder on a PDF page
+// Coordinates are in PDF points with the origin at the top-left corner of the page
+type PDFBox struct {
+	Page       int     `json:"page"` // 1-based
+	X0         float64 `json:"x0"`
+	Y0         float64 `json:"y0"`
+	X1         float64 `json:"x1"`
+	Y1         float64 `json:"y1"`
+	PageWidth  float64 `json:"page_width"`
+	PageHeight float64 `json:"page_height"`
+}
+
+// PlaceholderLocation is one occurrence of a placeholder in a DOCX
+type PlaceholderLocation struct {
+	Placeholder   string             `json:"placeholder"`
+	Part          string             `json:"part"`      // body, header or footer
+	PartName      string             `json:"part_name"` // e.g., word/header1.xml
+	Paragraph     int                `json:"paragraph"` // 0-based paragraph index within the part
+	Offset        int                `json:"offset"`    // Character offset within the paragraph text
+	Table         *TableCellLocation `json:"table,omitempty"`
+	Page          int                `json:"page,omitempty"`           // 1-based; 0 for headers and footers (every page)
+	PageEstimated bool               `json:"page_estimated,omitempty"` // Page comes from DOCX page breaks, not the PDF layout
+	PDFBoxes      []PDFBox           `json:"pdf_boxes,omitempty"`      // One box for body occurrences, one per page for headers/footers
+}
+
+// LocatePlaceholders returns every placeholder occurrence with its part, paragraph,
+// table cell and (estimated) page, in document order: headers, body, then footers
+// Must be called after UnzipDocx
+func (dp *DocxProcessor) LocatePlaceholders() ([]PlaceholderLocation, error) {
+	var locations []PlaceholderLocation
+
+	for _, part := range []string{PartHeader, PartBody, PartFooter} {
+		partNames, err := dp.partNames(part)
+		if err != nil {
+			return nil, err
+		}
+		for _, partName := range partNames {
+			content, err := os.ReadFile(filepath.Join(dp.tempDir, filepath.FromSlash(partName)))
+			if err != nil {
+				return nil, fmt.Errorf("failed to read %s: %w", partName, err)
+			}
+			partLocations, err := locateInPart(content, part, partName)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse %s: %w", partName, err)
+			}
+			locations = append(locations, partLocations...)
+		}
+	}
+
+	return locations, nil
+}
+
+// partNames lists the XML parts of a kind (word/document.xml, word/header*.xml, word/footer*.xml)
+func (dp *DocxProcessor) partNames(part string) ([]string, error) {
+	if part == PartBody {
+		return []string{"word/document.xml"}, nil
+	}
+	matches, err := filepath.Glob(filepath.Join(dp.tempDir, "word", part+"*.xml"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list %s parts: %w", part, err)
+	}
+	sort.Strings(matches)
+	names := make([]string, len(matches))
+	for i, match := range matches {
+		names[i] = "word/" + filepath.Base(match)
+	}
+	return names, nil
+}
+
+// paragraphState collects the text of one paragraph while it is parsed
+type paragraphState struct {
+	index int
+	text  strings.Builder
+	table *TableCellLocation
+	pages []pageMark // Page changes within the paragraph, by text offset
+}
+
+type pageMark struct {
+	offset int
+	page   int
+}
+
+// pageAt returns the page at a text offset in the paragraph
+func (p *paragraphState) pageAt(offset int) int {
+	page := 0
+	for _, mark := range p.pages {
+		if mark.offset > offset {
+			break
+		}
+		page = mark.page
+	}
+	return page
+}
+
+// tableState tracks the current row and cell of an open table
+type tableState struct {
+	index int
+	row   int
+	cell  int
+}
+
+// locateInPart finds placeholder occurrences in one WordprocessingML part
+// Pages are only tracked in the body: Word's rendered page breaks are used when present,
+// otherwise explicit page breaks and section breaks
+func locateInPart(content []byte, part, partName string) ([]PlaceholderLocation, error) {
+	trackPages := part == PartBody
+	renderedBreaks := bytes.Contains(content, []byte("lastRenderedPageBreak"))
+
+	decoder := xml.NewDecoder(bytes.NewReader(content))
+	var (
+		locations      []PlaceholderLocation
+		paragraphs     []*paragraphState
+		tables         []*tableState
+		paragraphCount int
+		tableCount     int
+		page           = 1
+		inText         bool
+		skipDepth      int // > 0 while inside mc:Fallback (duplicate of mc:Choice content)
+		inPPr          bool
+		sectionEnd     bool // Paragraph ends a section (w:sectPr in w:pPr)
+	)
+
+	newPage := func() {
+		if !trackPages {
+			return
+		}
+		page++
+		if len(paragraphs) == 0 {
+			return
+		}
+		current := paragraphs[len(paragraphs)-1]
+		mark := pageMark{offset: current.text.Len(), page: page}
+		if last := len(current.pages) - 1; last >= 0 && current.pages[last].offset == mark.offset {
+			current.pages[last] = mark
+		} else {
+			current.pages = append(current.pages, mark)
+		}
+	}
+
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			if skipDepth > 0 {
+				skipDepth++
+				continue
+			}
+			if t.Name.Space == markupCompatibilityNamespace && t.Name.Local == "Fallback" {
+				skipDepth = 1
+				continue
+			}
+			if t.Name.Space != wordNamespace {
+				continue
+			}
+
+			switch t.Name.Local {
+			case "p":
+				paragraph := &paragraphState{index: paragraphCount}
+				paragraphCount++
+				if len(tables) > 0 {
+					top := tables[len(tables)-1]
+					paragraph.table = &TableCellLocation{Table: top.index, Row: top.row, Cell: top.cell, Depth: len(tables)}
+				}
+				if trackPages {
+					paragraph.pages = []pageMark{{offset: 0, page: page}}
+				}
+				paragraphs = append(paragraphs, paragraph)
+			case "pPr":
+				inPPr = true
+			case "pageBreakBefore":
+				if inPPr && !renderedBreaks && paragraphCount > 1 && xmlAttr(t, "val") != "false" && xmlAttr(t, "val") != "0" {
+					newPage()
+				}
+			case "sectPr":
+				if inPPr {
+					sectionEnd = true
+				}
+			case "type":
+				// Continuous section breaks do not start a new page
+				if sectionEnd && xmlAttr(t, "val") == "continuous" {
+					sectionEnd = false
+				}
+			case "br":
+				if !renderedBreaks && xmlAttr(t, "type") == "page" {
+					newPage()
+				}
+			case "lastRenderedPageBreak":
+				if renderedBreaks {
+					newPage()
+				}
+			case "tbl":
+				tables = append(tables, &tableState{index: tableCount, row: -1, cell: -1})
+				tableCount++
+			case "tr":
+				if len(tables) > 0 {
+					top := tables[len(tables)-1]
+					top.row++
+					top.cell = -1
+				}
+			case "tc":
+				if len(tables) > 0 {
+					tables[len(tables)-1].cell++
+				}
+			case "t":
+				inText = true
+			case "tab":
+				if len(paragraphs) > 0 && !inPPr {
+					paragraphs[len(paragraphs)-1].text.WriteString(" ")
+				}
+			}
+
+		case xml.EndElement:
+			if skipDepth > 0 {
+				skipDepth--
+				continue
+			}
+			if t.Name.Space != wordNamespace {
+				continue
+			}
+
+			switch t.Name.Local {
+			case "p":
+				if len(paragraphs) == 0 {
+					continue
+				}
+				paragraph := paragraphs[len(paragraphs)-1]
+				paragraphs = paragraphs[:len(paragraphs)-1]
+				locations = append(locations, paragraphPlaceholders(paragraph, part, partName, trackPages)...)
+				if sectionEnd && !renderedBreaks && len(paragraphs) == 0 {
+					newPage()
+				}
+				sectionEnd = false
+			case "pPr":
+				inPPr = false
+			case "tbl":
+				if len(tables) > 0 {
+					tables = tables[:len(tables)-1]
+				}
+			case "t":
+				inText = false
+			}
+
+		case xml.CharData:
+			if inText && skipDepth == 0 && len(paragraphs) > 0 {
+				paragraphs[len(paragraphs)-1].text.Write(t)
+			}
+		}
+	}
+
+	return locations, nil
+}
+
+// paragraphPlaceholders returns the placeholder occurrences in a parsed paragraph
+func paragraphPlaceholders(paragraph *paragraphState, part, partName string, trackPages bool) []PlaceholderLocation {
+	text := paragraph.text.String()
+	var locations []PlaceholderLocation
+
+	for pos := 0; ; {
+		start := strings.Index(text[pos:], "{{")
+		if start == -1 {
+			break
+		}
+		start += pos
+
+		end := strings.Index(text[start:], "}}")
+		if end == -1 {
+			break
+		}
+		end += start + 2
+
+		location := PlaceholderLocation{
+			Placeholder: text[start:end],
+			Part:        part,
+			PartName:    partName,
+			Paragraph:   paragraph.index,
+			Offset:      len([]rune(text[:start])),
+			Table:       paragraph.table,
+		}
+		if trackPages {
+			location.Page = paragraph.pageAt(start)
+			location.PageEstimated = true
+		}
+		locations = append(locations, location)
+		pos = end
+	}
+
+	return locations
+}
+
+// xmlAttr returns the value of an attribute by local name
+func xmlAttr(element xml.StartElement, name string) string {
+	for _, attr := range element.Attr {
+		if attr.Name.Local == name {
+			return attr.Value
+		}
+	}
+	return ""
+}
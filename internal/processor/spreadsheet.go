@@ -0,0 +1,315 @@
+package processor
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Worksheet limits of Excel; cell references beyond them are rejected
+const (
+	xlsxMaxRows    = 1048576
+	xlsxMaxColumns = 16384
+)
+
+// maxXLSXPartSize caps the uncompressed size of a workbook part that is decoded
+const maxXLSXPartSize = 64 << 20
+
+// ReadSpreadsheet reads the rows of a CSV file or the first worksheet of an XLSX workbook
+// Rows are returned as displayed text; XLSX dates are returned as YYYY-MM-DD
+// Reading stops with an error once the sheet has more than maxRows rows (empty rows included)
+func ReadSpreadsheet(filePath string, maxRows int) ([][]string, error) {
+	switch strings.ToLower(filepath.Ext(filePath)) {
+	case ".csv":
+		file, err := os.Open(filePath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open CSV: %w", err)
+		}
+		defer file.Close()
+		return ReadCSV(file, maxRows)
+	case ".xlsx":
+		return ReadXLSX(filePath, maxRows)
+	}
+	return nil, fmt.Errorf("unsupported spreadsheet type '%s': expected .csv or .xlsx", filepath.Ext(filePath))
+}
+
+// ReadCSV reads comma-separated rows, skipping the UTF-8 byte order mark Excel writes
+// At most maxRows rows are read; a longer file is an error
+func ReadCSV(r io.Reader, maxRows int) ([][]string, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CSV: %w", err)
+	}
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+	var rows [][]string
+	for {
+		row, err := reader.Read()
+		if err == io.EOF {
+			return rows, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse CSV: %w", err)
+		}
+		if len(rows) >= maxRows {
+			return nil, fmt.Errorf("the spreadsheet has more than %d rows", maxRows)
+		}
+		rows = append(rows, row)
+	}
+}
+
+// XLSX parts used by ReadXLSX
+type xlsxWorkbook struct {
+	Sheets []struct {
+		Name string `xml:"name,attr"`
+		RID  string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+	} `xml:"sheets>sheet"`
+	Date1904 struct {
+		Value string `xml:"date1904,attr"`
+	} `xml:"workbookPr"`
+}
+
+type xlsxRelationships struct {
+	Relationships []struct {
+		ID     string `xml:"Id,attr"`
+		Target string `xml:"Target,attr"`
+	} `xml:"Relationship"`
+}
+
+type xlsxRichText struct {
+	Text string `xml:"t"`
+	Runs []struct {
+		Text string `xml:"t"`
+	} `xml:"r"`
+}
+
+func (t xlsxRichText) String() string {
+	if len(t.Runs) == 0 {
+		return t.Text
+	}
+	var text strings.Builder
+	for _, run := range t.Runs {
+		text.WriteString(run.Text)
+	}
+	return text.String()
+}
+
+type xlsxSharedStrings struct {
+	Items []xlsxRichText `xml:"si"`
+}
+
+type xlsxStyles struct {
+	NumFmts []struct {
+		ID   int    `xml:"numFmtId,attr"`
+		Code string `xml:"formatCode,attr"`
+	} `xml:"numFmts>numFmt"`
+	CellXfs []struct {
+		NumFmtID int `xml:"numFmtId,attr"`
+	} `xml:"cellXfs>xf"`
+}
+
+type xlsxSheet struct {
+	Rows []struct {
+		Index int `xml:"r,attr"`
+		Cells []struct {
+			Ref    string       `xml:"r,attr"`
+			Type   string       `xml:"t,attr"`
+			Style  int          `xml:"s,attr"`
+			Value  string       `xml:"v"`
+			Inline xlsxRichText `xml:"is"`
+		} `xml:"c"`
+	} `xml:"sheetData>row"`
+}
+
+// ReadXLSX reads the first worksheet of an XLSX workbook
+// Only cell values are read: formulas return their cached result and formatting is ignored
+// except for recognizing date cells. At most maxRows rows are read; a longer sheet is an error
+func ReadXLSX(filePath string, maxRows int) ([][]string, error) {
+	archive, err := zip.OpenReader(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open XLSX: %w", err)
+	}
+	defer archive.Close()
+
+	files := make(map[string]*zip.File, len(archive.File))
+	for _, file := range archive.File {
+		files[file.Name] = file
+	}
+	readXML := func(name string, v interface{}) error {
+		file, ok := files[name]
+		if !ok {
+			return fmt.Errorf("missing %s", name)
+		}
+		reader, err := file.Open()
+		if err != nil {
+			return err
+		}
+		defer reader.Close()
+		return xml.NewDecoder(io.LimitReader(reader, maxXLSXPartSize)).Decode(v)
+	}
+
+	var workbook xlsxWorkbook
+	if err := readXML("xl/workbook.xml", &workbook); err != nil {
+		return nil, fmt.Errorf("failed to read XLSX workbook: %w", err)
+	}
+	if len(workbook.Sheets) == 0 {
+		return nil, fmt.Errorf("the workbook has no sheets")
+	}
+
+	sheetPath := "xl/worksheets/sheet1.xml"
+	var rels xlsxRelationships
+	if err := readXML("xl/_rels/workbook.xml.rels", &rels); err == nil {
+		for _, rel := range rels.Relationships {
+			if rel.ID != workbook.Sheets[0].RID {
+				continue
+			}
+			if strings.HasPrefix(rel.Target, "/") {
+				sheetPath = strings.TrimPrefix(rel.Target, "/")
+			} else {
+				sheetPath = path.Join("xl", rel.Target)
+			}
+		}
+	}
+
+	// Shared strings and styles are optional parts
+	var sharedStrings xlsxSharedStrings
+	readXML("xl/sharedStrings.xml", &sharedStrings)
+	var styles xlsxStyles
+	readXML("xl/styles.xml", &styles)
+	dateStyles := xlsxDateStyles(styles)
+	date1904 := workbook.Date1904.Value == "1" || workbook.Date1904.Value == "true"
+
+	var sheet xlsxSheet
+	if err := readXML(sheetPath, &sheet); err != nil {
+		return nil, fmt.Errorf("failed to read XLSX worksheet: %w", err)
+	}
+
+	var rows [][]string
+	for _, sheetRow := range sheet.Rows {
+		// Empty rows are not stored; keep row numbers aligned with what the user sees
+		if sheetRow.Index > xlsxMaxRows {
+			return nil, fmt.Errorf("row %d is beyond the last worksheet row", sheetRow.Index)
+		}
+		index := sheetRow.Index - 1
+		if index < len(rows) {
+			index = len(rows)
+		}
+		if index >= maxRows {
+			return nil, fmt.Errorf("the spreadsheet has more than %d rows", maxRows)
+		}
+		for len(rows) < index {
+			rows = append(rows, nil)
+		}
+
+		var row []string
+		for i, cell := range sheetRow.Cells {
+			column := i
+			if cell.Ref != "" {
+				column = xlsxColumnIndex(strings.ToUpper(cell.Ref))
+				if column < 0 {
+					return nil, fmt.Errorf("invalid cell reference '%s'", cell.Ref)
+				}
+			}
+			if column >= xlsxMaxColumns {
+				return nil, fmt.Errorf("cell '%s' is beyond the last worksheet column", cell.Ref)
+			}
+			for len(row) < column {
+				row = append(row, "")
+			}
+
+			value := cell.Value
+			switch cell.Type {
+			case "s":
+				if n, err := strconv.Atoi(cell.Value); err == nil && n >= 0 && n < len(sharedStrings.Items) {
+					value = sharedStrings.Items[n].String()
+				}
+			case "inlineStr":
+				value = cell.Inline.String()
+			case "b":
+				value = map[string]string{"1": "TRUE", "0": "FALSE"}[cell.Value]
+			case "", "n":
+				if cell.Style >= 0 && cell.Style < len(dateStyles) && dateStyles[cell.Style] {
+					if serial, err := strconv.ParseFloat(cell.Value, 64); err == nil {
+						value = xlsxDate(serial, date1904)
+					}
+				} else if number, err := strconv.ParseFloat(cell.Value, 64); err == nil && strings.ContainsAny(cell.Value, "eE") {
+					value = strconv.FormatFloat(number, 'f', -1, 64)
+				}
+			}
+			if column < len(row) {
+				row[column] = value
+			} else {
+				row = append(row, value)
+			}
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// xlsxDateStyles reports for each cell style whether it formats numbers as dates
+func xlsxDateStyles(styles xlsxStyles) []bool {
+	customDates := make(map[int]bool)
+	for _, format := range styles.NumFmts {
+		code := strings.ToLower(format.Code)
+		// Drop quoted literals and colors/conditions before looking for date parts
+		for _, pair := range [][2]string{{`"`, `"`}, {"[", "]"}} {
+			for {
+				start := strings.Index(code, pair[0])
+				if start < 0 {
+					break
+				}
+				end := strings.Index(code[start+1:], pair[1])
+				if end < 0 {
+					break
+				}
+				code = code[:start] + code[start+end+2:]
+			}
+		}
+		customDates[format.ID] = strings.ContainsAny(code, "dy") || (strings.Contains(code, "m") && !strings.Contains(code, "h"))
+	}
+
+	dates := make([]bool, len(styles.CellXfs))
+	for i, xf := range styles.CellXfs {
+		id := xf.NumFmtID
+		dates[i] = (id >= 14 && id <= 17) || id == 22 || (id >= 27 && id <= 36) || (id >= 50 && id <= 58) || customDates[id]
+	}
+	return dates
+}
+
+// xlsxDate converts an Excel date serial number to YYYY-MM-DD
+func xlsxDate(serial float64, date1904 bool) string {
+	epoch := time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC)
+	if date1904 {
+		epoch = time.Date(1904, 1, 1, 0, 0, 0, 0, time.UTC)
+	}
+	return epoch.AddDate(0, 0, int(math.Floor(serial))).Format("2006-01-02")
+}
+
+// xlsxColumnIndex converts a cell reference such as "AB12" to a 0-based column index
+func xlsxColumnIndex(ref string) int {
+	column := 0
+	for _, r := range ref {
+		if r < 'A' || r > 'Z' {
+			break
+		}
+		column = column*26 + int(r-'A') + 1
+		if column > xlsxMaxColumns {
+			return xlsxMaxColumns // Out of range; stops before a long reference overflows
+		}
+	}
+	return column - 1
+}
@@ -0,0 +1,159 @@
+package processor
+
+import (
+	"archive/zip"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// writeTestXLSX writes a workbook whose first sheet has the given <sheetData> content
+func writeTestXLSX(t *testing.T, sheetData string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "book.xlsx")
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("create XLSX: %v", err)
+	}
+	defer file.Close()
+
+	parts := map[string]string{
+		"xl/workbook.xml": `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">` +
+			`<sheets><sheet name="Sheet1" sheetId="1" r:id="rId1"/></sheets></workbook>`,
+		"xl/_rels/workbook.xml.rels": `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+			`<Relationship Id="rId1" Target="worksheets/sheet1.xml"/></Relationships>`,
+		"xl/sharedStrings.xml": `<sst xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><si><t>name</t></si><si><t>Somchai</t></si></sst>`,
+		"xl/styles.xml": `<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">` +
+			`<cellXfs count="2"><xf numFmtId="0"/><xf numFmtId="14"/></cellXfs></styleSheet>`,
+		"xl/worksheets/sheet1.xml": `<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>` +
+			sheetData + `</sheetData></worksheet>`,
+	}
+	archive := zip.NewWriter(file)
+	for name, content := range parts {
+		w, err := archive.Create(name)
+		if err != nil {
+			t.Fatalf("create %s: %v", name, err)
+		}
+		if _, err := w.Write([]byte(content)); err != nil {
+			t.Fatalf("write %s: %v", name, err)
+		}
+	}
+	if err := archive.Close(); err != nil {
+		t.Fatalf("close XLSX: %v", err)
+	}
+	return path
+}
+
+func TestReadXLSX(t *testing.T) {
+	path := writeTestXLSX(t,
+		`<row r="1"><c r="A1" t="s"><v>0</v></c><c r="C1" t="inlineStr"><is><t>dob</t></is></c></row>`+
+			`<row r="3"><c r="a3" t="s"><v>1</v></c><c r="B3" t="b"><v>1</v></c><c r="C3" s="1"><v>32800</v></c></row>`)
+
+	rows, err := ReadXLSX(path, 10)
+	if err != nil {
+		t.Fatalf("ReadXLSX: %v", err)
+	}
+	want := [][]string{
+		{"name", "", "dob"},
+		nil,
+		{"Somchai", "TRUE", "1989-10-19"},
+	}
+	if !reflect.DeepEqual(rows, want) {
+		t.Fatalf("rows = %q, want %q", rows, want)
+	}
+}
+
+func TestReadXLSXRejectsInvalidSheets(t *testing.T) {
+	tests := []struct {
+		name      string
+		sheetData string
+	}{
+		{"reference without a column", `<row r="1"><c r="12"><v>1</v></c></row>`},
+		{"reference with symbols", `<row r="1"><c r="$A$1"><v>1</v></c></row>`},
+		{"column beyond the last column", `<row r="1"><c r="XFE1"><v>1</v></c></row>`},
+		{"long column reference", `<row r="1"><c r="` + strings.Repeat("Z", 40) + `1"><v>1</v></c></row>`},
+		{"row beyond the last row", `<row r="1048577"><c r="A1048577"><v>1</v></c></row>`},
+		{"too many rows", `<row r="1"><c r="A1"><v>1</v></c></row><row r="2"><c r="A2"><v>1</v></c></row><row r="3"><c r="A3"><v>1</v></c></row>`},
+		{"sparse row beyond the limit", `<row r="1000"><c r="A1000"><v>1</v></c></row>`},
+		{"malformed XML", `<row r="1"><c r="A1"><v>1</v>`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ReadXLSX(writeTestXLSX(t, tt.sheetData), 2); err == nil {
+				t.Fatal("ReadXLSX succeeded, want an error")
+			}
+		})
+	}
+}
+
+func TestReadXLSXRejectsNonWorkbooks(t *testing.T) {
+	dir := t.TempDir()
+	notZip := filepath.Join(dir, "not.xlsx")
+	if err := os.WriteFile(notZip, []byte("name,value\n"), 0o644); err != nil {
+		t.Fatalf("write file: %v", err)
+	}
+	if _, err := ReadXLSX(notZip, 10); err == nil {
+		t.Fatal("ReadXLSX succeeded on a file that is not a zip archive")
+	}
+
+	empty := filepath.Join(dir, "empty.xlsx")
+	file, err := os.Create(empty)
+	if err != nil {
+		t.Fatalf("create file: %v", err)
+	}
+	zip.NewWriter(file).Close()
+	file.Close()
+	if _, err := ReadXLSX(empty, 10); err == nil {
+		t.Fatal("ReadXLSX succeeded on an archive without a workbook")
+	}
+}
+
+func TestXLSXColumnIndex(t *testing.T) {
+	tests := []struct {
+		ref  string
+		want int
+	}{
+		{"A1", 0},
+		{"Z9", 25},
+		{"AA1", 26},
+		{"XFD1", xlsxMaxColumns - 1},
+		{"XFE1", xlsxMaxColumns},
+		{strings.Repeat("Z", 100), xlsxMaxColumns},
+		{"1", -1},
+		{"", -1},
+	}
+	for _, tt := range tests {
+		if got := xlsxColumnIndex(tt.ref); got != tt.want {
+			t.Errorf("xlsxColumnIndex(%q) = %d, want %d", tt.ref, got, tt.want)
+		}
+	}
+}
+
+func TestReadCSV(t *testing.T) {
+	rows, err := ReadCSV(strings.NewReader("\xef\xbb\xbfname,note\nSomchai,\"a \"\"quoted\"\" note\"\nshort\n"), 10)
+	if err != nil {
+		t.Fatalf("ReadCSV: %v", err)
+	}
+	want := [][]string{{"name", "note"}, {"Somchai", `a "quoted" note`}, {"short"}}
+	if !reflect.DeepEqual(rows, want) {
+		t.Fatalf("rows = %q, want %q", rows, want)
+	}
+}
+
+func TestReadCSVRowLimit(t *testing.T) {
+	if _, err := ReadCSV(strings.NewReader("a\nb\nc\n"), 3); err != nil {
+		t.Fatalf("ReadCSV at the limit: %v", err)
+	}
+	if _, err := ReadCSV(strings.NewReader("a\nb\nc\nd\n"), 3); err == nil {
+		t.Fatal("ReadCSV succeeded with more rows than the limit")
+	}
+}
+
+func TestReadSpreadsheetRejectsUnknownType(t *testing.T) {
+	if _, err := ReadSpreadsheet(filepath.Join(t.TempDir(), "data.txt"), 10); err == nil {
+		t.Fatal("ReadSpreadsheet succeeded on a .txt file")
+	}
+}
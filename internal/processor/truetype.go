@@ -0,0 +1,443 @@
+package processor
+
+import (
+	"bytes"
+	"compress/zlib"
+	"embed"
+	"encoding/binary"
+	"fmt"
+	"io/fs"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf16"
+)
+
+// embeddedFonts holds the fonts compiled into the binary (see fonts/README.md)
+//
+//go:embed fonts
+var embeddedFonts embed.FS
+
+// TrueTypeFont is a parsed TrueType font, embedded whole into PDFs as a CID font
+type TrueTypeFont struct {
+	Name       string // PostScript name
+	data       []byte
+	unitsPerEm int
+	ascent     int
+	descent    int // Negative below the baseline
+	lineGap    int
+	capHeight  int
+	bbox       [4]int
+	advances   []int // Advance width per glyph, in font units
+	cmap       map[rune]uint16
+}
+
+var (
+	overlayFontMu   sync.Mutex
+	overlayFont     *TrueTypeFont
+	overlayFontPath string
+)
+
+// SetOverlayFontPath sets a TrueType font file that takes precedence over the embedded fonts
+func SetOverlayFontPath(path string) {
+	overlayFontMu.Lock()
+	defer overlayFontMu.Unlock()
+	overlayFontPath = path
+	overlayFont = nil
+}
+
+// OverlayFont returns the font used for overlay text: the configured font file, otherwise the
+// first .ttf compiled in from the fonts directory. The font must cover Thai
+func OverlayFont() (*TrueTypeFont, error) {
+	overlayFontMu.Lock()
+	defer overlayFontMu.Unlock()
+	if overlayFont != nil {
+		return overlayFont, nil
+	}
+
+	var data []byte
+	if overlayFontPath != "" {
+		content, err := os.ReadFile(overlayFontPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read overlay font: %w", err)
+		}
+		data = content
+	} else {
+		names, _ := fs.Glob(embeddedFonts, "fonts/*.ttf")
+		if len(names) == 0 {
+			return nil, fmt.Errorf("no overlay font available: add a Thai .ttf to internal/processor/fonts or set OVERLAY_FONT_PATH")
+		}
+		sort.Strings(names)
+		content, err := embeddedFonts.ReadFile(names[0])
+		if err != nil {
+			return nil, fmt.Errorf("failed to read embedded font: %w", err)
+		}
+		data = content
+	}
+
+	font, err := ParseTrueTypeFont(data)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := font.cmap['ก']; !ok {
+		fmt.Printf("[WARNING] Overlay font %s has no Thai glyphs\n", font.Name)
+	}
+	overlayFont = font
+	return font, nil
+}
+
+// ParseTrueTypeFont reads the tables needed to lay out text and embed the font
+// Only TrueType outlines are supported (not CFF-based OpenType or collections)
+func ParseTrueTypeFont(data []byte) (*TrueTypeFont, error) {
+	if len(data) < 12 {
+		return nil, fmt.Errorf("font file is too short")
+	}
+	switch version := binary.BigEndian.Uint32(data); version {
+	case 0x00010000, 0x74727565: // 1.0 or "true"
+	case 0x4f54544f: // "OTTO"
+		return nil, fmt.Errorf("CFF-based OpenType fonts are not supported, use a TrueType font")
+	default:
+		return nil, fmt.Errorf("not a TrueType font")
+	}
+
+	tables := make(map[string][]byte)
+	numTables := int(binary.BigEndian.Uint16(data[4:]))
+	for i := 0; i < numTables; i++ {
+		record := 12 + 16*i
+		if record+16 > len(data) {
+			return nil, fmt.Errorf("truncated table directory")
+		}
+		tag := string(data[record : record+4])
+		offset := int(binary.BigEndian.Uint32(data[record+8:]))
+		length := int(binary.BigEndian.Uint32(data[record+12:]))
+		if offset+length > len(data) {
+			return nil, fmt.Errorf("table %s is out of range", tag)
+		}
+		tables[tag] = data[offset : offset+length]
+	}
+	for _, tag := range []string{"head", "hhea", "hmtx", "maxp", "cmap", "glyf"} {
+		if tables[tag] == nil {
+			return nil, fmt.Errorf("font has no %s table", tag)
+		}
+	}
+
+	font := &TrueTypeFont{data: data, Name: "OverlayFont"}
+
+	head := tables["head"]
+	if len(head) < 54 {
+		return nil, fmt.Errorf("invalid head table")
+	}
+	font.unitsPerEm = int(binary.BigEndian.Uint16(head[18:]))
+	for i := range font.bbox {
+		font.bbox[i] = int(int16(binary.BigEndian.Uint16(head[36+2*i:])))
+	}
+	if font.unitsPerEm == 0 {
+		return nil, fmt.Errorf("invalid unitsPerEm")
+	}
+
+	hhea := tables["hhea"]
+	if len(hhea) < 36 {
+		return nil, fmt.Errorf("invalid hhea table")
+	}
+	font.ascent = int(int16(binary.BigEndian.Uint16(hhea[4:])))
+	font.descent = int(int16(binary.BigEndian.Uint16(hhea[6:])))
+	font.lineGap = int(int16(binary.BigEndian.Uint16(hhea[8:])))
+	numHMetrics := int(binary.BigEndian.Uint16(hhea[34:]))
+
+	numGlyphs := int(binary.BigEndian.Uint16(tables["maxp"][4:]))
+	hmtx := tables["hmtx"]
+	if numHMetrics == 0 || len(hmtx) < 4*numHMetrics {
+		return nil, fmt.Errorf("invalid hmtx table")
+	}
+	font.advances = make([]int, numGlyphs)
+	for gid := 0; gid < numGlyphs; gid++ {
+		if gid < numHMetrics {
+			font.advances[gid] = int(binary.BigEndian.Uint16(hmtx[4*gid:]))
+		} else {
+			font.advances[gid] = font.advances[numHMetrics-1]
+		}
+	}
+
+	font.capHeight = font.ascent
+	if os2 := tables["OS/2"]; len(os2) >= 90 && binary.BigEndian.Uint16(os2) >= 2 {
+		font.capHeight = int(int16(binary.BigEndian.Uint16(os2[88:])))
+	}
+
+	cmap, err := parseCmap(tables["cmap"])
+	if err != nil {
+		return nil, err
+	}
+	font.cmap = cmap
+
+	if name := postScriptName(tables["name"]); name != "" {
+		font.Name = name
+	}
+	return font, nil
+}
+
+// parseCmap reads the best Unicode subtable: format 12 (full range), then format 4 (BMP)
+func parseCmap(table []byte) (map[rune]uint16, error) {
+	if len(table) < 4 {
+		return nil, fmt.Errorf("invalid cmap table")
+	}
+	var format4, format12 []byte
+	numTables := int(binary.BigEndian.Uint16(table[2:]))
+	for i := 0; i < numTables; i++ {
+		record := 4 + 8*i
+		if record+8 > len(table) {
+			break
+		}
+		platform := binary.BigEndian.Uint16(table[record:])
+		encoding := binary.BigEndian.Uint16(table[record+2:])
+		offset := int(binary.BigEndian.Uint32(table[record+4:]))
+		if offset+2 > len(table) || !(platform == 0 || (platform == 3 && (encoding == 1 || encoding == 10))) {
+			continue
+		}
+		switch binary.BigEndian.Uint16(table[offset:]) {
+		case 4:
+			format4 = table[offset:]
+		case 12:
+			format12 = table[offset:]
+		}
+	}
+
+	cmap := make(map[rune]uint16)
+	if len(format12) >= 16 {
+		groups := int(binary.BigEndian.Uint32(format12[12:]))
+		for i := 0; i < groups && 16+12*i+12 <= len(format12); i++ {
+			group := format12[16+12*i:]
+			start := rune(binary.BigEndian.Uint32(group))
+			end := rune(binary.BigEndian.Uint32(group[4:]))
+			glyph := binary.BigEndian.Uint32(group[8:])
+			for r := start; r <= end && r-start < 0x10000; r++ {
+				cmap[r] = uint16(glyph + uint32(r-start))
+			}
+		}
+		return cmap, nil
+	}
+	if len(format4) >= 14 {
+		segCount := int(binary.BigEndian.Uint16(format4[6:])) / 2
+		endCodes := 14
+		startCodes := endCodes + 2*segCount + 2
+		idDeltas := startCodes + 2*segCount
+		idRangeOffsets := idDeltas + 2*segCount
+		if idRangeOffsets+2*segCount > len(format4) {
+			return nil, fmt.Errorf("invalid cmap format 4 subtable")
+		}
+		for seg := 0; seg < segCount; seg++ {
+			end := int(binary.BigEndian.Uint16(format4[endCodes+2*seg:]))
+			start := int(binary.BigEndian.Uint16(format4[startCodes+2*seg:]))
+			delta := binary.BigEndian.Uint16(format4[idDeltas+2*seg:])
+			rangeOffset := int(binary.BigEndian.Uint16(format4[idRangeOffsets+2*seg:]))
+			for c := start; c <= end && c != 0xffff; c++ {
+				var glyph uint16
+				if rangeOffset == 0 {
+					glyph = uint16(c) + delta
+				} else {
+					pos := idRangeOffsets + 2*seg + rangeOffset + 2*(c-start)
+					if pos+2 > len(format4) {
+						continue
+					}
+					glyph = binary.BigEndian.Uint16(format4[pos:])
+					if glyph != 0 {
+						glyph += delta
+					}
+				}
+				if glyph != 0 {
+					cmap[rune(c)] = glyph
+				}
+			}
+		}
+		return cmap, nil
+	}
+	return nil, fmt.Errorf("font has no Unicode cmap")
+}
+
+// postScriptName reads name ID 6 from the name table
+func postScriptName(table []byte) string {
+	if len(table) < 6 {
+		return ""
+	}
+	count := int(binary.BigEndian.Uint16(table[2:]))
+	stringOffset := int(binary.BigEndian.Uint16(table[4:]))
+	for i := 0; i < count; i++ {
+		record := 6 + 12*i
+		if record+12 > len(table) {
+			break
+		}
+		platform := binary.BigEndian.Uint16(table[record:])
+		nameID := binary.BigEndian.Uint16(table[record+6:])
+		length := int(binary.BigEndian.Uint16(table[record+8:]))
+		offset := stringOffset + int(binary.BigEndian.Uint16(table[record+10:]))
+		if nameID != 6 || offset+length > len(table) {
+			continue
+		}
+		raw := table[offset : offset+length]
+		var name string
+		if platform == 3 || platform == 0 {
+			units := make([]uint16, len(raw)/2)
+			for j := range units {
+				units[j] = binary.BigEndian.Uint16(raw[2*j:])
+			}
+			name = string(utf16.Decode(units))
+		} else {
+			name = string(raw)
+		}
+		// PDF names must not contain spaces or delimiters
+		name = strings.Map(func(r rune) rune {
+			if r <= 0x20 || r >= 0x7f || isPDFDelimiter(byte(r)) {
+				return -1
+			}
+			return r
+		}, name)
+		if name != "" {
+			return name
+		}
+	}
+	return ""
+}
+
+// glyph returns the glyph ID for a rune (0, the .notdef glyph, when the font lacks it)
+func (f *TrueTypeFont) glyph(r rune) uint16 {
+	return f.cmap[r]
+}
+
+// HasGlyph reports whether the font covers a rune
+func (f *TrueTypeFont) HasGlyph(r rune) bool {
+	_, ok := f.cmap[r]
+	return ok
+}
+
+// advance returns the advance width of a glyph in points at a font size
+func (f *TrueTypeFont) advance(gid uint16, size float64) float64 {
+	if int(gid) >= len(f.advances) {
+		return 0
+	}
+	return float64(f.advances[gid]) * size / float64(f.unitsPerEm)
+}
+
+// TextWidth returns the width of a string in points at a font size
+func (f *TrueTypeFont) TextWidth(text string, size float64) float64 {
+	width := 0.0
+	for _, r := range text {
+		width += f.advance(f.glyph(r), size)
+	}
+	return width
+}
+
+// scale converts font units to PDF glyph space (1000 units per em)
+func (f *TrueTypeFont) scale(units int) int {
+	return units * 1000 / f.unitsPerEm
+}
+
+// pdfFontUsage collects the glyphs a document uses so widths and ToUnicode only cover those
+type pdfFontUsage struct {
+	font   *TrueTypeFont
+	glyphs map[uint16]rune
+}
+
+func newPDFFontUsage(font *TrueTypeFont) *pdfFontUsage {
+	return &pdfFontUsage{font: font, glyphs: make(map[uint16]rune)}
+}
+
+// encode returns the Identity-H encoded glyph string for text and records the glyphs
+func (u *pdfFontUsage) encode(text string) pdfString {
+	var out []byte
+	for _, r := range text {
+		gid := u.font.glyph(r)
+		if _, seen := u.glyphs[gid]; !seen && gid != 0 {
+			u.glyphs[gid] = r
+		}
+		out = append(out, byte(gid>>8), byte(gid))
+	}
+	return pdfString{value: out, hex: true}
+}
+
+// embed adds the font objects (Type0 font, CID font, descriptor, font file, ToUnicode) to a
+// document and returns the reference of the Type0 font
+func (u *pdfFontUsage) embed(doc *pdfDocument) pdfRef {
+	font := u.font
+
+	var compressed bytes.Buffer
+	writer := zlib.NewWriter(&compressed)
+	writer.Write(font.data)
+	writer.Close()
+	fontFile := doc.add(&pdfStream{
+		dict: pdfDict{
+			"Filter":  pdfName("FlateDecode"),
+			"Length1": pdfNumber(strconv.Itoa(len(font.data))),
+		},
+		data: compressed.Bytes(),
+	})
+
+	descriptor := doc.add(pdfDict{
+		"Type":        pdfName("FontDescriptor"),
+		"FontName":    pdfName(font.Name),
+		"Flags":       pdfNumber("32"),
+		"FontBBox":    pdfArray{pdfInt(font.scale(font.bbox[0])), pdfInt(font.scale(font.bbox[1])), pdfInt(font.scale(font.bbox[2])), pdfInt(font.scale(font.bbox[3]))},
+		"ItalicAngle": pdfNumber("0"),
+		"Ascent":      pdfInt(font.scale(font.ascent)),
+		"Descent":     pdfInt(font.scale(font.descent)),
+		"CapHeight":   pdfInt(font.scale(font.capHeight)),
+		"StemV":       pdfNumber("80"),
+		"FontFile2":   fontFile,
+	})
+
+	gids := make([]int, 0, len(u.glyphs))
+	for gid := range u.glyphs {
+		gids = append(gids, int(gid))
+	}
+	sort.Ints(gids)
+
+	widths := pdfArray{}
+	for _, gid := range gids {
+		widths = append(widths, pdfInt(gid), pdfArray{pdfInt(font.scale(font.advances[gid]))})
+	}
+
+	cidFont := doc.add(pdfDict{
+		"Type":           pdfName("Font"),
+		"Subtype":        pdfName("CIDFontType2"),
+		"BaseFont":       pdfName(font.Name),
+		"CIDSystemInfo":  pdfDict{"Registry": pdfString{value: []byte("Adobe")}, "Ordering": pdfString{value: []byte("Identity")}, "Supplement": pdfNumber("0")},
+		"FontDescriptor": descriptor,
+		"CIDToGIDMap":    pdfName("Identity"),
+		"DW":             pdfNumber("1000"),
+		"W":              widths,
+	})
+
+	// ToUnicode keeps the text searchable and extractable
+	var cmap strings.Builder
+	cmap.WriteString("/CIDInit /ProcSet findresource begin\n12 dict begin\nbegincmap\n")
+	cmap.WriteString("/CIDSystemInfo << /Registry (Adobe) /Ordering (UCS) /Supplement 0 >> def\n")
+	cmap.WriteString("/CMapName /Adobe-Identity-UCS def\n/CMapType 2 def\n")
+	cmap.WriteString("1 begincodespacerange\n<0000> <FFFF>\nendcodespacerange\n")
+	for start := 0; start < len(gids); start += 100 {
+		end := min(start+100, len(gids))
+		fmt.Fprintf(&cmap, "%d beginbfchar\n", end-start)
+		for _, gid := range gids[start:end] {
+			fmt.Fprintf(&cmap, "<%04X> <", gid)
+			for _, unit := range utf16.Encode([]rune{u.glyphs[uint16(gid)]}) {
+				fmt.Fprintf(&cmap, "%04X", unit)
+			}
+			cmap.WriteString(">\n")
+		}
+		cmap.WriteString("endbfchar\n")
+	}
+	cmap.WriteString("endcmap\nCMapName currentdict /CMap defineresource pop\nend\nend\n")
+	toUnicode := doc.add(&pdfStream{dict: pdfDict{}, data: []byte(cmap.String())})
+
+	return doc.add(pdfDict{
+		"Type":            pdfName("Font"),
+		"Subtype":         pdfName("Type0"),
+		"BaseFont":        pdfName(font.Name),
+		"Encoding":        pdfName("Identity-H"),
+		"DescendantFonts": pdfArray{cidFont},
+		"ToUnicode":       toUnicode,
+	})
+}
+
+func pdfInt(value int) pdfNumber {
+	return pdfNumber(strconv.Itoa(value))
+}
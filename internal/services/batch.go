@@ -0,0 +1,621 @@
+package services
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime/multipart"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"DF-PLCH/internal"
+	"DF-PLCH/internal/models"
+	"DF-PLCH/internal/processor"
+	"DF-PLCH/internal/storage"
+
+	"github.com/google/uuid"
+)
+
+// ErrInvalidBatch is returned when a batch request or its spreadsheet cannot be used
+var ErrInvalidBatch = errors.New("invalid batch")
+
+// ErrBatchQueueFull is returned when too many batch jobs are waiting for a worker
+var ErrBatchQueueFull = errors.New("too many batch jobs are queued, try again later")
+
+// maxBatchRows limits the number of documents generated by one batch job
+const maxBatchRows = 1000
+
+var batchPatternToken = regexp.MustCompile(`\{\{\s*([^{}]+?)\s*\}\}`)
+
+// BatchOptions are the settings of a batch job
+type BatchOptions struct {
+	Output          string // zip (default) or pdf (merged)
+	Format          string // File format inside a ZIP: docx (default for DOCX templates) or pdf
+	FilenamePattern string // Per-row file name, e.g. "certificate_{{name}}"; {{row}} is the row number
+}
+
+// BatchService generates documents from spreadsheet rows (mail merge)
+// Jobs wait in memory for one of a bounded number of workers
+type BatchService struct {
+	documentService *DocumentService
+	storageClient   storage.StorageClient
+	workers         int
+	runs            chan *batchRun
+	ctx             context.Context
+	cancel          context.CancelFunc
+	wg              sync.WaitGroup
+}
+
+func NewBatchService(documentService *DocumentService, storageClient storage.StorageClient, workers, maxQueued int) *BatchService {
+	if workers < 1 {
+		workers = 1
+	}
+	if maxQueued < 1 {
+		maxQueued = 1
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	return &BatchService{
+		documentService: documentService,
+		storageClient:   storageClient,
+		workers:         workers,
+		runs:            make(chan *batchRun, maxQueued),
+		ctx:             ctx,
+		cancel:          cancel,
+	}
+}
+
+func (s *BatchService) Start() {
+	for i := 0; i < s.workers; i++ {
+		s.wg.Add(1)
+		go s.work()
+	}
+	log.Printf("Batch workers started (workers: %d, max queued: %d)", s.workers, cap(s.runs))
+}
+
+// Stop cancels running jobs and waits for the workers to exit
+// Jobs still queued are marked failed by FailInterruptedJobs on the next start
+func (s *BatchService) Stop() {
+	s.cancel()
+	s.wg.Wait()
+	log.Println("Batch workers stopped")
+}
+
+// batchRun is a queued job with the rows it generates
+type batchRun struct {
+	job      *models.BatchJob
+	template *models.Template
+	rows     []batchRow
+	lookup   map[string]string
+}
+
+// batchRow is a spreadsheet row with the values of its mapped columns, keyed by placeholder or
+// merged field/radio group ("{{key}}")
+type batchRow struct {
+	number int
+	data   map[string]string
+}
+
+// StartBatch reads a CSV/XLSX and starts a job generating one document per row
+// The header row maps columns to placeholders, by key ("name" or "{{name}}") or alias; merged fields
+// and radio groups can be filled through a column named by their key.
+// Unknown columns are ignored; the job reports the mapping that was used
+func (s *BatchService) StartBatch(ctx context.Context, template *models.Template, file multipart.File, header *multipart.FileHeader, userID string, opts BatchOptions) (*models.BatchJob, error) {
+	if opts.Output == "" {
+		opts.Output = models.BatchOutputZip
+	}
+	if opts.Output != models.BatchOutputZip && opts.Output != models.BatchOutputPDF {
+		return nil, fmt.Errorf("%w: unsupported output '%s' (expected zip or pdf)", ErrInvalidBatch, opts.Output)
+	}
+	if opts.Output == models.BatchOutputPDF {
+		if s.documentService.pdfService == nil {
+			return nil, fmt.Errorf("%w: merged PDF output is not available", ErrInvalidBatch)
+		}
+		opts.Format = "pdf"
+	}
+	if !template.IsDocx() {
+		opts.Format = "pdf" // PDF forms and overlays only produce PDFs
+	}
+	if opts.Format == "" {
+		opts.Format = "docx"
+	}
+	if opts.Format != "docx" && opts.Format != "pdf" {
+		return nil, fmt.Errorf("%w: unsupported format '%s' (expected docx or pdf)", ErrInvalidBatch, opts.Format)
+	}
+
+	ext := strings.ToLower(filepath.Ext(header.Filename))
+	if ext != ".csv" && ext != ".xlsx" {
+		return nil, fmt.Errorf("%w: expected a .csv or .xlsx file, got %s", ErrInvalidBatch, ext)
+	}
+	tempFile, err := os.CreateTemp("", "batch_*"+ext)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temp file: %w", err)
+	}
+	defer os.Remove(tempFile.Name())
+	_, err = io.Copy(tempFile, file)
+	tempFile.Close()
+	if err != nil {
+		return nil, fmt.Errorf("failed to save spreadsheet: %w", err)
+	}
+
+	// One header row plus the row limit; empty rows count until they are dropped below
+	table, err := processor.ReadSpreadsheet(tempFile.Name(), maxBatchRows+1)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidBatch, err)
+	}
+	if len(table) == 0 {
+		return nil, fmt.Errorf("%w: the spreadsheet is empty", ErrInvalidBatch)
+	}
+
+	var placeholders []string
+	if err := json.Unmarshal([]byte(template.Placeholders), &placeholders); err != nil {
+		return nil, fmt.Errorf("failed to parse template placeholders: %w", err)
+	}
+	// Merged field and radio group columns are expanded like /process data
+	lookup, _ := processDataLookup(template, placeholders, parseFieldDefinitions(template.FieldDefinitions))
+
+	columns, mapping, err := mapBatchColumns(table[0], lookup)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkFilenamePattern(opts.FilenamePattern, lookup); err != nil {
+		return nil, err
+	}
+
+	var rows []batchRow
+	for i, cells := range table[1:] {
+		row := batchRow{number: i + 2, data: make(map[string]string, len(columns))}
+		empty := true
+		for column, placeholder := range columns {
+			if column < len(cells) {
+				value := strings.TrimSpace(cells[column])
+				row.data[placeholder] = value
+				if value != "" {
+					empty = false
+				}
+			}
+		}
+		if !empty {
+			rows = append(rows, row)
+		}
+	}
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("%w: the spreadsheet has no data rows", ErrInvalidBatch)
+	}
+	if len(rows) > maxBatchRows {
+		return nil, fmt.Errorf("%w: %d rows exceed the limit of %d per batch", ErrInvalidBatch, len(rows), maxBatchRows)
+	}
+
+	mappingJSON, err := json.Marshal(mapping)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal column mapping: %w", err)
+	}
+
+	job := &models.BatchJob{
+		ID:              uuid.New().String(),
+		TemplateID:      template.ID,
+		UserID:          userID,
+		Status:          models.BatchStatusQueued,
+		Output:          opts.Output,
+		Format:          opts.Format,
+		FilenamePattern: opts.FilenamePattern,
+		SourceFilename:  header.Filename,
+		TotalRows:       len(rows),
+		ColumnMap:       string(mappingJSON),
+		RowErrors:       "[]",
+		CreatedAt:       time.Now(),
+	}
+	if err := internal.DB.Create(job).Error; err != nil {
+		return nil, fmt.Errorf("failed to create batch job: %w", err)
+	}
+
+	// The job is updated by the worker, so it gets its own copy
+	running := *job
+	select {
+	case s.runs <- &batchRun{job: &running, template: template, rows: rows, lookup: lookup}:
+	default:
+		s.finish(&running, nil, ErrBatchQueueFull)
+		return nil, ErrBatchQueueFull
+	}
+
+	return job, nil
+}
+
+func (s *BatchService) work() {
+	defer s.wg.Done()
+	for {
+		select {
+		case <-s.ctx.Done():
+			return
+		case run := <-s.runs:
+			s.runSafely(run)
+		}
+	}
+}
+
+// runSafely runs a job, failing it instead of the server when bundling or storing panics
+func (s *BatchService) runSafely(run *batchRun) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("Batch job %s panicked: %v", run.job.ID, r)
+			s.finish(run.job, nil, fmt.Errorf("internal error: %v", r))
+		}
+	}()
+	s.run(s.ctx, run.job, run.template, run.rows, run.lookup)
+}
+
+// GetJob returns a batch job; userID must match the job owner when set
+func (s *BatchService) GetJob(jobID, userID string) (*models.BatchJob, error) {
+	var job models.BatchJob
+	if err := internal.DB.First(&job, "id = ?", jobID).Error; err != nil {
+		return nil, fmt.Errorf("batch job not found: %w", err)
+	}
+	if userID != "" && job.UserID != userID {
+		return nil, fmt.Errorf("unauthorized: you don't have access to this batch job")
+	}
+	return &job, nil
+}
+
+// GetResultReader opens the ZIP or merged PDF of a completed job
+// Returns the reader, download file name and MIME type
+func (s *BatchService) GetResultReader(ctx context.Context, jobID, userID string) (io.ReadCloser, string, string, error) {
+	job, err := s.GetJob(jobID, userID)
+	if err != nil {
+		return nil, "", "", err
+	}
+	if job.Status != models.BatchStatusCompleted || job.GCSPathResult == "" {
+		return nil, "", "", fmt.Errorf("%w: the batch job has no result (status: %s)", ErrInvalidBatch, job.Status)
+	}
+
+	reader, err := s.storageClient.ReadFile(ctx, job.GCSPathResult)
+	if err != nil {
+		return nil, "", "", fmt.Errorf("failed to read batch result: %w", err)
+	}
+
+	name := strings.TrimSuffix(job.SourceFilename, filepath.Ext(job.SourceFilename))
+	if job.Output == models.BatchOutputPDF {
+		return reader, name + ".pdf", "application/pdf", nil
+	}
+	return reader, name + ".zip", "application/zip", nil
+}
+
+// FailInterruptedJobs marks jobs left queued or processing by a previous server run as failed
+// Rows are kept in memory only, so these jobs cannot be resumed
+func (s *BatchService) FailInterruptedJobs() {
+	result := internal.DB.Model(&models.BatchJob{}).
+		Where("status IN ?", []string{models.BatchStatusQueued, models.BatchStatusProcessing}).
+		Updates(map[string]interface{}{
+			"status":      models.BatchStatusFailed,
+			"error":       "interrupted by a server restart",
+			"finished_at": time.Now(),
+		})
+	if result.Error != nil {
+		log.Printf("Failed to mark interrupted batch jobs: %v", result.Error)
+	} else if result.RowsAffected > 0 {
+		log.Printf("Marked %d interrupted batch jobs as failed", result.RowsAffected)
+	}
+}
+
+// run generates every row, then bundles the files and stores the result
+func (s *BatchService) run(ctx context.Context, job *models.BatchJob, template *models.Template, rows []batchRow, lookup map[string]string) {
+	startedAt := time.Now()
+	job.StartedAt = &startedAt
+	job.Status = models.BatchStatusProcessing
+	internal.DB.Model(job).Updates(map[string]interface{}{"status": job.Status, "started_at": startedAt})
+
+	workDir, err := os.MkdirTemp("", "batch_"+job.ID)
+	if err != nil {
+		s.finish(job, nil, fmt.Errorf("failed to create work directory: %w", err))
+		return
+	}
+	defer os.RemoveAll(workDir)
+
+	type generated struct {
+		path string
+		name string
+	}
+	var files []generated
+	var rowErrors []models.BatchRowError
+	usedNames := make(map[string]bool)
+	defaultName := strings.TrimSuffix(template.Filename, filepath.Ext(template.Filename))
+
+	for _, row := range rows {
+		if ctx.Err() != nil {
+			s.finish(job, nil, fmt.Errorf("interrupted by a server shutdown"))
+			return
+		}
+
+		path, err := s.generateRow(ctx, job, template, row, workDir)
+		if err != nil {
+			rowErrors = append(rowErrors, models.BatchRowError{Row: row.number, Error: err.Error()})
+			job.FailedRows++
+		} else {
+			name := uniqueBatchFilename(batchFilename(job.FilenamePattern, row, lookup, defaultName), usedNames)
+			files = append(files, generated{path: path, name: name + "." + job.Format})
+			job.SucceededRows++
+		}
+		job.ProcessedRows++
+
+		rowErrorsJSON, _ := json.Marshal(rowErrors)
+		job.RowErrors = string(rowErrorsJSON)
+		internal.DB.Model(job).Updates(map[string]interface{}{
+			"processed_rows": job.ProcessedRows,
+			"succeeded_rows": job.SucceededRows,
+			"failed_rows":    job.FailedRows,
+			"row_errors":     job.RowErrors,
+		})
+	}
+
+	if len(files) == 0 {
+		s.finish(job, nil, fmt.Errorf("no rows could be generated"))
+		return
+	}
+
+	resultPath := filepath.Join(workDir, "result."+job.Output)
+	if job.Output == models.BatchOutputPDF {
+		paths := make([]string, len(files))
+		for i, file := range files {
+			paths[i] = file.path
+		}
+		if len(paths) == 1 {
+			resultPath = paths[0]
+		} else if err := s.documentService.pdfService.MergePDFsToFile(ctx, paths, resultPath); err != nil {
+			s.finish(job, nil, fmt.Errorf("failed to merge PDFs: %w", err))
+			return
+		}
+	} else {
+		entries := make(map[string]string, len(files))
+		names := make([]string, 0, len(files))
+		for _, file := range files {
+			entries[file.name] = file.path
+			names = append(names, file.name)
+		}
+		if err := writeBatchZip(resultPath, names, entries); err != nil {
+			s.finish(job, nil, err)
+			return
+		}
+	}
+
+	s.finish(job, &resultPath, nil)
+}
+
+// uniqueBatchFilename returns name, or name_N when it is taken, and marks the result as used
+// Names are compared case-insensitively; a suffixed name can also be taken by a literal one
+func uniqueBatchFilename(name string, used map[string]bool) string {
+	unique := name
+	for n := 2; used[strings.ToLower(unique)]; n++ {
+		unique = fmt.Sprintf("%s_%d", name, n)
+	}
+	used[strings.ToLower(unique)] = true
+	return unique
+}
+
+// generateRow processes one row and downloads the output file into workDir
+// The document stays in the user's history; its stored files are removed once copied
+// A panic while generating the row is reported as the row's error
+func (s *BatchService) generateRow(ctx context.Context, job *models.BatchJob, template *models.Template, row batchRow, workDir string) (path string, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("Batch job %s panicked on row %d: %v", job.ID, row.number, r)
+			path, err = "", fmt.Errorf("internal error: %v", r)
+		}
+	}()
+
+	// Same pipeline as /process; columns that are not in the file are left blank or get their default
+	rowData := make(map[string]interface{}, len(row.data))
+	for placeholder, value := range row.data {
+		rowData[placeholder] = value
+	}
+	prepared, err := PrepareProcessData(template, rowData, ProcessingModeLenient, models.LanguageEnglish, time.Now())
+	if err != nil {
+		return "", err
+	}
+	if len(prepared.Missing) > 0 {
+		return "", fmt.Errorf("missing required values: %s", strings.Join(prepared.Missing, ", "))
+	}
+	if len(prepared.FieldErrors) > 0 {
+		return "", errors.New(FieldErrorsSummary(prepared.FieldErrors))
+	}
+
+	document, err := s.documentService.ProcessDocument(ctx, template.ID, prepared.Data, job.UserID)
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		if err := s.documentService.DeleteProcessedFiles(ctx, document.ID); err != nil {
+			fmt.Printf("[WARNING] Failed to clean up batch document %s: %v\n", document.ID, err)
+		}
+	}()
+
+	reader, _, _, err := s.documentService.getDocumentReaderFromDocument(ctx, document, job.Format)
+	if err != nil {
+		return "", err
+	}
+	defer reader.Close()
+
+	path = filepath.Join(workDir, fmt.Sprintf("%06d.%s", row.number, job.Format))
+	output, err := os.Create(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to create output file: %w", err)
+	}
+	defer output.Close()
+	if _, err := io.Copy(output, reader); err != nil {
+		return "", fmt.Errorf("failed to download generated document: %w", err)
+	}
+	return path, nil
+}
+
+// finish uploads the result (when there is one) and records the final job status
+func (s *BatchService) finish(job *models.BatchJob, resultPath *string, jobErr error) {
+	if jobErr == nil && resultPath != nil {
+		jobErr = func() error {
+			file, err := os.Open(*resultPath)
+			if err != nil {
+				return fmt.Errorf("failed to open batch result: %w", err)
+			}
+			defer file.Close()
+
+			name := strings.TrimSuffix(job.SourceFilename, filepath.Ext(job.SourceFilename)) + "." + job.Output
+			contentType := "application/zip"
+			if job.Output == models.BatchOutputPDF {
+				contentType = "application/pdf"
+			}
+			objectName := storage.GenerateBatchObjectName(job.ID, name)
+			result, err := s.storageClient.UploadFile(context.Background(), file, objectName, contentType)
+			if err != nil {
+				return fmt.Errorf("failed to upload batch result: %w", err)
+			}
+			job.GCSPathResult = objectName
+			job.ResultSize = result.Size
+			return nil
+		}()
+	}
+
+	finishedAt := time.Now()
+	job.FinishedAt = &finishedAt
+	job.Status = models.BatchStatusCompleted
+	if jobErr != nil {
+		job.Status = models.BatchStatusFailed
+		job.Error = jobErr.Error()
+	}
+	if err := internal.DB.Save(job).Error; err != nil {
+		log.Printf("Failed to save batch job %s: %v", job.ID, err)
+		return
+	}
+	log.Printf("Batch job %s %s: %d/%d rows generated", job.ID, job.Status, job.SucceededRows, job.TotalRows)
+}
+
+// batchPlaceholderLookup maps lower-cased keys and aliases to placeholders ("{{key}}")
+func batchPlaceholderLookup(template *models.Template, placeholders []string) map[string]string {
+	lookup := make(map[string]string, len(placeholders)*2)
+	for _, placeholder := range placeholders {
+		lookup[strings.ToLower(placeholderKey(placeholder))] = placeholder
+	}
+
+	var aliases map[string]string
+	if template.Aliases != "" {
+		json.Unmarshal([]byte(template.Aliases), &aliases)
+	}
+	for key, alias := range aliases {
+		placeholder := "{{" + placeholderKey(key) + "}}"
+		alias = strings.ToLower(strings.TrimSpace(alias))
+		if _, ok := lookup[strings.ToLower(placeholderKey(key))]; !ok || alias == "" {
+			continue
+		}
+		// Keys take precedence over aliases
+		if _, taken := lookup[alias]; !taken {
+			lookup[alias] = placeholder
+		}
+	}
+	return lookup
+}
+
+// mapBatchColumns maps header cells to placeholders
+// Returns column index -> placeholder and header -> placeholder (for the job report)
+func mapBatchColumns(header []string, lookup map[string]string) (map[int]string, map[string]string, error) {
+	columns := make(map[int]string)
+	mapping := make(map[string]string)
+	mappedBy := make(map[string]string)
+	for i, cell := range header {
+		name := strings.TrimSpace(cell)
+		placeholder, ok := lookup[strings.ToLower(placeholderKey(name))]
+		if name == "" || !ok {
+			continue
+		}
+		if previous, duplicate := mappedBy[placeholder]; duplicate {
+			return nil, nil, fmt.Errorf("%w: columns '%s' and '%s' both map to %s", ErrInvalidBatch, previous, name, placeholder)
+		}
+		mappedBy[placeholder] = name
+		columns[i] = placeholder
+		mapping[name] = placeholder
+	}
+
+	if len(columns) == 0 {
+		available := make([]string, 0, len(lookup))
+		for key := range lookup {
+			available = append(available, key)
+		}
+		sort.Strings(available)
+		return nil, nil, fmt.Errorf("%w: no column matches a placeholder or alias (available: %s)", ErrInvalidBatch, strings.Join(available, ", "))
+	}
+	return columns, mapping, nil
+}
+
+// checkFilenamePattern verifies that every {{token}} in the pattern is a placeholder, an alias or "row"
+func checkFilenamePattern(pattern string, lookup map[string]string) error {
+	for _, match := range batchPatternToken.FindAllStringSubmatch(pattern, -1) {
+		token := strings.ToLower(match[1])
+		if _, ok := lookup[token]; !ok && token != "row" {
+			return fmt.Errorf("%w: filename pattern refers to unknown field '%s'", ErrInvalidBatch, match[1])
+		}
+	}
+	return nil
+}
+
+// batchFilename renders the filename pattern for a row (without extension)
+// Falls back to "<template>_<row>" when there is no pattern or it renders empty
+func batchFilename(pattern string, row batchRow, lookup map[string]string, defaultName string) string {
+	name := batchPatternToken.ReplaceAllStringFunc(pattern, func(match string) string {
+		token := strings.ToLower(batchPatternToken.FindStringSubmatch(match)[1])
+		if placeholder, ok := lookup[token]; ok {
+			return row.data[placeholder]
+		}
+		if token == "row" {
+			return fmt.Sprintf("%d", row.number)
+		}
+		return ""
+	})
+
+	name = strings.Map(func(r rune) rune {
+		if r < 0x20 || strings.ContainsRune(`/\:*?"<>|`, r) {
+			return '_'
+		}
+		return r
+	}, name)
+	name = strings.Trim(strings.TrimSpace(name), ".")
+	if runes := []rune(name); len(runes) > 150 {
+		name = string(runes[:150])
+	}
+	if name == "" {
+		name = fmt.Sprintf("%s_%d", defaultName, row.number)
+	}
+	return name
+}
+
+// writeBatchZip writes the generated files into a ZIP archive, in row order
+func writeBatchZip(zipPath string, names []string, entries map[string]string) error {
+	output, err := os.Create(zipPath)
+	if err != nil {
+		return fmt.Errorf("failed to create ZIP: %w", err)
+	}
+	defer output.Close()
+
+	archive := zip.NewWriter(output)
+	for _, name := range names {
+		writer, err := archive.Create(name)
+		if err != nil {
+			return fmt.Errorf("failed to add %s to ZIP: %w", name, err)
+		}
+		file, err := os.Open(entries[name])
+		if err != nil {
+			return fmt.Errorf("failed to open %s: %w", name, err)
+		}
+		_, err = io.Copy(writer, file)
+		file.Close()
+		if err != nil {
+			return fmt.Errorf("failed to write %s to ZIP: %w", name, err)
+		}
+	}
+	if err := archive.Close(); err != nil {
+		return fmt.Errorf("failed to finish ZIP: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,276 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"DF-PLCH/internal/models"
+	"DF-PLCH/internal/utils"
+)
+
+// ExpressionError is why a computed field's expression was rejected
+type ExpressionError struct {
+	Field      string `json:"field"` // Key of the computed field
+	Expression string `json:"expression"`
+	Error      string `json:"error"`
+}
+
+// ExpressionErrors is returned when saving field definitions with invalid expressions
+// It matches utils.ErrInvalidExpression with errors.Is
+type ExpressionErrors []ExpressionError
+
+func (e ExpressionErrors) Error() string {
+	parts := make([]string, 0, len(e))
+	for _, expressionErr := range e {
+		parts = append(parts, fmt.Sprintf("%s: %s", expressionErr.Field, expressionErr.Error))
+	}
+	return fmt.Sprintf("%v: %s", utils.ErrInvalidExpression, strings.Join(parts, "; "))
+}
+
+func (e ExpressionErrors) Unwrap() error {
+	return utils.ErrInvalidExpression
+}
+
+// CheckFieldExpressions checks the expressions of computed fields: syntax, that they only read
+// placeholders of the template, and that computed fields do not depend on each other in a cycle
+func CheckFieldExpressions(placeholders []string, definitions map[string]utils.FieldDefinition) []ExpressionError {
+	_, expressionErrors := computeOrder(placeholders, definitions)
+	return expressionErrors
+}
+
+// computedField is a parsed computed field
+type computedField struct {
+	key        string
+	expression *utils.Expression
+}
+
+// computeOrder parses the template's computed fields and orders them so every field comes after
+// the computed fields it reads. Fields with errors are left out
+func computeOrder(placeholders []string, definitions map[string]utils.FieldDefinition) ([]computedField, []ExpressionError) {
+	keys := make(map[string]bool, len(placeholders))
+	for _, placeholder := range placeholders {
+		keys[placeholderKey(placeholder)] = true
+	}
+
+	var expressionErrors []ExpressionError
+	fail := func(key, format string, args ...interface{}) {
+		expressionErrors = append(expressionErrors, ExpressionError{Field: key, Expression: definitions[key].Expression, Error: fmt.Sprintf(format, args...)})
+	}
+
+	// Parse, sorted so errors and evaluation order are stable
+	var computedKeys []string
+	for key, def := range definitions {
+		if strings.TrimSpace(def.Expression) != "" {
+			computedKeys = append(computedKeys, key)
+		}
+	}
+	sort.Strings(computedKeys)
+
+	parsed := make(map[string]*utils.Expression, len(computedKeys))
+	for _, key := range computedKeys {
+		def := definitions[key]
+		if !keys[key] {
+			fail(key, "computed field '%s' is not a placeholder of the template", key)
+			continue
+		}
+		if def.IsMerged || def.IsRadioGroup {
+			fail(key, "merged fields and radio groups cannot be computed")
+			continue
+		}
+		expression, err := utils.ParseExpression(def.Expression)
+		if err != nil {
+			fail(key, "%v", err)
+			continue
+		}
+		var unknown []string
+		for _, field := range expression.Fields() {
+			if !keys[field] {
+				unknown = append(unknown, field)
+			}
+		}
+		if len(unknown) > 0 {
+			fail(key, "unknown fields: %s", strings.Join(unknown, ", "))
+			continue
+		}
+		parsed[key] = expression
+	}
+
+	// Depth-first topological sort over references to other computed fields
+	const (
+		visiting = 1
+		done     = 2
+	)
+	state := make(map[string]int, len(parsed))
+	reported := make(map[string]bool)
+	var order []computedField
+	var visit func(key string, path []string) bool
+	visit = func(key string, path []string) bool {
+		switch state[key] {
+		case visiting:
+			cycle := append(path, key)
+			for i, member := range cycle {
+				if member == key {
+					cycle = cycle[i:]
+					break
+				}
+			}
+			for _, member := range cycle[:len(cycle)-1] {
+				fail(member, "computed fields depend on each other: %s", strings.Join(cycle, " -> "))
+				reported[member] = true
+			}
+			return false
+		case done:
+			return true
+		}
+		state[key] = visiting
+		for _, field := range parsed[key].Fields() {
+			if _, computed := parsed[field]; computed && !visit(field, append(path, key)) {
+				return false
+			}
+		}
+		state[key] = done
+		order = append(order, computedField{key: key, expression: parsed[key]})
+		return true
+	}
+	for _, key := range computedKeys {
+		if _, ok := parsed[key]; ok && state[key] == 0 {
+			visit(key, nil)
+		}
+	}
+	for _, key := range computedKeys {
+		if _, ok := parsed[key]; ok && state[key] != done && !reported[key] {
+			fail(key, "reads a computed field that depends on itself")
+		}
+	}
+
+	return order, expressionErrors
+}
+
+// ComputeFieldValues fills computed fields from the other values (keyed by "{{placeholder}}")
+//
+// Computed fields are evaluated in dependency order at time now. A field that cannot be
+// computed (for example from a value that is not a date) is left blank with a warning, and so
+// are computed fields hidden by an unselected radio option. Values sent for computed fields are
+// replaced. The data is not modified.
+func ComputeFieldValues(template *models.Template, data map[string]string, now time.Time) (map[string]string, []string) {
+	definitions := parseFieldDefinitions(template.FieldDefinitions)
+	var placeholders []string
+	json.Unmarshal([]byte(template.Placeholders), &placeholders)
+
+	order, expressionErrors := computeOrder(placeholders, definitions)
+	if len(order) == 0 && len(expressionErrors) == 0 {
+		return data, nil
+	}
+
+	computed := make(map[string]string, len(data))
+	values := make(map[string]string, len(data))
+	for placeholder, value := range data {
+		computed[placeholder] = value
+		values[placeholderKey(placeholder)] = value
+	}
+
+	var warnings []string
+	for _, expressionErr := range expressionErrors {
+		computed["{{"+expressionErr.Field+"}}"] = ""
+		values[expressionErr.Field] = ""
+		warnings = append(warnings, fmt.Sprintf("{{%s}} was not computed: %s", expressionErr.Field, expressionErr.Error))
+	}
+
+	hidden := hiddenChildFields(definitions, data)
+	for _, field := range order {
+		placeholder := "{{" + field.key + "}}"
+		if strings.TrimSpace(data[placeholder]) != "" {
+			warnings = append(warnings, fmt.Sprintf("%s is computed, the sent value was replaced", placeholder))
+		}
+
+		value := ""
+		if !hidden[field.key] {
+			result, err := field.expression.Evaluate(values, now)
+			if err != nil {
+				warnings = append(warnings, fmt.Sprintf("%s was not computed: %v", placeholder, err))
+			}
+			value = result
+		}
+		computed[placeholder] = value
+		values[field.key] = value
+	}
+
+	return computed, warnings
+}
+
+// isComputedField reports whether a placeholder's value is computed rather than sent
+func isComputedField(definitions map[string]utils.FieldDefinition, key string) bool {
+	return strings.TrimSpace(definitions[key].Expression) != ""
+}
+
+// ExpressionCheck is the result of checking an expression against a template
+type ExpressionCheck struct {
+	Valid     bool              `json:"valid"`
+	Error     string            `json:"error,omitempty"`
+	Fields    []string          `json:"fields,omitempty"`     // Fields the expression reads
+	Result    string            `json:"result,omitempty"`     // Value computed from the sample data
+	EvalError string            `json:"eval_error,omitempty"` // Why the sample data could not be computed
+	Functions map[string]string `json:"functions,omitempty"`  // Available functions, when the expression does not parse
+}
+
+// CheckExpression checks an expression for a template and evaluates it with sample data
+// Data may be keyed with or without braces; other fields get sample values as in previews, and
+// computed fields are computed. field is the key of the field being edited, if any, so that an
+// expression reading its own field is reported
+func CheckExpression(template *models.Template, expression, field string, data map[string]string, now time.Time) *ExpressionCheck {
+	definitions := parseFieldDefinitions(template.FieldDefinitions)
+	var placeholders []string
+	json.Unmarshal([]byte(template.Placeholders), &placeholders)
+
+	parsed, err := utils.ParseExpression(expression)
+	if err != nil {
+		return &ExpressionCheck{Error: err.Error(), Functions: utils.ExpressionFunctions()}
+	}
+	check := &ExpressionCheck{Valid: true, Fields: parsed.Fields()}
+
+	// Check the expression in place of the edited field's, which catches cycles
+	field = placeholderKey(field)
+	if field != "" {
+		def := definitions[field]
+		def.Expression = expression
+		definitions[field] = def
+		for _, expressionErr := range CheckFieldExpressions(placeholders, definitions) {
+			if expressionErr.Field == field {
+				check.Valid = false
+				check.Error = expressionErr.Error
+				return check
+			}
+		}
+	} else {
+		keys := make(map[string]bool, len(placeholders))
+		for _, placeholder := range placeholders {
+			keys[placeholderKey(placeholder)] = true
+		}
+		var unknown []string
+		for _, name := range parsed.Fields() {
+			if !keys[name] {
+				unknown = append(unknown, name)
+			}
+		}
+		if len(unknown) > 0 {
+			check.Valid = false
+			check.Error = "unknown fields: " + strings.Join(unknown, ", ")
+			return check
+		}
+	}
+
+	sample, _ := previewValues(template, data, now)
+	values := make(map[string]string, len(sample))
+	for placeholder, value := range sample {
+		values[placeholderKey(placeholder)] = value
+	}
+	result, err := parsed.Evaluate(values, now)
+	if err != nil {
+		check.EvalError = err.Error()
+	}
+	check.Result = result
+	return check
+}
@@ -7,6 +7,7 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"DF-PLCH/internal"
 	"DF-PLCH/internal/models"
@@ -14,6 +15,7 @@ import (
 	"DF-PLCH/internal/storage"
 
 	"github.com/google/uuid"
+	"gorm.io/gorm"
 )
 
 type DocumentService struct {
@@ -47,7 +49,60 @@ func (s *DocumentService) SetLibreOfficeConfig(enabled bool, path string) {
 	}
 }
 
+// ProcessOptions are per-request processing options
+type ProcessOptions struct {
+	Flatten        bool   // Flatten PDF form fields into the page content (PDF form templates only)
+	DocumentID     string // Queued document to complete instead of creating a new one (async jobs)
+	OrganizationID string // Organization the document is filed under (reported in webhook events)
+	ParentID       string // Document the new document is a revision of (see linkRevision)
+}
+
+// newDocumentID returns the ID of the document being processed
+func (opts ProcessOptions) newDocumentID() string {
+	if opts.DocumentID != "" {
+		return opts.DocumentID
+	}
+	return uuid.New().String()
+}
+
+// saveProcessedDocument inserts a processed document, or fills in the queued document of an async job
+func saveProcessedDocument(document *models.Document, opts ProcessOptions) error {
+	if opts.DocumentID == "" {
+		if opts.ParentID == "" {
+			return internal.DB.Create(document).Error
+		}
+		return internal.DB.Transaction(func(tx *gorm.DB) error {
+			if err := linkRevision(tx, document, opts.ParentID); err != nil {
+				return err
+			}
+			return tx.Create(document).Error
+		})
+	}
+	result := internal.DB.Model(&models.Document{}).Where("id = ?", document.ID).Updates(map[string]interface{}{
+		"filename":      document.Filename,
+		"gcs_path_docx": document.GCSPathDocx,
+		"gcs_path_pdf":  document.GCSPathPdf,
+		"file_size":     document.FileSize,
+		"mime_type":     document.MimeType,
+		"data":          document.Data,
+		"status":        document.Status,
+		"flattened":     document.Flattened,
+	})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("queued document %s not found", document.ID)
+	}
+	return nil
+}
+
 func (s *DocumentService) ProcessDocument(ctx context.Context, templateID string, data map[string]string, userID string) (*models.Document, error) {
+	return s.ProcessDocumentWithOptions(ctx, templateID, data, userID, ProcessOptions{})
+}
+
+// ProcessDocumentWithOptions fills a template like ProcessDocument with processing options
+func (s *DocumentService) ProcessDocumentWithOptions(ctx context.Context, templateID string, data map[string]string, userID string, opts ProcessOptions) (*models.Document, error) {
 	fmt.Printf("[DEBUG] Starting ProcessDocument for template %s\n", templateID)
 
 	// Get template
@@ -76,8 +131,17 @@ func (s *DocumentService) ProcessDocument(ctx context.Context, templateID string
 	defer s.cleanupTempFile(tempInputFile)
 	fmt.Printf("[DEBUG] Temp input file created: %s\n", tempInputFile)
 
+	// Fillable PDF templates are filled field by field instead of being processed as DOCX
+	if template.IsPDFForm() {
+		return s.processPDFForm(ctx, template, tempInputFile, data, userID, opts)
+	}
+	// Overlay templates are drawn on their background PDF
+	if template.IsOverlay() {
+		return s.processOverlay(ctx, template, tempInputFile, data, userID, opts)
+	}
+
 	// Create temp output file
-	documentID := uuid.New().String()
+	documentID := opts.newDocumentID()
 	tempOutputFile := filepath.Join(os.TempDir(), documentID+".docx")
 	fmt.Printf("[DEBUG] Created document ID: %s, temp output file: %s\n", documentID, tempOutputFile)
 
@@ -269,7 +333,7 @@ func (s *DocumentService) ProcessDocument(ctx context.Context, templateID string
 		Status:      "completed",
 	}
 
-	if err := internal.DB.Create(document).Error; err != nil {
+	if err := saveProcessedDocument(document, opts); err != nil {
 		s.storageClient.DeleteFile(ctx, objectName)
 		if pdfObjectName != "" {
 			s.storageClient.DeleteFile(ctx, pdfObjectName)
@@ -353,13 +417,18 @@ func (s *DocumentService) GetDocumentReaderWithAuth(ctx context.Context, documen
 func (s *DocumentService) getDocumentReaderFromDocument(ctx context.Context, document *models.Document, format string) (io.ReadCloser, string, string, error) {
 	var gcsPath, filename, mimeType string
 
+	// PDF form documents only have a PDF version
+	if document.MimeType == models.MimeTypePDF {
+		format = "pdf"
+	}
+
 	switch format {
 	case "pdf":
 		if document.GCSPathPdf == "" {
 			return nil, "", "", fmt.Errorf("PDF version not available")
 		}
 		gcsPath = document.GCSPathPdf
-		filename = document.Filename[:len(document.Filename)-5] + ".pdf" // Remove .docx and add .pdf
+		filename = strings.TrimSuffix(document.Filename, filepath.Ext(document.Filename)) + ".pdf"
 		mimeType = "application/pdf"
 	case "docx":
 		fallthrough
@@ -492,6 +561,11 @@ func (s *DocumentService) RegenerateDocument(ctx context.Context, documentID str
 		return nil, fmt.Errorf("unauthorized: you don't have access to this document")
 	}
 
+	// Async documents are completed by the queue
+	if document.Status == models.DocumentStatusQueued || document.Status == models.DocumentStatusProcessing {
+		return nil, ErrDocumentPending
+	}
+
 	// Parse the stored data
 	var data map[string]string
 	if err := json.Unmarshal([]byte(document.Data), &data); err != nil {
@@ -518,6 +592,13 @@ func (s *DocumentService) RegenerateDocument(ctx context.Context, documentID str
 	}
 	defer s.cleanupTempFile(tempInputFile)
 
+	if template.IsPDFForm() {
+		return s.regeneratePDFForm(ctx, document, template, tempInputFile, data)
+	}
+	if template.IsOverlay() {
+		return s.regenerateOverlay(ctx, document, template, tempInputFile, data)
+	}
+
 	// Create temp output file (reuse same document ID)
 	tempOutputFile := filepath.Join(os.TempDir(), documentID+"_regen.docx")
 
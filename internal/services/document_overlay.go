@@ -0,0 +1,201 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"DF-PLCH/internal"
+	"DF-PLCH/internal/models"
+	"DF-PLCH/internal/processor"
+	"DF-PLCH/internal/storage"
+	"DF-PLCH/internal/utils"
+)
+
+// SetOverlayFontPath sets the TrueType font embedded in overlay documents
+// An empty path uses the font compiled into the binary (internal/processor/fonts)
+func (s *DocumentService) SetOverlayFontPath(path string) {
+	processor.SetOverlayFontPath(path)
+	if _, err := processor.OverlayFont(); err != nil {
+		fmt.Printf("[WARNING] Overlay templates cannot render text: %v\n", err)
+		return
+	}
+	fmt.Printf("[INFO] Overlay font ready\n")
+}
+
+// processOverlay draws the data on an overlay template's background and stores the result as
+// the document's PDF. Overlay documents have no DOCX version (GCSPathDocx stays empty)
+func (s *DocumentService) processOverlay(ctx context.Context, template *models.Template, templatePath string, data map[string]string, userID string, opts ProcessOptions) (*models.Document, error) {
+	var placeholders []string
+	if err := json.Unmarshal([]byte(template.Placeholders), &placeholders); err != nil {
+		return nil, fmt.Errorf("failed to parse template placeholders: %w", err)
+	}
+
+	// Only template fields are kept, missing ones are filled with empty strings
+	completeData := make(map[string]string, len(placeholders))
+	for _, placeholder := range placeholders {
+		completeData[placeholder] = data[placeholder]
+	}
+
+	documentID := opts.newDocumentID()
+	tempOutputFile := filepath.Join(os.TempDir(), documentID+"_overlay.pdf")
+	defer os.Remove(tempOutputFile)
+
+	warnings, err := renderOverlay(template, templatePath, tempOutputFile, completeData)
+	if err != nil {
+		return nil, err
+	}
+
+	outputFile, err := os.Open(tempOutputFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open output file: %w", err)
+	}
+	defer outputFile.Close()
+
+	objectName := storage.GenerateDocumentObjectName(documentID, template.Filename)
+	result, err := s.storageClient.UploadFile(ctx, outputFile, objectName, models.MimeTypePDF)
+	if err != nil {
+		return nil, fmt.Errorf("failed to upload processed document to GCS: %w", err)
+	}
+
+	dataJSON, err := json.Marshal(completeData)
+	if err != nil {
+		s.storageClient.DeleteFile(ctx, objectName)
+		return nil, fmt.Errorf("failed to marshal data: %w", err)
+	}
+
+	document := &models.Document{
+		ID:         documentID,
+		TemplateID: template.ID,
+		UserID:     userID,
+		Filename:   template.Filename,
+		GCSPathPdf: objectName,
+		FileSize:   result.Size,
+		MimeType:   models.MimeTypePDF,
+		Data:       string(dataJSON),
+		Status:     "completed",
+	}
+
+	if err := saveProcessedDocument(document, opts); err != nil {
+		s.storageClient.DeleteFile(ctx, objectName)
+		return nil, fmt.Errorf("failed to save document metadata: %w", err)
+	}
+
+	document.Warnings = warnings
+	return document, nil
+}
+
+// regenerateOverlay redraws an overlay document from its stored data with the current layout
+func (s *DocumentService) regenerateOverlay(ctx context.Context, document *models.Document, template *models.Template, templatePath string, data map[string]string) (*models.Document, error) {
+	tempOutputFile := filepath.Join(os.TempDir(), document.ID+"_regen.pdf")
+	defer os.Remove(tempOutputFile)
+
+	warnings, err := renderOverlay(template, templatePath, tempOutputFile, data)
+	if err != nil {
+		return nil, err
+	}
+
+	outputFile, err := os.Open(tempOutputFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open output file: %w", err)
+	}
+	defer outputFile.Close()
+
+	objectName := storage.GenerateDocumentObjectName(document.ID, template.Filename)
+	result, err := s.storageClient.UploadFile(ctx, outputFile, objectName, models.MimeTypePDF)
+	if err != nil {
+		return nil, fmt.Errorf("failed to upload regenerated document to GCS: %w", err)
+	}
+
+	updates := map[string]interface{}{
+		"status":        "completed",
+		"gcs_path_docx": "",
+		"gcs_path_pdf":  objectName,
+		"file_size":     result.Size,
+	}
+	if err := internal.DB.Model(document).Updates(updates).Error; err != nil {
+		return nil, fmt.Errorf("failed to update document: %w", err)
+	}
+
+	document, _ = s.GetDocument(document.ID)
+	if document != nil {
+		document.Warnings = warnings
+	}
+	return document, nil
+}
+
+// renderOverlay draws the positioned fields of an overlay template (data is keyed by
+// "{{placeholder}}") on its background PDF. The overlay font is only loaded when there is text
+func renderOverlay(template *models.Template, backgroundPath, outputPath string, data map[string]string) ([]string, error) {
+	values := make(map[string]string, len(data))
+	for placeholder, value := range data {
+		values[placeholderKey(placeholder)] = value
+	}
+
+	items := overlayItems(parseFieldDefinitions(template.FieldDefinitions), values)
+
+	var font *processor.TrueTypeFont
+	for _, item := range items {
+		if item.Kind == processor.OverlayKindText && item.Value != "" {
+			var err error
+			if font, err = processor.OverlayFont(); err != nil {
+				return nil, fmt.Errorf("failed to load overlay font: %w", err)
+			}
+			break
+		}
+	}
+
+	warnings, err := processor.RenderOverlayPDF(backgroundPath, outputPath, items, font)
+	if err != nil {
+		return nil, fmt.Errorf("failed to render overlay: %w", err)
+	}
+	for _, warning := range warnings {
+		fmt.Printf("[WARNING] Overlay render: %s\n", warning)
+	}
+	return warnings, nil
+}
+
+// overlayItems turns positioned field definitions into drawable items, in key order so the
+// output is stable. Checkbox fields without an explicit kind are drawn as marks
+func overlayItems(definitions map[string]utils.FieldDefinition, values map[string]string) []processor.OverlayItem {
+	keys := make([]string, 0, len(definitions))
+	for key, definition := range definitions {
+		if definition.Position != nil {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	items := make([]processor.OverlayItem, 0, len(keys))
+	for _, key := range keys {
+		definition := definitions[key]
+		position := definition.Position
+		kind := position.Kind
+		if kind == "" {
+			kind = processor.OverlayKindText
+			if definition.InputType == utils.InputTypeCheckbox {
+				kind = processor.OverlayKindCheck
+			}
+		}
+		items = append(items, processor.OverlayItem{
+			Key:       key,
+			Page:      position.Page,
+			X:         position.X,
+			Y:         position.Y,
+			Width:     position.Width,
+			Height:    position.Height,
+			FontSize:  position.FontSize,
+			Align:     position.Align,
+			Multiline: position.Multiline,
+			Comb:      position.Comb,
+			Kind:      kind,
+			Mark:      position.Mark,
+			Color:     position.Color,
+			Value:     values[key],
+		})
+	}
+	return items
+}
@@ -0,0 +1,158 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"DF-PLCH/internal"
+	"DF-PLCH/internal/models"
+	"DF-PLCH/internal/processor"
+	"DF-PLCH/internal/storage"
+)
+
+// processPDFForm fills a PDF form template and stores the result as the document's PDF
+// PDF form documents have no DOCX version (GCSPathDocx stays empty)
+func (s *DocumentService) processPDFForm(ctx context.Context, template *models.Template, templatePath string, data map[string]string, userID string, opts ProcessOptions) (*models.Document, error) {
+	var placeholders []string
+	if err := json.Unmarshal([]byte(template.Placeholders), &placeholders); err != nil {
+		return nil, fmt.Errorf("failed to parse template placeholders: %w", err)
+	}
+
+	// Only template fields are kept, missing ones are filled with empty strings
+	completeData := make(map[string]string, len(placeholders))
+	for _, placeholder := range placeholders {
+		completeData[placeholder] = data[placeholder]
+	}
+
+	documentID := opts.newDocumentID()
+	tempOutputFile := filepath.Join(os.TempDir(), documentID+"_form.pdf")
+	defer os.Remove(tempOutputFile)
+
+	flattened, warnings, err := s.fillPDFForm(ctx, templatePath, tempOutputFile, completeData, opts.Flatten)
+	if err != nil {
+		return nil, err
+	}
+
+	outputFile, err := os.Open(tempOutputFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open output file: %w", err)
+	}
+	defer outputFile.Close()
+
+	objectName := storage.GenerateDocumentObjectName(documentID, template.Filename)
+	result, err := s.storageClient.UploadFile(ctx, outputFile, objectName, models.MimeTypePDF)
+	if err != nil {
+		return nil, fmt.Errorf("failed to upload processed document to GCS: %w", err)
+	}
+
+	dataJSON, err := json.Marshal(completeData)
+	if err != nil {
+		s.storageClient.DeleteFile(ctx, objectName)
+		return nil, fmt.Errorf("failed to marshal data: %w", err)
+	}
+
+	document := &models.Document{
+		ID:         documentID,
+		TemplateID: template.ID,
+		UserID:     userID,
+		Filename:   template.Filename,
+		GCSPathPdf: objectName,
+		FileSize:   result.Size,
+		MimeType:   models.MimeTypePDF,
+		Data:       string(dataJSON),
+		Status:     "completed",
+		Flattened:  flattened,
+	}
+
+	if err := saveProcessedDocument(document, opts); err != nil {
+		s.storageClient.DeleteFile(ctx, objectName)
+		return nil, fmt.Errorf("failed to save document metadata: %w", err)
+	}
+
+	document.Warnings = warnings
+	return document, nil
+}
+
+// regeneratePDFForm refills a PDF form document from its stored data, flattening it again if
+// the original was flattened
+func (s *DocumentService) regeneratePDFForm(ctx context.Context, document *models.Document, template *models.Template, templatePath string, data map[string]string) (*models.Document, error) {
+	tempOutputFile := filepath.Join(os.TempDir(), document.ID+"_regen.pdf")
+	defer os.Remove(tempOutputFile)
+
+	flattened, warnings, err := s.fillPDFForm(ctx, templatePath, tempOutputFile, data, document.Flattened)
+	if err != nil {
+		return nil, err
+	}
+
+	outputFile, err := os.Open(tempOutputFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open output file: %w", err)
+	}
+	defer outputFile.Close()
+
+	objectName := storage.GenerateDocumentObjectName(document.ID, template.Filename)
+	result, err := s.storageClient.UploadFile(ctx, outputFile, objectName, models.MimeTypePDF)
+	if err != nil {
+		return nil, fmt.Errorf("failed to upload regenerated document to GCS: %w", err)
+	}
+
+	updates := map[string]interface{}{
+		"status":        "completed",
+		"gcs_path_docx": "",
+		"gcs_path_pdf":  objectName,
+		"file_size":     result.Size,
+		"flattened":     flattened,
+	}
+	if err := internal.DB.Model(document).Updates(updates).Error; err != nil {
+		return nil, fmt.Errorf("failed to update document: %w", err)
+	}
+
+	document, _ = s.GetDocument(document.ID)
+	if document != nil {
+		document.Warnings = warnings
+	}
+	return document, nil
+}
+
+// fillPDFForm fills the form fields (data is keyed by "{{field name}}") and optionally flattens
+// the result. Flattening needs Gotenberg; when it is unavailable or fails the filled form is kept
+// and a warning is returned instead
+func (s *DocumentService) fillPDFForm(ctx context.Context, templatePath, outputPath string, data map[string]string, flatten bool) (bool, []string, error) {
+	values := make(map[string]string, len(data))
+	for placeholder, value := range data {
+		values[placeholderKey(placeholder)] = value
+	}
+
+	filledPath := outputPath
+	if flatten {
+		filledPath = outputPath + ".filled.pdf"
+		defer os.Remove(filledPath)
+	}
+
+	warnings, err := processor.FillPDFForm(templatePath, filledPath, values)
+	if err != nil {
+		return false, nil, fmt.Errorf("failed to fill PDF form: %w", err)
+	}
+	for _, warning := range warnings {
+		fmt.Printf("[WARNING] PDF form fill: %s\n", warning)
+	}
+	if !flatten {
+		return false, warnings, nil
+	}
+
+	if s.pdfService != nil {
+		err = s.pdfService.FlattenPDFToFile(ctx, filledPath, outputPath)
+		if err == nil {
+			return true, warnings, nil
+		}
+		fmt.Printf("[ERROR] PDF flattening failed: %v\n", err)
+	}
+	warnings = append(warnings, "PDF could not be flattened, the form fields were kept editable")
+	if err := os.Rename(filledPath, outputPath); err != nil {
+		return false, nil, fmt.Errorf("failed to move filled PDF: %w", err)
+	}
+	return false, warnings, nil
+}
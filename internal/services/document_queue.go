@@ -0,0 +1,327 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"DF-PLCH/internal"
+	"DF-PLCH/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ErrDocumentPending is returned for documents whose async processing has not finished
+var ErrDocumentPending = errors.New("document is still being processed")
+
+// processedFilesTTL is how long processed files are kept before DeleteProcessedFiles runs
+const processedFilesTTL = 10 * time.Minute
+
+// DocumentQueue processes documents asynchronously with a bounded pool of workers
+// Jobs are persisted in document_jobs, so queued jobs and jobs interrupted by a restart are
+// picked up again when the server starts
+type DocumentQueue struct {
+	documentService *DocumentService
+	workers         int
+	pollInterval    time.Duration
+	maxAttempts     int
+	lockTimeout     time.Duration   // A processing job locked longer than this is considered abandoned
+	webhookService  *WebhookService // Optional: publishes document.completed and document.failed
+	wake            chan struct{}
+	ctx             context.Context
+	cancel          context.CancelFunc
+	wg              sync.WaitGroup
+}
+
+func NewDocumentQueue(documentService *DocumentService, workers int, pollInterval time.Duration, maxAttempts int) *DocumentQueue {
+	if workers < 1 {
+		workers = 1
+	}
+	if maxAttempts < 1 {
+		maxAttempts = 1
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	return &DocumentQueue{
+		documentService: documentService,
+		workers:         workers,
+		pollInterval:    pollInterval,
+		maxAttempts:     maxAttempts,
+		lockTimeout:     15 * time.Minute,
+		wake:            make(chan struct{}, 1),
+		ctx:             ctx,
+		cancel:          cancel,
+	}
+}
+
+// SetWebhookService publishes document.completed and document.failed events for queued documents
+func (q *DocumentQueue) SetWebhookService(webhookService *WebhookService) {
+	q.webhookService = webhookService
+}
+
+func (q *DocumentQueue) Start() {
+	for i := 0; i < q.workers; i++ {
+		q.wg.Add(1)
+		go q.work()
+	}
+	log.Printf("Document queue started (workers: %d, poll interval: %s, max attempts: %d)", q.workers, q.pollInterval, q.maxAttempts)
+}
+
+// Stop cancels in-flight jobs and waits for the workers to exit
+// Cancelled jobs are put back in the queue without counting the attempt
+func (q *DocumentQueue) Stop() {
+	q.cancel()
+	q.wg.Wait()
+	log.Println("Document queue stopped")
+}
+
+// Enqueue creates a queued document with the request data and its job, and returns the document
+// The data is stored as sent, so a failed document can still be regenerated from history
+func (q *DocumentQueue) Enqueue(template *models.Template, data map[string]string, userID string, opts ProcessOptions) (*models.Document, error) {
+	dataJSON, err := json.Marshal(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal data: %w", err)
+	}
+
+	now := time.Now()
+	document := &models.Document{
+		ID:         uuid.New().String(),
+		TemplateID: template.ID,
+		UserID:     userID,
+		Filename:   template.Filename,
+		Data:       string(dataJSON),
+		Status:     models.DocumentStatusQueued,
+	}
+	job := &models.DocumentJob{
+		DocumentID:     document.ID,
+		TemplateID:     template.ID,
+		UserID:         userID,
+		Status:         models.DocumentStatusQueued,
+		Flatten:        opts.Flatten,
+		OrganizationID: opts.OrganizationID,
+		AvailableAt:    now,
+	}
+
+	err = internal.DB.Transaction(func(tx *gorm.DB) error {
+		if opts.ParentID != "" {
+			if err := linkRevision(tx, document, opts.ParentID); err != nil {
+				return err
+			}
+		}
+		if err := tx.Create(document).Error; err != nil {
+			return err
+		}
+		return tx.Create(job).Error
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to queue document: %w", err)
+	}
+
+	// Wake an idle worker; if all are busy the job is picked up on the next poll
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+	return document, nil
+}
+
+// GetJob returns the queue entry of a document, or nil for documents processed synchronously
+func (q *DocumentQueue) GetJob(documentID string) (*models.DocumentJob, error) {
+	var job models.DocumentJob
+	if err := internal.DB.First(&job, "document_id = ?", documentID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get document job: %w", err)
+	}
+	return &job, nil
+}
+
+func (q *DocumentQueue) work() {
+	defer q.wg.Done()
+	ticker := time.NewTicker(q.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		// Drain the queue before waiting again
+		for q.ctx.Err() == nil {
+			job, err := q.claim()
+			if err != nil {
+				log.Printf("Error claiming document job: %v", err)
+				break
+			}
+			if job == nil {
+				break
+			}
+			q.process(job)
+		}
+
+		select {
+		case <-q.ctx.Done():
+			return
+		case <-q.wake:
+		case <-ticker.C:
+		}
+	}
+}
+
+// claim locks the oldest job that is due, or one whose worker disappeared, and counts the attempt
+// SKIP LOCKED lets several workers (and server instances) claim jobs without blocking each other
+func (q *DocumentQueue) claim() (*models.DocumentJob, error) {
+	now := time.Now()
+	var jobs []models.DocumentJob
+	err := internal.DB.Raw(`
+        UPDATE document_jobs
+        SET status = ?, attempts = attempts + 1, locked_at = ?, updated_at = ?
+        WHERE document_id = (
+            SELECT document_id FROM document_jobs
+            WHERE (status = ? AND available_at <= ?) OR (status = ? AND locked_at < ?)
+            ORDER BY available_at
+            FOR UPDATE SKIP LOCKED
+            LIMIT 1
+        )
+        RETURNING *`,
+		models.DocumentStatusProcessing, now, now,
+		models.DocumentStatusQueued, now, models.DocumentStatusProcessing, now.Add(-q.lockTimeout),
+	).Scan(&jobs).Error
+	if err != nil {
+		return nil, err
+	}
+	if len(jobs) == 0 {
+		return nil, nil
+	}
+	return &jobs[0], nil
+}
+
+// process runs one attempt of a claimed job
+func (q *DocumentQueue) process(job *models.DocumentJob) {
+	// A job that keeps getting abandoned (e.g. crashes the server) is not retried forever
+	if job.Attempts > q.maxAttempts {
+		q.fail(job, fmt.Errorf("processing was interrupted %d times", job.Attempts-1))
+		return
+	}
+
+	document, err := q.documentService.GetDocument(job.DocumentID)
+	if err != nil {
+		q.fail(job, err)
+		return
+	}
+	if err := internal.DB.Model(document).Update("status", models.DocumentStatusProcessing).Error; err != nil {
+		log.Printf("Warning: failed to mark document %s as processing: %v", document.ID, err)
+	}
+
+	var data map[string]string
+	if err := json.Unmarshal([]byte(document.Data), &data); err != nil {
+		q.fail(job, fmt.Errorf("failed to parse stored data: %w", err))
+		return
+	}
+
+	opts := ProcessOptions{Flatten: job.Flatten, DocumentID: job.DocumentID}
+	processed, err := q.documentService.ProcessDocumentWithOptions(q.ctx, job.TemplateID, data, job.UserID, opts)
+	if err != nil {
+		if q.ctx.Err() != nil {
+			q.requeue(job)
+			return
+		}
+		q.retry(job, err)
+		return
+	}
+
+	now := time.Now()
+	warningsJSON, _ := json.Marshal(processed.Warnings)
+	updates := map[string]interface{}{
+		"status":      models.DocumentStatusCompleted,
+		"error":       "",
+		"warnings":    string(warningsJSON),
+		"locked_at":   nil,
+		"finished_at": now,
+		"updated_at":  now,
+	}
+	if err := internal.DB.Model(&models.DocumentJob{}).Where("document_id = ?", job.DocumentID).Updates(updates).Error; err != nil {
+		log.Printf("Warning: failed to complete document job %s: %v", job.DocumentID, err)
+	}
+	log.Printf("Processed queued document %s (attempt %d)", job.DocumentID, job.Attempts)
+
+	if q.webhookService != nil {
+		q.webhookService.Publish(models.WebhookEventDocumentCompleted, q.webhookService.DocumentEventData(processed, job.OrganizationID))
+	}
+
+	// Same retention as synchronous processing
+	go func(documentID string) {
+		time.Sleep(processedFilesTTL)
+		if err := q.documentService.DeleteProcessedFiles(context.Background(), documentID); err != nil {
+			fmt.Printf("Warning: failed to auto-delete processed files for document %s: %v\n", documentID, err)
+		}
+	}(job.DocumentID)
+}
+
+// retry schedules another attempt with a growing delay, or fails the job for good
+func (q *DocumentQueue) retry(job *models.DocumentJob, cause error) {
+	if job.Attempts >= q.maxAttempts {
+		q.fail(job, cause)
+		return
+	}
+
+	delay := time.Duration(job.Attempts*job.Attempts) * 15 * time.Second
+	log.Printf("Document %s failed (attempt %d/%d), retrying in %s: %v", job.DocumentID, job.Attempts, q.maxAttempts, delay, cause)
+	q.release(job, map[string]interface{}{
+		"error":        cause.Error(),
+		"available_at": time.Now().Add(delay),
+	})
+}
+
+// requeue puts back a job cancelled by shutdown without counting the attempt
+func (q *DocumentQueue) requeue(job *models.DocumentJob) {
+	q.release(job, map[string]interface{}{
+		"attempts":     job.Attempts - 1,
+		"available_at": time.Now(),
+	})
+}
+
+// release returns a claimed job to the queue
+func (q *DocumentQueue) release(job *models.DocumentJob, updates map[string]interface{}) {
+	updates["status"] = models.DocumentStatusQueued
+	updates["locked_at"] = nil
+	updates["updated_at"] = time.Now()
+	if err := internal.DB.Model(&models.DocumentJob{}).Where("document_id = ?", job.DocumentID).Updates(updates).Error; err != nil {
+		log.Printf("Warning: failed to requeue document job %s: %v", job.DocumentID, err)
+	}
+	if err := internal.DB.Model(&models.Document{}).Where("id = ?", job.DocumentID).Update("status", models.DocumentStatusQueued).Error; err != nil {
+		log.Printf("Warning: failed to mark document %s as queued: %v", job.DocumentID, err)
+	}
+}
+
+// fail marks the job and its document as failed
+func (q *DocumentQueue) fail(job *models.DocumentJob, cause error) {
+	log.Printf("Document %s failed after %d attempts: %v", job.DocumentID, job.Attempts, cause)
+	now := time.Now()
+	updates := map[string]interface{}{
+		"status":      models.DocumentStatusFailed,
+		"error":       cause.Error(),
+		"locked_at":   nil,
+		"finished_at": now,
+		"updated_at":  now,
+	}
+	if err := internal.DB.Model(&models.DocumentJob{}).Where("document_id = ?", job.DocumentID).Updates(updates).Error; err != nil {
+		log.Printf("Warning: failed to fail document job %s: %v", job.DocumentID, err)
+	}
+	if err := internal.DB.Model(&models.Document{}).Where("id = ?", job.DocumentID).Update("status", models.DocumentStatusFailed).Error; err != nil {
+		log.Printf("Warning: failed to mark document %s as failed: %v", job.DocumentID, err)
+	}
+
+	if q.webhookService != nil {
+		document := &models.Document{ID: job.DocumentID, TemplateID: job.TemplateID, UserID: job.UserID, CreatedAt: job.CreatedAt}
+		if stored, err := q.documentService.GetDocument(job.DocumentID); err == nil {
+			document = stored
+		}
+		document.Status = models.DocumentStatusFailed
+		data := q.webhookService.DocumentEventData(document, job.OrganizationID)
+		data["error"] = cause.Error()
+		data["attempts"] = job.Attempts
+		q.webhookService.Publish(models.WebhookEventDocumentFailed, data)
+	}
+}
@@ -0,0 +1,236 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+
+	"DF-PLCH/internal"
+	"DF-PLCH/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrInvalidRevision is returned for revision requests that can never succeed, e.g. diffing
+// documents of different revision chains
+var ErrInvalidRevision = errors.New("invalid revision")
+
+// Field change types in a revision diff
+const (
+	FieldChangeAdded   = "added"
+	FieldChangeRemoved = "removed"
+	FieldChangeChanged = "changed"
+)
+
+// FieldChange is a field whose value differs between two revisions
+type FieldChange struct {
+	Field  string `json:"field"`  // Placeholder key without braces
+	Label  string `json:"label"`  // Field label in the requested language
+	Change string `json:"change"` // added, removed or changed
+	Before string `json:"before"`
+	After  string `json:"after"`
+}
+
+// RevisionDiff is the field-level difference from one revision (against) to another (document)
+type RevisionDiff struct {
+	DocumentID      string        `json:"document_id"`
+	Revision        int           `json:"revision"`
+	AgainstID       string        `json:"against_id"`
+	AgainstRevision int           `json:"against_revision"`
+	Changes         []FieldChange `json:"changes"`
+}
+
+// revisionRootID returns the ID of the first document of a document's revision chain
+func revisionRootID(document *models.Document) string {
+	if document.RootID != "" {
+		return document.RootID
+	}
+	return document.ID
+}
+
+// linkRevision makes a new document the next revision of parentID's chain
+// Revisions are numbered across the whole chain, so revising an older revision still gets a new number.
+// The chain's first document is locked so concurrent revisions don't get the same number
+func linkRevision(tx *gorm.DB, document *models.Document, parentID string) error {
+	var parent models.Document
+	if err := tx.Unscoped().First(&parent, "id = ?", parentID).Error; err != nil {
+		return fmt.Errorf("parent document not found: %w", err)
+	}
+	rootID := revisionRootID(&parent)
+
+	var locked []models.Document
+	if err := tx.Unscoped().Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").Where("id = ?", rootID).Find(&locked).Error; err != nil {
+		return fmt.Errorf("failed to lock revision chain: %w", err)
+	}
+
+	var latest int
+	if err := tx.Unscoped().Model(&models.Document{}).
+		Where("id = ? OR root_id = ?", rootID, rootID).
+		Select("COALESCE(MAX(revision), 1)").
+		Scan(&latest).Error; err != nil {
+		return fmt.Errorf("failed to get latest revision: %w", err)
+	}
+
+	document.ParentID = parent.ID
+	document.RootID = rootID
+	document.Revision = latest + 1
+	return nil
+}
+
+// GetRevisableDocument returns a user's document that can be revised
+// Queued and processing documents can't be revised until the queue completes them
+func (s *DocumentService) GetRevisableDocument(documentID, userID string) (*models.Document, error) {
+	document, err := s.GetDocument(documentID)
+	if err != nil {
+		return nil, err
+	}
+	if document.UserID != userID {
+		return nil, fmt.Errorf("unauthorized: you don't have access to this document")
+	}
+	if document.Status == models.DocumentStatusQueued || document.Status == models.DocumentStatusProcessing {
+		return nil, ErrDocumentPending
+	}
+	return document, nil
+}
+
+// ReviseData applies a patch (keyed by "{{placeholder}}", as returned by ResolveProcessData) over a
+// document's stored data. The result is data for /process to produce the revision:
+//   - a merged field or radio group in the patch replaces the member values stored for it
+//   - computed fields are dropped, so they are recomputed from the revised values
+func ReviseData(template *models.Template, document *models.Document, patch map[string]string) (map[string]string, error) {
+	stored := make(map[string]string)
+	if document.Data != "" {
+		if err := json.Unmarshal([]byte(document.Data), &stored); err != nil {
+			return nil, fmt.Errorf("failed to parse stored data: %w", err)
+		}
+	}
+	definitions := parseFieldDefinitions(template.FieldDefinitions)
+
+	for _, master := range virtualFieldPlaceholders(definitions) {
+		if _, patched := patch[master]; !patched {
+			continue
+		}
+		def := definitions[placeholderKey(master)]
+		for _, field := range def.MergedFields {
+			delete(stored, "{{"+placeholderKey(field)+"}}")
+		}
+		for _, option := range def.RadioOptions {
+			delete(stored, "{{"+placeholderKey(option.Placeholder)+"}}")
+		}
+	}
+	for placeholder := range stored {
+		if isComputedField(definitions, placeholderKey(placeholder)) {
+			delete(stored, placeholder)
+		}
+	}
+
+	for placeholder, value := range patch {
+		stored[placeholder] = value
+	}
+	return stored, nil
+}
+
+// GetRevisions returns the revision chain a user's document belongs to, oldest first
+func (s *DocumentService) GetRevisions(documentID, userID string) ([]models.Document, error) {
+	document, err := s.GetDocumentWithAuth(documentID, userID)
+	if err != nil {
+		return nil, err
+	}
+	rootID := revisionRootID(document)
+
+	var revisions []models.Document
+	if err := internal.DB.Where("id = ? OR root_id = ?", rootID, rootID).
+		Order("revision ASC").
+		Order("created_at ASC").
+		Find(&revisions).Error; err != nil {
+		return nil, fmt.Errorf("failed to get revisions: %w", err)
+	}
+	return revisions, nil
+}
+
+// DiffRevisions compares the data of a user's document with another revision of its chain,
+// by default its parent. Labels are taken from the template's field definitions in lang
+func (s *DocumentService) DiffRevisions(documentID, againstID, userID, lang string) (*RevisionDiff, error) {
+	document, err := s.GetDocumentWithAuth(documentID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if againstID == "" {
+		if document.ParentID == "" {
+			return nil, fmt.Errorf("%w: document %s is the first revision, specify a document to compare against", ErrInvalidRevision, document.ID)
+		}
+		againstID = document.ParentID
+	}
+	against, err := s.GetDocumentWithAuth(againstID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if revisionRootID(against) != revisionRootID(document) {
+		return nil, fmt.Errorf("%w: documents %s and %s are not revisions of the same document", ErrInvalidRevision, against.ID, document.ID)
+	}
+
+	before := make(map[string]string)
+	after := make(map[string]string)
+	if against.Data != "" {
+		if err := json.Unmarshal([]byte(against.Data), &before); err != nil {
+			return nil, fmt.Errorf("failed to parse stored data: %w", err)
+		}
+	}
+	if document.Data != "" {
+		if err := json.Unmarshal([]byte(document.Data), &after); err != nil {
+			return nil, fmt.Errorf("failed to parse stored data: %w", err)
+		}
+	}
+
+	// Labels are best effort; the template may have been deleted since
+	var template models.Template
+	internal.DB.Unscoped().Select("field_definitions").First(&template, "id = ?", document.TemplateID)
+	definitions := parseFieldDefinitions(template.FieldDefinitions)
+
+	keys := make(map[string]bool, len(before)+len(after))
+	for placeholder := range before {
+		keys[placeholder] = true
+	}
+	for placeholder := range after {
+		keys[placeholder] = true
+	}
+	placeholders := make([]string, 0, len(keys))
+	for placeholder := range keys {
+		placeholders = append(placeholders, placeholder)
+	}
+	sort.Strings(placeholders)
+
+	diff := &RevisionDiff{
+		DocumentID:      document.ID,
+		Revision:        document.Revision,
+		AgainstID:       against.ID,
+		AgainstRevision: against.Revision,
+		Changes:         []FieldChange{},
+	}
+	for _, placeholder := range placeholders {
+		oldValue, hadValue := before[placeholder]
+		newValue, hasValue := after[placeholder]
+		var change string
+		switch {
+		case !hadValue:
+			change = FieldChangeAdded
+		case !hasValue:
+			change = FieldChangeRemoved
+		case oldValue != newValue:
+			change = FieldChangeChanged
+		default:
+			continue
+		}
+		key := placeholderKey(placeholder)
+		diff.Changes = append(diff.Changes, FieldChange{
+			Field:  key,
+			Label:  fieldLabel(key, definitions[key], lang),
+			Change: change,
+			Before: oldValue,
+			After:  newValue,
+		})
+	}
+	return diff, nil
+}
@@ -211,6 +211,15 @@ func (s *DocumentTypeService) Update(id string, req *UpdateDocumentTypeRequest)
 		return nil, fmt.Errorf("failed to update document type: %w", err)
 	}
 
+	// Document type names are indexed with each linked template
+	if req.Name != "" || req.NameEN != "" {
+		var templateIDs []string
+		internal.DB.Model(&models.Template{}).Where("document_type_id = ?", id).Pluck("id", &templateIDs)
+		for _, templateID := range templateIDs {
+			refreshTemplateSearch(templateID)
+		}
+	}
+
 	return docType, nil
 }
 
@@ -275,6 +284,8 @@ func (s *DocumentTypeService) AssignTemplateToDocumentType(templateID, documentT
 		return fmt.Errorf("template not found")
 	}
 
+	refreshTemplateSearch(templateID)
+
 	return nil
 }
 
@@ -294,6 +305,8 @@ func (s *DocumentTypeService) UnassignTemplateFromDocumentType(templateID string
 		return fmt.Errorf("template not found")
 	}
 
+	refreshTemplateSearch(templateID)
+
 	return nil
 }
 
@@ -324,6 +337,8 @@ func (s *DocumentTypeService) BulkAssignTemplates(documentTypeID string, assignm
 		if result.Error != nil {
 			return fmt.Errorf("failed to assign template %s: %w", assignment.TemplateID, result.Error)
 		}
+
+		refreshTemplateSearch(assignment.TemplateID)
 	}
 
 	return nil
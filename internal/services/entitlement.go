@@ -0,0 +1,154 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"DF-PLCH/internal/models"
+)
+
+// ErrEntitlementUnavailable is returned when the entitlement service cannot confirm a user's tier
+var ErrEntitlementUnavailable = errors.New("entitlement service unavailable")
+
+// EntitlementProvider resolves the tier a user is entitled to
+// headerTier is the raw tier sent by the API gateway (may be empty)
+type EntitlementProvider interface {
+	ResolveTier(ctx context.Context, userID, headerTier string) (models.Tier, error)
+}
+
+// HeaderEntitlementProvider trusts the tier forwarded by the API gateway
+type HeaderEntitlementProvider struct {
+	defaultTier models.Tier
+}
+
+func NewHeaderEntitlementProvider(defaultTier models.Tier) *HeaderEntitlementProvider {
+	return &HeaderEntitlementProvider{defaultTier: defaultTier}
+}
+
+// ResolveTier returns the gateway tier, or the default tier if none/unknown was sent
+func (p *HeaderEntitlementProvider) ResolveTier(ctx context.Context, userID, headerTier string) (models.Tier, error) {
+	tier := models.Tier(strings.ToLower(strings.TrimSpace(headerTier)))
+	if tier.IsValid() {
+		return tier, nil
+	}
+	return p.defaultTier, nil
+}
+
+// HTTPEntitlementProvider asks an external entitlement service for the user's tier
+// The service is called as GET {serviceURL}?user_id=... and must return {"tier": "..."}
+type HTTPEntitlementProvider struct {
+	serviceURL string
+	fallback   EntitlementProvider
+	client     *http.Client
+}
+
+func NewHTTPEntitlementProvider(serviceURL string, fallback EntitlementProvider) *HTTPEntitlementProvider {
+	return &HTTPEntitlementProvider{
+		serviceURL: serviceURL,
+		fallback:   fallback,
+		client:     &http.Client{Timeout: 5 * time.Second},
+	}
+}
+
+// ResolveTier queries the entitlement service. A failed lookup returns ErrEntitlementUnavailable
+// rather than trusting the gateway tier, so an outage never grants a higher tier
+func (p *HTTPEntitlementProvider) ResolveTier(ctx context.Context, userID, headerTier string) (models.Tier, error) {
+	if userID == "" {
+		return p.fallback.ResolveTier(ctx, userID, headerTier)
+	}
+
+	reqURL := fmt.Sprintf("%s?user_id=%s", p.serviceURL, url.QueryEscape(userID))
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, reqURL, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to create entitlement request: %w", err)
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrEntitlementUnavailable, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("%w: status %d", ErrEntitlementUnavailable, resp.StatusCode)
+	}
+
+	var result struct {
+		Tier string `json:"tier"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", fmt.Errorf("%w: failed to decode response: %v", ErrEntitlementUnavailable, err)
+	}
+
+	return p.fallback.ResolveTier(ctx, userID, result.Tier)
+}
+
+// TierAccessError describes why a user cannot access a template
+type TierAccessError struct {
+	StatusCode   int         `json:"-"`
+	Code         string      `json:"code"`
+	Message      string      `json:"error"`
+	RequiredTier models.Tier `json:"required_tier"`
+	CurrentTier  models.Tier `json:"current_tier"`
+	UpgradeHint  string      `json:"upgrade_hint"`
+	UpgradeURL   string      `json:"upgrade_url,omitempty"`
+}
+
+func (e *TierAccessError) Error() string {
+	return e.Message
+}
+
+// EntitlementService enforces template tier requirements
+type EntitlementService struct {
+	provider   EntitlementProvider
+	upgradeURL string
+}
+
+func NewEntitlementService(provider EntitlementProvider, upgradeURL string) *EntitlementService {
+	return &EntitlementService{
+		provider:   provider,
+		upgradeURL: upgradeURL,
+	}
+}
+
+// ResolveTier returns the effective tier for a user
+func (s *EntitlementService) ResolveTier(ctx context.Context, userID, headerTier string) (models.Tier, error) {
+	return s.provider.ResolveTier(ctx, userID, headerTier)
+}
+
+// IsLocked reports whether a template requires a higher tier than the user has
+func (s *EntitlementService) IsLocked(template *models.Template, userTier models.Tier) bool {
+	return !userTier.Allows(template.Tier)
+}
+
+// CheckAccess returns a TierAccessError if the user's tier does not allow the template
+// Enterprise templates return 403 (sales contact required), other upgrades return 402
+func (s *EntitlementService) CheckAccess(template *models.Template, userTier models.Tier) *TierAccessError {
+	if !s.IsLocked(template, userTier) {
+		return nil
+	}
+
+	accessErr := &TierAccessError{
+		StatusCode:   http.StatusPaymentRequired,
+		Code:         "tier_upgrade_required",
+		Message:      fmt.Sprintf("This template requires the %s tier", template.Tier),
+		RequiredTier: template.Tier,
+		CurrentTier:  userTier,
+		UpgradeHint:  fmt.Sprintf("Upgrade from %s to %s or higher to use this template", userTier, template.Tier),
+		UpgradeURL:   s.upgradeURL,
+	}
+
+	if template.Tier == models.TierEnterprise {
+		accessErr.StatusCode = http.StatusForbidden
+		accessErr.Code = "tier_enterprise_required"
+		accessErr.UpgradeHint = "This template is available on enterprise plans only. Contact sales to enable it for your organization"
+	}
+
+	return accessErr
+}
@@ -0,0 +1,168 @@
+package services
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+
+	"DF-PLCH/internal/models"
+	"DF-PLCH/internal/utils"
+)
+
+// virtualFieldPlaceholders returns "{{key}}" for each merged field and radio group master, sorted
+// Masters are not placeholders in the document; their values are expanded by ExpandFieldValues
+func virtualFieldPlaceholders(definitions map[string]utils.FieldDefinition) []string {
+	var masters []string
+	for key, def := range definitions {
+		if def.IsMerged || def.IsRadioGroup {
+			masters = append(masters, "{{"+key+"}}")
+		}
+	}
+	sort.Strings(masters)
+	return masters
+}
+
+// ExpandFieldValues expands values sent for merged fields and radio groups (keyed by "{{masterKey}}")
+//
+// A merged field's value is split by its separator, or into characters when it has none (spaces and
+// dashes are dropped, e.g. for ID number boxes), and placed in its member placeholders in order.
+// A radio group's value names the selected option by placeholder key or label; the selected option
+// gets its value ("/" by default) and the others are blanked.
+// Child fields of unselected radio options are hidden and blanked, whether the option was chosen
+// through its group or by sending the option placeholders directly.
+// The request data is not modified.
+func ExpandFieldValues(template *models.Template, data map[string]string) (map[string]string, []string, error) {
+	definitions := parseFieldDefinitions(template.FieldDefinitions)
+
+	expanded := make(map[string]string, len(data))
+	for key, value := range data {
+		expanded[key] = value
+	}
+	var warnings []string
+
+	// Members must not also be sent on their own
+	setMember := func(master, placeholder, value string) error {
+		if _, sent := data[placeholder]; sent {
+			return fmt.Errorf("%w: %s is set by %s and must not also be sent", ErrInvalidProcessData, placeholder, master)
+		}
+		expanded[placeholder] = value
+		return nil
+	}
+
+	for _, master := range virtualFieldPlaceholders(definitions) {
+		value, sent := data[master]
+		if !sent {
+			continue
+		}
+		delete(expanded, master)
+		def := definitions[placeholderKey(master)]
+
+		if def.IsMerged {
+			parts := splitMergedValue(value, def.Separator)
+			if len(parts) > len(def.MergedFields) {
+				return nil, nil, fmt.Errorf("%w: %s has %d parts but only %d fields", ErrInvalidProcessData, master, len(parts), len(def.MergedFields))
+			}
+			for i, field := range def.MergedFields {
+				part := ""
+				if i < len(parts) {
+					part = parts[i]
+				}
+				if err := setMember(master, "{{"+placeholderKey(field)+"}}", part); err != nil {
+					return nil, nil, err
+				}
+			}
+			continue
+		}
+
+		selected := -1
+		if choice := strings.TrimSpace(value); choice != "" {
+			labels := make([]string, 0, len(def.RadioOptions))
+			for i, option := range def.RadioOptions {
+				labels = append(labels, option.Label)
+				if strings.EqualFold(choice, placeholderKey(option.Placeholder)) || strings.EqualFold(choice, option.Label) {
+					selected = i
+					break
+				}
+			}
+			if selected < 0 {
+				return nil, nil, fmt.Errorf("%w: %s must be one of %s", ErrInvalidProcessData, master, strings.Join(labels, ", "))
+			}
+		}
+		for i, option := range def.RadioOptions {
+			optionValue := ""
+			if i == selected {
+				optionValue = option.Value
+				if optionValue == "" {
+					optionValue = "/"
+				}
+			}
+			if err := setMember(master, "{{"+placeholderKey(option.Placeholder)+"}}", optionValue); err != nil {
+				return nil, nil, err
+			}
+		}
+	}
+
+	hidden := hiddenChildFields(definitions, expanded)
+	keys := make([]string, 0, len(hidden))
+	for key := range hidden {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		placeholder := "{{" + key + "}}"
+		if strings.TrimSpace(expanded[placeholder]) != "" {
+			warnings = append(warnings, fmt.Sprintf("%s belongs to an unselected option, its value was cleared", placeholder))
+		}
+		expanded[placeholder] = ""
+	}
+
+	return expanded, warnings, nil
+}
+
+// splitMergedValue splits a merged field's value into its member values
+func splitMergedValue(value, separator string) []string {
+	if value == "" {
+		return nil
+	}
+	if separator != "" {
+		return strings.Split(value, separator)
+	}
+
+	var parts []string
+	for _, r := range value {
+		if unicode.IsSpace(r) || r == '-' {
+			continue
+		}
+		parts = append(parts, string(r))
+	}
+	return parts
+}
+
+// hiddenChildFields returns the keys of radio option child fields whose option is not selected
+// An option is selected when its placeholder has a non-empty value in data ("{{key}}" -> value)
+func hiddenChildFields(definitions map[string]utils.FieldDefinition, data map[string]string) map[string]bool {
+	hidden := make(map[string]bool)
+	visible := make(map[string]bool)
+	for _, def := range definitions {
+		if !def.IsRadioGroup {
+			continue
+		}
+		for _, option := range def.RadioOptions {
+			selected := strings.TrimSpace(data["{{"+placeholderKey(option.Placeholder)+"}}"]) != ""
+			for _, child := range option.ChildFields {
+				if selected {
+					visible[placeholderKey(child)] = true
+				} else {
+					hidden[placeholderKey(child)] = true
+				}
+			}
+		}
+	}
+
+	// A field shown by any selected option stays visible
+	for key := range visible {
+		delete(hidden, key)
+	}
+	return hidden
+}
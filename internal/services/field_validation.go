@@ -0,0 +1,272 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"DF-PLCH/internal/models"
+	"DF-PLCH/internal/utils"
+)
+
+// Field validation error codes
+const (
+	FieldErrorRequired  = "required"
+	FieldErrorPattern   = "pattern"
+	FieldErrorMinLength = "min_length"
+	FieldErrorMaxLength = "max_length"
+	FieldErrorNumber    = "number"
+	FieldErrorMin       = "min"
+	FieldErrorMax       = "max"
+	FieldErrorOption    = "option"
+	FieldErrorThaiID    = "thai_id"
+	FieldErrorDate      = "date"
+	FieldErrorTime      = "time"
+	FieldErrorEmail     = "email"
+	FieldErrorPhone     = "phone"
+)
+
+// FieldError is why one submitted value was rejected
+type FieldError struct {
+	Field   string                 `json:"field"` // Placeholder key without braces
+	Label   string                 `json:"label"` // Field label in the requested language
+	Code    string                 `json:"code"`
+	Message string                 `json:"message"`          // Localized message
+	Params  map[string]interface{} `json:"params,omitempty"` // Rule values, e.g. {"min": 13}
+}
+
+// fieldErrorMessages are the localized messages per language and code
+// {label} and the keys of FieldError.Params are substituted
+var fieldErrorMessages = map[string]map[string]string{
+	models.LanguageThai: {
+		FieldErrorRequired:  "กรุณากรอก{label}",
+		FieldErrorPattern:   "{label}มีรูปแบบไม่ถูกต้อง",
+		FieldErrorMinLength: "{label}ต้องมีอย่างน้อย {min} ตัวอักษร",
+		FieldErrorMaxLength: "{label}ต้องมีไม่เกิน {max} ตัวอักษร",
+		FieldErrorNumber:    "{label}ต้องเป็นตัวเลข",
+		FieldErrorMin:       "{label}ต้องไม่น้อยกว่า {min}",
+		FieldErrorMax:       "{label}ต้องไม่มากกว่า {max}",
+		FieldErrorOption:    "{label}ต้องเป็นหนึ่งในตัวเลือกที่กำหนด",
+		FieldErrorThaiID:    "{label}ไม่ใช่เลขประจำตัวประชาชนที่ถูกต้อง",
+		FieldErrorDate:      "{label}ไม่ใช่วันที่ที่ถูกต้อง",
+		FieldErrorTime:      "{label}ไม่ใช่เวลาที่ถูกต้อง",
+		FieldErrorEmail:     "{label}ไม่ใช่อีเมลที่ถูกต้อง",
+		FieldErrorPhone:     "{label}ไม่ใช่หมายเลขโทรศัพท์ที่ถูกต้อง",
+	},
+	models.LanguageEnglish: {
+		FieldErrorRequired:  "{label} is required",
+		FieldErrorPattern:   "{label} has an invalid format",
+		FieldErrorMinLength: "{label} must be at least {min} characters",
+		FieldErrorMaxLength: "{label} must be at most {max} characters",
+		FieldErrorNumber:    "{label} must be a number",
+		FieldErrorMin:       "{label} must be at least {min}",
+		FieldErrorMax:       "{label} must be at most {max}",
+		FieldErrorOption:    "{label} must be one of the allowed options",
+		FieldErrorThaiID:    "{label} is not a valid Thai national ID number",
+		FieldErrorDate:      "{label} is not a valid date",
+		FieldErrorTime:      "{label} is not a valid time",
+		FieldErrorEmail:     "{label} is not a valid email address",
+		FieldErrorPhone:     "{label} is not a valid phone number",
+	},
+}
+
+// ValidateDocumentData checks submitted values (keyed by "{{placeholder}}") against the template's
+// field definitions: the validation rules first, then the data type. Empty values are only checked
+// for required; computed fields and child fields of unselected radio options are skipped.
+// Members of a merged field are only checked together, through the merged field's definition.
+// Errors are sorted by field order, messages are in lang (Thai by default)
+func ValidateDocumentData(template *models.Template, data map[string]string, lang string) []FieldError {
+	var placeholders []string
+	if err := json.Unmarshal([]byte(template.Placeholders), &placeholders); err != nil {
+		return nil
+	}
+	definitions := parseFieldDefinitions(template.FieldDefinitions)
+
+	// Child fields of unselected radio options are hidden and not checked
+	hidden := hiddenChildFields(definitions, data)
+
+	// Members of merged fields (e.g. single-digit ID number boxes) are validated as one value below
+	members := make(map[string]bool)
+	for _, definition := range definitions {
+		if definition.IsMerged {
+			for _, field := range definition.MergedFields {
+				members[placeholderKey(field)] = true
+			}
+		}
+	}
+
+	var fieldErrors []FieldError
+	for _, placeholder := range placeholders {
+		key := placeholderKey(placeholder)
+		definition, ok := definitions[key]
+		if !ok || hidden[key] || members[key] || isComputedField(definitions, key) {
+			continue
+		}
+		if fieldError := ValidateFieldValue(key, definition, data[placeholder], lang); fieldError != nil {
+			fieldErrors = append(fieldErrors, *fieldError)
+		}
+	}
+
+	// A merged field's data type applies to its members' values joined together (e.g. ID number boxes)
+	for _, master := range virtualFieldPlaceholders(definitions) {
+		key := placeholderKey(master)
+		definition := definitions[key]
+		if !definition.IsMerged || hidden[key] {
+			continue
+		}
+		values := make([]string, 0, len(definition.MergedFields))
+		for _, field := range definition.MergedFields {
+			values = append(values, data["{{"+placeholderKey(field)+"}}"])
+		}
+		if fieldError := ValidateFieldValue(key, definition, strings.Join(values, definition.Separator), lang); fieldError != nil {
+			fieldErrors = append(fieldErrors, *fieldError)
+		}
+	}
+
+	sort.SliceStable(fieldErrors, func(i, j int) bool {
+		return definitions[fieldErrors[i].Field].Order < definitions[fieldErrors[j].Field].Order
+	})
+	return fieldErrors
+}
+
+// ValidateFieldValue returns the first rule a value breaks, or nil
+func ValidateFieldValue(key string, definition utils.FieldDefinition, value, lang string) *FieldError {
+	fail := func(code string, params map[string]interface{}) *FieldError {
+		return newFieldError(key, definition, code, params, lang)
+	}
+
+	rules := definition.Validation
+	if rules == nil {
+		rules = &utils.FieldValidation{}
+	}
+
+	value = strings.TrimSpace(value)
+	if value == "" {
+		if rules.Required {
+			return fail(FieldErrorRequired, nil)
+		}
+		return nil
+	}
+
+	// Checkbox and radio placeholders hold a mark (e.g. "/"), not a value of their data type
+	if definition.InputType == utils.InputTypeCheckbox || definition.InputType == utils.InputTypeRadio {
+		return nil
+	}
+
+	if len(rules.Options) > 0 && !containsString(rules.Options, value) {
+		return fail(FieldErrorOption, map[string]interface{}{"options": rules.Options})
+	}
+	length := utf8.RuneCountInString(value)
+	if rules.MinLength != nil && length < *rules.MinLength {
+		return fail(FieldErrorMinLength, map[string]interface{}{"min": *rules.MinLength})
+	}
+	if rules.MaxLength != nil && length > *rules.MaxLength {
+		return fail(FieldErrorMaxLength, map[string]interface{}{"max": *rules.MaxLength})
+	}
+	if rules.Pattern != "" {
+		// Patterns are written for ASCII digits
+		pattern, err := regexp.Compile(rules.Pattern)
+		if err != nil {
+			fmt.Printf("[WARNING] Ignoring invalid validation pattern for field %s: %v\n", key, err)
+		} else if !pattern.MatchString(utils.NormalizeDigits(value)) {
+			return fail(FieldErrorPattern, map[string]interface{}{"pattern": rules.Pattern})
+		}
+	}
+
+	// Min/max only make sense for numbers; number fields without them may hold marks or codes
+	if rules.Min != nil || rules.Max != nil || definition.InputType == utils.InputTypeNumber {
+		number, ok := utils.ParseNumber(value)
+		if !ok {
+			return fail(FieldErrorNumber, nil)
+		}
+		if rules.Min != nil && number < float64(*rules.Min) {
+			return fail(FieldErrorMin, map[string]interface{}{"min": *rules.Min})
+		}
+		if rules.Max != nil && number > float64(*rules.Max) {
+			return fail(FieldErrorMax, map[string]interface{}{"max": *rules.Max})
+		}
+	}
+
+	switch definition.DataType {
+	case utils.DataTypeIDNumber:
+		if !utils.ValidThaiID(value) {
+			return fail(FieldErrorThaiID, nil)
+		}
+	case utils.DataTypeDate:
+		if _, ok := utils.ParseDate(value); !ok {
+			return fail(FieldErrorDate, nil)
+		}
+	case utils.DataTypeTime:
+		if !utils.ValidTime(value) {
+			return fail(FieldErrorTime, nil)
+		}
+	case utils.DataTypeEmail:
+		if !utils.ValidEmail(value) {
+			return fail(FieldErrorEmail, nil)
+		}
+	case utils.DataTypePhone:
+		if !utils.ValidThaiPhone(value) {
+			return fail(FieldErrorPhone, nil)
+		}
+	}
+	return nil
+}
+
+// FieldErrorsSummary joins field errors into one line, e.g. for batch row errors
+func FieldErrorsSummary(fieldErrors []FieldError) string {
+	parts := make([]string, 0, len(fieldErrors))
+	for _, fieldError := range fieldErrors {
+		parts = append(parts, fmt.Sprintf("%s: %s", fieldError.Field, fieldError.Message))
+	}
+	return strings.Join(parts, "; ")
+}
+
+func newFieldError(key string, definition utils.FieldDefinition, code string, params map[string]interface{}, lang string) *FieldError {
+	label := fieldLabel(key, definition, lang)
+
+	messages, ok := fieldErrorMessages[lang]
+	if !ok {
+		messages = fieldErrorMessages[models.DefaultLanguage]
+	}
+	replacements := []string{"{label}", label}
+	for name, value := range params {
+		switch v := value.(type) {
+		case int:
+			replacements = append(replacements, "{"+name+"}", strconv.Itoa(v))
+		case string:
+			replacements = append(replacements, "{"+name+"}", v)
+		}
+	}
+
+	return &FieldError{
+		Field:   key,
+		Label:   label,
+		Code:    code,
+		Message: strings.NewReplacer(replacements...).Replace(messages[code]),
+		Params:  params,
+	}
+}
+
+// fieldLabel returns a field's label in lang, falling back to its Thai label and then its key
+func fieldLabel(key string, definition utils.FieldDefinition, lang string) string {
+	if translation, ok := definition.Translations[lang]; ok && translation.Label != "" {
+		return translation.Label
+	}
+	if definition.Label != "" {
+		return definition.Label
+	}
+	return key
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
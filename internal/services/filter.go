@@ -444,5 +444,54 @@ func (s *FilterService) GetFiltersWithCounts() ([]map[string]interface{}, error)
 		})
 	}
 
+	// Tags are many-to-many, so they are counted through template_tags instead of a template column
+	tagFilter, err := s.getTagFilterWithCounts()
+	if err != nil {
+		return nil, err
+	}
+	if tagFilter != nil {
+		result = append(result, tagFilter)
+	}
+
 	return result, nil
 }
+
+// getTagFilterWithCounts builds a virtual "tags" filter category from the tags table
+// Returns nil if no tags exist
+func (s *FilterService) getTagFilterWithCounts() (map[string]interface{}, error) {
+	tags, err := NewTagService().GetAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(tags) == 0 {
+		return nil, nil
+	}
+
+	options := make([]map[string]interface{}, 0, len(tags))
+	for _, tag := range tags {
+		options = append(options, map[string]interface{}{
+			"id":         tag.ID,
+			"value":      tag.Slug,
+			"label":      tag.Name,
+			"label_en":   tag.NameEN,
+			"color":      tag.Color,
+			"icon":       "",
+			"sort_order": tag.SortOrder,
+			"count":      tag.TemplateCount,
+			"is_default": false,
+			"is_active":  true,
+		})
+	}
+
+	return map[string]interface{}{
+		"id":         "tags",
+		"code":       "tags",
+		"name":       "แท็ก",
+		"name_en":    "Tags",
+		"field_name": "tags",
+		"sort_order": 1000,
+		"is_system":  true,
+		"is_active":  true,
+		"options":    options,
+	}, nil
+}
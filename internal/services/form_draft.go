@@ -0,0 +1,255 @@
+package services
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"DF-PLCH/internal"
+	"DF-PLCH/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// ErrInvalidDraft is returned for draft data that /process could never accept
+var ErrInvalidDraft = errors.New("invalid draft")
+
+// FormDraftService stores users' partially filled forms
+type FormDraftService struct {
+	ttl time.Duration
+}
+
+// NewFormDraftService creates a draft service; drafts expire ttl after their last update
+func NewFormDraftService(ttl time.Duration) *FormDraftService {
+	return &FormDraftService{ttl: ttl}
+}
+
+// TemplateVersion identifies the version of a template's fields (placeholders and definitions)
+// Drafts record it so clients can tell when the form changed after the draft was saved
+func TemplateVersion(template *models.Template) string {
+	sum := sha256.Sum256([]byte(template.Placeholders + "\n" + template.FieldDefinitions))
+	return hex.EncodeToString(sum[:8])
+}
+
+// CreateDraft saves a new draft of a template for a user
+func (s *FormDraftService) CreateDraft(template *models.Template, userID, name string, data map[string]interface{}) (*models.FormDraft, error) {
+	dataJSON, err := s.marshalData(template, data)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	draft := &models.FormDraft{
+		ID:              uuid.New().String(),
+		UserID:          userID,
+		TemplateID:      template.ID,
+		Name:            name,
+		Data:            dataJSON,
+		TemplateVersion: TemplateVersion(template),
+		ExpiresAt:       now.Add(s.ttl),
+	}
+	if err := internal.DB.Create(draft).Error; err != nil {
+		return nil, fmt.Errorf("failed to create draft: %w", err)
+	}
+	return draft, nil
+}
+
+// UpdateDraft replaces a draft's data (when data is not nil) and name (when name is not nil)
+// Saving data records the template's current version; every update extends the expiry
+func (s *FormDraftService) UpdateDraft(draftID, userID string, name *string, data map[string]interface{}) (*models.FormDraft, error) {
+	draft, err := s.GetDraft(draftID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if data != nil {
+		var template models.Template
+		if err := internal.DB.First(&template, "id = ?", draft.TemplateID).Error; err != nil {
+			return nil, fmt.Errorf("template not found: %w", err)
+		}
+		dataJSON, err := s.marshalData(&template, data)
+		if err != nil {
+			return nil, err
+		}
+		draft.Data = dataJSON
+		draft.TemplateVersion = TemplateVersion(&template)
+	}
+	if name != nil {
+		draft.Name = *name
+	}
+	draft.ExpiresAt = time.Now().Add(s.ttl)
+
+	if err := internal.DB.Save(draft).Error; err != nil {
+		return nil, fmt.Errorf("failed to update draft: %w", err)
+	}
+	return draft, nil
+}
+
+// GetDraft returns a user's draft; expired drafts are not found
+func (s *FormDraftService) GetDraft(draftID, userID string) (*models.FormDraft, error) {
+	var draft models.FormDraft
+	if err := internal.DB.Where("id = ? AND expires_at > ?", draftID, time.Now()).First(&draft).Error; err != nil {
+		return nil, fmt.Errorf("draft not found: %w", err)
+	}
+	if draft.UserID != userID {
+		return nil, fmt.Errorf("unauthorized: you don't have access to this draft")
+	}
+	return &draft, nil
+}
+
+// ListDrafts returns a user's drafts, most recently updated first, optionally for one template
+func (s *FormDraftService) ListDrafts(userID, templateID string) ([]models.FormDraft, error) {
+	query := internal.DB.Where("user_id = ? AND expires_at > ?", userID, time.Now())
+	if templateID != "" {
+		query = query.Where("template_id = ?", templateID)
+	}
+
+	var drafts []models.FormDraft
+	if err := query.Order("updated_at DESC").Find(&drafts).Error; err != nil {
+		return nil, fmt.Errorf("failed to list drafts: %w", err)
+	}
+	return drafts, nil
+}
+
+// DeleteDraft deletes a user's draft
+func (s *FormDraftService) DeleteDraft(draftID, userID string) error {
+	if _, err := s.GetDraft(draftID, userID); err != nil {
+		return err
+	}
+	if err := internal.DB.Delete(&models.FormDraft{}, "id = ?", draftID).Error; err != nil {
+		return fmt.Errorf("failed to delete draft: %w", err)
+	}
+	return nil
+}
+
+// DraftResponses builds API responses for drafts, comparing them with their templates' current
+// version. Data is only included when withData is set
+func (s *FormDraftService) DraftResponses(drafts []models.FormDraft, withData bool) ([]models.FormDraftResponse, error) {
+	templateIDs := make([]string, 0, len(drafts))
+	for _, draft := range drafts {
+		templateIDs = append(templateIDs, draft.TemplateID)
+	}
+	templates := make(map[string]models.Template, len(templateIDs))
+	if len(templateIDs) > 0 {
+		var list []models.Template
+		if err := internal.DB.Where("id IN ?", templateIDs).Find(&list).Error; err != nil {
+			return nil, fmt.Errorf("failed to get templates: %w", err)
+		}
+		for _, template := range list {
+			templates[template.ID] = template
+		}
+	}
+
+	responses := make([]models.FormDraftResponse, 0, len(drafts))
+	for i := range drafts {
+		draft := &drafts[i]
+		data := draft.GetData()
+		response := models.FormDraftResponse{
+			ID:              draft.ID,
+			TemplateID:      draft.TemplateID,
+			Name:            draft.Name,
+			FieldCount:      len(data),
+			TemplateVersion: draft.TemplateVersion,
+			TemplateChanged: true,
+			ExpiresAt:       draft.ExpiresAt,
+			CreatedAt:       draft.CreatedAt,
+			UpdatedAt:       draft.UpdatedAt,
+		}
+		if template, ok := templates[draft.TemplateID]; ok {
+			response.TemplateName = template.DisplayName
+			if response.TemplateName == "" {
+				response.TemplateName = template.OriginalName
+			}
+			response.TemplateChanged = TemplateVersion(&template) != draft.TemplateVersion
+		}
+		if withData {
+			response.Data = data
+		}
+		responses = append(responses, response)
+	}
+	return responses, nil
+}
+
+// PurgeExpiredDrafts deletes drafts past their expiry
+func (s *FormDraftService) PurgeExpiredDrafts() (int64, error) {
+	result := internal.DB.Where("expires_at <= ?", time.Now()).Delete(&models.FormDraft{})
+	if result.Error != nil {
+		return 0, fmt.Errorf("failed to purge expired drafts: %w", result.Error)
+	}
+	return result.RowsAffected, nil
+}
+
+// marshalData checks that draft data resolves against the template and encodes it
+// Partial data is fine; values of the wrong type or unknown entity sections are not
+func (s *FormDraftService) marshalData(template *models.Template, data map[string]interface{}) (string, error) {
+	if data == nil {
+		data = make(map[string]interface{})
+	}
+	if _, err := ResolveProcessData(template, data); err != nil {
+		if errors.Is(err, ErrInvalidProcessData) {
+			return "", fmt.Errorf("%w: %v", ErrInvalidDraft, err)
+		}
+		return "", err
+	}
+
+	dataJSON, err := json.Marshal(data)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal draft data: %w", err)
+	}
+	return string(dataJSON), nil
+}
+
+// DraftPurgeScheduler periodically deletes expired drafts
+type DraftPurgeScheduler struct {
+	draftService *FormDraftService
+	interval     time.Duration
+	ticker       *time.Ticker
+	done         chan bool
+}
+
+func NewDraftPurgeScheduler(draftService *FormDraftService, interval time.Duration) *DraftPurgeScheduler {
+	return &DraftPurgeScheduler{
+		draftService: draftService,
+		interval:     interval,
+		done:         make(chan bool),
+	}
+}
+
+func (d *DraftPurgeScheduler) Start() {
+	d.ticker = time.NewTicker(d.interval)
+	go func() {
+		d.purge()
+		for {
+			select {
+			case <-d.done:
+				return
+			case <-d.ticker.C:
+				d.purge()
+			}
+		}
+	}()
+	log.Printf("Form draft purge started (ttl: %s, interval: %s)", d.draftService.ttl, d.interval)
+}
+
+func (d *DraftPurgeScheduler) Stop() {
+	if d.ticker != nil {
+		d.ticker.Stop()
+	}
+	d.done <- true
+	log.Println("Form draft purge stopped")
+}
+
+func (d *DraftPurgeScheduler) purge() {
+	purged, err := d.draftService.PurgeExpiredDrafts()
+	if err != nil {
+		log.Printf("Error during draft purge: %v", err)
+		return
+	}
+	if purged > 0 {
+		log.Printf("Purged %d expired form drafts", purged)
+	}
+}
@@ -14,6 +14,8 @@ import (
 	"strings"
 	"time"
 	"unicode"
+
+	"DF-PLCH/internal/utils"
 )
 
 type OCRService struct {
@@ -166,26 +168,6 @@ func convertThaiDate(thaiDate string) string {
 	return fmt.Sprintf("%s %s %d", day, engMonth, year)
 }
 
-// validateThaiID validates Thai ID number checksum
-func validateThaiID(id string) bool {
-	// Remove non-digits
-	digits := regexp.MustCompile(`\D`).ReplaceAllString(id, "")
-	if len(digits) != 13 {
-		return false
-	}
-
-	sum := 0
-	for i := 0; i < 12; i++ {
-		digit, _ := strconv.Atoi(string(digits[i]))
-		sum += digit * (13 - i)
-	}
-
-	checksum := (11 - (sum % 11)) % 10
-	lastDigit, _ := strconv.Atoi(string(digits[12]))
-
-	return checksum == lastDigit
-}
-
 // GeminiRequest is the request structure for Gemini API
 type GeminiRequest struct {
 	Contents []GeminiContent `json:"contents"`
@@ -335,7 +317,7 @@ func (s *OCRService) parseExtractedText(rawText string) *OCRResult {
 		cleanID := regexp.MustCompile(`\D`).ReplaceAllString(match, "")
 		result.ExtractedData["id_number"] = cleanID
 		score += 25
-		if validateThaiID(cleanID) {
+		if utils.ValidThaiID(cleanID) {
 			result.ExtractedData["id_valid"] = "true"
 			score += 10
 		}
@@ -996,7 +978,7 @@ func (s *OCRService) parseTyphoonText(rawText string) *TyphoonExtractedData {
 	if match := idPattern.FindString(normalizedText); match != "" {
 		cleanID := regexp.MustCompile(`\D`).ReplaceAllString(match, "")
 		data.IDNumber = cleanID
-		data.IDValid = validateThaiID(cleanID)
+		data.IDValid = utils.ValidThaiID(cleanID)
 	}
 
 	// Extract Thai name with prefix
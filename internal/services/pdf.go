@@ -114,6 +114,46 @@ func (s *PDFService) ConvertDocxToPDFToFileWithOrientation(ctx context.Context,
 	return nil
 }
 
+// FlattenPDFToFile flattens the form fields and annotations of a PDF into its page content
+// Field appearances are regenerated first, so values filled with /NeedAppearances are kept
+func (s *PDFService) FlattenPDFToFile(ctx context.Context, pdfPath string, outputPath string) error {
+	doc, err := document.FromPath("document.pdf", pdfPath)
+	if err != nil {
+		return fmt.Errorf("failed to create document from path: %w", err)
+	}
+
+	flattenCtx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	if err := s.client.Store(flattenCtx, gotenberg.NewFlattenRequest(doc), outputPath); err != nil {
+		return fmt.Errorf("failed to store flattened document: %w", err)
+	}
+
+	return nil
+}
+
+// MergePDFsToFile merges PDFs into one file, in the order given
+func (s *PDFService) MergePDFsToFile(ctx context.Context, pdfPaths []string, outputPath string) error {
+	docs := make([]document.Document, 0, len(pdfPaths))
+	for i, pdfPath := range pdfPaths {
+		// Gotenberg merges in alphabetical order of the file names
+		doc, err := document.FromPath(fmt.Sprintf("%06d.pdf", i), pdfPath)
+		if err != nil {
+			return fmt.Errorf("failed to create document from path: %w", err)
+		}
+		docs = append(docs, doc)
+	}
+
+	mergeCtx, cancel := context.WithTimeout(ctx, s.timeout*time.Duration(1+len(pdfPaths)/50))
+	defer cancel()
+
+	if err := s.client.Store(mergeCtx, gotenberg.NewMergeRequest(docs...), outputPath); err != nil {
+		return fmt.Errorf("failed to store merged document: %w", err)
+	}
+
+	return nil
+}
+
 func (s *PDFService) GetClient() *gotenberg.Client {
 	return s.client
 }
@@ -0,0 +1,201 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+
+	"DF-PLCH/internal/models"
+	"DF-PLCH/internal/processor"
+)
+
+// Fraction of the page height treated as header/footer area when matching PDF boxes
+const headerFooterZone = 0.15
+
+// PlaceholderLocationMap is where each placeholder of a template lands in the DOCX and PDF preview
+type PlaceholderLocationMap struct {
+	TemplateID string                          `json:"template_id"`
+	Locations  []processor.PlaceholderLocation `json:"locations"`
+	FieldOrder []string                        `json:"field_order"` // Unique placeholders in layout order (header, body, footer)
+	PDFLayout  bool                            `json:"pdf_layout"`  // Whether PDF bounding boxes were resolved
+}
+
+// GetPlaceholderLocations locates every placeholder occurrence in a template's DOCX
+// With withPDF, occurrences are matched to bounding boxes in the stored PDF preview
+// (requires poppler's pdftotext; skipped otherwise)
+func (s *TemplateService) GetPlaceholderLocations(ctx context.Context, templateID string, withPDF bool) (*PlaceholderLocationMap, error) {
+	template, err := s.GetTemplate(templateID)
+	if err != nil {
+		return nil, err
+	}
+	if !template.IsDocx() {
+		return nil, ErrDocxOnly
+	}
+
+	reader, err := s.storageClient.ReadFile(ctx, template.GCSPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read template from storage: %w", err)
+	}
+	tempFile, err := s.createTempFile(reader)
+	reader.Close()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temp file: %w", err)
+	}
+	defer s.cleanupTempFile(tempFile)
+
+	proc := processor.NewDocxProcessor(tempFile, "")
+	if err := proc.UnzipDocx(); err != nil {
+		return nil, fmt.Errorf("failed to unzip document: %w", err)
+	}
+	defer proc.Cleanup()
+
+	locations, err := proc.LocatePlaceholders()
+	if err != nil {
+		return nil, fmt.Errorf("failed to locate placeholders: %w", err)
+	}
+
+	result := &PlaceholderLocationMap{TemplateID: templateID, Locations: locations}
+	if withPDF {
+		result.PDFLayout = s.attachPDFBoxes(ctx, template, locations)
+	}
+	if result.Locations == nil {
+		result.Locations = []processor.PlaceholderLocation{}
+	}
+	result.FieldOrder = layoutFieldOrder(locations)
+	return result, nil
+}
+
+// attachPDFBoxes matches DOCX occurrences to boxes in the template's PDF preview
+// Returns false when the PDF or pdftotext is unavailable
+func (s *TemplateService) attachPDFBoxes(ctx context.Context, template *models.Template, locations []processor.PlaceholderLocation) bool {
+	if template.GCSPathPDF == "" || !processor.IsPDFTextLayoutAvailable() {
+		return false
+	}
+
+	reader, err := s.storageClient.ReadFile(ctx, template.GCSPathPDF)
+	if err != nil {
+		fmt.Printf("[WARNING] Failed to read PDF preview for placeholder locations: %v\n", err)
+		return false
+	}
+	tempPDF, err := s.createTempFile(reader)
+	reader.Close()
+	if err != nil {
+		fmt.Printf("[WARNING] Failed to create temp PDF file: %v\n", err)
+		return false
+	}
+	defer s.cleanupTempFile(tempPDF)
+
+	boxes, err := processor.LocatePlaceholdersInPDF(tempPDF)
+	if err != nil {
+		fmt.Printf("[WARNING] Failed to locate placeholders in PDF preview: %v\n", err)
+		return false
+	}
+
+	// Group occurrences by token and part, in document order
+	type occurrences struct {
+		body, headerFooter []int
+	}
+	byToken := make(map[string]*occurrences)
+	for i := range locations {
+		token := processor.NormalizePlaceholderToken(locations[i].Placeholder)
+		if byToken[token] == nil {
+			byToken[token] = &occurrences{}
+		}
+		if locations[i].Part == processor.PartBody {
+			byToken[token].body = append(byToken[token].body, i)
+		} else {
+			byToken[token].headerFooter = append(byToken[token].headerFooter, i)
+		}
+	}
+
+	for token, occ := range byToken {
+		bodyBoxes := boxes[token]
+		var headerFooterBoxes []processor.PDFBox
+
+		// Tokens also in headers/footers: split boxes by their position on the page
+		if len(occ.headerFooter) > 0 {
+			bodyBoxes = nil
+			for _, box := range boxes[token] {
+				inMargin := box.PageHeight > 0 && (box.Y1 < box.PageHeight*headerFooterZone || box.Y0 > box.PageHeight*(1-headerFooterZone))
+				if inMargin || len(occ.body) == 0 {
+					headerFooterBoxes = append(headerFooterBoxes, box)
+				} else {
+					bodyBoxes = append(bodyBoxes, box)
+				}
+			}
+		}
+
+		for i, index := range occ.body {
+			if i >= len(bodyBoxes) {
+				break
+			}
+			locations[index].PDFBoxes = []processor.PDFBox{bodyBoxes[i]}
+			locations[index].Page = bodyBoxes[i].Page
+			locations[index].PageEstimated = false
+		}
+		for _, index := range occ.headerFooter {
+			for _, box := range headerFooterBoxes {
+				if locations[index].Part == processor.PartHeader && box.Y1 > box.PageHeight/2 {
+					continue
+				}
+				if locations[index].Part == processor.PartFooter && box.Y0 < box.PageHeight/2 {
+					continue
+				}
+				locations[index].PDFBoxes = append(locations[index].PDFBoxes, box)
+			}
+		}
+	}
+
+	return true
+}
+
+// layoutFieldOrder lists unique placeholders in layout order: headers, then body, then footers
+// Body occurrences are ordered by PDF position (page, line, x) when every one has a box,
+// otherwise by document order
+func layoutFieldOrder(locations []processor.PlaceholderLocation) []string {
+	partRank := map[string]int{processor.PartHeader: 0, processor.PartBody: 1, processor.PartFooter: 2}
+
+	allBoxed := true
+	for _, location := range locations {
+		if location.Part == processor.PartBody && len(location.PDFBoxes) == 0 {
+			allBoxed = false
+			break
+		}
+	}
+
+	ordered := make([]int, len(locations))
+	for i := range ordered {
+		ordered[i] = i
+	}
+	sort.SliceStable(ordered, func(i, j int) bool {
+		a, b := locations[ordered[i]], locations[ordered[j]]
+		if partRank[a.Part] != partRank[b.Part] {
+			return partRank[a.Part] < partRank[b.Part]
+		}
+		if a.Part != processor.PartBody || !allBoxed {
+			return false // Keep document order
+		}
+		boxA, boxB := a.PDFBoxes[0], b.PDFBoxes[0]
+		if boxA.Page != boxB.Page {
+			return boxA.Page < boxB.Page
+		}
+		// Words on the same line have slightly different tops; compare by 4pt line bands
+		lineA, lineB := math.Round(boxA.Y0/4), math.Round(boxB.Y0/4)
+		if lineA != lineB {
+			return lineA < lineB
+		}
+		return boxA.X0 < boxB.X0
+	})
+
+	seen := make(map[string]bool)
+	order := []string{}
+	for _, index := range ordered {
+		placeholder := locations[index].Placeholder
+		if !seen[placeholder] {
+			seen[placeholder] = true
+			order = append(order, placeholder)
+		}
+	}
+	return order
+}
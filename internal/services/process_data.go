@@ -0,0 +1,184 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"DF-PLCH/internal/models"
+	"DF-PLCH/internal/utils"
+)
+
+// ErrInvalidProcessData is returned for request data that cannot be resolved to placeholders
+var ErrInvalidProcessData = errors.New("invalid process data")
+
+// entityKeyPrefixes are the placeholder key prefixes of entities (see utils.DetectFieldType)
+var entityKeyPrefixes = map[utils.Entity]string{
+	utils.EntityMother:    "m_",
+	utils.EntityFather:    "f_",
+	utils.EntityInformant: "b_",
+	utils.EntityRegistrar: "r_",
+}
+
+// ResolveProcessData converts /process request data to values keyed by "{{placeholder}}"
+//
+// Top-level keys may be a placeholder ("{{m_first_name}}"), its key ("m_first_name") or its alias,
+// or the key of a merged field or radio group (resolved to "{{key}}" for ExpandFieldValues).
+// An object value nests fields by entity or group, e.g. {"mother": {"first_name": "..."}}; inside it
+// a field may also be named by its key without the entity or group prefix.
+// Names are matched case-insensitively. Names that match nothing are kept as given (nested ones as
+// "mother.unknown") so the processing mode can reject or ignore them.
+func ResolveProcessData(template *models.Template, data map[string]interface{}) (map[string]string, error) {
+	var placeholders []string
+	if err := json.Unmarshal([]byte(template.Placeholders), &placeholders); err != nil {
+		return nil, fmt.Errorf("failed to parse template placeholders: %w", err)
+	}
+	definitions := parseFieldDefinitions(template.FieldDefinitions)
+	lookup, masters := processDataLookup(template, placeholders, definitions)
+	sections := processDataSections(definitions, append(append([]string{}, placeholders...), masters...), lookup)
+
+	resolved := make(map[string]string, len(data))
+	sources := make(map[string]string, len(data))
+	set := func(name, placeholder string, raw interface{}) error {
+		value, err := processDataValue(name, raw)
+		if err != nil {
+			return err
+		}
+		if previous, duplicate := sources[placeholder]; duplicate {
+			return fmt.Errorf("%w: '%s' and '%s' both set %s", ErrInvalidProcessData, previous, name, placeholder)
+		}
+		sources[placeholder] = name
+		resolved[placeholder] = value
+		return nil
+	}
+
+	// Sorted so duplicate errors are stable
+	names := make([]string, 0, len(data))
+	for name := range data {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		raw := data[name]
+		if nested, ok := raw.(map[string]interface{}); ok {
+			section, ok := sections[strings.ToLower(strings.TrimSpace(name))]
+			if !ok {
+				return nil, fmt.Errorf("%w: '%s' is not an entity or group of this template", ErrInvalidProcessData, name)
+			}
+			fieldNames := make([]string, 0, len(nested))
+			for fieldName := range nested {
+				fieldNames = append(fieldNames, fieldName)
+			}
+			sort.Strings(fieldNames)
+			for _, fieldName := range fieldNames {
+				path := name + "." + fieldName
+				placeholder, ok := section[strings.ToLower(placeholderKey(fieldName))]
+				if !ok {
+					placeholder = path
+				}
+				if err := set(path, placeholder, nested[fieldName]); err != nil {
+					return nil, err
+				}
+			}
+			continue
+		}
+
+		placeholder := name
+		if !containsString(placeholders, name) {
+			if match, ok := lookup[strings.ToLower(placeholderKey(name))]; ok {
+				placeholder = match
+			}
+		}
+		if err := set(name, placeholder, raw); err != nil {
+			return nil, err
+		}
+	}
+
+	return resolved, nil
+}
+
+// processDataLookup maps lower-cased keys, aliases and merged field/radio group keys to
+// placeholders ("{{key}}"); it also returns the merged field and radio group masters
+func processDataLookup(template *models.Template, placeholders []string, definitions map[string]utils.FieldDefinition) (map[string]string, []string) {
+	lookup := batchPlaceholderLookup(template, placeholders)
+
+	// Merged fields and radio groups are named by their key and expanded by ExpandFieldValues
+	masters := virtualFieldPlaceholders(definitions)
+	for _, master := range masters {
+		if _, taken := lookup[strings.ToLower(placeholderKey(master))]; !taken {
+			lookup[strings.ToLower(placeholderKey(master))] = master
+		}
+	}
+	return lookup, masters
+}
+
+// processDataSections maps lower-cased entity and group names to the names of their fields
+func processDataSections(definitions map[string]utils.FieldDefinition, placeholders []string, lookup map[string]string) map[string]map[string]string {
+	sections := make(map[string]map[string]string)
+	add := func(section, name, placeholder string) {
+		if section == "" || name == "" {
+			return
+		}
+		if sections[section] == nil {
+			sections[section] = make(map[string]string)
+		}
+		// The first field keeps an ambiguous short name
+		if _, taken := sections[section][name]; !taken {
+			sections[section][name] = placeholder
+		}
+	}
+
+	// Aliases of each placeholder
+	aliasesOf := make(map[string][]string)
+	for name, placeholder := range lookup {
+		if name != strings.ToLower(placeholderKey(placeholder)) {
+			aliasesOf[placeholder] = append(aliasesOf[placeholder], name)
+		}
+	}
+
+	for _, placeholder := range placeholders {
+		key := placeholderKey(placeholder)
+		definition, ok := definitions[key]
+		if !ok {
+			definition = utils.DetectFieldType(placeholder)
+		}
+		lowerKey := strings.ToLower(key)
+
+		entity := strings.ToLower(string(definition.Entity))
+		group := strings.ToLower(definition.Group)
+		for _, section := range []string{entity, group} {
+			add(section, lowerKey, placeholder)
+			for _, alias := range aliasesOf[placeholder] {
+				add(section, alias, placeholder)
+			}
+		}
+		if prefix, ok := entityKeyPrefixes[definition.Entity]; ok {
+			add(entity, strings.TrimPrefix(lowerKey, prefix), placeholder)
+		}
+		// Prefix groups are named "prefix_<p>" for keys "<p>_..."
+		if strings.HasPrefix(group, "prefix_") {
+			add(group, strings.TrimPrefix(lowerKey, strings.TrimPrefix(group, "prefix_")+"_"), placeholder)
+		}
+	}
+	return sections
+}
+
+// processDataValue converts a JSON value to the string placed in the document
+func processDataValue(name string, raw interface{}) (string, error) {
+	switch value := raw.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return value, nil
+	case float64:
+		return strconv.FormatFloat(value, 'f', -1, 64), nil
+	case json.Number:
+		return value.String(), nil
+	default:
+		return "", fmt.Errorf("%w: '%s' must be a string or number", ErrInvalidProcessData, name)
+	}
+}
@@ -0,0 +1,65 @@
+package services
+
+import (
+	"time"
+
+	"DF-PLCH/internal/models"
+)
+
+// PreparedProcessData is request data run through the processing pipeline
+// When Missing, Extra or FieldErrors is set the data must not be processed
+type PreparedProcessData struct {
+	Data        map[string]string // Values keyed by "{{placeholder}}", computed fields included
+	Warnings    []string          // Values that were expanded, filled in, ignored or not computed
+	Missing     []string          // Placeholders the processing mode could not fill
+	Extra       []string          // Unknown keys the processing mode does not allow
+	FieldErrors []FieldError      // Values that break their field's rules or data type
+}
+
+// Rejected reports whether the data failed the processing mode or field validation
+func (p *PreparedProcessData) Rejected() bool {
+	return len(p.Missing) > 0 || len(p.Extra) > 0 || len(p.FieldErrors) > 0
+}
+
+// PrepareProcessData turns request data into the values a document is generated from, the same
+// way for every entry point (/process, batches, ...):
+// ResolveProcessData, ExpandFieldValues, ApplyProcessingMode, ComputeFieldValues, ValidateDocumentData
+//
+// Errors wrap ErrInvalidProcessData or ErrInvalidProcessingMode for bad input; any other error means
+// the template could not be read. Field validation messages are in lang
+func PrepareProcessData(template *models.Template, data map[string]interface{}, mode, lang string, now time.Time) (*PreparedProcessData, error) {
+	// Resolve aliases and entity/group nesting to placeholders
+	resolved, err := ResolveProcessData(template, data)
+	if err != nil {
+		return nil, err
+	}
+
+	// Split merged field values and fill radio group options
+	expanded, expandWarnings, err := ExpandFieldValues(template, resolved)
+	if err != nil {
+		return nil, err
+	}
+
+	// Check the data against the template placeholders, filling in or ignoring keys per mode
+	modeResult, err := ApplyProcessingMode(template, expanded, mode)
+	if err != nil {
+		return nil, err
+	}
+	prepared := &PreparedProcessData{
+		Warnings: append(expandWarnings, modeResult.Warnings...),
+		Missing:  modeResult.Missing,
+		Extra:    modeResult.Extra,
+	}
+	if prepared.Rejected() {
+		return prepared, nil
+	}
+
+	// Fill computed fields; their values are stored with the document for regeneration
+	computed, computeWarnings := ComputeFieldValues(template, modeResult.Data, now)
+	prepared.Data = computed
+	prepared.Warnings = append(prepared.Warnings, computeWarnings...)
+
+	// Validate each value against its field definition and data type
+	prepared.FieldErrors = ValidateDocumentData(template, computed, lang)
+	return prepared, nil
+}
@@ -0,0 +1,97 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+
+	"DF-PLCH/internal/models"
+)
+
+// Processing modes for /process requests
+const (
+	ProcessingModeStrict   = "strict"   // Every placeholder must be sent and no other keys (default)
+	ProcessingModeDefaults = "defaults" // Missing fields with a default value get it; other mismatches are rejected
+	ProcessingModeLenient  = "lenient"  // Missing fields get their default or blank unless required; unknown keys are ignored
+)
+
+// ErrInvalidProcessingMode is returned for a mode other than strict, defaults or lenient
+var ErrInvalidProcessingMode = errors.New("invalid processing mode")
+
+// ProcessingModeResult is request data after applying a processing mode
+type ProcessingModeResult struct {
+	Data     map[string]string // Data to process, keyed by "{{placeholder}}"
+	Missing  []string          // Placeholders the mode could not fill
+	Extra    []string          // Unknown keys the mode does not allow
+	Warnings []string          // Fields that were filled in or ignored
+}
+
+// ApplyProcessingMode checks request data against the template's placeholders and, depending on
+// mode, fills missing fields from their field definitions and drops unknown keys
+// The request data is not modified
+func ApplyProcessingMode(template *models.Template, data map[string]string, mode string) (*ProcessingModeResult, error) {
+	if mode == "" {
+		mode = ProcessingModeStrict
+	}
+	if mode != ProcessingModeStrict && mode != ProcessingModeDefaults && mode != ProcessingModeLenient {
+		return nil, fmt.Errorf("%w: %q (use strict, defaults or lenient)", ErrInvalidProcessingMode, mode)
+	}
+
+	var placeholders []string
+	if err := json.Unmarshal([]byte(template.Placeholders), &placeholders); err != nil {
+		return nil, fmt.Errorf("failed to parse template placeholders: %w", err)
+	}
+	definitions := parseFieldDefinitions(template.FieldDefinitions)
+
+	result := &ProcessingModeResult{Data: make(map[string]string, len(placeholders))}
+	placeholderSet := make(map[string]bool, len(placeholders))
+	for _, placeholder := range placeholders {
+		placeholderSet[placeholder] = true
+
+		if value, exists := data[placeholder]; exists {
+			result.Data[placeholder] = value
+			continue
+		}
+		// Computed fields are filled by ComputeFieldValues
+		if isComputedField(definitions, placeholderKey(placeholder)) {
+			continue
+		}
+		if mode == ProcessingModeStrict {
+			result.Missing = append(result.Missing, placeholder)
+			continue
+		}
+
+		definition := definitions[placeholderKey(placeholder)]
+		required := definition.Validation != nil && definition.Validation.Required
+		switch {
+		case definition.DefaultValue != "":
+			result.Data[placeholder] = definition.DefaultValue
+			result.Warnings = append(result.Warnings, fmt.Sprintf("%s was not sent, used its default value %q", placeholder, definition.DefaultValue))
+		case mode == ProcessingModeLenient && !required:
+			result.Data[placeholder] = ""
+			result.Warnings = append(result.Warnings, fmt.Sprintf("%s was not sent, left blank", placeholder))
+		default:
+			result.Missing = append(result.Missing, placeholder)
+		}
+	}
+
+	var ignored []string
+	for key := range data {
+		if placeholderSet[key] {
+			continue
+		}
+		if mode == ProcessingModeLenient {
+			ignored = append(ignored, key)
+		} else {
+			result.Extra = append(result.Extra, key)
+		}
+	}
+	sort.Strings(ignored)
+	sort.Strings(result.Extra)
+	for _, key := range ignored {
+		result.Warnings = append(result.Warnings, fmt.Sprintf("%s is not a placeholder of this template, ignored", key))
+	}
+
+	return result, nil
+}
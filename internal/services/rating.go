@@ -0,0 +1,303 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"DF-PLCH/internal"
+	"DF-PLCH/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrInvalidFeedback is returned when a rating or feedback entry fails validation
+var ErrInvalidFeedback = errors.New("invalid feedback")
+
+const maxFeedbackLength = 2000
+
+// ReportedTemplate is a template in the moderation queue
+type ReportedTemplate struct {
+	Template       models.Template
+	OpenReports    int
+	LatestReportAt time.Time
+	Reports        []models.TemplateFeedback
+}
+
+// RatingService manages template ratings, feedback and issue reports
+type RatingService struct{}
+
+func NewRatingService() *RatingService {
+	return &RatingService{}
+}
+
+// RateTemplate creates or replaces a user's rating of a community template
+// The template's rating aggregates are refreshed in the same transaction
+func (s *RatingService) RateTemplate(templateID, userID string, stars int, comment string) (*models.TemplateRating, error) {
+	if stars < models.MinTemplateRating || stars > models.MaxTemplateRating {
+		return nil, fmt.Errorf("%w: rating must be between %d and %d", ErrInvalidFeedback, models.MinTemplateRating, models.MaxTemplateRating)
+	}
+	comment = strings.TrimSpace(comment)
+	if utf8.RuneCountInString(comment) > maxFeedbackLength {
+		return nil, fmt.Errorf("%w: comment must be at most %d characters", ErrInvalidFeedback, maxFeedbackLength)
+	}
+
+	var template models.Template
+	if err := internal.DB.Select("id", "type").First(&template, "id = ?", templateID).Error; err != nil {
+		return nil, fmt.Errorf("template not found: %w", err)
+	}
+	if template.Type != models.TypeCommunity {
+		return nil, fmt.Errorf("%w: only community templates can be rated", ErrInvalidFeedback)
+	}
+
+	rating := models.TemplateRating{
+		ID:         uuid.New().String(),
+		TemplateID: templateID,
+		UserID:     userID,
+		Rating:     stars,
+		Comment:    comment,
+	}
+	err := internal.DB.Transaction(func(tx *gorm.DB) error {
+		// Upsert so that concurrent first ratings by the same user cannot collide
+		err := tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "template_id"}, {Name: "user_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"rating", "comment", "updated_at"}),
+		}).Create(&rating).Error
+		if err != nil {
+			return err
+		}
+		// Reload to return the stored row's ID and creation time when it already existed
+		var stored models.TemplateRating
+		if err := tx.Where("template_id = ? AND user_id = ?", templateID, userID).First(&stored).Error; err != nil {
+			return err
+		}
+		rating = stored
+		return refreshRatingAggregates(tx, templateID)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to save rating: %w", err)
+	}
+	return &rating, nil
+}
+
+// DeleteRating removes a user's rating of a template
+func (s *RatingService) DeleteRating(templateID, userID string) error {
+	return internal.DB.Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("template_id = ? AND user_id = ?", templateID, userID).Delete(&models.TemplateRating{})
+		if result.Error != nil {
+			return fmt.Errorf("failed to delete rating: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return fmt.Errorf("rating not found")
+		}
+		return refreshRatingAggregates(tx, templateID)
+	})
+}
+
+// refreshRatingAggregates recomputes the denormalized rating average and count of a template
+func refreshRatingAggregates(tx *gorm.DB, templateID string) error {
+	return tx.Exec(`
+		UPDATE document_templates SET
+			rating_average = COALESCE((SELECT AVG(rating) FROM template_ratings WHERE template_id = ?), 0),
+			rating_count = (SELECT COUNT(*) FROM template_ratings WHERE template_id = ?)
+		WHERE id = ?`, templateID, templateID, templateID).Error
+}
+
+// GetRatings returns a template's rating summary and its most recent ratings
+func (s *RatingService) GetRatings(templateID string, limit int) (*models.RatingSummary, []models.TemplateRating, error) {
+	var count int64
+	if err := internal.DB.Model(&models.Template{}).Where("id = ?", templateID).Count(&count).Error; err != nil {
+		return nil, nil, fmt.Errorf("failed to verify template: %w", err)
+	}
+	if count == 0 {
+		return nil, nil, fmt.Errorf("template not found")
+	}
+
+	type bucket struct {
+		Rating int
+		Count  int
+	}
+	var buckets []bucket
+	if err := internal.DB.Model(&models.TemplateRating{}).
+		Select("rating, COUNT(*) AS count").
+		Where("template_id = ?", templateID).
+		Group("rating").
+		Scan(&buckets).Error; err != nil {
+		return nil, nil, fmt.Errorf("failed to get rating summary: %w", err)
+	}
+
+	summary := &models.RatingSummary{Distribution: make(map[int]int)}
+	for stars := models.MinTemplateRating; stars <= models.MaxTemplateRating; stars++ {
+		summary.Distribution[stars] = 0
+	}
+	total := 0
+	for _, b := range buckets {
+		summary.Distribution[b.Rating] = b.Count
+		summary.Count += b.Count
+		total += b.Rating * b.Count
+	}
+	if summary.Count > 0 {
+		summary.Average = float64(total) / float64(summary.Count)
+	}
+
+	query := internal.DB.Where("template_id = ?", templateID).Order("updated_at DESC")
+	if limit > 0 {
+		query = query.Limit(limit)
+	}
+	var ratings []models.TemplateRating
+	if err := query.Find(&ratings).Error; err != nil {
+		return nil, nil, fmt.Errorf("failed to get ratings: %w", err)
+	}
+	return summary, ratings, nil
+}
+
+// SubmitFeedback records text feedback or an issue report on a template
+// Issue reports are queued for moderators until resolved or dismissed
+func (s *RatingService) SubmitFeedback(templateID, userID string, kind models.FeedbackKind, fieldKey, message string) (*models.TemplateFeedback, error) {
+	if kind == "" {
+		kind = models.FeedbackKindGeneral
+	}
+	if kind != models.FeedbackKindGeneral && kind != models.FeedbackKindIssue {
+		return nil, fmt.Errorf("%w: kind must be '%s' or '%s'", ErrInvalidFeedback, models.FeedbackKindGeneral, models.FeedbackKindIssue)
+	}
+	message = strings.TrimSpace(message)
+	if message == "" {
+		return nil, fmt.Errorf("%w: message is required", ErrInvalidFeedback)
+	}
+	if utf8.RuneCountInString(message) > maxFeedbackLength {
+		return nil, fmt.Errorf("%w: message must be at most %d characters", ErrInvalidFeedback, maxFeedbackLength)
+	}
+
+	var template models.Template
+	if err := internal.DB.Select("id", "placeholders").First(&template, "id = ?", templateID).Error; err != nil {
+		return nil, fmt.Errorf("template not found: %w", err)
+	}
+
+	// The field must be one of the template's placeholders
+	fieldKey = placeholderKey(strings.TrimSpace(fieldKey))
+	if fieldKey != "" && !templateHasPlaceholder(&template, fieldKey) {
+		return nil, fmt.Errorf("%w: template has no field '%s'", ErrInvalidFeedback, fieldKey)
+	}
+
+	feedback := &models.TemplateFeedback{
+		ID:         uuid.New().String(),
+		TemplateID: templateID,
+		UserID:     userID,
+		Kind:       kind,
+		FieldKey:   fieldKey,
+		Message:    message,
+		Status:     models.FeedbackStatusOpen,
+	}
+	if err := internal.DB.Create(feedback).Error; err != nil {
+		return nil, fmt.Errorf("failed to save feedback: %w", err)
+	}
+	return feedback, nil
+}
+
+// templateHasPlaceholder reports whether a template uses the placeholder key (without braces)
+func templateHasPlaceholder(template *models.Template, key string) bool {
+	var placeholders []string
+	if template.Placeholders != "" {
+		json.Unmarshal([]byte(template.Placeholders), &placeholders)
+	}
+	for _, placeholder := range placeholders {
+		if placeholderKey(placeholder) == key {
+			return true
+		}
+	}
+	return false
+}
+
+// GetFeedback lists a template's feedback, newest first, optionally filtered by kind and status
+func (s *RatingService) GetFeedback(templateID string, kind models.FeedbackKind, status models.FeedbackStatus) ([]models.TemplateFeedback, error) {
+	query := internal.DB.Where("template_id = ?", templateID)
+	if kind != "" {
+		query = query.Where("kind = ?", kind)
+	}
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var feedback []models.TemplateFeedback
+	if err := query.Order("created_at DESC").Find(&feedback).Error; err != nil {
+		return nil, fmt.Errorf("failed to get feedback: %w", err)
+	}
+	return feedback, nil
+}
+
+// GetModerationQueue returns templates with open issue reports, most reported first
+func (s *RatingService) GetModerationQueue() ([]ReportedTemplate, error) {
+	var reports []models.TemplateFeedback
+	if err := internal.DB.
+		Where("kind = ? AND status = ?", models.FeedbackKindIssue, models.FeedbackStatusOpen).
+		Order("created_at DESC").
+		Find(&reports).Error; err != nil {
+		return nil, fmt.Errorf("failed to get reports: %w", err)
+	}
+
+	byTemplate := make(map[string][]models.TemplateFeedback)
+	var templateIDs []string
+	for _, report := range reports {
+		if _, ok := byTemplate[report.TemplateID]; !ok {
+			templateIDs = append(templateIDs, report.TemplateID)
+		}
+		byTemplate[report.TemplateID] = append(byTemplate[report.TemplateID], report)
+	}
+	if len(templateIDs) == 0 {
+		return []ReportedTemplate{}, nil
+	}
+
+	var templates []models.Template
+	if err := internal.DB.Preload("Tags").Where("id IN ?", templateIDs).Find(&templates).Error; err != nil {
+		return nil, fmt.Errorf("failed to get templates: %w", err)
+	}
+
+	queue := make([]ReportedTemplate, 0, len(templates))
+	for _, template := range templates {
+		templateReports := byTemplate[template.ID]
+		queue = append(queue, ReportedTemplate{
+			Template:       template,
+			OpenReports:    len(templateReports),
+			LatestReportAt: templateReports[0].CreatedAt,
+			Reports:        templateReports,
+		})
+	}
+	sort.Slice(queue, func(i, j int) bool {
+		if queue[i].OpenReports != queue[j].OpenReports {
+			return queue[i].OpenReports > queue[j].OpenReports
+		}
+		return queue[i].LatestReportAt.After(queue[j].LatestReportAt)
+	})
+	return queue, nil
+}
+
+// ModerateFeedback sets the moderation status of a feedback entry
+func (s *RatingService) ModerateFeedback(feedbackID, moderatorID string, status models.FeedbackStatus, note string) (*models.TemplateFeedback, error) {
+	if !status.IsValid() {
+		return nil, fmt.Errorf("%w: status must be '%s', '%s' or '%s'", ErrInvalidFeedback, models.FeedbackStatusOpen, models.FeedbackStatusResolved, models.FeedbackStatusDismissed)
+	}
+
+	var feedback models.TemplateFeedback
+	if err := internal.DB.First(&feedback, "id = ?", feedbackID).Error; err != nil {
+		return nil, fmt.Errorf("feedback not found: %w", err)
+	}
+
+	feedback.Status = status
+	feedback.ModeratorID = moderatorID
+	feedback.ModeratorNote = strings.TrimSpace(note)
+	feedback.ResolvedAt = nil
+	if status != models.FeedbackStatusOpen {
+		now := time.Now()
+		feedback.ResolvedAt = &now
+	}
+	if err := internal.DB.Save(&feedback).Error; err != nil {
+		return nil, fmt.Errorf("failed to update feedback: %w", err)
+	}
+	return &feedback, nil
+}
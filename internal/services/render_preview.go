@@ -0,0 +1,282 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"DF-PLCH/internal/models"
+	"DF-PLCH/internal/processor"
+	"DF-PLCH/internal/utils"
+
+	"github.com/google/uuid"
+)
+
+// Preview formats supported by RenderPreview
+const (
+	PreviewFormatHTML = "html"
+	PreviewFormatPDF  = "pdf"
+	PreviewFormatPNG  = "png"
+)
+
+// RenderedPreview is a filled template rendered in memory
+type RenderedPreview struct {
+	Content      []byte
+	ContentType  string
+	Filename     string
+	SampleFields []string // Placeholders filled with default or synthetic values
+}
+
+// RenderPreview fills a template with the supplied values and renders it as HTML, PDF or PNG
+// Supplied data is resolved and expanded like /process data (aliases, entity nesting, merged
+// fields and radio groups); placeholders without a value get their default or a synthetic sample
+// Nothing is written to storage and no Document is created
+func (s *DocumentService) RenderPreview(ctx context.Context, template *models.Template, data map[string]interface{}, format string, width int) (*RenderedPreview, error) {
+	if format != PreviewFormatHTML && format != PreviewFormatPDF && format != PreviewFormatPNG {
+		return nil, fmt.Errorf("unsupported preview format '%s': expected html, pdf or png", format)
+	}
+
+	resolved := make(map[string]string)
+	if len(data) > 0 {
+		var err error
+		if resolved, err = ResolveProcessData(template, data); err != nil {
+			return nil, err
+		}
+		if resolved, _, err = ExpandFieldValues(template, resolved); err != nil {
+			return nil, err
+		}
+	}
+	values, sampleFields := previewValues(template, resolved, time.Now())
+
+	// Download template
+	reader, err := s.storageClient.ReadFile(ctx, template.GCSPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read template from storage: %w", err)
+	}
+	tempInputFile, err := s.createTempFile(reader)
+	reader.Close()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temp input file: %w", err)
+	}
+	defer s.cleanupTempFile(tempInputFile)
+
+	baseName := strings.TrimSuffix(template.Filename, filepath.Ext(template.Filename)) + "_preview"
+	preview := &RenderedPreview{SampleFields: sampleFields}
+
+	// PDF forms are filled (and flattened when possible so every renderer shows the values)
+	if template.IsPDFForm() {
+		if format == PreviewFormatHTML {
+			return nil, fmt.Errorf("%w: HTML previews of PDF forms are not supported", ErrDocxOnly)
+		}
+		tempPDFFile := filepath.Join(os.TempDir(), "preview_"+uuid.New().String()+".pdf")
+		defer os.Remove(tempPDFFile)
+		if _, _, err := s.fillPDFForm(ctx, tempInputFile, tempPDFFile, values, true); err != nil {
+			return nil, err
+		}
+		pdfContent, err := os.ReadFile(tempPDFFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read filled PDF: %w", err)
+		}
+		return s.finishPDFPreview(ctx, preview, pdfContent, baseName, format, width)
+	}
+
+	if template.IsOverlay() {
+		if format == PreviewFormatHTML {
+			return nil, fmt.Errorf("%w: HTML previews of overlay templates are not supported", ErrDocxOnly)
+		}
+		tempPDFFile := filepath.Join(os.TempDir(), "preview_"+uuid.New().String()+".pdf")
+		defer os.Remove(tempPDFFile)
+		if _, err := renderOverlay(template, tempInputFile, tempPDFFile, values); err != nil {
+			return nil, err
+		}
+		pdfContent, err := os.ReadFile(tempPDFFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read overlay PDF: %w", err)
+		}
+		return s.finishPDFPreview(ctx, preview, pdfContent, baseName, format, width)
+	}
+
+	tempOutputFile := filepath.Join(os.TempDir(), "preview_"+uuid.New().String()+".docx")
+	defer os.Remove(tempOutputFile)
+
+	// Fill placeholders with the same processor as ProcessDocument so the preview matches the output
+	landscape := false
+	if s.useLibreOffice && processor.IsLibreOfficeAvailable() {
+		loProc := processor.NewLibreOfficeProcessor(tempInputFile, tempOutputFile)
+		defer loProc.Cleanup()
+		if err := loProc.ProcessWithPlaceholders(values); err != nil {
+			return nil, fmt.Errorf("failed to process with LibreOffice: %w", err)
+		}
+		if orientation, err := loProc.DetectOrientation(); err == nil {
+			landscape = orientation
+		}
+	} else {
+		proc := processor.NewDocxProcessor(tempInputFile, tempOutputFile)
+		if err := proc.UnzipDocx(); err != nil {
+			return nil, fmt.Errorf("failed to unzip document: %w", err)
+		}
+		defer proc.Cleanup()
+		if err := proc.FindAndReplaceInDocument(values); err != nil {
+			return nil, fmt.Errorf("failed to replace placeholders: %w", err)
+		}
+		if err := proc.ReZipDocx(); err != nil {
+			return nil, fmt.Errorf("failed to create output document: %w", err)
+		}
+		if orientation, err := proc.DetectOrientation(); err == nil {
+			landscape = orientation
+		}
+	}
+
+	if format == PreviewFormatHTML {
+		htmlContent, err := s.renderPreviewHTML(ctx, tempOutputFile)
+		if err != nil {
+			return nil, err
+		}
+		preview.Content = htmlContent
+		preview.ContentType = "text/html; charset=utf-8"
+		preview.Filename = baseName + ".html"
+		return preview, nil
+	}
+
+	pdfContent, err := s.renderPreviewPDF(ctx, tempOutputFile, template.Filename, landscape)
+	if err != nil {
+		return nil, err
+	}
+	return s.finishPDFPreview(ctx, preview, pdfContent, baseName, format, width)
+}
+
+// finishPDFPreview returns a rendered PDF as is, or as a PNG of its first page
+func (s *DocumentService) finishPDFPreview(ctx context.Context, preview *RenderedPreview, pdfContent []byte, baseName, format string, width int) (*RenderedPreview, error) {
+	if format == PreviewFormatPDF {
+		preview.Content = pdfContent
+		preview.ContentType = "application/pdf"
+		preview.Filename = baseName + ".pdf"
+		return preview, nil
+	}
+
+	conversionService := s.templateService.conversionService
+	if conversionService == nil || !conversionService.IsThumbnailGenerationAvailable() {
+		return nil, fmt.Errorf("PNG rendering is not available")
+	}
+	var err error
+	preview.Content, err = conversionService.GenerateThumbnailFromPDFBytesWithQuality(ctx, pdfContent, baseName+".pdf", width, ThumbnailQualityHD)
+	if err != nil {
+		return nil, fmt.Errorf("failed to render PNG: %w", err)
+	}
+	preview.ContentType = "image/png"
+	preview.Filename = baseName + ".png"
+	return preview, nil
+}
+
+// renderPreviewHTML converts a filled DOCX to HTML (remote service first, then local LibreOffice)
+func (s *DocumentService) renderPreviewHTML(ctx context.Context, docxPath string) ([]byte, error) {
+	if conversionService := s.templateService.conversionService; conversionService != nil && conversionService.IsHTMLConversionAvailable() {
+		htmlContent, err := conversionService.ConvertDocxToHTML(ctx, docxPath)
+		if err == nil {
+			return htmlContent, nil
+		}
+		fmt.Printf("[WARNING] Failed to render HTML preview via remote service: %v\n", err)
+	}
+	if processor.IsLibreOfficeAvailable() {
+		htmlContent, err := processor.ConvertToHTMLBytes(docxPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to render HTML: %w", err)
+		}
+		return htmlContent, nil
+	}
+	return nil, fmt.Errorf("HTML rendering is not available")
+}
+
+// renderPreviewPDF converts a filled DOCX to PDF using the same converters as ProcessDocument
+func (s *DocumentService) renderPreviewPDF(ctx context.Context, docxPath, filename string, landscape bool) ([]byte, error) {
+	tempPDFPath := strings.TrimSuffix(docxPath, filepath.Ext(docxPath)) + ".pdf"
+	defer os.Remove(tempPDFPath)
+
+	// Use LibreOffice first if enabled, then Gotenberg
+	if s.useLibreOffice && processor.IsLibreOfficeAvailable() {
+		err := processor.ConvertToPDF(docxPath, tempPDFPath)
+		if err == nil {
+			return os.ReadFile(tempPDFPath)
+		}
+		fmt.Printf("[WARNING] LibreOffice preview conversion failed: %v\n", err)
+	}
+	if s.pdfService != nil {
+		docxFile, err := os.Open(docxPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open filled document: %w", err)
+		}
+		defer docxFile.Close()
+		err = s.pdfService.ConvertDocxToPDFToFileWithOrientation(ctx, docxFile, filename, tempPDFPath, landscape)
+		if err == nil {
+			return os.ReadFile(tempPDFPath)
+		}
+		fmt.Printf("[WARNING] Gotenberg preview conversion failed: %v\n", err)
+	}
+	if conversionService := s.templateService.conversionService; conversionService != nil && conversionService.IsPDFConversionAvailable() {
+		pdfContent, err := conversionService.ConvertDocxToPDF(ctx, docxPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to render PDF: %w", err)
+		}
+		return pdfContent, nil
+	}
+	return nil, fmt.Errorf("PDF rendering is not available")
+}
+
+// previewValues builds the placeholder values for a preview ("{{key}}" -> value)
+// Supplied data may be keyed with or without braces; other placeholders get sample values
+func previewValues(template *models.Template, data map[string]string, now time.Time) (map[string]string, []string) {
+	var placeholders []string
+	if template.Placeholders != "" {
+		json.Unmarshal([]byte(template.Placeholders), &placeholders)
+	}
+	definitions := parseFieldDefinitions(template.FieldDefinitions)
+
+	supplied := make(map[string]string, len(data))
+	for key, value := range data {
+		supplied[placeholderKey(key)] = value
+	}
+
+	// Radio groups: select the first option so exactly one box is ticked
+	radioValues := make(map[string]string)
+	for _, def := range definitions {
+		if !def.IsRadioGroup {
+			continue
+		}
+		for i, option := range def.RadioOptions {
+			value := ""
+			if i == 0 {
+				value = option.Value
+				if value == "" {
+					value = "/"
+				}
+			}
+			radioValues[placeholderKey(option.Placeholder)] = value
+		}
+	}
+
+	values := make(map[string]string, len(placeholders))
+	var sampleFields []string
+	for _, placeholder := range placeholders {
+		key := placeholderKey(placeholder)
+		if value, ok := supplied[key]; ok {
+			values[placeholder] = value
+			continue
+		}
+
+		sampleFields = append(sampleFields, placeholder)
+		if value, ok := radioValues[key]; ok {
+			values[placeholder] = value
+		} else if def, ok := definitions[key]; ok {
+			values[placeholder] = utils.SampleValue(def, now)
+		} else {
+			values[placeholder] = utils.SampleValue(utils.DetectFieldType(placeholder), now)
+		}
+	}
+
+	values, _ = ComputeFieldValues(template, values, now)
+	return values, sampleFields
+}
@@ -0,0 +1,321 @@
+package services
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"DF-PLCH/internal"
+	"DF-PLCH/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type TagService struct{}
+
+func NewTagService() *TagService {
+	return &TagService{}
+}
+
+// CreateTagRequest contains fields for creating a tag
+type CreateTagRequest struct {
+	Slug        string `json:"slug"` // Optional: generated from name_en or name if empty
+	Name        string `json:"name"`
+	NameEN      string `json:"name_en"`
+	Description string `json:"description"`
+	Color       string `json:"color"`
+	SortOrder   int    `json:"sort_order"`
+}
+
+// UpdateTagRequest contains fields for updating a tag
+type UpdateTagRequest struct {
+	Slug        string `json:"slug"`
+	Name        string `json:"name"`
+	NameEN      string `json:"name_en"`
+	Description string `json:"description"`
+	Color       string `json:"color"`
+	SortOrder   *int   `json:"sort_order"`
+}
+
+// TagWithCount is a tag with the number of templates using it
+type TagWithCount struct {
+	models.Tag
+	TemplateCount int64 `json:"template_count"`
+}
+
+var slugInvalidChars = regexp.MustCompile(`[^\p{L}\p{M}\p{N}]+`)
+
+// slugify converts a tag name to a slug (e.g., "Court Filing" -> "court-filing")
+// Thai characters are kept as-is
+func slugify(name string) string {
+	return strings.Trim(slugInvalidChars.ReplaceAllString(strings.ToLower(strings.TrimSpace(name)), "-"), "-")
+}
+
+// Create creates a new tag
+func (s *TagService) Create(req *CreateTagRequest) (*models.Tag, error) {
+	slug := slugify(req.Slug)
+	if slug == "" {
+		slug = slugify(req.NameEN)
+	}
+	if slug == "" {
+		slug = slugify(req.Name)
+	}
+	if slug == "" {
+		return nil, fmt.Errorf("could not derive a slug for tag '%s'", req.Name)
+	}
+
+	// Check if slug already exists
+	var existing models.Tag
+	if err := internal.DB.Where("slug = ?", slug).First(&existing).Error; err == nil {
+		return nil, fmt.Errorf("tag with slug '%s' already exists", slug)
+	}
+
+	tag := &models.Tag{
+		ID:          uuid.New().String(),
+		Slug:        slug,
+		Name:        req.Name,
+		NameEN:      req.NameEN,
+		Description: req.Description,
+		Color:       req.Color,
+		SortOrder:   req.SortOrder,
+	}
+
+	if err := internal.DB.Create(tag).Error; err != nil {
+		return nil, fmt.Errorf("failed to create tag: %w", err)
+	}
+
+	return tag, nil
+}
+
+// GetByID retrieves a tag by ID
+func (s *TagService) GetByID(id string) (*models.Tag, error) {
+	var tag models.Tag
+	if err := internal.DB.First(&tag, "id = ?", id).Error; err != nil {
+		return nil, fmt.Errorf("tag not found: %w", err)
+	}
+	return &tag, nil
+}
+
+// GetAll retrieves all tags with the number of templates using each
+func (s *TagService) GetAll() ([]TagWithCount, error) {
+	var tags []TagWithCount
+	err := internal.DB.Model(&models.Tag{}).
+		Select("tags.*, COUNT(document_templates.id) AS template_count").
+		Joins("LEFT JOIN template_tags ON template_tags.tag_id = tags.id").
+		Joins("LEFT JOIN document_templates ON document_templates.id = template_tags.template_id AND document_templates.deleted_at IS NULL").
+		Group("tags.id").
+		Order("tags.sort_order ASC, tags.name ASC").
+		Scan(&tags).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tags: %w", err)
+	}
+	return tags, nil
+}
+
+// Update updates an existing tag
+func (s *TagService) Update(id string, req *UpdateTagRequest) (*models.Tag, error) {
+	tag, err := s.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	// Check if new slug conflicts with existing one (if slug is being changed)
+	if slug := slugify(req.Slug); slug != "" && slug != tag.Slug {
+		var existing models.Tag
+		if err := internal.DB.Where("slug = ? AND id != ?", slug, id).First(&existing).Error; err == nil {
+			return nil, fmt.Errorf("tag with slug '%s' already exists", slug)
+		}
+		tag.Slug = slug
+	}
+
+	// Update fields
+	if req.Name != "" {
+		tag.Name = req.Name
+	}
+	if req.NameEN != "" {
+		tag.NameEN = req.NameEN
+	}
+	if req.Description != "" {
+		tag.Description = req.Description
+	}
+	if req.Color != "" {
+		tag.Color = req.Color
+	}
+	if req.SortOrder != nil {
+		tag.SortOrder = *req.SortOrder
+	}
+
+	if err := internal.DB.Save(tag).Error; err != nil {
+		return nil, fmt.Errorf("failed to update tag: %w", err)
+	}
+
+	// Tag names are indexed with each tagged template
+	for _, templateID := range s.templateIDsForTag(id) {
+		refreshTemplateSearch(templateID)
+	}
+
+	return tag, nil
+}
+
+// Delete soft-deletes a tag and removes it from all templates
+func (s *TagService) Delete(id string) error {
+	tag, err := s.GetByID(id)
+	if err != nil {
+		return err
+	}
+
+	templateIDs := s.templateIDsForTag(id)
+
+	err = internal.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("tag_id = ?", id).Delete(&models.TemplateTag{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(tag).Error
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete tag: %w", err)
+	}
+
+	for _, templateID := range templateIDs {
+		refreshTemplateSearch(templateID)
+	}
+
+	return nil
+}
+
+// GetTemplateTags retrieves the tags attached to a template
+func (s *TagService) GetTemplateTags(templateID string) ([]models.Tag, error) {
+	var tags []models.Tag
+	err := internal.DB.
+		Joins("JOIN template_tags ON template_tags.tag_id = tags.id").
+		Where("template_tags.template_id = ?", templateID).
+		Order("tags.sort_order ASC, tags.name ASC").
+		Find(&tags).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get template tags: %w", err)
+	}
+	return tags, nil
+}
+
+// SetTemplateTags replaces all tags on a template
+func (s *TagService) SetTemplateTags(templateID string, tagIDs []string) ([]models.Tag, error) {
+	if err := s.verifyTemplates([]string{templateID}); err != nil {
+		return nil, err
+	}
+	if err := s.verifyTags(tagIDs); err != nil {
+		return nil, err
+	}
+
+	err := internal.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("template_id = ?", templateID).Delete(&models.TemplateTag{}).Error; err != nil {
+			return err
+		}
+		return createTemplateTags(tx, []string{templateID}, tagIDs)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to set template tags: %w", err)
+	}
+
+	refreshTemplateSearch(templateID)
+
+	return s.GetTemplateTags(templateID)
+}
+
+// BulkTag adds or removes tags on many templates at once
+// action is "add" or "remove"
+func (s *TagService) BulkTag(templateIDs, tagIDs []string, action string) error {
+	if len(templateIDs) == 0 || len(tagIDs) == 0 {
+		return fmt.Errorf("template_ids and tag_ids are required")
+	}
+	if err := s.verifyTemplates(templateIDs); err != nil {
+		return err
+	}
+	if err := s.verifyTags(tagIDs); err != nil {
+		return err
+	}
+
+	var err error
+	switch action {
+	case "add":
+		err = createTemplateTags(internal.DB, templateIDs, tagIDs)
+	case "remove":
+		err = internal.DB.Where("template_id IN ? AND tag_id IN ?", templateIDs, tagIDs).Delete(&models.TemplateTag{}).Error
+	default:
+		return fmt.Errorf("invalid action '%s': expected 'add' or 'remove'", action)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to %s tags: %w", action, err)
+	}
+
+	for _, templateID := range templateIDs {
+		refreshTemplateSearch(templateID)
+	}
+
+	return nil
+}
+
+// createTemplateTags links every template to every tag, ignoring links that already exist
+func createTemplateTags(tx *gorm.DB, templateIDs, tagIDs []string) error {
+	if len(tagIDs) == 0 {
+		return nil
+	}
+
+	now := time.Now()
+	links := make([]models.TemplateTag, 0, len(templateIDs)*len(tagIDs))
+	for _, templateID := range uniqueStrings(templateIDs) {
+		for _, tagID := range uniqueStrings(tagIDs) {
+			links = append(links, models.TemplateTag{TemplateID: templateID, TagID: tagID, CreatedAt: now})
+		}
+	}
+
+	return tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&links).Error
+}
+
+// verifyTemplates checks that all template IDs exist
+func (s *TagService) verifyTemplates(templateIDs []string) error {
+	var count int64
+	if err := internal.DB.Model(&models.Template{}).Where("id IN ?", templateIDs).Count(&count).Error; err != nil {
+		return fmt.Errorf("failed to check templates: %w", err)
+	}
+	if int(count) != len(uniqueStrings(templateIDs)) {
+		return fmt.Errorf("template not found")
+	}
+	return nil
+}
+
+// verifyTags checks that all tag IDs exist
+func (s *TagService) verifyTags(tagIDs []string) error {
+	if len(tagIDs) == 0 {
+		return nil
+	}
+	var count int64
+	if err := internal.DB.Model(&models.Tag{}).Where("id IN ?", tagIDs).Count(&count).Error; err != nil {
+		return fmt.Errorf("failed to check tags: %w", err)
+	}
+	if int(count) != len(uniqueStrings(tagIDs)) {
+		return fmt.Errorf("tag not found")
+	}
+	return nil
+}
+
+// templateIDsForTag returns the IDs of all templates carrying a tag
+func (s *TagService) templateIDsForTag(tagID string) []string {
+	var templateIDs []string
+	internal.DB.Model(&models.TemplateTag{}).Where("tag_id = ?", tagID).Pluck("template_id", &templateIDs)
+	return templateIDs
+}
+
+func uniqueStrings(values []string) []string {
+	seen := make(map[string]bool, len(values))
+	result := make([]string, 0, len(values))
+	for _, v := range values {
+		if !seen[v] {
+			seen[v] = true
+			result = append(result, v)
+		}
+	}
+	return result
+}
@@ -25,6 +25,7 @@ import (
 type TemplateService struct {
 	storageClient     storage.StorageClient
 	conversionService *ConversionService
+	webhookService    *WebhookService // Optional: publishes template.* events
 }
 
 func NewTemplateService(storageClient storage.StorageClient) *TemplateService {
@@ -33,6 +34,18 @@ func NewTemplateService(storageClient storage.StorageClient) *TemplateService {
 	}
 }
 
+// SetWebhookService publishes template.published and template.updated events
+func (s *TemplateService) SetWebhookService(webhookService *WebhookService) {
+	s.webhookService = webhookService
+}
+
+// publishTemplateEvent sends a template event to subscribed webhooks; change says what was updated
+func (s *TemplateService) publishTemplateEvent(event string, template *models.Template, change string) {
+	if s.webhookService != nil {
+		s.webhookService.Publish(event, s.webhookService.TemplateEventData(template, change))
+	}
+}
+
 // SetConversionService sets the conversion service for automatic HTML/PDF generation
 func (s *TemplateService) SetConversionService(convService *ConversionService) {
 	s.conversionService = convService
@@ -347,6 +360,12 @@ func (s *TemplateService) UploadTemplateWithHTMLPreview(ctx context.Context, fil
 		fmt.Printf("[INFO] Detected landscape orientation for template %s\n", templateID)
 	}
 
+	// Extract body text for the search index
+	bodyText, err := proc.ExtractText()
+	if err != nil {
+		fmt.Printf("[WARNING] Failed to extract body text for search: %v\n", err)
+	}
+
 	// Convert placeholders to JSON
 	placeholdersJSON, err := json.Marshal(placeholders)
 	if err != nil {
@@ -378,6 +397,7 @@ func (s *TemplateService) UploadTemplateWithHTMLPreview(ctx context.Context, fil
 		MimeType:         header.Header.Get("Content-Type"),
 		Placeholders:     string(placeholdersJSON),
 		FieldDefinitions: string(fieldDefinitionsJSON),
+		Translations:     "{}",
 		PageOrientation:  pageOrientation,
 	}
 
@@ -399,6 +419,12 @@ func (s *TemplateService) UploadTemplateWithHTMLPreview(ctx context.Context, fil
 		return nil, fmt.Errorf("failed to save template metadata: %w", err)
 	}
 
+	// Index for full-text search (search falls back to name matching if this fails)
+	if err := indexTemplateSearch(template, &bodyText); err != nil {
+		fmt.Printf("[WARNING] %v\n", err)
+	}
+
+	s.publishTemplateEvent(models.WebhookEventTemplatePublished, template, "")
 	return template, nil
 }
 
@@ -410,9 +436,18 @@ func (s *TemplateService) GetTemplate(templateID string) (*models.Template, erro
 	return &template, nil
 }
 
+// GetTemplateUnscoped retrieves a template, including one that is in the trash
+func (s *TemplateService) GetTemplateUnscoped(templateID string) (*models.Template, error) {
+	var template models.Template
+	if err := internal.DB.Unscoped().First(&template, "id = ?", templateID).Error; err != nil {
+		return nil, fmt.Errorf("template not found: %w", err)
+	}
+	return &template, nil
+}
+
 func (s *TemplateService) GetAllTemplates() ([]models.Template, error) {
 	var templates []models.Template
-	if err := internal.DB.Find(&templates).Error; err != nil {
+	if err := internal.DB.Preload("Tags").Find(&templates).Error; err != nil {
 		return nil, fmt.Errorf("failed to get templates: %w", err)
 	}
 	return templates, nil
@@ -420,20 +455,25 @@ func (s *TemplateService) GetAllTemplates() ([]models.Template, error) {
 
 // TemplateFilter represents filter options for querying templates
 type TemplateFilter struct {
-	DocumentTypeID      string // Filter by document type ID
-	Type                string // Filter by template type (official, private, community)
-	Tier                string // Filter by tier (free, basic, premium, enterprise)
-	Category            string // Filter by category
-	IsVerified          *bool  // Filter by verification status
-	Search              string // Search in name, description, author
-	IncludeDocumentType bool   // Whether to preload document type
-	Sort                string // Sort order: "popular" (by usage), "recent" (by created_at), "name" (alphabetical)
-	Limit               int    // Limit number of results (0 = no limit)
+	DocumentTypeID      string   // Filter by document type ID
+	Type                string   // Filter by template type (official, private, community)
+	Tier                string   // Filter by tier (free, basic, premium, enterprise)
+	Category            string   // Filter by category
+	IsVerified          *bool    // Filter by verification status
+	Search              string   // Full-text search in body text, placeholders, aliases, names and document type
+	Tags                []string // Filter by tag slugs
+	MatchAllTags        bool     // Require every tag in Tags (default: any tag)
+	IncludeDocumentType bool     // Whether to preload document type
+	Sort                string   // Sort order: "popular" (by usage), "recent" (by created_at), "name" (alphabetical), "top_rated" (by ratings), "relevance" (default when searching)
+	Limit               int      // Limit number of results (0 = no limit), ignored when PageSize is set
+	PageSize            int      // Page size for cursor pagination (0 = no pagination)
+	Cursor              string   // Cursor returned as NextCursor by the previous page
+	BoostUserID         string   // Default sort only: list this user's recently used templates first
 }
 
 // GetTemplatesWithFilter retrieves templates with filtering options
-func (s *TemplateService) GetTemplatesWithFilter(filter *TemplateFilter) ([]models.Template, error) {
-	var templates []models.Template
+// Ordering is total (ties broken by ID) for every sort, so cursor pages never skip or repeat templates
+func (s *TemplateService) GetTemplatesWithFilter(filter *TemplateFilter) (*TemplatePage, error) {
 	query := internal.DB.Model(&models.Template{})
 
 	// Apply filters
@@ -452,43 +492,69 @@ func (s *TemplateService) GetTemplatesWithFilter(filter *TemplateFilter) ([]mode
 	if filter.IsVerified != nil {
 		query = query.Where("is_verified = ?", *filter.IsVerified)
 	}
+	if len(filter.Tags) > 0 {
+		tagSubquery := internal.DB.Table("template_tags").
+			Select("template_tags.template_id").
+			Joins("JOIN tags ON tags.id = template_tags.tag_id AND tags.deleted_at IS NULL").
+			Where("tags.slug IN ?", filter.Tags)
+		if filter.MatchAllTags {
+			tagSubquery = tagSubquery.Group("template_tags.template_id").Having("COUNT(DISTINCT tags.slug) = ?", len(uniqueStrings(filter.Tags)))
+		}
+		query = query.Where("document_templates.id IN (?)", tagSubquery)
+	}
 	if filter.Search != "" {
-		searchPattern := "%" + filter.Search + "%"
-		query = query.Where("display_name ILIKE ? OR description ILIKE ? OR author ILIKE ?", searchPattern, searchPattern, searchPattern)
+		query = applyTemplateSearch(query, filter.Search)
+	}
+
+	// Filtered query is shared by the count and the page query
+	query = query.Session(&gorm.Session{})
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, fmt.Errorf("failed to count templates: %w", err)
+	}
+
+	mode, keys := templateSortKeys(filter)
+	pageQuery := applyTemplateSortJoins(query.Select("document_templates.*"), mode, false)
+
+	if filter.Cursor != "" {
+		values, err := decodeTemplateCursor(filter.Cursor, mode, len(keys))
+		if err != nil {
+			return nil, err
+		}
+		condition, vars := templateKeysetCondition(keys, values)
+		pageQuery = pageQuery.Where(condition, vars...)
 	}
 
 	// Preload document type if requested
 	if filter.IncludeDocumentType {
-		query = query.Preload("DocumentType")
+		pageQuery = pageQuery.Preload("DocumentType")
 	}
+	pageQuery = pageQuery.Preload("Tags").Order(templateOrderBy(keys))
 
-	// Apply sorting
-	switch filter.Sort {
-	case "popular":
-		// Sort by actual document count - most used first
-		// Uses documents table which has real records of generated documents
-		query = query.
-			Joins("LEFT JOIN (SELECT template_id, COUNT(*) as doc_count FROM documents WHERE deleted_at IS NULL GROUP BY template_id) doc_counts ON doc_counts.template_id = id").
-			Order("COALESCE(doc_counts.doc_count, 0) DESC, created_at DESC")
-	case "name":
-		query = query.Order("COALESCE(NULLIF(display_name, ''), filename) ASC")
-	case "recent":
-		query = query.Order("created_at DESC")
-	default:
-		// Default: order by variant_order within document type, then by created_at
-		query = query.Order("document_type_id ASC, variant_order ASC, created_at DESC")
+	// Fetch one extra row to know whether there is a next page
+	if filter.PageSize > 0 {
+		pageQuery = pageQuery.Limit(filter.PageSize + 1)
+	} else if filter.Limit > 0 {
+		pageQuery = pageQuery.Limit(filter.Limit)
 	}
 
-	// Apply limit if specified
-	if filter.Limit > 0 {
-		query = query.Limit(filter.Limit)
+	var templates []models.Template
+	if err := pageQuery.Find(&templates).Error; err != nil {
+		return nil, fmt.Errorf("failed to get templates: %w", err)
 	}
 
-	if err := query.Find(&templates).Error; err != nil {
-		return nil, fmt.Errorf("failed to get templates: %w", err)
+	page := &TemplatePage{Templates: templates, Total: total}
+	if filter.PageSize > 0 && len(templates) > filter.PageSize {
+		page.Templates = templates[:filter.PageSize]
+		values, err := templateCursorValues(page.Templates[filter.PageSize-1].ID, mode, keys)
+		if err != nil {
+			return nil, err
+		}
+		page.NextCursor = encodeTemplateCursor(mode, values)
 	}
 
-	return templates, nil
+	return page, nil
 }
 
 // GetTemplatesGroupedByDocumentType retrieves all templates grouped by their document type
@@ -497,13 +563,13 @@ func (s *TemplateService) GetTemplatesGroupedByDocumentType() ([]models.Document
 	var documentTypes []models.DocumentType
 	if err := internal.DB.Preload("Templates", func(db *gorm.DB) *gorm.DB {
 		return db.Order("variant_order ASC")
-	}).Order("sort_order ASC, name ASC").Find(&documentTypes).Error; err != nil {
+	}).Preload("Templates.Tags").Order("sort_order ASC, name ASC").Find(&documentTypes).Error; err != nil {
 		return nil, nil, fmt.Errorf("failed to get document types: %w", err)
 	}
 
 	// Get orphan templates (templates without a document type)
 	var orphanTemplates []models.Template
-	if err := internal.DB.Where("document_type_id IS NULL OR document_type_id = ''").Order("created_at DESC").Find(&orphanTemplates).Error; err != nil {
+	if err := internal.DB.Preload("Tags").Where("document_type_id IS NULL OR document_type_id = ''").Order("created_at DESC").Find(&orphanTemplates).Error; err != nil {
 		return nil, nil, fmt.Errorf("failed to get orphan templates: %w", err)
 	}
 
@@ -524,16 +590,23 @@ func (s *TemplateService) GetPlaceholders(templateID string) ([]string, error) {
 	return placeholders, nil
 }
 
-func (s *TemplateService) DeleteTemplate(ctx context.Context, templateID string) error {
+// DeleteTemplate moves a template to the trash (soft delete)
+// Storage objects are kept until the template is purged so it can be restored.
+// Returns a TemplateInUseError if documents still reference the template, unless force is set
+func (s *TemplateService) DeleteTemplate(ctx context.Context, templateID string, force bool) error {
 	template, err := s.GetTemplate(templateID)
 	if err != nil {
 		return err
 	}
 
-	// Delete from GCS
-	if err := s.storageClient.DeleteFile(ctx, template.GCSPath); err != nil {
-		// Log error but continue with database deletion
-		fmt.Printf("Warning: failed to delete GCS file %s: %v\n", template.GCSPath, err)
+	if !force {
+		var documentCount int64
+		if err := internal.DB.Model(&models.Document{}).Where("template_id = ?", templateID).Count(&documentCount).Error; err != nil {
+			return fmt.Errorf("failed to check referencing documents: %w", err)
+		}
+		if documentCount > 0 {
+			return &TemplateInUseError{TemplateID: templateID, DocumentCount: documentCount}
+		}
 	}
 
 	// Soft delete from database
@@ -555,6 +628,7 @@ type TemplateUpdateRequest struct {
 	Tier           string
 	Group          string
 	Aliases        map[string]string
+	Translations   map[string]models.TemplateTranslation // Replaces all translations when non-nil
 }
 
 func (s *TemplateService) UpdateTemplate(ctx context.Context, templateID string, req *TemplateUpdateRequest, htmlFile multipart.File, htmlHeader *multipart.FileHeader) (*models.Template, error) {
@@ -606,6 +680,20 @@ func (s *TemplateService) UpdateTemplate(ctx context.Context, templateID string,
 		template.Aliases = string(aliasesJSON)
 	}
 
+	// Convert translations to JSON (if provided)
+	if req.Translations != nil {
+		for lang := range req.Translations {
+			if !models.IsSupportedLanguage(lang) {
+				return nil, fmt.Errorf("unsupported language '%s'", lang)
+			}
+		}
+		translationsJSON, err := json.Marshal(req.Translations)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal translations: %w", err)
+		}
+		template.Translations = string(translationsJSON)
+	}
+
 	// Upload HTML preview file if provided
 	if htmlFile != nil && htmlHeader != nil {
 		htmlObjectName := storage.GenerateObjectName(templateID, htmlHeader.Filename)
@@ -630,30 +718,46 @@ func (s *TemplateService) UpdateTemplate(ctx context.Context, templateID string,
 		return nil, fmt.Errorf("failed to update template: %w", err)
 	}
 
+	// Names and aliases are part of the search index
+	if err := indexTemplateSearch(template, nil); err != nil {
+		fmt.Printf("[WARNING] %v\n", err)
+	}
+
+	s.publishTemplateEvent(models.WebhookEventTemplateUpdated, template, "metadata")
 	return template, nil
 }
 
 // ReplaceTemplateFiles replaces the DOCX and/or HTML files for an existing template
 // If regenerateFields is true, field definitions will be regenerated from the new placeholders
 // HTML and PDF previews are auto-generated from the new DOCX file
-func (s *TemplateService) ReplaceTemplateFiles(ctx context.Context, templateID string, docxFile multipart.File, docxHeader *multipart.FileHeader, htmlFile multipart.File, htmlHeader *multipart.FileHeader, thumbnailFile multipart.File, thumbnailHeader *multipart.FileHeader, regenerateFields bool) (*models.Template, error) {
+// A new DOCX must pass the template's fixtures unless skipFixtures is set; on failure nothing changes
+// and a *FixtureFailureError carries the report
+func (s *TemplateService) ReplaceTemplateFiles(ctx context.Context, templateID string, docxFile multipart.File, docxHeader *multipart.FileHeader, htmlFile multipart.File, htmlHeader *multipart.FileHeader, thumbnailFile multipart.File, thumbnailHeader *multipart.FileHeader, regenerateFields, skipFixtures bool) (*models.Template, *models.FixtureRunReport, error) {
 	template, err := s.GetTemplate(templateID)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
+	// New body text for the search index (nil keeps the indexed text when the DOCX is unchanged)
+	var bodyText *string
+	var fixtureReport *models.FixtureRunReport
+
 	// Handle DOCX file replacement
 	if docxFile != nil && docxHeader != nil {
+		if !template.IsDocx() {
+			return nil, nil, ErrDocxOnly
+		}
+
 		// Validate file extension
 		if ext := strings.ToLower(filepath.Ext(docxHeader.Filename)); ext != ".docx" {
-			return nil, fmt.Errorf("invalid file type: expected .docx, got %s", ext)
+			return nil, nil, fmt.Errorf("invalid file type: expected .docx, got %s", ext)
 		}
 
 		// Upload new DOCX file
 		objectName := storage.GenerateObjectName(templateID, docxHeader.Filename)
 		result, err := s.storageClient.UploadFile(ctx, docxFile, objectName, "application/vnd.openxmlformats-officedocument.wordprocessingml.document")
 		if err != nil {
-			return nil, fmt.Errorf("failed to upload DOCX file: %w", err)
+			return nil, nil, fmt.Errorf("failed to upload DOCX file: %w", err)
 		}
 
 		// Create temp file for processing
@@ -661,10 +765,19 @@ func (s *TemplateService) ReplaceTemplateFiles(ctx context.Context, templateID s
 		tempFile, err := s.createTempFile(docxFile)
 		if err != nil {
 			s.storageClient.DeleteFile(ctx, objectName)
-			return nil, fmt.Errorf("failed to create temp file: %w", err)
+			return nil, nil, fmt.Errorf("failed to create temp file: %w", err)
 		}
 		defer s.cleanupTempFile(tempFile)
 
+		// Run the template's fixtures against the new DOCX before anything is replaced
+		if !skipFixtures {
+			fixtureReport, err = s.checkFixtures(templateID, tempFile)
+			if err != nil {
+				s.storageClient.DeleteFile(ctx, objectName)
+				return nil, fixtureReport, err
+			}
+		}
+
 		// Auto-generate HTML preview from DOCX (unless user provides HTML file)
 		if htmlFile == nil {
 			var htmlContent []byte
@@ -828,21 +941,27 @@ func (s *TemplateService) ReplaceTemplateFiles(ctx context.Context, templateID s
 		proc := processor.NewDocxProcessor(tempFile, "")
 		if err := proc.UnzipDocx(); err != nil {
 			s.storageClient.DeleteFile(ctx, objectName)
-			return nil, fmt.Errorf("failed to process document: %w", err)
+			return nil, nil, fmt.Errorf("failed to process document: %w", err)
 		}
 		defer proc.Cleanup()
 
 		placeholders, err := proc.ExtractPlaceholders()
 		if err != nil {
 			s.storageClient.DeleteFile(ctx, objectName)
-			return nil, fmt.Errorf("failed to extract placeholders: %w", err)
+			return nil, nil, fmt.Errorf("failed to extract placeholders: %w", err)
 		}
 
+		text, err := proc.ExtractText()
+		if err != nil {
+			fmt.Printf("[WARNING] Failed to extract body text for search: %v\n", err)
+		}
+		bodyText = &text
+
 		// Convert placeholders to JSON
 		placeholdersJSON, err := json.Marshal(placeholders)
 		if err != nil {
 			s.storageClient.DeleteFile(ctx, objectName)
-			return nil, fmt.Errorf("failed to marshal placeholders: %w", err)
+			return nil, nil, fmt.Errorf("failed to marshal placeholders: %w", err)
 		}
 
 		// Delete old DOCX file
@@ -863,7 +982,7 @@ func (s *TemplateService) ReplaceTemplateFiles(ctx context.Context, templateID s
 			fieldDefinitions := generateFieldDefinitionsFromDatabase(placeholders)
 			fieldDefinitionsJSON, err := json.Marshal(fieldDefinitions)
 			if err != nil {
-				return nil, fmt.Errorf("failed to marshal field definitions: %w", err)
+				return nil, nil, fmt.Errorf("failed to marshal field definitions: %w", err)
 			}
 			template.FieldDefinitions = string(fieldDefinitionsJSON)
 		}
@@ -873,14 +992,14 @@ func (s *TemplateService) ReplaceTemplateFiles(ctx context.Context, templateID s
 	if htmlFile != nil && htmlHeader != nil {
 		// Validate file extension
 		if ext := strings.ToLower(filepath.Ext(htmlHeader.Filename)); ext != ".html" && ext != ".htm" {
-			return nil, fmt.Errorf("invalid file type: expected .html, got %s", ext)
+			return nil, nil, fmt.Errorf("invalid file type: expected .html, got %s", ext)
 		}
 
 		// Upload new HTML file
 		htmlObjectName := storage.GenerateObjectName(templateID, htmlHeader.Filename)
 		_, err := s.storageClient.UploadFile(ctx, htmlFile, htmlObjectName, "text/html")
 		if err != nil {
-			return nil, fmt.Errorf("failed to upload HTML file: %w", err)
+			return nil, nil, fmt.Errorf("failed to upload HTML file: %w", err)
 		}
 
 		// Delete old HTML file
@@ -898,7 +1017,7 @@ func (s *TemplateService) ReplaceTemplateFiles(ctx context.Context, templateID s
 		// Validate file extension
 		ext := strings.ToLower(filepath.Ext(thumbnailHeader.Filename))
 		if ext != ".png" && ext != ".jpg" && ext != ".jpeg" && ext != ".webp" {
-			return nil, fmt.Errorf("invalid file type: expected .png, .jpg, .jpeg, or .webp, got %s", ext)
+			return nil, nil, fmt.Errorf("invalid file type: expected .png, .jpg, .jpeg, or .webp, got %s", ext)
 		}
 
 		// Determine content type
@@ -914,7 +1033,7 @@ func (s *TemplateService) ReplaceTemplateFiles(ctx context.Context, templateID s
 		thumbnailObjectName := storage.GenerateObjectName(templateID, thumbnailHeader.Filename)
 		_, err := s.storageClient.UploadFile(ctx, thumbnailFile, thumbnailObjectName, contentType)
 		if err != nil {
-			return nil, fmt.Errorf("failed to upload thumbnail file: %w", err)
+			return nil, nil, fmt.Errorf("failed to upload thumbnail file: %w", err)
 		}
 
 		// Delete old thumbnail file
@@ -930,10 +1049,16 @@ func (s *TemplateService) ReplaceTemplateFiles(ctx context.Context, templateID s
 
 	// Save to database
 	if err := internal.DB.Save(template).Error; err != nil {
-		return nil, fmt.Errorf("failed to update template: %w", err)
+		return nil, nil, fmt.Errorf("failed to update template: %w", err)
 	}
 
-	return template, nil
+	// Rebuild the search index from the new content
+	if err := indexTemplateSearch(template, bodyText); err != nil {
+		fmt.Printf("[WARNING] %v\n", err)
+	}
+
+	s.publishTemplateEvent(models.WebhookEventTemplateUpdated, template, "files")
+	return template, fixtureReport, nil
 }
 
 func (s *TemplateService) createTempFile(reader io.Reader) (string, error) {
@@ -999,6 +1124,10 @@ func (s *TemplateService) GenerateAndStorePDFPreview(ctx context.Context, templa
 	if template.GCSPath == "" {
 		return nil, "", fmt.Errorf("template has no DOCX file")
 	}
+	// PDF forms and overlays are their own PDF preview
+	if !template.IsDocx() {
+		return nil, "", ErrDocxOnly
+	}
 
 	// Download DOCX from storage
 	reader, err := s.storageClient.ReadFile(ctx, template.GCSPath)
@@ -1203,6 +1332,13 @@ func (s *TemplateService) UpdateFieldDefinitions(templateID string, fieldDefinit
 		return nil, err
 	}
 
+	// Computed fields must parse, read only this template's placeholders and not depend on themselves
+	var placeholders []string
+	json.Unmarshal([]byte(template.Placeholders), &placeholders)
+	if expressionErrors := CheckFieldExpressions(placeholders, fieldDefinitions); len(expressionErrors) > 0 {
+		return nil, ExpressionErrors(expressionErrors)
+	}
+
 	// Convert field definitions to JSON
 	fieldDefinitionsJSON, err := json.Marshal(fieldDefinitions)
 	if err != nil {
@@ -1215,6 +1351,10 @@ func (s *TemplateService) UpdateFieldDefinitions(templateID string, fieldDefinit
 		return nil, fmt.Errorf("failed to update field definitions: %w", err)
 	}
 
+	// Field labels are part of the search index
+	refreshTemplateSearch(template.ID)
+
+	s.publishTemplateEvent(models.WebhookEventTemplateUpdated, template, "field_definitions")
 	return template, nil
 }
 
@@ -1263,5 +1403,6 @@ func (s *TemplateService) RegenerateFieldDefinitions(templateID string) (*models
 		return nil, fmt.Errorf("failed to save field definitions: %w", err)
 	}
 
+	s.publishTemplateEvent(models.WebhookEventTemplateUpdated, template, "field_definitions")
 	return template, nil
 }
\ No newline at end of file
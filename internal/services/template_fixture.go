@@ -0,0 +1,436 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"DF-PLCH/internal"
+	"DF-PLCH/internal/models"
+	"DF-PLCH/internal/processor"
+
+	"github.com/google/uuid"
+)
+
+// ErrInvalidFixture is returned when a fixture fails validation
+var ErrInvalidFixture = errors.New("invalid fixture")
+
+// maxFixtureDiffCells bounds the line diff table (expected lines x actual lines)
+const maxFixtureDiffCells = 4_000_000
+
+// FixtureFailureError is returned by ReplaceTemplateFiles when the new DOCX fails the template's fixtures
+type FixtureFailureError struct {
+	Report *models.FixtureRunReport
+}
+
+func (e *FixtureFailureError) Error() string {
+	return fmt.Sprintf("%d of %d template fixtures failed", e.Report.Failed, e.Report.Total)
+}
+
+// TemplateFixtureRequest creates or updates a fixture
+// ExpectedText nil keeps the current snapshot; Snapshot records it from the current DOCX instead
+type TemplateFixtureRequest struct {
+	Name         string            `json:"name"`
+	Data         map[string]string `json:"data"`
+	ExpectedText *string           `json:"expected_text"`
+	Snapshot     bool              `json:"snapshot"`
+}
+
+// GetFixtures lists a template's fixtures by name
+func (s *TemplateService) GetFixtures(templateID string) ([]models.TemplateFixture, error) {
+	var fixtures []models.TemplateFixture
+	if err := internal.DB.Where("template_id = ?", templateID).Order("name").Find(&fixtures).Error; err != nil {
+		return nil, fmt.Errorf("failed to get fixtures: %w", err)
+	}
+	return fixtures, nil
+}
+
+// GetFixture returns one fixture of a template
+func (s *TemplateService) GetFixture(templateID, fixtureID string) (*models.TemplateFixture, error) {
+	var fixture models.TemplateFixture
+	if err := internal.DB.First(&fixture, "id = ? AND template_id = ?", fixtureID, templateID).Error; err != nil {
+		return nil, fmt.Errorf("fixture not found: %w", err)
+	}
+	return &fixture, nil
+}
+
+// CreateFixture adds a named sample dataset to a template
+// Without expected text (or with Snapshot set) the golden snapshot is recorded from the current DOCX
+func (s *TemplateService) CreateFixture(ctx context.Context, templateID, userID string, req *TemplateFixtureRequest) (*models.TemplateFixture, error) {
+	template, err := s.GetTemplate(templateID)
+	if err != nil {
+		return nil, err
+	}
+
+	fixture := &models.TemplateFixture{
+		ID:         uuid.New().String(),
+		TemplateID: templateID,
+		CreatedBy:  userID,
+	}
+	if err := applyFixtureRequest(fixture, req); err != nil {
+		return nil, err
+	}
+	if req.Snapshot || req.ExpectedText == nil {
+		if fixture.ExpectedText, err = s.fixtureOutputText(ctx, template, fixture); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := internal.DB.Create(fixture).Error; err != nil {
+		if strings.Contains(err.Error(), "duplicate") {
+			return nil, fmt.Errorf("%w: a fixture named '%s' already exists", ErrInvalidFixture, fixture.Name)
+		}
+		return nil, fmt.Errorf("failed to create fixture: %w", err)
+	}
+	return fixture, nil
+}
+
+// UpdateFixture changes a fixture's name, data or snapshot
+func (s *TemplateService) UpdateFixture(ctx context.Context, templateID, fixtureID string, req *TemplateFixtureRequest) (*models.TemplateFixture, error) {
+	fixture, err := s.GetFixture(templateID, fixtureID)
+	if err != nil {
+		return nil, err
+	}
+	if req.Name == "" {
+		req.Name = fixture.Name
+	}
+	if req.Data == nil {
+		req.Data = fixture.GetData()
+	}
+	if err := applyFixtureRequest(fixture, req); err != nil {
+		return nil, err
+	}
+	if req.Snapshot {
+		template, err := s.GetTemplate(templateID)
+		if err != nil {
+			return nil, err
+		}
+		if fixture.ExpectedText, err = s.fixtureOutputText(ctx, template, fixture); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := internal.DB.Save(fixture).Error; err != nil {
+		if strings.Contains(err.Error(), "duplicate") {
+			return nil, fmt.Errorf("%w: a fixture named '%s' already exists", ErrInvalidFixture, fixture.Name)
+		}
+		return nil, fmt.Errorf("failed to update fixture: %w", err)
+	}
+	return fixture, nil
+}
+
+// DeleteFixture removes a fixture from a template
+func (s *TemplateService) DeleteFixture(templateID, fixtureID string) error {
+	result := internal.DB.Where("id = ? AND template_id = ?", fixtureID, templateID).Delete(&models.TemplateFixture{})
+	if result.Error != nil {
+		return fmt.Errorf("failed to delete fixture: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("fixture not found")
+	}
+	return nil
+}
+
+// applyFixtureRequest validates a request and copies it onto the fixture
+// Data keys are stored in placeholder form ("{{key}}")
+func applyFixtureRequest(fixture *models.TemplateFixture, req *TemplateFixtureRequest) error {
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalidFixture)
+	}
+
+	data := make(map[string]string, len(req.Data))
+	for key, value := range req.Data {
+		if key = placeholderKey(strings.TrimSpace(key)); key == "" {
+			return fmt.Errorf("%w: data contains an empty field name", ErrInvalidFixture)
+		}
+		data["{{"+key+"}}"] = value
+	}
+	dataJSON, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("failed to marshal fixture data: %w", err)
+	}
+
+	fixture.Name = name
+	fixture.Data = string(dataJSON)
+	if req.ExpectedText != nil {
+		fixture.ExpectedText = normalizeFixtureText(*req.ExpectedText)
+	}
+	return nil
+}
+
+// RunFixtures fills the template's current DOCX with every fixture and compares the output
+// Each fixture's last run status is recorded
+func (s *TemplateService) RunFixtures(ctx context.Context, templateID string) (*models.FixtureRunReport, error) {
+	template, err := s.GetTemplate(templateID)
+	if err != nil {
+		return nil, err
+	}
+	if !template.IsDocx() {
+		return nil, ErrDocxOnly
+	}
+	fixtures, err := s.GetFixtures(templateID)
+	if err != nil {
+		return nil, err
+	}
+
+	reader, err := s.storageClient.ReadFile(ctx, template.GCSPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read template from storage: %w", err)
+	}
+	tempFile, err := s.createTempFile(reader)
+	reader.Close()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temp file: %w", err)
+	}
+	defer s.cleanupTempFile(tempFile)
+
+	report := runFixtures(templateID, tempFile, fixtures)
+	recordFixtureRun(report)
+	return report, nil
+}
+
+// runFixtures runs fixtures against a local DOCX file
+func runFixtures(templateID, docxPath string, fixtures []models.TemplateFixture) *models.FixtureRunReport {
+	report := &models.FixtureRunReport{
+		TemplateID: templateID,
+		Passed:     true,
+		Total:      len(fixtures),
+		RanAt:      time.Now(),
+		Results:    make([]models.FixtureResult, 0, len(fixtures)),
+	}
+
+	placeholders, err := docxPlaceholders(docxPath)
+	for i := range fixtures {
+		var result models.FixtureResult
+		if err != nil {
+			result = models.FixtureResult{FixtureID: fixtures[i].ID, Name: fixtures[i].Name, Error: err.Error()}
+		} else {
+			result = runFixture(docxPath, placeholders, &fixtures[i])
+		}
+		if !result.Passed {
+			report.Passed = false
+			report.Failed++
+		}
+		report.Results = append(report.Results, result)
+	}
+	return report
+}
+
+// runFixture fills a DOCX with one fixture's data and checks the output
+func runFixture(docxPath string, placeholders map[string]bool, fixture *models.TemplateFixture) models.FixtureResult {
+	result := models.FixtureResult{
+		FixtureID: fixture.ID,
+		Name:      fixture.Name,
+		Snapshot:  fixture.ExpectedText != "",
+	}
+
+	data := fixture.GetData()
+	for placeholder := range data {
+		if !placeholders[placeholder] {
+			result.MissingPlaceholders = append(result.MissingPlaceholders, placeholder)
+		}
+	}
+	sort.Strings(result.MissingPlaceholders)
+
+	actual, err := fillDocxText(docxPath, data)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	result.LeftoverTokens = leftoverTokens(actual)
+	if result.Snapshot {
+		result.Diff = diffFixtureText(fixture.ExpectedText, actual)
+	}
+	result.Passed = len(result.MissingPlaceholders) == 0 && len(result.LeftoverTokens) == 0 && len(result.Diff) == 0
+	return result
+}
+
+// recordFixtureRun stores each fixture's last run time and outcome
+func recordFixtureRun(report *models.FixtureRunReport) {
+	for _, result := range report.Results {
+		err := internal.DB.Model(&models.TemplateFixture{}).
+			Where("id = ?", result.FixtureID).
+			UpdateColumns(map[string]interface{}{"last_run_at": report.RanAt, "last_passed": result.Passed}).Error
+		if err != nil {
+			fmt.Printf("[WARNING] Failed to record fixture run for %s: %v\n", result.FixtureID, err)
+		}
+	}
+}
+
+// checkFixtures runs a template's fixtures against a replacement DOCX before it is accepted
+func (s *TemplateService) checkFixtures(templateID, docxPath string) (*models.FixtureRunReport, error) {
+	fixtures, err := s.GetFixtures(templateID)
+	if err != nil {
+		return nil, err
+	}
+	if len(fixtures) == 0 {
+		return nil, nil
+	}
+
+	report := runFixtures(templateID, docxPath, fixtures)
+	if !report.Passed {
+		return report, &FixtureFailureError{Report: report}
+	}
+	recordFixtureRun(report)
+	return report, nil
+}
+
+// fixtureOutputText fills the template's current DOCX with a fixture and returns the output text
+func (s *TemplateService) fixtureOutputText(ctx context.Context, template *models.Template, fixture *models.TemplateFixture) (string, error) {
+	if !template.IsDocx() {
+		return "", ErrDocxOnly
+	}
+	reader, err := s.storageClient.ReadFile(ctx, template.GCSPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to read template from storage: %w", err)
+	}
+	tempFile, err := s.createTempFile(reader)
+	reader.Close()
+	if err != nil {
+		return "", fmt.Errorf("failed to create temp file: %w", err)
+	}
+	defer s.cleanupTempFile(tempFile)
+
+	return fillDocxText(tempFile, fixture.GetData())
+}
+
+// docxPlaceholders returns the set of placeholders in a DOCX file
+func docxPlaceholders(docxPath string) (map[string]bool, error) {
+	proc := processor.NewDocxProcessor(docxPath, "")
+	if err := proc.UnzipDocx(); err != nil {
+		return nil, fmt.Errorf("failed to unzip document: %w", err)
+	}
+	defer proc.Cleanup()
+
+	placeholders, err := proc.ExtractPlaceholders()
+	if err != nil {
+		return nil, fmt.Errorf("failed to extract placeholders: %w", err)
+	}
+	set := make(map[string]bool, len(placeholders))
+	for _, placeholder := range placeholders {
+		set[placeholder] = true
+	}
+	return set, nil
+}
+
+// fillDocxText fills a DOCX in place of a temp copy and extracts the body text
+// The built-in processor is used (not LibreOffice) so output is deterministic across hosts
+func fillDocxText(docxPath string, data map[string]string) (string, error) {
+	proc := processor.NewDocxProcessor(docxPath, "")
+	if err := proc.UnzipDocx(); err != nil {
+		return "", fmt.Errorf("failed to unzip document: %w", err)
+	}
+	defer proc.Cleanup()
+
+	if err := proc.FindAndReplaceInDocument(data); err != nil {
+		return "", fmt.Errorf("failed to replace placeholders: %w", err)
+	}
+	text, err := proc.ExtractText()
+	if err != nil {
+		return "", err
+	}
+	return normalizeFixtureText(text), nil
+}
+
+// normalizeFixtureText trims lines and drops blank ones so snapshots ignore layout whitespace
+func normalizeFixtureText(text string) string {
+	var lines []string
+	for _, line := range strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// leftoverTokens returns the unique "{{...}}" tokens (or unterminated "{{" fragments) in a text
+func leftoverTokens(text string) []string {
+	seen := make(map[string]bool)
+	var tokens []string
+	for pos := 0; ; {
+		start := strings.Index(text[pos:], "{{")
+		if start == -1 {
+			break
+		}
+		start += pos
+
+		token := text[start:]
+		if end := strings.Index(token, "}}"); end != -1 && !strings.Contains(token[:end], "\n") {
+			token = token[:end+2]
+		} else if newline := strings.IndexByte(token, '\n'); newline != -1 {
+			token = token[:newline]
+		}
+		if !seen[token] {
+			seen[token] = true
+			tokens = append(tokens, token)
+		}
+		pos = start + 2
+	}
+	return tokens
+}
+
+// diffFixtureText returns the lines removed from expected and added in actual (LCS line diff)
+func diffFixtureText(expected, actual string) []models.FixtureDiffLine {
+	if expected == actual {
+		return nil
+	}
+	a := strings.Split(expected, "\n")
+	b := strings.Split(actual, "\n")
+
+	// Very large documents: report the first differing line only
+	if len(a)*len(b) > maxFixtureDiffCells {
+		for i := 0; i < len(a) || i < len(b); i++ {
+			if i >= len(a) {
+				return []models.FixtureDiffLine{{Op: "+", Line: i + 1, Text: b[i]}}
+			}
+			if i >= len(b) || a[i] != b[i] {
+				diff := []models.FixtureDiffLine{{Op: "-", Line: i + 1, Text: a[i]}}
+				if i < len(b) {
+					diff = append(diff, models.FixtureDiffLine{Op: "+", Line: i + 1, Text: b[i]})
+				}
+				return diff
+			}
+		}
+		return nil
+	}
+
+	// lcs[i][j] is the LCS length of a[i:] and b[j:]
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	var diff []models.FixtureDiffLine
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			i++
+			j++
+		case j < len(b) && (i == len(a) || lcs[i][j+1] > lcs[i+1][j]):
+			diff = append(diff, models.FixtureDiffLine{Op: "+", Line: j + 1, Text: b[j]})
+			j++
+		default:
+			diff = append(diff, models.FixtureDiffLine{Op: "-", Line: i + 1, Text: a[i]})
+			i++
+		}
+	}
+	return diff
+}
@@ -0,0 +1,593 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"DF-PLCH/internal"
+	"DF-PLCH/internal/models"
+	"DF-PLCH/internal/processor"
+	"DF-PLCH/internal/storage"
+	"DF-PLCH/internal/utils"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// IntegrityService checks that templates' stored files, placeholders and field definitions agree
+type IntegrityService struct {
+	templateService *TemplateService
+	storageClient   storage.StorageClient
+	mu              sync.Mutex
+	running         bool
+}
+
+func NewIntegrityService(templateService *TemplateService, storageClient storage.StorageClient) *IntegrityService {
+	return &IntegrityService{
+		templateService: templateService,
+		storageClient:   storageClient,
+	}
+}
+
+// StartCheck creates a report and runs the check over all templates in the background
+// Only one check runs at a time
+func (s *IntegrityService) StartCheck(triggeredBy string) (*models.IntegrityReport, error) {
+	s.mu.Lock()
+	if s.running {
+		s.mu.Unlock()
+		return nil, fmt.Errorf("an integrity check is already running")
+	}
+	s.running = true
+	s.mu.Unlock()
+
+	report := &models.IntegrityReport{
+		ID:          uuid.New().String(),
+		Status:      "running",
+		TriggeredBy: triggeredBy,
+		Issues:      "[]",
+		StartedAt:   time.Now(),
+	}
+	if err := internal.DB.Create(report).Error; err != nil {
+		s.setRunning(false)
+		return nil, fmt.Errorf("failed to create integrity report: %w", err)
+	}
+
+	go func() {
+		defer s.setRunning(false)
+		s.runCheck(context.Background(), report)
+	}()
+
+	return report, nil
+}
+
+func (s *IntegrityService) setRunning(running bool) {
+	s.mu.Lock()
+	s.running = running
+	s.mu.Unlock()
+}
+
+// runCheck walks all templates and stores the issues found in the report
+func (s *IntegrityService) runCheck(ctx context.Context, report *models.IntegrityReport) {
+	issues := []models.IntegrityIssue{}
+	var templates []models.Template
+	err := internal.DB.FindInBatches(&templates, 50, func(tx *gorm.DB, batch int) error {
+		for i := range templates {
+			issues = append(issues, s.CheckTemplate(ctx, &templates[i])...)
+			report.TemplatesChecked++
+		}
+		return nil
+	}).Error
+
+	now := time.Now()
+	report.FinishedAt = &now
+	report.IssueCount = len(issues)
+	if issuesJSON, marshalErr := json.Marshal(issues); marshalErr == nil {
+		report.Issues = string(issuesJSON)
+	}
+	report.Status = "completed"
+	if err != nil {
+		report.Status = "failed"
+		report.Error = err.Error()
+	}
+
+	if err := internal.DB.Save(report).Error; err != nil {
+		log.Printf("Failed to save integrity report %s: %v", report.ID, err)
+		return
+	}
+	log.Printf("Template integrity check %s: %d templates checked, %d issues found", report.Status, report.TemplatesChecked, report.IssueCount)
+}
+
+// GetReport retrieves an integrity report by ID
+func (s *IntegrityService) GetReport(reportID string) (*models.IntegrityReport, error) {
+	var report models.IntegrityReport
+	if err := internal.DB.First(&report, "id = ?", reportID).Error; err != nil {
+		return nil, fmt.Errorf("report not found: %w", err)
+	}
+	return &report, nil
+}
+
+// GetLatestReport retrieves the most recent integrity report
+func (s *IntegrityService) GetLatestReport() (*models.IntegrityReport, error) {
+	var report models.IntegrityReport
+	if err := internal.DB.Order("started_at DESC").First(&report).Error; err != nil {
+		return nil, fmt.Errorf("report not found: %w", err)
+	}
+	return &report, nil
+}
+
+// GetReports lists recent integrity reports without their issues
+func (s *IntegrityService) GetReports(limit int) ([]models.IntegrityReport, error) {
+	var reports []models.IntegrityReport
+	if err := internal.DB.Omit("issues").Order("started_at DESC").Limit(limit).Find(&reports).Error; err != nil {
+		return nil, fmt.Errorf("failed to get integrity reports: %w", err)
+	}
+	return reports, nil
+}
+
+// CheckTemplate returns the integrity issues of a single template
+func (s *IntegrityService) CheckTemplate(ctx context.Context, template *models.Template) []models.IntegrityIssue {
+	var issues []models.IntegrityIssue
+	newIssue := func(issueType models.IntegrityIssueType, detail string, action models.IntegrityRepairAction) models.IntegrityIssue {
+		return models.IntegrityIssue{
+			TemplateID:   template.ID,
+			TemplateName: template.DisplayName,
+			Type:         issueType,
+			Detail:       detail,
+			RepairAction: action,
+		}
+	}
+
+	// Storage objects
+	docxExists := s.objectExists(ctx, template.GCSPath)
+	if !docxExists {
+		issue := newIssue(models.IntegrityMissingDocx, "DOCX file is missing from storage; re-upload it with POST /templates/:templateId/files", "")
+		issue.Path = template.GCSPath
+		issues = append(issues, issue)
+	}
+
+	previews := []struct {
+		path      string
+		issueType models.IntegrityIssueType
+		label     string
+		action    models.IntegrityRepairAction
+	}{
+		{template.GCSPathHTML, models.IntegrityMissingHTML, "HTML preview", models.RepairRegenerateHTML},
+		{template.GCSPathPDF, models.IntegrityMissingPDF, "PDF preview", models.RepairRegeneratePDF},
+		{template.GCSPathThumbnail, models.IntegrityMissingThumbnail, "Thumbnail", models.RepairRegenerateThumbnail},
+	}
+	for _, preview := range previews {
+		if preview.issueType == models.IntegrityMissingHTML && !template.IsDocx() {
+			continue // PDF forms and overlays are previewed as PDF only
+		}
+		// Previews that were never generated are not missing objects
+		if preview.path == "" || s.objectExists(ctx, preview.path) {
+			continue
+		}
+		detail := preview.label + " is missing from storage"
+		action := preview.action
+		if !docxExists {
+			action = "" // Previews are generated from the DOCX
+		}
+		issue := newIssue(preview.issueType, detail, action)
+		issue.Path = preview.path
+		issues = append(issues, issue)
+	}
+
+	if !docxExists {
+		return issues
+	}
+
+	// Placeholders
+	extracted, err := s.extractPlaceholders(ctx, template)
+	if err != nil {
+		return append(issues, newIssue(models.IntegrityUnreadableDocx, err.Error(), ""))
+	}
+
+	var stored []string
+	if template.Placeholders != "" {
+		json.Unmarshal([]byte(template.Placeholders), &stored)
+	}
+	added, removed := diffPlaceholderKeys(stored, extracted)
+	if len(added) > 0 || len(removed) > 0 {
+		issue := newIssue(models.IntegrityStalePlaceholders,
+			fmt.Sprintf("Stored placeholders differ from the DOCX: %d not stored, %d no longer in the document", len(added), len(removed)),
+			models.RepairSyncPlaceholders)
+		for _, key := range added {
+			issue.Items = append(issue.Items, "+"+key)
+		}
+		for _, key := range removed {
+			issue.Items = append(issue.Items, "-"+key)
+		}
+		issues = append(issues, issue)
+	}
+
+	// Field definition coverage (against the DOCX, the source of truth)
+	definitions := parseFieldDefinitions(template.FieldDefinitions)
+	if missing := uncoveredPlaceholders(extracted, definitions); len(missing) > 0 {
+		issue := newIssue(models.IntegrityMissingFieldDefinitions,
+			fmt.Sprintf("%d placeholders have no field definition", len(missing)),
+			models.RepairAddFieldDefinitions)
+		issue.Items = missing
+		issues = append(issues, issue)
+	}
+	if orphans := orphanFieldDefinitions(extracted, definitions); len(orphans) > 0 {
+		issue := newIssue(models.IntegrityOrphanFieldDefinitions,
+			fmt.Sprintf("%d field definitions refer to placeholders not in the DOCX", len(orphans)),
+			models.RepairRemoveOrphanFieldDefinitions)
+		issue.Items = orphans
+		issues = append(issues, issue)
+	}
+
+	return issues
+}
+
+// CheckTemplateByID returns the integrity issues of a single template
+func (s *IntegrityService) CheckTemplateByID(ctx context.Context, templateID string) ([]models.IntegrityIssue, error) {
+	template, err := s.templateService.GetTemplate(templateID)
+	if err != nil {
+		return nil, err
+	}
+	issues := s.CheckTemplate(ctx, template)
+	if issues == nil {
+		issues = []models.IntegrityIssue{}
+	}
+	return issues, nil
+}
+
+// Repair applies a repair action to a template
+func (s *IntegrityService) Repair(ctx context.Context, templateID string, action models.IntegrityRepairAction) error {
+	template, err := s.templateService.GetTemplate(templateID)
+	if err != nil {
+		return err
+	}
+
+	switch action {
+	case models.RepairRegenerateHTML:
+		return s.templateService.RegenerateHTMLPreview(ctx, template)
+	case models.RepairRegeneratePDF:
+		_, _, err := s.templateService.GenerateAndStorePDFPreview(ctx, template)
+		return err
+	case models.RepairRegenerateThumbnail:
+		// The thumbnail is rendered from the PDF, so regenerate a missing PDF first
+		if template.GCSPathPDF != "" && !s.objectExists(ctx, template.GCSPathPDF) {
+			template.GCSPathPDF = ""
+		}
+		_, err := s.templateService.GenerateThumbnailForTemplate(ctx, template)
+		return err
+	case models.RepairSyncPlaceholders, models.RepairAddFieldDefinitions, models.RepairRemoveOrphanFieldDefinitions:
+		return s.repairPlaceholders(ctx, template, action)
+	default:
+		return fmt.Errorf("unknown repair action '%s'", action)
+	}
+}
+
+// RepairReport applies every repair action listed in a report
+// Returns the number of repairs applied and the errors of those that failed
+func (s *IntegrityService) RepairReport(ctx context.Context, reportID string) (int, []string, error) {
+	report, err := s.GetReport(reportID)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	var issues []models.IntegrityIssue
+	if err := json.Unmarshal([]byte(report.Issues), &issues); err != nil {
+		return 0, nil, fmt.Errorf("failed to parse report issues: %w", err)
+	}
+
+	repaired := 0
+	var errors []string
+	done := make(map[string]bool)
+	for _, issue := range issues {
+		if issue.RepairAction == "" {
+			continue
+		}
+		// sync_placeholders also adds field definitions; avoid repeating the same action
+		key := issue.TemplateID + "/" + string(issue.RepairAction)
+		if done[key] {
+			continue
+		}
+		done[key] = true
+
+		if err := s.Repair(ctx, issue.TemplateID, issue.RepairAction); err != nil {
+			errors = append(errors, fmt.Sprintf("Template %s (%s): %s: %v", issue.TemplateID, issue.TemplateName, issue.RepairAction, err))
+			continue
+		}
+		repaired++
+	}
+
+	return repaired, errors, nil
+}
+
+// repairPlaceholders re-extracts placeholders from the DOCX and fixes the stored list and/or field definitions
+func (s *IntegrityService) repairPlaceholders(ctx context.Context, template *models.Template, action models.IntegrityRepairAction) error {
+	extracted, err := s.extractPlaceholders(ctx, template)
+	if err != nil {
+		return err
+	}
+
+	definitions := parseFieldDefinitions(template.FieldDefinitions)
+
+	if action == models.RepairSyncPlaceholders {
+		placeholdersJSON, err := json.Marshal(extracted)
+		if err != nil {
+			return fmt.Errorf("failed to marshal placeholders: %w", err)
+		}
+		template.Placeholders = string(placeholdersJSON)
+	}
+
+	if action == models.RepairSyncPlaceholders || action == models.RepairAddFieldDefinitions {
+		var missing []string
+		for _, key := range uncoveredPlaceholders(extracted, definitions) {
+			missing = append(missing, "{{"+key+"}}")
+		}
+		if len(missing) > 0 {
+			order := len(definitions)
+			for key, def := range generateFieldDefinitionsFromDatabase(missing) {
+				def.Order += order
+				definitions[key] = def
+			}
+		}
+	}
+
+	if action == models.RepairRemoveOrphanFieldDefinitions {
+		for _, key := range orphanFieldDefinitions(extracted, definitions) {
+			delete(definitions, key)
+		}
+	}
+
+	definitionsJSON, err := json.Marshal(definitions)
+	if err != nil {
+		return fmt.Errorf("failed to marshal field definitions: %w", err)
+	}
+	template.FieldDefinitions = string(definitionsJSON)
+
+	if err := internal.DB.Save(template).Error; err != nil {
+		return fmt.Errorf("failed to save template: %w", err)
+	}
+
+	refreshTemplateSearch(template.ID)
+	return nil
+}
+
+// objectExists reports whether a storage object exists, without downloading it
+func (s *IntegrityService) objectExists(ctx context.Context, path string) bool {
+	if path == "" {
+		return false
+	}
+	exists, err := s.storageClient.FileExists(ctx, path)
+	if err != nil {
+		// Unknown is not reported as missing; a transient storage error is not an integrity issue
+		fmt.Printf("[WARNING] Failed to check storage object %s: %v\n", path, err)
+		return true
+	}
+	return exists
+}
+
+// extractPlaceholders downloads a template's DOCX and extracts its placeholders
+func (s *IntegrityService) extractPlaceholders(ctx context.Context, template *models.Template) ([]string, error) {
+	// Overlay placeholders exist only as positioned field definitions
+	if template.IsOverlay() {
+		return overlayPlaceholders(parseFieldDefinitions(template.FieldDefinitions)), nil
+	}
+
+	reader, err := s.storageClient.ReadFile(ctx, template.GCSPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read DOCX from storage: %w", err)
+	}
+	tempFile, err := s.templateService.createTempFile(reader)
+	reader.Close()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temp file: %w", err)
+	}
+	defer s.templateService.cleanupTempFile(tempFile)
+
+	if template.IsPDFForm() {
+		fields, err := processor.ExtractPDFFormFields(tempFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read PDF form fields: %w", err)
+		}
+		placeholders := pdfFormPlaceholders(fields)
+		if placeholders == nil {
+			placeholders = []string{}
+		}
+		return placeholders, nil
+	}
+
+	proc := processor.NewDocxProcessor(tempFile, "")
+	if err := proc.UnzipDocx(); err != nil {
+		return nil, fmt.Errorf("failed to unzip DOCX: %w", err)
+	}
+	defer proc.Cleanup()
+
+	placeholders, err := proc.ExtractPlaceholders()
+	if err != nil {
+		return nil, fmt.Errorf("failed to extract placeholders: %w", err)
+	}
+	if placeholders == nil {
+		placeholders = []string{}
+	}
+	return placeholders, nil
+}
+
+func parseFieldDefinitions(fieldDefinitions string) map[string]utils.FieldDefinition {
+	definitions := make(map[string]utils.FieldDefinition)
+	if fieldDefinitions != "" {
+		json.Unmarshal([]byte(fieldDefinitions), &definitions)
+	}
+	return definitions
+}
+
+// diffPlaceholderKeys returns the keys only in extracted (added) and only in stored (removed)
+func diffPlaceholderKeys(stored, extracted []string) ([]string, []string) {
+	storedKeys := make(map[string]bool, len(stored))
+	for _, placeholder := range stored {
+		storedKeys[placeholderKey(placeholder)] = true
+	}
+	extractedKeys := make(map[string]bool, len(extracted))
+	for _, placeholder := range extracted {
+		extractedKeys[placeholderKey(placeholder)] = true
+	}
+
+	var added, removed []string
+	for key := range extractedKeys {
+		if !storedKeys[key] {
+			added = append(added, key)
+		}
+	}
+	for key := range storedKeys {
+		if !extractedKeys[key] {
+			removed = append(removed, key)
+		}
+	}
+	sort.Strings(added)
+	sort.Strings(removed)
+	return added, removed
+}
+
+// uncoveredPlaceholders returns the placeholder keys that no field definition covers
+// A placeholder is covered by its own definition, a merged field, or a radio group option
+func uncoveredPlaceholders(placeholders []string, definitions map[string]utils.FieldDefinition) []string {
+	covered := make(map[string]bool, len(definitions))
+	for key, def := range definitions {
+		covered[key] = true
+		for _, field := range def.MergedFields {
+			covered[placeholderKey(field)] = true
+		}
+		for _, option := range def.RadioOptions {
+			covered[placeholderKey(option.Placeholder)] = true
+		}
+	}
+
+	var missing []string
+	for _, placeholder := range placeholders {
+		if key := placeholderKey(placeholder); !covered[key] {
+			missing = append(missing, key)
+		}
+	}
+	return missing
+}
+
+// orphanFieldDefinitions returns definition keys whose placeholder is not in the document
+// Merged fields and radio groups are virtual and never orphans
+func orphanFieldDefinitions(placeholders []string, definitions map[string]utils.FieldDefinition) []string {
+	keys := make(map[string]bool, len(placeholders))
+	for _, placeholder := range placeholders {
+		keys[placeholderKey(placeholder)] = true
+	}
+
+	var orphans []string
+	for key, def := range definitions {
+		if def.IsMerged || def.IsRadioGroup || keys[key] {
+			continue
+		}
+		orphans = append(orphans, key)
+	}
+	sort.Strings(orphans)
+	return orphans
+}
+
+// RegenerateHTMLPreview regenerates and stores a template's HTML preview from its DOCX
+func (s *TemplateService) RegenerateHTMLPreview(ctx context.Context, template *models.Template) error {
+	if !template.IsDocx() {
+		return ErrDocxOnly
+	}
+	reader, err := s.storageClient.ReadFile(ctx, template.GCSPath)
+	if err != nil {
+		return fmt.Errorf("failed to read DOCX from storage: %w", err)
+	}
+	tempFile, err := s.createTempFile(reader)
+	reader.Close()
+	if err != nil {
+		return fmt.Errorf("failed to create temp file: %w", err)
+	}
+	defer s.cleanupTempFile(tempFile)
+
+	// Try remote service (Gotenberg) first, then local LibreOffice
+	var htmlContent []byte
+	if s.conversionService != nil && s.conversionService.IsHTMLConversionAvailable() {
+		htmlContent, err = s.conversionService.ConvertDocxToHTML(ctx, tempFile)
+		if err != nil {
+			fmt.Printf("[WARNING] Failed to generate HTML preview via remote service: %v\n", err)
+		}
+	}
+	if len(htmlContent) == 0 && processor.IsLibreOfficeAvailable() {
+		htmlContent, err = processor.ConvertToHTMLBytes(tempFile)
+		if err != nil {
+			fmt.Printf("[WARNING] Failed to generate HTML preview via local LibreOffice: %v\n", err)
+		}
+	}
+	if len(htmlContent) == 0 {
+		return fmt.Errorf("HTML conversion is not available")
+	}
+
+	htmlFileName := strings.TrimSuffix(template.Filename, filepath.Ext(template.Filename)) + ".html"
+	htmlObjectName := storage.GenerateObjectName(template.ID, htmlFileName)
+	if _, err := s.storageClient.UploadFile(ctx, io.NopCloser(bytes.NewReader(htmlContent)), htmlObjectName, "text/html"); err != nil {
+		return fmt.Errorf("failed to upload HTML preview: %w", err)
+	}
+
+	oldHTMLPath := template.GCSPathHTML
+	template.GCSPathHTML = htmlObjectName
+	if err := internal.DB.Save(template).Error; err != nil {
+		return fmt.Errorf("failed to update template with HTML path: %w", err)
+	}
+
+	if oldHTMLPath != "" {
+		if err := s.storageClient.DeleteFile(ctx, oldHTMLPath); err != nil {
+			fmt.Printf("[WARNING] Failed to delete old HTML file %s: %v\n", oldHTMLPath, err)
+		}
+	}
+
+	fmt.Printf("[INFO] Regenerated HTML preview for template %s at %s\n", template.ID, htmlObjectName)
+	return nil
+}
+
+// IntegrityCheckScheduler periodically runs the template integrity check
+type IntegrityCheckScheduler struct {
+	integrityService *IntegrityService
+	interval         time.Duration
+	ticker           *time.Ticker
+	done             chan bool
+}
+
+func NewIntegrityCheckScheduler(integrityService *IntegrityService, interval time.Duration) *IntegrityCheckScheduler {
+	return &IntegrityCheckScheduler{
+		integrityService: integrityService,
+		interval:         interval,
+		done:             make(chan bool),
+	}
+}
+
+func (t *IntegrityCheckScheduler) Start() {
+	t.ticker = time.NewTicker(t.interval)
+	go func() {
+		for {
+			select {
+			case <-t.done:
+				return
+			case <-t.ticker.C:
+				if _, err := t.integrityService.StartCheck("scheduled"); err != nil {
+					log.Printf("Skipping scheduled integrity check: %v", err)
+				}
+			}
+		}
+	}()
+	log.Printf("Template integrity check scheduled (interval: %s)", t.interval)
+}
+
+func (t *IntegrityCheckScheduler) Stop() {
+	if t.ticker != nil {
+		t.ticker.Stop()
+	}
+	t.done <- true
+	log.Println("Template integrity check stopped")
+}
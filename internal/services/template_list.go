@@ -0,0 +1,202 @@
+package services
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"DF-PLCH/internal"
+	"DF-PLCH/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrInvalidCursor is returned when a pagination cursor cannot be decoded or belongs to another sort
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// popularityJoin adds doc_counts.doc_count (number of generated documents per template)
+const popularityJoin = "LEFT JOIN (SELECT template_id, COUNT(*) as doc_count FROM documents WHERE deleted_at IS NULL GROUP BY template_id) doc_counts ON doc_counts.template_id = document_templates.id"
+
+// topRatedScoreExpr weights each template's average toward a prior of 3 stars from 5 ratings
+const topRatedScoreExpr = "((COALESCE(document_templates.rating_average, 0) * COALESCE(document_templates.rating_count, 0) + 3.0 * 5) / (COALESCE(document_templates.rating_count, 0) + 5))"
+
+// TemplatePage is one page of a template listing
+type TemplatePage struct {
+	Templates  []models.Template
+	Total      int64  // Number of templates matching the filter (across all pages)
+	NextCursor string // Empty on the last page
+}
+
+// templateSortKey is one column of a listing's ORDER BY
+// Every sort ends with the template ID so the order is total and cursors are stable
+type templateSortKey struct {
+	expr    string        // SQL expression (never NULL)
+	sqlType string        // Type used to cast cursor values back for comparison
+	desc    bool          // Descending order
+	vars    []interface{} // Bind variables used by expr
+}
+
+// templateSortKeys returns the effective sort mode and its ordering keys
+func templateSortKeys(filter *TemplateFilter) (string, []templateSortKey) {
+	createdAt := templateSortKey{expr: "COALESCE(document_templates.created_at, 'epoch'::timestamp)", sqlType: "timestamp", desc: true}
+	idAsc := templateSortKey{expr: "document_templates.id", sqlType: "text"}
+	idDesc := templateSortKey{expr: "document_templates.id", sqlType: "text", desc: true}
+
+	switch filter.Sort {
+	case "popular":
+		// Sort by actual document count - most used first
+		return "popular", []templateSortKey{
+			{expr: "COALESCE(doc_counts.doc_count, 0)", sqlType: "bigint", desc: true},
+			createdAt,
+			idDesc,
+		}
+	case "name":
+		return "name", []templateSortKey{
+			{expr: "COALESCE(NULLIF(document_templates.display_name, ''), document_templates.filename)", sqlType: "text"},
+			idAsc,
+		}
+	case "recent":
+		return "recent", []templateSortKey{createdAt, idDesc}
+	case "top_rated":
+		// Bayesian average so a single 5-star rating does not outrank many good ratings
+		return "top_rated", []templateSortKey{
+			{expr: topRatedScoreExpr, sqlType: "double precision", desc: true},
+			{expr: "COALESCE(document_templates.rating_count, 0)", sqlType: "int", desc: true},
+			createdAt,
+			idDesc,
+		}
+	case "", "relevance":
+		if tsQuery := buildSearchTSQuery(filter.Search); filter.Search != "" && tsQuery != "" {
+			return "relevance", []templateSortKey{
+				{expr: "COALESCE(ts_rank_cd(tsd.search_vector, to_tsquery('simple', ?)), 0)", sqlType: "real", desc: true, vars: []interface{}{tsQuery}},
+				createdAt,
+				idDesc,
+			}
+		}
+		if filter.Sort == "relevance" {
+			return "recent", []templateSortKey{createdAt, idDesc}
+		}
+	}
+
+	// Default: order by variant_order within document type (templates without a type last), then by created_at
+	keys := []templateSortKey{
+		{expr: "(document_templates.document_type_id IS NULL)", sqlType: "boolean"},
+		{expr: "COALESCE(document_templates.document_type_id, '')", sqlType: "text"},
+		{expr: "COALESCE(document_templates.variant_order, 0)", sqlType: "int"},
+		createdAt,
+		idAsc,
+	}
+	if filter.BoostUserID != "" {
+		// The user's recently used templates first (most recent first), then the default order
+		recency := templateSortKey{
+			expr:    "COALESCE(" + userRecencyExpr + ", 'epoch'::timestamp)",
+			sqlType: "timestamp",
+			desc:    true,
+			vars:    []interface{}{filter.BoostUserID, filter.BoostUserID},
+		}
+		return "default_boosted", append([]templateSortKey{recency}, keys...)
+	}
+	return "default", keys
+}
+
+// applyTemplateSortJoins adds the joins a sort mode's keys refer to
+// The search join is already present on filtered queries, so it is only added when includeSearch is set
+func applyTemplateSortJoins(query *gorm.DB, mode string, includeSearch bool) *gorm.DB {
+	if mode == "popular" {
+		query = query.Joins(popularityJoin)
+	}
+	if mode == "relevance" && includeSearch {
+		query = query.Joins(templateSearchJoin)
+	}
+	return query
+}
+
+// templateOrderBy builds a single ORDER BY expression for all keys
+func templateOrderBy(keys []templateSortKey) clause.OrderBy {
+	parts := make([]string, len(keys))
+	var vars []interface{}
+	for i, key := range keys {
+		direction := "ASC"
+		if key.desc {
+			direction = "DESC"
+		}
+		parts[i] = key.expr + " " + direction
+		vars = append(vars, key.vars...)
+	}
+	return clause.OrderBy{Expression: clause.Expr{SQL: strings.Join(parts, ", "), Vars: vars, WithoutParentheses: true}}
+}
+
+// templateKeysetCondition builds the WHERE clause selecting rows after the cursor position
+func templateKeysetCondition(keys []templateSortKey, values []string) (string, []interface{}) {
+	var clauses []string
+	var vars []interface{}
+	for i, key := range keys {
+		var parts []string
+		for j := 0; j < i; j++ {
+			parts = append(parts, fmt.Sprintf("%s = CAST(? AS %s)", keys[j].expr, keys[j].sqlType))
+			vars = append(vars, keys[j].vars...)
+			vars = append(vars, values[j])
+		}
+		op := ">"
+		if key.desc {
+			op = "<"
+		}
+		parts = append(parts, fmt.Sprintf("%s %s CAST(? AS %s)", key.expr, op, key.sqlType))
+		vars = append(vars, key.vars...)
+		vars = append(vars, values[i])
+		clauses = append(clauses, "("+strings.Join(parts, " AND ")+")")
+	}
+	return "(" + strings.Join(clauses, " OR ") + ")", vars
+}
+
+// templateCursor is the decoded form of an opaque pagination cursor
+type templateCursor struct {
+	Sort   string   `json:"s"`
+	Values []string `json:"v"`
+}
+
+func encodeTemplateCursor(mode string, values []string) string {
+	data, _ := json.Marshal(templateCursor{Sort: mode, Values: values})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeTemplateCursor(cursor, mode string, keyCount int) ([]string, error) {
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	var decoded templateCursor
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return nil, ErrInvalidCursor
+	}
+	if decoded.Sort != mode || len(decoded.Values) != keyCount {
+		return nil, fmt.Errorf("%w: cursor does not match the requested sort", ErrInvalidCursor)
+	}
+	return decoded.Values, nil
+}
+
+// templateCursorValues reads the sort key values of a template to build the next cursor
+func templateCursorValues(templateID, mode string, keys []templateSortKey) ([]string, error) {
+	selects := make([]string, len(keys))
+	var vars []interface{}
+	for i, key := range keys {
+		selects[i] = fmt.Sprintf("CAST(%s AS text)", key.expr)
+		vars = append(vars, key.vars...)
+	}
+
+	query := internal.DB.Table("document_templates").Select(strings.Join(selects, ", "), vars...)
+	query = applyTemplateSortJoins(query, mode, true)
+
+	values := make([]string, len(keys))
+	dest := make([]interface{}, len(keys))
+	for i := range values {
+		dest[i] = &values[i]
+	}
+	if err := query.Where("document_templates.id = ?", templateID).Row().Scan(dest...); err != nil {
+		return nil, fmt.Errorf("failed to build cursor: %w", err)
+	}
+	return values, nil
+}
@@ -0,0 +1,362 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime/multipart"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"DF-PLCH/internal"
+	"DF-PLCH/internal/models"
+	"DF-PLCH/internal/processor"
+	"DF-PLCH/internal/storage"
+	"DF-PLCH/internal/utils"
+
+	"github.com/google/uuid"
+)
+
+// ErrInvalidOverlayBackground is returned when an overlay background cannot be read
+var ErrInvalidOverlayBackground = errors.New("invalid overlay background")
+
+// ErrInvalidOverlayLayout is returned when saved field positions are not valid
+var ErrInvalidOverlayLayout = errors.New("invalid overlay layout")
+
+// ErrNotOverlay is returned by overlay features on other template formats
+var ErrNotOverlay = errors.New("this operation is only supported for overlay templates")
+
+var overlayColorPattern = regexp.MustCompile(`^#[0-9a-fA-F]{6}$`)
+
+// OverlayLayout is what the positioning editor needs: page sizes and the fields on them
+type OverlayLayout struct {
+	TemplateID string                  `json:"template_id"`
+	Pages      []processor.PDFPageSize `json:"pages"`
+	Fields     []OverlayField          `json:"fields"`
+}
+
+// OverlayField is a placeholder and its position on the background
+type OverlayField struct {
+	Key       string               `json:"key"`
+	Label     string               `json:"label,omitempty"`
+	InputType utils.InputType      `json:"input_type"`
+	Position  *utils.FieldPosition `json:"position"`
+}
+
+// UploadOverlayTemplate creates an overlay template from a scanned form
+// The background can be a PDF or a PNG/JPEG image (wrapped in an A4-wide PDF page). The template
+// starts without fields; they are added by saving positions with UpdateOverlayLayout
+func (s *TemplateService) UploadOverlayTemplate(ctx context.Context, file multipart.File, header *multipart.FileHeader, fileName, description, author string) (*models.Template, error) {
+	tempFile, err := s.createTempFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temp file: %w", err)
+	}
+	defer s.cleanupTempFile(tempFile)
+
+	ext := strings.ToLower(filepath.Ext(header.Filename))
+	backgroundPath := tempFile
+	switch ext {
+	case ".pdf":
+	case ".png", ".jpg", ".jpeg":
+		imageData, err := os.ReadFile(tempFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read image: %w", err)
+		}
+		backgroundPath = tempFile + ".pdf"
+		defer os.Remove(backgroundPath)
+		if err := processor.ImageToPDF(imageData, backgroundPath); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidOverlayBackground, err)
+		}
+	default:
+		return nil, fmt.Errorf("%w: expected .pdf, .png or .jpg, got %s", ErrInvalidOverlayBackground, ext)
+	}
+
+	pages, err := processor.PDFPageSizes(backgroundPath)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidOverlayBackground, err)
+	}
+	if len(pages) == 0 {
+		return nil, fmt.Errorf("%w: the PDF has no pages", ErrInvalidOverlayBackground)
+	}
+
+	// Image backgrounds are stored as the PDF they were converted to
+	filename := strings.TrimSuffix(header.Filename, filepath.Ext(header.Filename)) + ".pdf"
+	templateID := uuid.New().String()
+	objectName := storage.GenerateObjectName(templateID, filename)
+
+	background, err := os.Open(backgroundPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open background PDF: %w", err)
+	}
+	defer background.Close()
+	result, err := s.storageClient.UploadFile(ctx, background, objectName, models.MimeTypePDF)
+	if err != nil {
+		return nil, fmt.Errorf("failed to upload to GCS: %w", err)
+	}
+
+	template := &models.Template{
+		ID:               templateID,
+		Filename:         filename,
+		OriginalName:     header.Filename,
+		DisplayName:      fileName,
+		Description:      description,
+		Author:           author,
+		GCSPath:          objectName,
+		GCSPathPDF:       objectName,
+		GCSPathThumbnail: s.generatePDFThumbnail(ctx, templateID, filename, backgroundPath),
+		FileSize:         result.Size,
+		MimeType:         models.MimeTypePDF,
+		Format:           models.FormatOverlay,
+		Placeholders:     "[]",
+		FieldDefinitions: "{}",
+		Translations:     "{}",
+		Aliases:          "{}",
+		PageOrientation:  models.OrientationPortrait,
+	}
+	if pages[0].Width > pages[0].Height {
+		template.PageOrientation = models.OrientationLandscape
+	}
+
+	if err := internal.DB.Create(template).Error; err != nil {
+		s.storageClient.DeleteFile(ctx, objectName)
+		if template.GCSPathThumbnail != "" {
+			s.storageClient.DeleteFile(ctx, template.GCSPathThumbnail)
+		}
+		return nil, fmt.Errorf("failed to save template metadata: %w", err)
+	}
+
+	bodyText := ""
+	if err := indexTemplateSearch(template, &bodyText); err != nil {
+		fmt.Printf("[WARNING] %v\n", err)
+	}
+
+	s.publishTemplateEvent(models.WebhookEventTemplatePublished, template, "")
+	return template, nil
+}
+
+// GetOverlayLayout returns the background page sizes and the positioned fields of an overlay template
+func (s *TemplateService) GetOverlayLayout(ctx context.Context, templateID string) (*OverlayLayout, error) {
+	template, err := s.GetTemplate(templateID)
+	if err != nil {
+		return nil, err
+	}
+	if !template.IsOverlay() {
+		return nil, ErrNotOverlay
+	}
+
+	pages, err := s.overlayPageSizes(ctx, template)
+	if err != nil {
+		return nil, err
+	}
+
+	definitions := parseFieldDefinitions(template.FieldDefinitions)
+	keys := make([]string, 0, len(definitions))
+	for key, definition := range definitions {
+		if definition.Position != nil {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	layout := &OverlayLayout{TemplateID: template.ID, Pages: pages, Fields: make([]OverlayField, 0, len(keys))}
+	for _, key := range keys {
+		definition := definitions[key]
+		layout.Fields = append(layout.Fields, OverlayField{
+			Key:       key,
+			Label:     definition.Label,
+			InputType: definition.InputType,
+			Position:  definition.Position,
+		})
+	}
+	return layout, nil
+}
+
+// UpdateOverlayLayout replaces the positions of an overlay template's fields
+// positions is keyed by placeholder (with or without braces). New keys get field definitions
+// generated like DOCX placeholders; fields missing from positions are removed from the template
+func (s *TemplateService) UpdateOverlayLayout(ctx context.Context, templateID string, positions map[string]utils.FieldPosition) (*OverlayLayout, error) {
+	template, err := s.GetTemplate(templateID)
+	if err != nil {
+		return nil, err
+	}
+	if !template.IsOverlay() {
+		return nil, ErrNotOverlay
+	}
+
+	pages, err := s.overlayPageSizes(ctx, template)
+	if err != nil {
+		return nil, err
+	}
+
+	normalized := make(map[string]utils.FieldPosition, len(positions))
+	for placeholder, position := range positions {
+		key := placeholderKey(placeholder)
+		if key == "" || strings.ContainsAny(key, "{}") {
+			return nil, fmt.Errorf("%w: invalid placeholder '%s'", ErrInvalidOverlayLayout, placeholder)
+		}
+		if _, exists := normalized[key]; exists {
+			return nil, fmt.Errorf("%w: duplicate placeholder '%s'", ErrInvalidOverlayLayout, key)
+		}
+		if err := validateFieldPosition(key, position, pages); err != nil {
+			return nil, err
+		}
+		normalized[key] = position
+	}
+
+	keys := make([]string, 0, len(normalized))
+	for key := range normalized {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	existing := parseFieldDefinitions(template.FieldDefinitions)
+	var added []string
+	for _, key := range keys {
+		if _, ok := existing[key]; !ok {
+			added = append(added, "{{"+key+"}}")
+		}
+	}
+	generated := generateFieldDefinitionsFromDatabase(added)
+
+	placeholders := make([]string, 0, len(keys))
+	definitions := make(map[string]utils.FieldDefinition, len(keys))
+	for _, key := range keys {
+		position := normalized[key]
+		definition, ok := existing[key]
+		if !ok {
+			definition = generated[key]
+			if position.Kind == processor.OverlayKindCheck {
+				definition.DataType = utils.DataTypeText
+				definition.InputType = utils.InputTypeCheckbox
+				definition.Validation = nil
+			}
+		}
+		definition.Position = &position
+		definitions[key] = definition
+		placeholders = append(placeholders, "{{"+key+"}}")
+	}
+
+	placeholdersJSON, err := json.Marshal(placeholders)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal placeholders: %w", err)
+	}
+	fieldDefinitionsJSON, err := json.Marshal(definitions)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal field definitions: %w", err)
+	}
+
+	template.Placeholders = string(placeholdersJSON)
+	template.FieldDefinitions = string(fieldDefinitionsJSON)
+	if err := internal.DB.Save(template).Error; err != nil {
+		return nil, fmt.Errorf("failed to update overlay layout: %w", err)
+	}
+
+	bodyText := overlaySearchText(definitions)
+	if err := indexTemplateSearch(template, &bodyText); err != nil {
+		fmt.Printf("[WARNING] %v\n", err)
+	}
+
+	s.publishTemplateEvent(models.WebhookEventTemplateUpdated, template, "overlay_layout")
+	return s.GetOverlayLayout(ctx, templateID)
+}
+
+// overlayPageSizes reads the page sizes of an overlay template's background
+func (s *TemplateService) overlayPageSizes(ctx context.Context, template *models.Template) ([]processor.PDFPageSize, error) {
+	reader, err := s.storageClient.ReadFile(ctx, template.GCSPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read background from storage: %w", err)
+	}
+	tempFile, err := s.createTempFile(reader)
+	reader.Close()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temp file: %w", err)
+	}
+	defer s.cleanupTempFile(tempFile)
+
+	pages, err := processor.PDFPageSizes(tempFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read background pages: %w", err)
+	}
+	return pages, nil
+}
+
+// validateFieldPosition checks that a position lies on an existing page and uses known settings
+func validateFieldPosition(key string, position utils.FieldPosition, pages []processor.PDFPageSize) error {
+	invalid := func(format string, args ...interface{}) error {
+		return fmt.Errorf("%w: %s: %s", ErrInvalidOverlayLayout, key, fmt.Sprintf(format, args...))
+	}
+
+	if position.Page < 1 || position.Page > len(pages) {
+		return invalid("page %d does not exist (the background has %d pages)", position.Page, len(pages))
+	}
+	page := pages[position.Page-1]
+	if position.X < 0 || position.Y < 0 || position.X > page.Width || position.Y > page.Height {
+		return invalid("position (%g, %g) is outside the page (%g x %g)", position.X, position.Y, page.Width, page.Height)
+	}
+	if position.Width < 0 || position.Height < 0 {
+		return invalid("width and height must not be negative")
+	}
+	if position.FontSize != 0 && (position.FontSize < 4 || position.FontSize > 72) {
+		return invalid("font size must be between 4 and 72")
+	}
+	if position.Comb < 0 || (position.Comb > 0 && position.Width == 0) {
+		return invalid("comb fields need a width and a positive number of boxes")
+	}
+
+	switch position.Kind {
+	case "", processor.OverlayKindText, processor.OverlayKindCheck, processor.OverlayKindImage:
+	default:
+		return invalid("unknown kind '%s' (expected text, check or image)", position.Kind)
+	}
+	switch position.Align {
+	case "", "left", "center", "right":
+	default:
+		return invalid("unknown align '%s' (expected left, center or right)", position.Align)
+	}
+	switch position.Mark {
+	case "", "check", "cross", "dot":
+	default:
+		return invalid("unknown mark '%s' (expected check, cross or dot)", position.Mark)
+	}
+	if position.Color != "" && !overlayColorPattern.MatchString(position.Color) {
+		return invalid("color must be #RRGGBB")
+	}
+	return nil
+}
+
+// overlayPlaceholders returns the placeholders of the positioned fields, sorted
+func overlayPlaceholders(definitions map[string]utils.FieldDefinition) []string {
+	placeholders := []string{}
+	for key, definition := range definitions {
+		if definition.Position != nil {
+			placeholders = append(placeholders, "{{"+key+"}}")
+		}
+	}
+	sort.Strings(placeholders)
+	return placeholders
+}
+
+// overlaySearchText joins the labels and keys of positioned fields for full-text search
+// (the scanned background has no extractable text)
+func overlaySearchText(definitions map[string]utils.FieldDefinition) string {
+	keys := make([]string, 0, len(definitions))
+	for key, definition := range definitions {
+		if definition.Position != nil {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	parts := make([]string, 0, len(keys)*2)
+	for _, key := range keys {
+		if label := definitions[key].Label; label != "" {
+			parts = append(parts, label)
+		}
+		parts = append(parts, key)
+	}
+	return strings.Join(parts, "\n")
+}
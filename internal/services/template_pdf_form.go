@@ -0,0 +1,248 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"path/filepath"
+	"strings"
+
+	"DF-PLCH/internal"
+	"DF-PLCH/internal/models"
+	"DF-PLCH/internal/processor"
+	"DF-PLCH/internal/storage"
+	"DF-PLCH/internal/utils"
+
+	"github.com/google/uuid"
+)
+
+// ErrInvalidPDFForm is returned when an uploaded PDF cannot be used as a form template
+var ErrInvalidPDFForm = errors.New("invalid PDF form")
+
+// ErrDocxOnly is returned by features that only work on DOCX templates
+var ErrDocxOnly = errors.New("this operation is only supported for DOCX templates")
+
+// UploadPDFFormTemplate creates a template from a fillable PDF (AcroForm)
+// Every form field becomes a placeholder ({{field name}}); field types, options, lengths and
+// required flags are carried over to the field definitions. The PDF doubles as the preview
+func (s *TemplateService) UploadPDFFormTemplate(ctx context.Context, file multipart.File, header *multipart.FileHeader, fileName, description, author string, aliases map[string]string) (*models.Template, error) {
+	tempFile, err := s.createTempFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temp file: %w", err)
+	}
+	defer s.cleanupTempFile(tempFile)
+
+	fields, err := processor.ExtractPDFFormFields(tempFile)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPDFForm, err)
+	}
+	placeholders := pdfFormPlaceholders(fields)
+	if len(placeholders) == 0 {
+		return nil, fmt.Errorf("%w: the PDF has no fillable fields", ErrInvalidPDFForm)
+	}
+
+	templateID := uuid.New().String()
+	objectName := storage.GenerateObjectName(templateID, header.Filename)
+
+	file.Seek(0, 0)
+	result, err := s.storageClient.UploadFile(ctx, file, objectName, models.MimeTypePDF)
+	if err != nil {
+		return nil, fmt.Errorf("failed to upload to GCS: %w", err)
+	}
+
+	placeholdersJSON, err := json.Marshal(placeholders)
+	if err != nil {
+		s.storageClient.DeleteFile(ctx, objectName)
+		return nil, fmt.Errorf("failed to marshal placeholders: %w", err)
+	}
+
+	fieldDefinitions := generateFieldDefinitionsFromDatabase(placeholders)
+	applyPDFFormFields(fieldDefinitions, fields)
+	fieldDefinitionsJSON, err := json.Marshal(fieldDefinitions)
+	if err != nil {
+		s.storageClient.DeleteFile(ctx, objectName)
+		return nil, fmt.Errorf("failed to marshal field definitions: %w", err)
+	}
+
+	template := &models.Template{
+		ID:               templateID,
+		Filename:         header.Filename,
+		OriginalName:     header.Filename,
+		DisplayName:      fileName,
+		Description:      description,
+		Author:           author,
+		GCSPath:          objectName,
+		GCSPathPDF:       objectName,
+		GCSPathThumbnail: s.generatePDFThumbnail(ctx, templateID, header.Filename, tempFile),
+		FileSize:         result.Size,
+		MimeType:         models.MimeTypePDF,
+		Format:           models.FormatPDFForm,
+		Placeholders:     string(placeholdersJSON),
+		FieldDefinitions: string(fieldDefinitionsJSON),
+		Translations:     "{}",
+		Aliases:          "{}",
+		PageOrientation:  models.OrientationPortrait,
+	}
+
+	if len(aliases) > 0 {
+		aliasesBytes, err := json.Marshal(aliases)
+		if err != nil {
+			s.storageClient.DeleteFile(ctx, objectName)
+			return nil, fmt.Errorf("failed to marshal aliases: %w", err)
+		}
+		template.Aliases = string(aliasesBytes)
+	}
+
+	if err := internal.DB.Create(template).Error; err != nil {
+		s.storageClient.DeleteFile(ctx, objectName)
+		if template.GCSPathThumbnail != "" {
+			s.storageClient.DeleteFile(ctx, template.GCSPathThumbnail)
+		}
+		return nil, fmt.Errorf("failed to save template metadata: %w", err)
+	}
+
+	// Field labels and names stand in for body text in the search index
+	bodyText := pdfFormSearchText(fields)
+	if err := indexTemplateSearch(template, &bodyText); err != nil {
+		fmt.Printf("[WARNING] %v\n", err)
+	}
+
+	s.publishTemplateEvent(models.WebhookEventTemplatePublished, template, "")
+	return template, nil
+}
+
+// GetPDFFormFields returns the AcroForm fields of a PDF form template
+func (s *TemplateService) GetPDFFormFields(ctx context.Context, templateID string) ([]processor.PDFFormField, error) {
+	template, err := s.GetTemplate(templateID)
+	if err != nil {
+		return nil, err
+	}
+	if !template.IsPDFForm() {
+		return nil, fmt.Errorf("%w: template is not a PDF form", ErrInvalidPDFForm)
+	}
+
+	reader, err := s.storageClient.ReadFile(ctx, template.GCSPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read template from storage: %w", err)
+	}
+	defer reader.Close()
+
+	tempFile, err := s.createTempFile(reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temp file: %w", err)
+	}
+	defer s.cleanupTempFile(tempFile)
+
+	fields, err := processor.ExtractPDFFormFields(tempFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read PDF form fields: %w", err)
+	}
+	return fields, nil
+}
+
+// generatePDFThumbnail renders the first page of a PDF template; returns "" when unavailable
+func (s *TemplateService) generatePDFThumbnail(ctx context.Context, templateID, filename, pdfPath string) string {
+	if s.conversionService == nil || !s.conversionService.IsThumbnailGenerationAvailable() {
+		return ""
+	}
+
+	thumbnailContent, err := s.conversionService.GenerateThumbnailFromPDFWithQuality(ctx, pdfPath, 600, ThumbnailQualityHD)
+	if err != nil {
+		fmt.Printf("[WARNING] Failed to generate thumbnail for PDF template %s: %v\n", templateID, err)
+		return ""
+	}
+
+	thumbnailFileName := strings.TrimSuffix(filename, filepath.Ext(filename)) + "_thumb.png"
+	thumbnailObjectName := storage.GenerateObjectName(templateID, thumbnailFileName)
+	if _, err := s.storageClient.UploadFile(ctx, io.NopCloser(bytes.NewReader(thumbnailContent)), thumbnailObjectName, "image/png"); err != nil {
+		fmt.Printf("[WARNING] Failed to upload thumbnail: %v\n", err)
+		return ""
+	}
+	return thumbnailObjectName
+}
+
+// pdfFormPlaceholders returns one placeholder per fillable field, in form order
+// Signature fields are skipped since they cannot be filled with text
+func pdfFormPlaceholders(fields []processor.PDFFormField) []string {
+	seen := make(map[string]bool)
+	var placeholders []string
+	for _, field := range fields {
+		if field.Type == processor.PDFFieldSignature || seen[field.Name] {
+			continue
+		}
+		seen[field.Name] = true
+		placeholders = append(placeholders, "{{"+field.Name+"}}")
+	}
+	return placeholders
+}
+
+// applyPDFFormFields overlays what the PDF itself says about each field on the generated
+// field definitions: input type, options, maximum length, required flag and label
+func applyPDFFormFields(definitions map[string]utils.FieldDefinition, fields []processor.PDFFormField) {
+	for _, field := range fields {
+		definition, ok := definitions[field.Name]
+		if !ok {
+			continue
+		}
+
+		switch field.Type {
+		case processor.PDFFieldText:
+			if field.Multiline {
+				definition.InputType = utils.InputTypeTextarea
+			}
+			if field.MaxLength > 0 {
+				if definition.Validation == nil {
+					definition.Validation = &utils.FieldValidation{}
+				}
+				maxLength := field.MaxLength
+				definition.Validation.MaxLength = &maxLength
+			}
+		case processor.PDFFieldCheckbox:
+			definition.DataType = utils.DataTypeText
+			definition.InputType = utils.InputTypeCheckbox
+			definition.Validation = nil
+			if field.Value != "" && field.Value != "Off" {
+				definition.DefaultValue = "/"
+			}
+		case processor.PDFFieldRadio, processor.PDFFieldChoice:
+			// Radio states and choice export values are what the form accepts
+			definition.DataType = utils.DataTypeText
+			definition.InputType = utils.InputTypeSelect
+			definition.Validation = &utils.FieldValidation{Options: field.Options}
+			if field.Value != "" && field.Value != "Off" {
+				definition.DefaultValue = field.Value
+			}
+		}
+
+		if field.Required {
+			if definition.Validation == nil {
+				definition.Validation = &utils.FieldValidation{}
+			}
+			definition.Validation.Required = true
+		}
+		if field.Label != "" {
+			definition.Label = field.Label
+		}
+		if field.Type == processor.PDFFieldText && field.Value != "" {
+			definition.DefaultValue = field.Value
+		}
+
+		definitions[field.Name] = definition
+	}
+}
+
+// pdfFormSearchText joins field labels and names for full-text search
+func pdfFormSearchText(fields []processor.PDFFormField) string {
+	parts := make([]string, 0, len(fields))
+	for _, field := range fields {
+		if field.Label != "" {
+			parts = append(parts, field.Label)
+		}
+		parts = append(parts, field.Name)
+	}
+	return strings.Join(parts, "\n")
+}
@@ -0,0 +1,488 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"DF-PLCH/internal"
+	"DF-PLCH/internal/models"
+	"DF-PLCH/internal/processor"
+	"DF-PLCH/internal/storage"
+	"DF-PLCH/internal/utils"
+
+	"gorm.io/gorm"
+)
+
+// ErrInvalidRename is returned when a placeholder rename mapping cannot be applied
+var ErrInvalidRename = errors.New("invalid rename")
+
+// placeholderKeyInvalidChars matches characters that cannot appear in a placeholder key
+var placeholderKeyInvalidChars = regexp.MustCompile(`[{}<>&"\s]`)
+
+// PlaceholderRenameResult describes a completed placeholder rename
+type PlaceholderRenameResult struct {
+	Template         *models.Template
+	Renamed          map[string]string // Old key -> new key (without braces)
+	Occurrences      map[string]int    // Old key -> number of tokens rewritten in the DOCX
+	DocumentsUpdated int               // Documents whose stored data was rewritten
+	FixturesUpdated  int               // Regression fixtures whose data was rewritten
+	DraftsUpdated    int               // Form drafts whose data was rewritten
+	Warnings         []string
+}
+
+// placeholderKey strips the braces from a placeholder ("{{name}}" -> "name")
+func placeholderKey(placeholder string) string {
+	key := strings.TrimSpace(placeholder)
+	key = strings.TrimPrefix(key, "{{")
+	key = strings.TrimSuffix(key, "}}")
+	return strings.TrimSpace(key)
+}
+
+// renamePlaceholderRef renames a key or "{{key}}" reference, keeping its form
+func renamePlaceholderRef(ref string, renames map[string]string) (string, bool) {
+	if newKey, ok := renames[ref]; ok {
+		return newKey, true
+	}
+	if strings.HasPrefix(ref, "{{") && strings.HasSuffix(ref, "}}") {
+		if newKey, ok := renames[placeholderKey(ref)]; ok {
+			return "{{" + newKey + "}}", true
+		}
+	}
+	return ref, false
+}
+
+// RenamePlaceholders renames placeholders in the stored DOCX and migrates the template's
+// placeholders, field definitions, aliases and (optionally) the data of its generated documents
+// renames maps old keys to new keys; braces are optional ("name" and "{{name}}" are the same)
+func (s *TemplateService) RenamePlaceholders(ctx context.Context, templateID string, renames map[string]string, rewriteDocuments bool) (*PlaceholderRenameResult, error) {
+	template, err := s.GetTemplate(templateID)
+	if err != nil {
+		return nil, err
+	}
+	if !template.IsDocx() {
+		return nil, ErrDocxOnly
+	}
+
+	var placeholders []string
+	if template.Placeholders != "" {
+		if err := json.Unmarshal([]byte(template.Placeholders), &placeholders); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal placeholders: %w", err)
+		}
+	}
+
+	keyRenames, err := normalizePlaceholderRenames(renames, placeholders)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &PlaceholderRenameResult{
+		Renamed:     keyRenames,
+		Occurrences: make(map[string]int, len(keyRenames)),
+	}
+
+	// Rewrite the tokens inside the DOCX
+	tokens := make(map[string]string, len(keyRenames))
+	for oldKey, newKey := range keyRenames {
+		tokens["{{"+oldKey+"}}"] = "{{" + newKey + "}}"
+	}
+
+	reader, err := s.storageClient.ReadFile(ctx, template.GCSPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read template from storage: %w", err)
+	}
+	tempInput, err := s.createTempFile(reader)
+	reader.Close()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temp file: %w", err)
+	}
+	defer s.cleanupTempFile(tempInput)
+
+	tempOutput := tempInput + ".renamed.docx"
+	defer os.Remove(tempOutput)
+
+	proc := processor.NewDocxProcessor(tempInput, tempOutput)
+	if err := proc.UnzipDocx(); err != nil {
+		return nil, fmt.Errorf("failed to unzip document: %w", err)
+	}
+	defer proc.Cleanup()
+
+	counts, err := proc.RenamePlaceholders(tokens)
+	if err != nil {
+		return nil, err
+	}
+	for token, count := range counts {
+		oldKey := placeholderKey(token)
+		result.Occurrences[oldKey] = count
+		if count == 0 {
+			result.Warnings = append(result.Warnings, fmt.Sprintf("placeholder '%s' was not found in the DOCX body", oldKey))
+		}
+	}
+
+	// New body text for the search index (nil keeps the indexed text if extraction fails)
+	var bodyText *string
+	if text, err := proc.ExtractText(); err != nil {
+		fmt.Printf("[WARNING] Failed to extract text for search index: %v\n", err)
+	} else {
+		bodyText = &text
+	}
+
+	if err := proc.ReZipDocx(); err != nil {
+		return nil, fmt.Errorf("failed to create output document: %w", err)
+	}
+
+	// Upload the rewritten DOCX; the old file is deleted once the database is updated
+	docxFile, err := os.Open(tempOutput)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open output file: %w", err)
+	}
+	docxObjectName := storage.GenerateObjectName(template.ID, template.Filename)
+	uploadResult, err := s.storageClient.UploadFile(ctx, docxFile, docxObjectName, "application/vnd.openxmlformats-officedocument.wordprocessingml.document")
+	docxFile.Close()
+	if err != nil {
+		return nil, fmt.Errorf("failed to upload renamed DOCX: %w", err)
+	}
+
+	oldPaths := []string{template.GCSPath}
+	template.GCSPath = docxObjectName
+	template.FileSize = uploadResult.Size
+
+	// Rewrite the HTML preview so it shows the new tokens (best effort)
+	if template.GCSPathHTML != "" {
+		htmlObjectName, err := s.renameHTMLPreviewTokens(ctx, template, tokens)
+		if err != nil {
+			result.Warnings = append(result.Warnings, fmt.Sprintf("HTML preview was not updated: %v", err))
+		} else {
+			oldPaths = append(oldPaths, template.GCSPathHTML)
+			template.GCSPathHTML = htmlObjectName
+		}
+	}
+
+	// Migrate placeholders, field definitions and aliases
+	for i, placeholder := range placeholders {
+		placeholders[i], _ = renamePlaceholderRef(placeholder, keyRenames)
+	}
+	placeholdersJSON, err := json.Marshal(placeholders)
+	if err != nil {
+		s.storageClient.DeleteFile(ctx, docxObjectName)
+		return nil, fmt.Errorf("failed to marshal placeholders: %w", err)
+	}
+	template.Placeholders = string(placeholdersJSON)
+
+	if template.FieldDefinitions != "" {
+		var definitions map[string]utils.FieldDefinition
+		if err := json.Unmarshal([]byte(template.FieldDefinitions), &definitions); err != nil {
+			s.storageClient.DeleteFile(ctx, docxObjectName)
+			return nil, fmt.Errorf("failed to unmarshal field definitions: %w", err)
+		}
+		definitionsJSON, err := json.Marshal(renameFieldDefinitions(definitions, keyRenames))
+		if err != nil {
+			s.storageClient.DeleteFile(ctx, docxObjectName)
+			return nil, fmt.Errorf("failed to marshal field definitions: %w", err)
+		}
+		template.FieldDefinitions = string(definitionsJSON)
+	}
+
+	if template.Aliases != "" {
+		var aliases map[string]string
+		if err := json.Unmarshal([]byte(template.Aliases), &aliases); err == nil {
+			renamed := make(map[string]string, len(aliases))
+			for key, alias := range aliases {
+				newKey, _ := renamePlaceholderRef(key, keyRenames)
+				renamed[newKey] = alias
+			}
+			aliasesJSON, err := json.Marshal(renamed)
+			if err != nil {
+				s.storageClient.DeleteFile(ctx, docxObjectName)
+				return nil, fmt.Errorf("failed to marshal aliases: %w", err)
+			}
+			template.Aliases = string(aliasesJSON)
+		}
+	}
+
+	err = internal.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(template).Error; err != nil {
+			return err
+		}
+		// Fixtures always follow the rename, otherwise they fail and block file replacement
+		fixtures, err := renameFixtureData(tx, template.ID, keyRenames)
+		if err != nil {
+			return err
+		}
+		result.FixturesUpdated = fixtures
+		// Drafts always follow too, so resuming one after the rename keeps its values
+		drafts, err := renameDraftData(tx, template.ID, keyRenames)
+		if err != nil {
+			return err
+		}
+		result.DraftsUpdated = drafts
+		if rewriteDocuments {
+			updated, err := renameDocumentData(tx, template.ID, keyRenames)
+			if err != nil {
+				return err
+			}
+			result.DocumentsUpdated = updated
+		}
+		return nil
+	})
+	if err != nil {
+		s.storageClient.DeleteFile(ctx, docxObjectName)
+		if len(oldPaths) > 1 {
+			s.storageClient.DeleteFile(ctx, template.GCSPathHTML)
+		}
+		return nil, fmt.Errorf("failed to save renamed placeholders: %w", err)
+	}
+
+	for _, path := range oldPaths {
+		// Never delete an object the template still points at
+		if path == template.GCSPath || path == template.GCSPathHTML {
+			continue
+		}
+		if err := s.storageClient.DeleteFile(ctx, path); err != nil {
+			fmt.Printf("[WARNING] Failed to delete old file %s: %v\n", path, err)
+		}
+	}
+
+	// Regenerate the PDF preview from the new DOCX (best effort)
+	if template.GCSPathPDF != "" {
+		oldPDFPath := template.GCSPathPDF
+		if _, _, err := s.GenerateAndStorePDFPreview(ctx, template); err != nil {
+			result.Warnings = append(result.Warnings, fmt.Sprintf("PDF preview was not regenerated: %v", err))
+		} else if oldPDFPath == template.GCSPathPDF {
+			// Same object name: the new preview replaced the old one in place
+		} else if err := s.storageClient.DeleteFile(ctx, oldPDFPath); err != nil {
+			fmt.Printf("[WARNING] Failed to delete old PDF preview %s: %v\n", oldPDFPath, err)
+		}
+	}
+
+	if err := indexTemplateSearch(template, bodyText); err != nil {
+		fmt.Printf("[WARNING] %v\n", err)
+	}
+
+	fmt.Printf("[INFO] Renamed %d placeholders in template %s (%d documents, %d fixtures, %d drafts updated)\n", len(keyRenames), template.ID, result.DocumentsUpdated, result.FixturesUpdated, result.DraftsUpdated)
+
+	s.publishTemplateEvent(models.WebhookEventTemplateUpdated, template, "placeholders")
+	result.Template = template
+	return result, nil
+}
+
+// normalizePlaceholderRenames strips braces, drops no-op entries and validates the mapping
+// against the template's placeholders
+func normalizePlaceholderRenames(renames map[string]string, placeholders []string) (map[string]string, error) {
+	existing := make(map[string]bool, len(placeholders))
+	for _, placeholder := range placeholders {
+		existing[placeholderKey(placeholder)] = true
+	}
+
+	keyRenames := make(map[string]string, len(renames))
+	targets := make(map[string]string, len(renames))
+	for oldRef, newRef := range renames {
+		oldKey, newKey := placeholderKey(oldRef), placeholderKey(newRef)
+		if oldKey == newKey {
+			continue
+		}
+		if !existing[oldKey] {
+			return nil, fmt.Errorf("%w: placeholder '%s' not found in template", ErrInvalidRename, oldKey)
+		}
+		if newKey == "" || placeholderKeyInvalidChars.MatchString(newKey) {
+			return nil, fmt.Errorf("%w: '%s' is not a valid placeholder name", ErrInvalidRename, newRef)
+		}
+		if other, ok := targets[newKey]; ok {
+			return nil, fmt.Errorf("%w: '%s' and '%s' are both renamed to '%s'", ErrInvalidRename, other, oldKey, newKey)
+		}
+		targets[newKey] = oldKey
+		keyRenames[oldKey] = newKey
+	}
+
+	if len(keyRenames) == 0 {
+		return nil, fmt.Errorf("%w: no placeholders to rename", ErrInvalidRename)
+	}
+
+	// A new key may only reuse an existing name if that placeholder is renamed away too
+	for newKey := range targets {
+		if _, renamedAway := keyRenames[newKey]; existing[newKey] && !renamedAway {
+			return nil, fmt.Errorf("%w: placeholder '%s' already exists", ErrInvalidRename, newKey)
+		}
+	}
+
+	return keyRenames, nil
+}
+
+// renameFieldDefinitions re-keys field definitions and updates references to renamed
+// placeholders inside merged fields, radio groups and computed field expressions
+func renameFieldDefinitions(definitions map[string]utils.FieldDefinition, renames map[string]string) map[string]utils.FieldDefinition {
+	renamed := make(map[string]utils.FieldDefinition, len(definitions))
+	for key, def := range definitions {
+		def.Placeholder, _ = renamePlaceholderRef(def.Placeholder, renames)
+		if def.Expression != "" {
+			def.Expression = utils.RenameExpressionFields(def.Expression, renames)
+		}
+		for i, field := range def.MergedFields {
+			def.MergedFields[i], _ = renamePlaceholderRef(field, renames)
+		}
+		for i := range def.RadioOptions {
+			def.RadioOptions[i].Placeholder, _ = renamePlaceholderRef(def.RadioOptions[i].Placeholder, renames)
+			for j, child := range def.RadioOptions[i].ChildFields {
+				def.RadioOptions[i].ChildFields[j], _ = renamePlaceholderRef(child, renames)
+			}
+		}
+
+		newKey, _ := renamePlaceholderRef(key, renames)
+		renamed[newKey] = def
+	}
+	return renamed
+}
+
+// renameDocumentData rewrites the keys of stored document data for a template
+// so regeneration keeps working after a rename
+func renameDocumentData(tx *gorm.DB, templateID string, renames map[string]string) (int, error) {
+	var documents []models.Document
+	if err := tx.Select("id", "data").Where("template_id = ?", templateID).Find(&documents).Error; err != nil {
+		return 0, fmt.Errorf("failed to load documents: %w", err)
+	}
+
+	updated := 0
+	for _, document := range documents {
+		if document.Data == "" {
+			continue
+		}
+		dataJSON, changed, err := renameDataKeys(document.Data, renames)
+		if err != nil {
+			fmt.Printf("[WARNING] Skipping document %s with invalid data: %v\n", document.ID, err)
+			continue
+		}
+		if !changed {
+			continue
+		}
+		if err := tx.Model(&models.Document{}).Where("id = ?", document.ID).Update("data", dataJSON).Error; err != nil {
+			return 0, fmt.Errorf("failed to update document %s: %w", document.ID, err)
+		}
+		updated++
+	}
+
+	return updated, nil
+}
+
+// renameFixtureData rewrites the keys of the regression fixtures' data for a template
+func renameFixtureData(tx *gorm.DB, templateID string, renames map[string]string) (int, error) {
+	var fixtures []models.TemplateFixture
+	if err := tx.Select("id", "data").Where("template_id = ?", templateID).Find(&fixtures).Error; err != nil {
+		return 0, fmt.Errorf("failed to load fixtures: %w", err)
+	}
+
+	updated := 0
+	for _, fixture := range fixtures {
+		if fixture.Data == "" {
+			continue
+		}
+		dataJSON, changed, err := renameDataKeys(fixture.Data, renames)
+		if err != nil {
+			fmt.Printf("[WARNING] Skipping fixture %s with invalid data: %v\n", fixture.ID, err)
+			continue
+		}
+		if !changed {
+			continue
+		}
+		if err := tx.Model(&models.TemplateFixture{}).Where("id = ?", fixture.ID).Update("data", dataJSON).Error; err != nil {
+			return 0, fmt.Errorf("failed to update fixture %s: %w", fixture.ID, err)
+		}
+		updated++
+	}
+
+	return updated, nil
+}
+
+// renameDraftData rewrites the keys of the form drafts' data for a template
+func renameDraftData(tx *gorm.DB, templateID string, renames map[string]string) (int, error) {
+	var drafts []models.FormDraft
+	if err := tx.Select("id", "data").Where("template_id = ?", templateID).Find(&drafts).Error; err != nil {
+		return 0, fmt.Errorf("failed to load drafts: %w", err)
+	}
+
+	updated := 0
+	for _, draft := range drafts {
+		if draft.Data == "" {
+			continue
+		}
+		dataJSON, changed, err := renameDataKeys(draft.Data, renames)
+		if err != nil {
+			fmt.Printf("[WARNING] Skipping draft %s with invalid data: %v\n", draft.ID, err)
+			continue
+		}
+		if !changed {
+			continue
+		}
+		if err := tx.Model(&models.FormDraft{}).Where("id = ?", draft.ID).Update("data", dataJSON).Error; err != nil {
+			return 0, fmt.Errorf("failed to update draft %s: %w", draft.ID, err)
+		}
+		updated++
+	}
+
+	return updated, nil
+}
+
+// renameDataKeys renames the keys of a JSON data object; reports whether any key changed
+func renameDataKeys(dataJSON string, renames map[string]string) (string, bool, error) {
+	var data map[string]interface{}
+	if err := json.Unmarshal([]byte(dataJSON), &data); err != nil {
+		return "", false, err
+	}
+
+	changed := false
+	renamed := make(map[string]interface{}, len(data))
+	for key, value := range data {
+		newKey, ok := renamePlaceholderRef(key, renames)
+		changed = changed || ok
+		renamed[newKey] = value
+	}
+	if !changed {
+		return dataJSON, false, nil
+	}
+
+	result, err := json.Marshal(renamed)
+	if err != nil {
+		return "", false, err
+	}
+	return string(result), true, nil
+}
+
+// renameHTMLPreviewTokens uploads a copy of the HTML preview with renamed tokens and returns its object name
+func (s *TemplateService) renameHTMLPreviewTokens(ctx context.Context, template *models.Template, tokens map[string]string) (string, error) {
+	reader, err := s.storageClient.ReadFile(ctx, template.GCSPathHTML)
+	if err != nil {
+		return "", fmt.Errorf("failed to read HTML preview: %w", err)
+	}
+	content, err := io.ReadAll(reader)
+	reader.Close()
+	if err != nil {
+		return "", fmt.Errorf("failed to read HTML preview: %w", err)
+	}
+
+	// Replace through temporary markers so swapped names do not collide
+	htmlContent := string(content)
+	markers := make(map[string]string, len(tokens))
+	i := 0
+	for oldToken, newToken := range tokens {
+		marker := fmt.Sprintf("\x00rename%d\x00", i)
+		htmlContent = strings.ReplaceAll(htmlContent, oldToken, marker)
+		markers[marker] = newToken
+		i++
+	}
+	for marker, newToken := range markers {
+		htmlContent = strings.ReplaceAll(htmlContent, marker, newToken)
+	}
+
+	htmlFileName := strings.TrimSuffix(template.Filename, filepath.Ext(template.Filename)) + ".html"
+	htmlObjectName := storage.GenerateObjectName(template.ID, htmlFileName)
+	if _, err := s.storageClient.UploadFile(ctx, bytes.NewReader([]byte(htmlContent)), htmlObjectName, "text/html"); err != nil {
+		return "", fmt.Errorf("failed to upload HTML preview: %w", err)
+	}
+	return htmlObjectName, nil
+}
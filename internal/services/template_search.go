@@ -0,0 +1,384 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"html"
+	"strings"
+	"unicode"
+
+	"DF-PLCH/internal"
+	"DF-PLCH/internal/models"
+	"DF-PLCH/internal/processor"
+	"DF-PLCH/internal/utils"
+
+	"gorm.io/gorm"
+)
+
+// Thai has no spaces between words, so Postgres cannot split compound words like
+// "หนังสือมอบอำนาจ" into dictionary words. Instead Thai text is indexed as overlapping
+// character trigrams: a search phrase matches when all of its trigrams are present,
+// regardless of where the word boundaries are.
+// Trigrams are stored as ASCII tokens ("th" + 2 hex digits per character) so the
+// 'simple' text search parser never splits them on Thai vowel or tone marks.
+const (
+	thaiGramSize      = 3
+	searchSnippetSize = 40 // Characters of context on each side of a highlighted match
+	maxSearchSnippets = 3
+)
+
+func isThaiRune(r rune) bool {
+	return r >= 0x0E00 && r <= 0x0E7F
+}
+
+func isSearchRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.Is(unicode.Mn, r)
+}
+
+// encodeSearchTerm converts a run of characters to a token the 'simple' parser keeps intact
+func encodeSearchTerm(runes []rune) string {
+	var sb strings.Builder
+	if isThaiRune(runes[0]) {
+		sb.WriteString("th")
+		for _, r := range runes {
+			fmt.Fprintf(&sb, "%02x", r-0x0E00)
+		}
+		return sb.String()
+	}
+
+	ascii := true
+	for _, r := range runes {
+		if r > unicode.MaxASCII {
+			ascii = false
+			break
+		}
+	}
+	if ascii {
+		return string(runes)
+	}
+
+	sb.WriteString("u")
+	for _, r := range runes {
+		fmt.Fprintf(&sb, "%06x", r)
+	}
+	return sb.String()
+}
+
+// splitSearchRuns splits text into lower-cased runs of word characters,
+// starting a new run whenever the script switches between Thai and non-Thai
+func splitSearchRuns(text string) [][]rune {
+	var runs [][]rune
+	var current []rune
+	for _, r := range text {
+		if !isSearchRune(r) {
+			if len(current) > 0 {
+				runs = append(runs, current)
+				current = nil
+			}
+			continue
+		}
+		if len(current) > 0 && isThaiRune(current[0]) != isThaiRune(r) {
+			runs = append(runs, current)
+			current = nil
+		}
+		current = append(current, unicode.ToLower(r))
+	}
+	if len(current) > 0 {
+		runs = append(runs, current)
+	}
+	return runs
+}
+
+// searchDocumentTokens tokenizes text for indexing
+func searchDocumentTokens(text string) string {
+	var tokens []string
+	for _, run := range splitSearchRuns(text) {
+		if !isThaiRune(run[0]) || len(run) <= thaiGramSize {
+			tokens = append(tokens, encodeSearchTerm(run))
+			continue
+		}
+		for i := 0; i+thaiGramSize <= len(run); i++ {
+			tokens = append(tokens, encodeSearchTerm(run[i:i+thaiGramSize]))
+		}
+	}
+	return strings.Join(tokens, " ")
+}
+
+// buildSearchTSQuery converts user input into a to_tsquery('simple', ...) expression
+// All terms must match; short terms match as prefixes. Returns "" if there is nothing to search
+func buildSearchTSQuery(search string) string {
+	var terms []string
+	for _, run := range splitSearchRuns(search) {
+		if !isThaiRune(run[0]) || len(run) < thaiGramSize {
+			terms = append(terms, encodeSearchTerm(run)+":*")
+			continue
+		}
+		for i := 0; i+thaiGramSize <= len(run); i++ {
+			terms = append(terms, encodeSearchTerm(run[i:i+thaiGramSize]))
+		}
+	}
+	return strings.Join(terms, " & ")
+}
+
+// templateSearchJoin adds tsd.search_vector for full-text matching and ranking
+const templateSearchJoin = "LEFT JOIN template_search_documents tsd ON tsd.template_id = document_templates.id"
+
+// applyTemplateSearch adds full-text matching to a template query (ranking is a sort key, see templateSortKeys)
+// Templates that have not been indexed yet still match on name, description and author
+func applyTemplateSearch(query *gorm.DB, search string) *gorm.DB {
+	searchPattern := "%" + search + "%"
+	tsQuery := buildSearchTSQuery(search)
+	if tsQuery == "" {
+		return query.Where("(display_name ILIKE ? OR description ILIKE ? OR author ILIKE ?)", searchPattern, searchPattern, searchPattern)
+	}
+
+	return query.
+		Joins(templateSearchJoin).
+		Where("(tsd.search_vector @@ to_tsquery('simple', ?) OR display_name ILIKE ? OR description ILIKE ? OR author ILIKE ?)", tsQuery, searchPattern, searchPattern, searchPattern)
+}
+
+// templateSearchFields collects the weighted text for a template's search vector
+// A: names and document type, B: placeholders, aliases, labels, tags and description
+func templateSearchFields(template *models.Template) (string, string) {
+	weightA := []string{template.DisplayName, template.Name, template.OriginalName}
+	if template.DocumentTypeID != "" {
+		var docType models.DocumentType
+		if err := internal.DB.First(&docType, "id = ?", template.DocumentTypeID).Error; err == nil {
+			weightA = append(weightA, docType.Name, docType.NameEN)
+		}
+	}
+
+	weightB := []string{template.Description, template.Author, template.Remarks}
+
+	var translations map[string]models.TemplateTranslation
+	if err := json.Unmarshal([]byte(template.Translations), &translations); err == nil {
+		for _, translation := range translations {
+			weightA = append(weightA, translation.Name)
+			weightB = append(weightB, translation.Description, translation.Remarks)
+		}
+	}
+
+	var placeholders []string
+	if err := json.Unmarshal([]byte(template.Placeholders), &placeholders); err == nil {
+		for _, p := range placeholders {
+			weightB = append(weightB, strings.Trim(p, "{}"))
+		}
+	}
+
+	var aliases map[string]string
+	if err := json.Unmarshal([]byte(template.Aliases), &aliases); err == nil {
+		for _, alias := range aliases {
+			weightB = append(weightB, alias)
+		}
+	}
+
+	var definitions map[string]utils.FieldDefinition
+	if err := json.Unmarshal([]byte(template.FieldDefinitions), &definitions); err == nil {
+		for _, def := range definitions {
+			weightB = append(weightB, def.Label, def.Description)
+			for _, translation := range def.Translations {
+				weightB = append(weightB, translation.Label, translation.Description)
+			}
+		}
+	}
+
+	var tags []models.Tag
+	internal.DB.Joins("JOIN template_tags ON template_tags.tag_id = tags.id").Where("template_tags.template_id = ?", template.ID).Find(&tags)
+	for _, tag := range tags {
+		weightB = append(weightB, tag.Name, tag.NameEN)
+	}
+
+	return strings.Join(weightA, " "), strings.Join(weightB, " ")
+}
+
+// indexTemplateSearch writes the search document for a template
+// bodyText replaces the stored body text; pass nil to keep the existing text
+func indexTemplateSearch(template *models.Template, bodyText *string) error {
+	var body string
+	if bodyText != nil {
+		body = *bodyText
+	} else {
+		var existing models.TemplateSearchDocument
+		if err := internal.DB.First(&existing, "template_id = ?", template.ID).Error; err == nil {
+			body = existing.BodyText
+		}
+	}
+
+	weightA, weightB := templateSearchFields(template)
+
+	err := internal.DB.Exec(`
+        INSERT INTO template_search_documents (template_id, body_text, search_vector, indexed_at)
+        VALUES (?, ?,
+            setweight(to_tsvector('simple', ?), 'A') ||
+            setweight(to_tsvector('simple', ?), 'B') ||
+            setweight(to_tsvector('simple', ?), 'C'),
+            NOW())
+        ON CONFLICT (template_id) DO UPDATE SET
+            body_text = EXCLUDED.body_text,
+            search_vector = EXCLUDED.search_vector,
+            indexed_at = EXCLUDED.indexed_at
+    `, template.ID, body, searchDocumentTokens(weightA), searchDocumentTokens(weightB), searchDocumentTokens(body)).Error
+	if err != nil {
+		return fmt.Errorf("failed to index template %s: %w", template.ID, err)
+	}
+	return nil
+}
+
+// refreshTemplateSearch rebuilds the search vector from the stored body text and current metadata
+// Used when metadata changes without a new DOCX (rename, aliases, document type assignment)
+func refreshTemplateSearch(templateID string) {
+	var template models.Template
+	if err := internal.DB.First(&template, "id = ?", templateID).Error; err != nil {
+		return
+	}
+	if err := indexTemplateSearch(&template, nil); err != nil {
+		fmt.Printf("[WARNING] Failed to refresh search index: %v\n", err)
+	}
+}
+
+// RebuildSearchIndex re-extracts body text from every template DOCX and rebuilds its search document
+// Returns the number of indexed and failed templates with error messages
+func (s *TemplateService) RebuildSearchIndex(ctx context.Context) (int, int, []string) {
+	var templates []models.Template
+	if err := internal.DB.Find(&templates).Error; err != nil {
+		return 0, 0, []string{fmt.Sprintf("failed to get templates: %v", err)}
+	}
+
+	successCount := 0
+	failCount := 0
+	var errors []string
+
+	for i := range templates {
+		template := &templates[i]
+		bodyText, err := s.extractTemplateText(ctx, template)
+		if err == nil {
+			err = indexTemplateSearch(template, &bodyText)
+		}
+		if err != nil {
+			failCount++
+			errMsg := fmt.Sprintf("Template %s (%s): %v", template.ID, template.DisplayName, err)
+			errors = append(errors, errMsg)
+			fmt.Printf("[ERROR] %s\n", errMsg)
+			continue
+		}
+		successCount++
+	}
+
+	return successCount, failCount, errors
+}
+
+// extractTemplateText downloads a template DOCX and returns its body text
+func (s *TemplateService) extractTemplateText(ctx context.Context, template *models.Template) (string, error) {
+	if template.IsOverlay() {
+		return overlaySearchText(parseFieldDefinitions(template.FieldDefinitions)), nil
+	}
+
+	reader, err := s.storageClient.ReadFile(ctx, template.GCSPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to read template file: %w", err)
+	}
+	defer reader.Close()
+
+	tempFile, err := s.createTempFile(reader)
+	if err != nil {
+		return "", fmt.Errorf("failed to create temp file: %w", err)
+	}
+	defer s.cleanupTempFile(tempFile)
+
+	if template.IsPDFForm() {
+		fields, err := processor.ExtractPDFFormFields(tempFile)
+		if err != nil {
+			return "", fmt.Errorf("failed to read PDF form fields: %w", err)
+		}
+		return pdfFormSearchText(fields), nil
+	}
+
+	proc := processor.NewDocxProcessor(tempFile, "")
+	if err := proc.UnzipDocx(); err != nil {
+		return "", fmt.Errorf("failed to process document: %w", err)
+	}
+	defer proc.Cleanup()
+
+	return proc.ExtractText()
+}
+
+// GetSearchHighlights returns highlighted body text snippets for each template that matches search
+// Matches are wrapped in <mark></mark>; the surrounding text is HTML-escaped
+func (s *TemplateService) GetSearchHighlights(templateIDs []string, search string) (map[string][]string, error) {
+	highlights := make(map[string][]string)
+	if len(templateIDs) == 0 || strings.TrimSpace(search) == "" {
+		return highlights, nil
+	}
+
+	var docs []models.TemplateSearchDocument
+	if err := internal.DB.Where("template_id IN ?", templateIDs).Find(&docs).Error; err != nil {
+		return nil, fmt.Errorf("failed to get search documents: %w", err)
+	}
+
+	for _, doc := range docs {
+		if snippets := highlightSnippets(doc.BodyText, search); len(snippets) > 0 {
+			highlights[doc.TemplateID] = snippets
+		}
+	}
+
+	return highlights, nil
+}
+
+// highlightSnippets finds the search phrase (or, failing that, its individual words) in text
+func highlightSnippets(text, search string) []string {
+	textRunes := []rune(text)
+	lowerRunes := make([]rune, len(textRunes))
+	for i, r := range textRunes {
+		lowerRunes[i] = unicode.ToLower(r)
+	}
+
+	terms := []string{strings.TrimSpace(search)}
+	if fields := strings.Fields(search); len(fields) > 1 {
+		terms = append(terms, fields...)
+	}
+
+	var snippets []string
+	lastEnd := -1
+	for _, term := range terms {
+		termRunes := []rune(strings.ToLower(term))
+		if len(termRunes) == 0 {
+			continue
+		}
+		for i := 0; i+len(termRunes) <= len(lowerRunes) && len(snippets) < maxSearchSnippets; i++ {
+			if i < lastEnd || string(lowerRunes[i:i+len(termRunes)]) != string(termRunes) {
+				continue
+			}
+			matchEnd := i + len(termRunes)
+			start := i - searchSnippetSize
+			if start < 0 {
+				start = 0
+			}
+			end := matchEnd + searchSnippetSize
+			if end > len(textRunes) {
+				end = len(textRunes)
+			}
+
+			var sb strings.Builder
+			if start > 0 {
+				sb.WriteString("…")
+			}
+			sb.WriteString(html.EscapeString(string(textRunes[start:i])))
+			sb.WriteString("<mark>")
+			sb.WriteString(html.EscapeString(string(textRunes[i:matchEnd])))
+			sb.WriteString("</mark>")
+			sb.WriteString(html.EscapeString(string(textRunes[matchEnd:end])))
+			if end < len(textRunes) {
+				sb.WriteString("…")
+			}
+
+			snippets = append(snippets, strings.ReplaceAll(sb.String(), "\n", " "))
+			lastEnd = end
+		}
+		if len(snippets) > 0 {
+			break
+		}
+	}
+
+	return snippets
+}
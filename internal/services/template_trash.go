@@ -0,0 +1,193 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"DF-PLCH/internal"
+	"DF-PLCH/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// TemplateInUseError is returned when deleting a template that documents still reference
+type TemplateInUseError struct {
+	TemplateID    string
+	DocumentCount int64
+}
+
+func (e *TemplateInUseError) Error() string {
+	return fmt.Sprintf("template %s is referenced by %d documents", e.TemplateID, e.DocumentCount)
+}
+
+// GetTrashedTemplates retrieves all soft-deleted templates, most recently deleted first
+func (s *TemplateService) GetTrashedTemplates() ([]models.Template, error) {
+	var templates []models.Template
+	if err := internal.DB.Unscoped().Preload("Tags").Where("deleted_at IS NOT NULL").Order("deleted_at DESC").Find(&templates).Error; err != nil {
+		return nil, fmt.Errorf("failed to get trashed templates: %w", err)
+	}
+	return templates, nil
+}
+
+// getTrashedTemplate retrieves a soft-deleted template by ID
+func (s *TemplateService) getTrashedTemplate(templateID string) (*models.Template, error) {
+	var template models.Template
+	if err := internal.DB.Unscoped().Where("deleted_at IS NOT NULL").First(&template, "id = ?", templateID).Error; err != nil {
+		return nil, fmt.Errorf("template not found in trash: %w", err)
+	}
+	return &template, nil
+}
+
+// RestoreTemplate moves a template out of the trash
+func (s *TemplateService) RestoreTemplate(templateID string) (*models.Template, error) {
+	template, err := s.getTrashedTemplate(templateID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := internal.DB.Unscoped().Model(template).Update("deleted_at", nil).Error; err != nil {
+		return nil, fmt.Errorf("failed to restore template: %w", err)
+	}
+
+	restored, err := s.GetTemplate(templateID)
+	if err != nil {
+		return nil, err
+	}
+	// Back in the catalog
+	s.publishTemplateEvent(models.WebhookEventTemplatePublished, restored, "restored")
+	return restored, nil
+}
+
+// PurgeTemplate permanently deletes a trashed template, its storage objects and search/tag data
+func (s *TemplateService) PurgeTemplate(ctx context.Context, templateID string) error {
+	template, err := s.getTrashedTemplate(templateID)
+	if err != nil {
+		return err
+	}
+	return s.purgeTemplate(ctx, template)
+}
+
+func (s *TemplateService) purgeTemplate(ctx context.Context, template *models.Template) error {
+	// Delete storage objects (log errors but continue so the row does not linger forever)
+	deleted := make(map[string]bool)
+	for _, path := range []string{template.GCSPath, template.GCSPathHTML, template.GCSPathPDF, template.GCSPathThumbnail} {
+		// PDF form templates use the same object as file and PDF preview
+		if path == "" || deleted[path] {
+			continue
+		}
+		deleted[path] = true
+		if err := s.storageClient.DeleteFile(ctx, path); err != nil {
+			fmt.Printf("[WARNING] Failed to delete storage object %s: %v\n", path, err)
+		}
+	}
+
+	err := internal.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("template_id = ?", template.ID).Delete(&models.TemplateTag{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("template_id = ?", template.ID).Delete(&models.TemplateSearchDocument{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("template_id = ?", template.ID).Delete(&models.TemplateFixture{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("template_id = ?", template.ID).Delete(&models.TemplateFavorite{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("template_id = ?", template.ID).Delete(&models.UserTemplateUsage{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("template_id = ?", template.ID).Delete(&models.TemplateRating{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("template_id = ?", template.ID).Delete(&models.TemplateFeedback{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("template_id = ?", template.ID).Delete(&models.FormDraft{}).Error; err != nil {
+			return err
+		}
+		return tx.Unscoped().Delete(template).Error
+	})
+	if err != nil {
+		return fmt.Errorf("failed to purge template %s: %w", template.ID, err)
+	}
+
+	fmt.Printf("[INFO] Purged template %s (%s)\n", template.ID, template.DisplayName)
+	return nil
+}
+
+// PurgeExpiredTemplates permanently deletes templates that have been in the trash longer than retention
+// Returns the number of purged templates and any errors
+func (s *TemplateService) PurgeExpiredTemplates(ctx context.Context, retention time.Duration) (int, []string) {
+	var templates []models.Template
+	cutoff := time.Now().Add(-retention)
+	if err := internal.DB.Unscoped().Where("deleted_at IS NOT NULL AND deleted_at < ?", cutoff).Find(&templates).Error; err != nil {
+		return 0, []string{fmt.Sprintf("failed to get expired templates: %v", err)}
+	}
+
+	purged := 0
+	var errors []string
+	for i := range templates {
+		if err := s.purgeTemplate(ctx, &templates[i]); err != nil {
+			errors = append(errors, err.Error())
+			continue
+		}
+		purged++
+	}
+
+	return purged, errors
+}
+
+// TrashPurgeScheduler periodically purges templates whose trash retention has expired
+type TrashPurgeScheduler struct {
+	templateService *TemplateService
+	retention       time.Duration
+	interval        time.Duration
+	ticker          *time.Ticker
+	done            chan bool
+}
+
+func NewTrashPurgeScheduler(templateService *TemplateService, retention, interval time.Duration) *TrashPurgeScheduler {
+	return &TrashPurgeScheduler{
+		templateService: templateService,
+		retention:       retention,
+		interval:        interval,
+		done:            make(chan bool),
+	}
+}
+
+func (t *TrashPurgeScheduler) Start() {
+	t.ticker = time.NewTicker(t.interval)
+	go func() {
+		t.purge()
+		for {
+			select {
+			case <-t.done:
+				return
+			case <-t.ticker.C:
+				t.purge()
+			}
+		}
+	}()
+	log.Printf("Template trash purge started (retention: %s, interval: %s)", t.retention, t.interval)
+}
+
+func (t *TrashPurgeScheduler) Stop() {
+	if t.ticker != nil {
+		t.ticker.Stop()
+	}
+	t.done <- true
+	log.Println("Template trash purge stopped")
+}
+
+func (t *TrashPurgeScheduler) purge() {
+	purged, errors := t.templateService.PurgeExpiredTemplates(context.Background(), t.retention)
+	if purged > 0 {
+		log.Printf("Purged %d expired templates from trash", purged)
+	}
+	for _, errMsg := range errors {
+		log.Printf("Error during trash purge: %s", errMsg)
+	}
+}
@@ -0,0 +1,216 @@
+package services
+
+import (
+	"fmt"
+	"time"
+
+	"DF-PLCH/internal"
+	"DF-PLCH/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	defaultRecentTemplatesLimit = 20
+	maxRecentTemplatesLimit     = 100
+)
+
+// userRecentUsageQuery merges a user's generated documents with their form submit counts
+// Binds the user ID twice; yields template_id, last_used_at, use_count per template
+const userRecentUsageQuery = `
+	SELECT template_id, MAX(last_used_at) AS last_used_at, MAX(use_count) AS use_count FROM (
+		SELECT template_id, MAX(created_at) AS last_used_at, COUNT(*) AS use_count
+		FROM documents WHERE user_id = ? AND deleted_at IS NULL GROUP BY template_id
+		UNION ALL
+		SELECT template_id, last_used_at, use_count FROM user_template_usage WHERE user_id = ?
+	) combined GROUP BY template_id`
+
+// userRecencyExpr is the last time a user used the template being listed (NULL if never)
+// Binds the user ID twice; used by the boosted default sort
+const userRecencyExpr = `GREATEST(
+	(SELECT MAX(d.created_at) FROM documents d WHERE d.template_id = document_templates.id AND d.user_id = ? AND d.deleted_at IS NULL),
+	(SELECT u.last_used_at FROM user_template_usage u WHERE u.template_id = document_templates.id AND u.user_id = ?))`
+
+// FavoriteTemplate is a favorited template with the time it was starred
+type FavoriteTemplate struct {
+	Template    models.Template
+	FavoritedAt time.Time
+}
+
+// RecentTemplate is a template the user has used, most recent first
+type RecentTemplate struct {
+	Template   models.Template
+	LastUsedAt time.Time
+	UseCount   int64
+}
+
+// UserTemplateService manages per-user favorites and recent template usage
+type UserTemplateService struct{}
+
+func NewUserTemplateService() *UserTemplateService {
+	return &UserTemplateService{}
+}
+
+// AddFavorite stars a template for a user (no-op if already a favorite)
+func (s *UserTemplateService) AddFavorite(userID, templateID string) error {
+	var count int64
+	if err := internal.DB.Model(&models.Template{}).Where("id = ?", templateID).Count(&count).Error; err != nil {
+		return fmt.Errorf("failed to verify template: %w", err)
+	}
+	if count == 0 {
+		return fmt.Errorf("template not found")
+	}
+
+	favorite := models.TemplateFavorite{
+		UserID:     userID,
+		TemplateID: templateID,
+		CreatedAt:  time.Now(),
+	}
+	if err := internal.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(&favorite).Error; err != nil {
+		return fmt.Errorf("failed to add favorite: %w", err)
+	}
+	return nil
+}
+
+// RemoveFavorite un-stars a template for a user
+func (s *UserTemplateService) RemoveFavorite(userID, templateID string) error {
+	result := internal.DB.Where("user_id = ? AND template_id = ?", userID, templateID).Delete(&models.TemplateFavorite{})
+	if result.Error != nil {
+		return fmt.Errorf("failed to remove favorite: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("favorite not found")
+	}
+	return nil
+}
+
+// GetFavorites returns a user's favorite templates, most recently starred first
+// Templates in the trash are left out
+func (s *UserTemplateService) GetFavorites(userID string) ([]FavoriteTemplate, error) {
+	var favorites []models.TemplateFavorite
+	if err := internal.DB.Where("user_id = ?", userID).Order("created_at DESC").Find(&favorites).Error; err != nil {
+		return nil, fmt.Errorf("failed to get favorites: %w", err)
+	}
+
+	templateIDs := make([]string, len(favorites))
+	for i, favorite := range favorites {
+		templateIDs[i] = favorite.TemplateID
+	}
+	templates, err := s.loadTemplates(templateIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]FavoriteTemplate, 0, len(favorites))
+	for _, favorite := range favorites {
+		if template, ok := templates[favorite.TemplateID]; ok {
+			result = append(result, FavoriteTemplate{Template: template, FavoritedAt: favorite.CreatedAt})
+		}
+	}
+	return result, nil
+}
+
+// FavoriteTemplateIDs returns which of the given templates the user has favorited
+func (s *UserTemplateService) FavoriteTemplateIDs(userID string, templateIDs []string) (map[string]bool, error) {
+	favorites := make(map[string]bool)
+	if userID == "" || len(templateIDs) == 0 {
+		return favorites, nil
+	}
+
+	var ids []string
+	if err := internal.DB.Model(&models.TemplateFavorite{}).
+		Where("user_id = ? AND template_id IN ?", userID, templateIDs).
+		Pluck("template_id", &ids).Error; err != nil {
+		return nil, fmt.Errorf("failed to get favorites: %w", err)
+	}
+	for _, id := range ids {
+		favorites[id] = true
+	}
+	return favorites, nil
+}
+
+// RecordUsage counts a form submission of a template by a user
+func (s *UserTemplateService) RecordUsage(userID, templateID string) error {
+	usage := models.UserTemplateUsage{
+		UserID:     userID,
+		TemplateID: templateID,
+		UseCount:   1,
+		LastUsedAt: time.Now(),
+	}
+	err := internal.DB.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "user_id"}, {Name: "template_id"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"use_count":    gorm.Expr("user_template_usage.use_count + 1"),
+			"last_used_at": usage.LastUsedAt,
+		}),
+	}).Create(&usage).Error
+	if err != nil {
+		return fmt.Errorf("failed to record template usage: %w", err)
+	}
+	return nil
+}
+
+// GetRecent returns the templates a user has used most recently
+// Usage is derived from the user's generated documents and form submit events
+func (s *UserTemplateService) GetRecent(userID string, limit int) ([]RecentTemplate, error) {
+	if limit <= 0 {
+		limit = defaultRecentTemplatesLimit
+	}
+	if limit > maxRecentTemplatesLimit {
+		limit = maxRecentTemplatesLimit
+	}
+
+	type usageRow struct {
+		TemplateID string
+		LastUsedAt time.Time
+		UseCount   int64
+	}
+	// Over-fetch so templates in the trash do not shorten the list
+	var rows []usageRow
+	if err := internal.DB.Raw(
+		"SELECT template_id, last_used_at, use_count FROM ("+userRecentUsageQuery+") recent ORDER BY last_used_at DESC, template_id LIMIT ?",
+		userID, userID, limit*2,
+	).Scan(&rows).Error; err != nil {
+		return nil, fmt.Errorf("failed to get recent templates: %w", err)
+	}
+
+	templateIDs := make([]string, len(rows))
+	for i, row := range rows {
+		templateIDs[i] = row.TemplateID
+	}
+	templates, err := s.loadTemplates(templateIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]RecentTemplate, 0, limit)
+	for _, row := range rows {
+		template, ok := templates[row.TemplateID]
+		if !ok {
+			continue
+		}
+		result = append(result, RecentTemplate{Template: template, LastUsedAt: row.LastUsedAt, UseCount: row.UseCount})
+		if len(result) == limit {
+			break
+		}
+	}
+	return result, nil
+}
+
+// loadTemplates loads templates (with tags) by ID, skipping deleted ones
+func (s *UserTemplateService) loadTemplates(templateIDs []string) (map[string]models.Template, error) {
+	templates := make(map[string]models.Template, len(templateIDs))
+	if len(templateIDs) == 0 {
+		return templates, nil
+	}
+
+	var list []models.Template
+	if err := internal.DB.Preload("Tags").Where("id IN ?", templateIDs).Find(&list).Error; err != nil {
+		return nil, fmt.Errorf("failed to get templates: %w", err)
+	}
+	for _, template := range list {
+		templates[template.ID] = template
+	}
+	return templates, nil
+}
@@ -0,0 +1,593 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"DF-PLCH/internal"
+	"DF-PLCH/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// ErrInvalidWebhook is returned for webhook subscriptions that cannot be saved
+var ErrInvalidWebhook = errors.New("invalid webhook")
+
+const (
+	webhookWorkers         = 4
+	webhookPollInterval    = 5 * time.Second
+	webhookLockTimeout     = 5 * time.Minute // A delivery locked longer than this is considered abandoned
+	webhookFirstRetryDelay = 30 * time.Second
+	webhookMaxRetryDelay   = 6 * time.Hour
+	webhookResponseLimit   = 2048 // Bytes of the response body kept in the delivery log
+)
+
+// Signature headers sent with every delivery
+// The signature is hex(HMAC-SHA256(secret, timestamp + "." + body)), prefixed with "sha256="
+const (
+	WebhookHeaderEvent     = "X-Webhook-Event"
+	WebhookHeaderDelivery  = "X-Webhook-Delivery"
+	WebhookHeaderTimestamp = "X-Webhook-Timestamp"
+	WebhookHeaderSignature = "X-Webhook-Signature"
+)
+
+// WebhookRequest creates or updates a webhook subscription
+type WebhookRequest struct {
+	Name   string   `json:"name"`
+	URL    string   `json:"url"`
+	Events []string `json:"events"`           // Subscribed events, "*" for all
+	Secret string   `json:"secret,omitempty"` // Generated on creation when empty
+	Active *bool    `json:"active,omitempty"`
+}
+
+// WebhookEvent is the JSON body POSTed to webhooks
+type WebhookEvent struct {
+	ID        string      `json:"id"` // Same for every webhook receiving the event; use it to deduplicate
+	Event     string      `json:"event"`
+	CreatedAt time.Time   `json:"created_at"`
+	Data      interface{} `json:"data"`
+}
+
+// WebhookService manages webhook subscriptions and delivers events to them
+// Events are stored as deliveries first, so they are retried with backoff and survive restarts
+type WebhookService struct {
+	client      *http.Client
+	maxAttempts int
+	baseURL     string // Makes download URLs in event data absolute
+	wake        chan struct{}
+	ctx         context.Context
+	cancel      context.CancelFunc
+	wg          sync.WaitGroup
+}
+
+func NewWebhookService(timeout time.Duration, maxAttempts int) *WebhookService {
+	if maxAttempts < 1 {
+		maxAttempts = 1
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	return &WebhookService{
+		client:      &http.Client{Timeout: timeout},
+		maxAttempts: maxAttempts,
+		wake:        make(chan struct{}, 1),
+		ctx:         ctx,
+		cancel:      cancel,
+	}
+}
+
+// SetBaseURL sets the public base URL used for download links in event data
+func (s *WebhookService) SetBaseURL(baseURL string) {
+	s.baseURL = strings.TrimSuffix(baseURL, "/")
+}
+
+// CreateWebhook creates a subscription; the response carries the signing secret, which is not
+// returned again
+func (s *WebhookService) CreateWebhook(req *WebhookRequest, userID string) (*models.WebhookResponse, error) {
+	events, err := validateWebhookRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
+	secret := req.Secret
+	if secret == "" {
+		if secret, err = generateWebhookSecret(); err != nil {
+			return nil, err
+		}
+	}
+
+	eventsJSON, _ := json.Marshal(events)
+	webhook := &models.Webhook{
+		ID:        uuid.New().String(),
+		Name:      strings.TrimSpace(req.Name),
+		URL:       strings.TrimSpace(req.URL),
+		Secret:    secret,
+		Events:    string(eventsJSON),
+		Active:    req.Active == nil || *req.Active,
+		CreatedBy: userID,
+	}
+	if err := internal.DB.Create(webhook).Error; err != nil {
+		return nil, fmt.Errorf("failed to create webhook: %w", err)
+	}
+	// Active defaults to true in the database; store an explicit false
+	if !webhook.Active {
+		internal.DB.Model(webhook).Update("active", false)
+	}
+
+	response := webhook.ToResponse()
+	response.Secret = secret
+	return &response, nil
+}
+
+// GetWebhooks returns all subscriptions, newest first
+func (s *WebhookService) GetWebhooks() ([]models.Webhook, error) {
+	var webhooks []models.Webhook
+	if err := internal.DB.Order("created_at DESC").Find(&webhooks).Error; err != nil {
+		return nil, fmt.Errorf("failed to get webhooks: %w", err)
+	}
+	return webhooks, nil
+}
+
+// GetWebhook returns a subscription
+func (s *WebhookService) GetWebhook(id string) (*models.Webhook, error) {
+	var webhook models.Webhook
+	if err := internal.DB.First(&webhook, "id = ?", id).Error; err != nil {
+		return nil, fmt.Errorf("webhook not found: %w", err)
+	}
+	return &webhook, nil
+}
+
+// UpdateWebhook replaces a subscription's name, URL, events and active flag
+// The secret is only changed when one is given (see also RotateSecret)
+func (s *WebhookService) UpdateWebhook(id string, req *WebhookRequest) (*models.Webhook, error) {
+	webhook, err := s.GetWebhook(id)
+	if err != nil {
+		return nil, err
+	}
+	events, err := validateWebhookRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
+	eventsJSON, _ := json.Marshal(events)
+	updates := map[string]interface{}{
+		"name":   strings.TrimSpace(req.Name),
+		"url":    strings.TrimSpace(req.URL),
+		"events": string(eventsJSON),
+	}
+	if req.Active != nil {
+		updates["active"] = *req.Active
+	}
+	if req.Secret != "" {
+		updates["secret"] = req.Secret
+	}
+	if err := internal.DB.Model(webhook).Updates(updates).Error; err != nil {
+		return nil, fmt.Errorf("failed to update webhook: %w", err)
+	}
+	return s.GetWebhook(id)
+}
+
+// RotateSecret replaces a subscription's signing secret and returns the new one
+func (s *WebhookService) RotateSecret(id string) (*models.WebhookResponse, error) {
+	webhook, err := s.GetWebhook(id)
+	if err != nil {
+		return nil, err
+	}
+	secret, err := generateWebhookSecret()
+	if err != nil {
+		return nil, err
+	}
+	if err := internal.DB.Model(webhook).Update("secret", secret).Error; err != nil {
+		return nil, fmt.Errorf("failed to rotate webhook secret: %w", err)
+	}
+
+	response := webhook.ToResponse()
+	response.Secret = secret
+	return &response, nil
+}
+
+// DeleteWebhook deletes a subscription and its delivery log
+func (s *WebhookService) DeleteWebhook(id string) error {
+	if _, err := s.GetWebhook(id); err != nil {
+		return err
+	}
+	if err := internal.DB.Where("webhook_id = ?", id).Delete(&models.WebhookDelivery{}).Error; err != nil {
+		return fmt.Errorf("failed to delete webhook deliveries: %w", err)
+	}
+	if err := internal.DB.Delete(&models.Webhook{}, "id = ?", id).Error; err != nil {
+		return fmt.Errorf("failed to delete webhook: %w", err)
+	}
+	return nil
+}
+
+// GetDeliveries returns a subscription's delivery log, newest first
+func (s *WebhookService) GetDeliveries(webhookID, status string, page, limit int) ([]models.WebhookDelivery, int64, error) {
+	if _, err := s.GetWebhook(webhookID); err != nil {
+		return nil, 0, err
+	}
+
+	query := internal.DB.Model(&models.WebhookDelivery{}).Where("webhook_id = ?", webhookID)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count webhook deliveries: %w", err)
+	}
+
+	var deliveries []models.WebhookDelivery
+	if err := query.Order("created_at DESC").Offset((page - 1) * limit).Limit(limit).Find(&deliveries).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to get webhook deliveries: %w", err)
+	}
+	return deliveries, total, nil
+}
+
+// Redeliver queues a new delivery of a logged event (same event ID and payload)
+func (s *WebhookService) Redeliver(webhookID, deliveryID string) (*models.WebhookDelivery, error) {
+	var original models.WebhookDelivery
+	if err := internal.DB.First(&original, "id = ? AND webhook_id = ?", deliveryID, webhookID).Error; err != nil {
+		return nil, fmt.Errorf("webhook delivery not found: %w", err)
+	}
+
+	delivery := newWebhookDelivery(webhookID, original.Event, original.EventID, original.Payload)
+	if err := internal.DB.Create(delivery).Error; err != nil {
+		return nil, fmt.Errorf("failed to queue webhook delivery: %w", err)
+	}
+	s.notify()
+	return delivery, nil
+}
+
+// Ping queues a test event to a subscription, whatever its event filter and active flag
+func (s *WebhookService) Ping(webhookID string) (*models.WebhookDelivery, error) {
+	webhook, err := s.GetWebhook(webhookID)
+	if err != nil {
+		return nil, err
+	}
+
+	event := WebhookEvent{
+		ID:        uuid.New().String(),
+		Event:     models.WebhookEventPing,
+		CreatedAt: time.Now(),
+		Data:      map[string]interface{}{"webhook_id": webhook.ID},
+	}
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal webhook event: %w", err)
+	}
+
+	delivery := newWebhookDelivery(webhook.ID, event.Event, event.ID, string(payload))
+	if err := internal.DB.Create(delivery).Error; err != nil {
+		return nil, fmt.Errorf("failed to queue webhook delivery: %w", err)
+	}
+	s.notify()
+	return delivery, nil
+}
+
+// Publish queues an event for every active webhook subscribed to it
+// Errors are logged: an event that cannot be queued must not fail the operation that raised it
+func (s *WebhookService) Publish(event string, data interface{}) {
+	var webhooks []models.Webhook
+	if err := internal.DB.Where("active = ?", true).Find(&webhooks).Error; err != nil {
+		log.Printf("Warning: failed to load webhooks for %s: %v", event, err)
+		return
+	}
+
+	var deliveries []models.WebhookDelivery
+	payload := ""
+	eventID := uuid.New().String()
+	for _, webhook := range webhooks {
+		if !webhook.Subscribes(event) {
+			continue
+		}
+		if payload == "" {
+			body, err := json.Marshal(WebhookEvent{ID: eventID, Event: event, CreatedAt: time.Now(), Data: data})
+			if err != nil {
+				log.Printf("Warning: failed to marshal %s event: %v", event, err)
+				return
+			}
+			payload = string(body)
+		}
+		deliveries = append(deliveries, *newWebhookDelivery(webhook.ID, event, eventID, payload))
+	}
+	if len(deliveries) == 0 {
+		return
+	}
+
+	if err := internal.DB.Create(&deliveries).Error; err != nil {
+		log.Printf("Warning: failed to queue %s webhook deliveries: %v", event, err)
+		return
+	}
+	s.notify()
+}
+
+// DocumentEventData is the data of document.* events
+func (s *WebhookService) DocumentEventData(document *models.Document, organizationID string) map[string]interface{} {
+	data := map[string]interface{}{
+		"document_id": document.ID,
+		"template_id": document.TemplateID,
+		"user_id":     document.UserID,
+		"filename":    document.Filename,
+		"file_size":   document.FileSize,
+		"mime_type":   document.MimeType,
+		"status":      document.Status,
+		"created_at":  document.CreatedAt,
+	}
+	if organizationID != "" {
+		data["organization_id"] = organizationID
+	}
+	if document.GCSPathDocx != "" {
+		data["storage_path"] = document.GCSPathDocx
+		data["download_url"] = fmt.Sprintf("%s/api/v1/documents/%s/download", s.baseURL, document.ID)
+	}
+	if document.GCSPathPdf != "" {
+		data["pdf_path"] = document.GCSPathPdf
+		data["download_pdf_url"] = fmt.Sprintf("%s/api/v1/documents/%s/download?format=pdf", s.baseURL, document.ID)
+	}
+	return data
+}
+
+// TemplateEventData is the data of template.* events; change says what was updated
+func (s *WebhookService) TemplateEventData(template *models.Template, change string) map[string]interface{} {
+	data := map[string]interface{}{
+		"template_id":      template.ID,
+		"name":             template.Name,
+		"display_name":     template.DisplayName,
+		"filename":         template.Filename,
+		"format":           template.Format,
+		"category":         template.Category,
+		"type":             template.Type,
+		"tier":             template.Tier,
+		"document_type_id": template.DocumentTypeID,
+		"updated_at":       template.UpdatedAt,
+	}
+	if change != "" {
+		data["change"] = change
+	}
+	return data
+}
+
+func (s *WebhookService) Start() {
+	for i := 0; i < webhookWorkers; i++ {
+		s.wg.Add(1)
+		go s.work()
+	}
+	log.Printf("Webhook delivery started (workers: %d, max attempts: %d)", webhookWorkers, s.maxAttempts)
+}
+
+// Stop waits for in-flight deliveries; pending ones are sent after the next start
+func (s *WebhookService) Stop() {
+	s.cancel()
+	s.wg.Wait()
+	log.Println("Webhook delivery stopped")
+}
+
+// notify wakes an idle delivery worker
+func (s *WebhookService) notify() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (s *WebhookService) work() {
+	defer s.wg.Done()
+	ticker := time.NewTicker(webhookPollInterval)
+	defer ticker.Stop()
+
+	for {
+		for s.ctx.Err() == nil {
+			delivery, err := s.claim()
+			if err != nil {
+				log.Printf("Error claiming webhook delivery: %v", err)
+				break
+			}
+			if delivery == nil {
+				break
+			}
+			s.deliver(delivery)
+		}
+
+		select {
+		case <-s.ctx.Done():
+			return
+		case <-s.wake:
+		case <-ticker.C:
+		}
+	}
+}
+
+// claim locks the oldest due delivery (see DocumentQueue.claim)
+func (s *WebhookService) claim() (*models.WebhookDelivery, error) {
+	now := time.Now()
+	var deliveries []models.WebhookDelivery
+	err := internal.DB.Raw(`
+        UPDATE webhook_deliveries
+        SET locked_at = ?, updated_at = ?
+        WHERE id = (
+            SELECT id FROM webhook_deliveries
+            WHERE status = ? AND next_attempt_at <= ? AND (locked_at IS NULL OR locked_at < ?)
+            ORDER BY next_attempt_at
+            FOR UPDATE SKIP LOCKED
+            LIMIT 1
+        )
+        RETURNING *`,
+		now, now, models.WebhookDeliveryPending, now, now.Add(-webhookLockTimeout),
+	).Scan(&deliveries).Error
+	if err != nil {
+		return nil, err
+	}
+	if len(deliveries) == 0 {
+		return nil, nil
+	}
+	return &deliveries[0], nil
+}
+
+// deliver makes one attempt and records its outcome in the delivery log
+func (s *WebhookService) deliver(delivery *models.WebhookDelivery) {
+	webhook, err := s.GetWebhook(delivery.WebhookID)
+	if err != nil {
+		s.record(delivery, 0, "", 0, err)
+		return
+	}
+	if !webhook.Active && delivery.Event != models.WebhookEventPing {
+		s.record(delivery, 0, "", 0, errors.New("webhook is inactive"))
+		return
+	}
+
+	started := time.Now()
+	status, body, err := s.send(webhook, delivery)
+	s.record(delivery, status, body, time.Since(started), err)
+}
+
+// send POSTs the payload with its signature headers
+func (s *WebhookService) send(webhook *models.Webhook, delivery *models.WebhookDelivery) (int, string, error) {
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+
+	req, err := http.NewRequestWithContext(s.ctx, http.MethodPost, webhook.URL, bytes.NewReader([]byte(delivery.Payload)))
+	if err != nil {
+		return 0, "", fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "DF-PLCH-Webhooks/1.0")
+	req.Header.Set(WebhookHeaderEvent, delivery.Event)
+	req.Header.Set(WebhookHeaderDelivery, delivery.ID)
+	req.Header.Set(WebhookHeaderTimestamp, timestamp)
+	req.Header.Set(WebhookHeaderSignature, SignWebhookPayload(webhook.Secret, timestamp, []byte(delivery.Payload)))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, "", err
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, webhookResponseLimit))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, string(body), fmt.Errorf("webhook responded with status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, string(body), nil
+}
+
+// record stores the outcome of an attempt and schedules the retry of a failed one
+func (s *WebhookService) record(delivery *models.WebhookDelivery, status int, body string, duration time.Duration, cause error) {
+	now := time.Now()
+	attempts := delivery.Attempts + 1
+	updates := map[string]interface{}{
+		"attempts":        attempts,
+		"response_status": status,
+		"response_body":   strings.ToValidUTF8(body, ""),
+		"duration_ms":     duration.Milliseconds(),
+		"locked_at":       nil,
+		"updated_at":      now,
+	}
+
+	switch {
+	case cause == nil:
+		updates["status"] = models.WebhookDeliverySucceeded
+		updates["error"] = ""
+		updates["next_attempt_at"] = nil
+		updates["delivered_at"] = now
+	case s.ctx.Err() != nil:
+		// Interrupted by shutdown: try again after the restart without counting the attempt
+		updates["attempts"] = delivery.Attempts
+		updates["error"] = cause.Error()
+	case attempts >= s.maxAttempts:
+		updates["status"] = models.WebhookDeliveryFailed
+		updates["error"] = cause.Error()
+		updates["next_attempt_at"] = nil
+		log.Printf("Webhook delivery %s (%s) failed after %d attempts: %v", delivery.ID, delivery.Event, attempts, cause)
+	default:
+		updates["error"] = cause.Error()
+		updates["next_attempt_at"] = now.Add(webhookRetryDelay(attempts))
+	}
+
+	if err := internal.DB.Model(&models.WebhookDelivery{}).Where("id = ?", delivery.ID).Updates(updates).Error; err != nil {
+		log.Printf("Warning: failed to record webhook delivery %s: %v", delivery.ID, err)
+	}
+}
+
+// SignWebhookPayload returns the X-Webhook-Signature value of a payload
+// Receivers recompute it with their secret and compare in constant time
+func SignWebhookPayload(secret, timestamp string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(payload)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// webhookRetryDelay is the exponential backoff after a failed attempt: 30s, 2m, 8m, 32m, ... up to 6h
+func webhookRetryDelay(attempts int) time.Duration {
+	delay := webhookFirstRetryDelay
+	for i := 1; i < attempts && delay < webhookMaxRetryDelay; i++ {
+		delay *= 4
+	}
+	if delay > webhookMaxRetryDelay {
+		delay = webhookMaxRetryDelay
+	}
+	return delay
+}
+
+func newWebhookDelivery(webhookID, event, eventID, payload string) *models.WebhookDelivery {
+	now := time.Now()
+	return &models.WebhookDelivery{
+		ID:            uuid.New().String(),
+		WebhookID:     webhookID,
+		Event:         event,
+		EventID:       eventID,
+		Payload:       payload,
+		Status:        models.WebhookDeliveryPending,
+		NextAttemptAt: &now,
+	}
+}
+
+// validateWebhookRequest checks the URL and returns the deduplicated event filter
+func validateWebhookRequest(req *WebhookRequest) ([]string, error) {
+	parsed, err := url.Parse(strings.TrimSpace(req.URL))
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return nil, fmt.Errorf("%w: url must be an absolute http(s) URL", ErrInvalidWebhook)
+	}
+	if len(req.Events) == 0 {
+		return nil, fmt.Errorf("%w: at least one event is required (or \"*\" for all)", ErrInvalidWebhook)
+	}
+
+	known := map[string]bool{models.WebhookEventAll: true}
+	for _, event := range models.WebhookEvents {
+		known[event] = true
+	}
+	seen := make(map[string]bool, len(req.Events))
+	events := make([]string, 0, len(req.Events))
+	for _, event := range req.Events {
+		event = strings.TrimSpace(event)
+		if !known[event] {
+			return nil, fmt.Errorf("%w: unknown event '%s' (expected one of %s or \"*\")", ErrInvalidWebhook, event, strings.Join(models.WebhookEvents, ", "))
+		}
+		if !seen[event] {
+			seen[event] = true
+			events = append(events, event)
+		}
+	}
+	return events, nil
+}
+
+// generateWebhookSecret returns a random signing secret
+func generateWebhookSecret() (string, error) {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return "", fmt.Errorf("failed to generate webhook secret: %w", err)
+	}
+	return "whsec_" + hex.EncodeToString(key), nil
+}
@@ -2,6 +2,7 @@ package storage
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"time"
@@ -71,6 +72,18 @@ func (g *GCSClient) ReadFile(ctx context.Context, objectName string) (io.ReadClo
 	return obj.NewReader(ctx)
 }
 
+// FileExists checks an object's metadata without downloading it
+func (g *GCSClient) FileExists(ctx context.Context, objectName string) (bool, error) {
+	_, err := g.client.Bucket(g.bucketName).Object(objectName).Attrs(ctx)
+	if errors.Is(err, storage.ErrObjectNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
 func (g *GCSClient) GetSignedURL(objectName string, expiry time.Duration) (string, error) {
 	opts := &storage.SignedURLOptions{
 		Scheme:  storage.SigningSchemeV4,
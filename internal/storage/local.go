@@ -123,6 +123,21 @@ func (l *LocalStorageClient) ReadFile(ctx context.Context, objectName string) (i
 	return file, nil
 }
 
+// FileExists checks whether a file exists on the local filesystem
+func (l *LocalStorageClient) FileExists(ctx context.Context, objectName string) (bool, error) {
+	fullPath := filepath.Join(l.basePath, objectName)
+
+	info, err := os.Stat(fullPath)
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to stat file %s: %w", fullPath, err)
+	}
+
+	return !info.IsDir(), nil
+}
+
 // GetSignedURL generates a signed URL for temporary access
 // For local storage, we generate a URL with an expiration timestamp and signature
 func (l *LocalStorageClient) GetSignedURL(objectName string, expiry time.Duration) (string, error) {
@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"io"
 	"time"
+
+	"github.com/google/uuid"
 )
 
 // StorageClient is the interface for file storage operations
@@ -13,6 +15,7 @@ type StorageClient interface {
 	UploadFile(ctx context.Context, reader io.Reader, objectName, contentType string) (*UploadResult, error)
 	DeleteFile(ctx context.Context, objectName string) error
 	ReadFile(ctx context.Context, objectName string) (io.ReadCloser, error)
+	FileExists(ctx context.Context, objectName string) (bool, error)
 	GetSignedURL(objectName string, expiry time.Duration) (string, error)
 	Close() error
 }
@@ -25,18 +28,26 @@ type UploadResult struct {
 }
 
 // Helper functions for generating object names
+// Names get a random suffix after the timestamp, so a file replaced within the same second
+// never reuses (and then deletes) the name of the file it replaces
 func GenerateObjectName(templateID, filename string) string {
-	timestamp := time.Now().Unix()
-	return fmt.Sprintf("templates/%s/%d_%s", templateID, timestamp, filename)
+	return fmt.Sprintf("templates/%s/%s_%s", templateID, uniquePrefix(), filename)
 }
 
 func GenerateDocumentObjectName(documentID, filename string) string {
-	timestamp := time.Now().Unix()
-	return fmt.Sprintf("documents/%s/%d_%s", documentID, timestamp, filename)
+	return fmt.Sprintf("documents/%s/%s_%s", documentID, uniquePrefix(), filename)
+}
+
+func GenerateBatchObjectName(jobID, filename string) string {
+	return fmt.Sprintf("batches/%s/%s_%s", jobID, uniquePrefix(), filename)
 }
 
 func GenerateDocumentPDFObjectName(documentID, filename string) string {
-	timestamp := time.Now().Unix()
 	pdfFilename := filename[:len(filename)-5] + ".pdf" // Replace .docx with .pdf
-	return fmt.Sprintf("documents/%s/%d_%s", documentID, timestamp, pdfFilename)
+	return fmt.Sprintf("documents/%s/%s_%s", documentID, uniquePrefix(), pdfFilename)
+}
+
+// uniquePrefix returns "<unix timestamp>_<8 random hex digits>"
+func uniquePrefix() string {
+	return fmt.Sprintf("%d_%s", time.Now().Unix(), uuid.New().String()[:8])
 }
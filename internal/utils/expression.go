@@ -0,0 +1,896 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Computed field expressions
+//
+// An expression computes a field's value from other fields at process time. The language is
+// deliberately small: no assignment, loops or user functions, and only the functions below.
+//
+//	Fields:    m_first_name, {{$1}} (keys that are not identifiers go in braces)
+//	Literals:  "text", 'text', 12, 3.5, true, false
+//	Operators: + - * / % (numbers), & (join text), == != < <= > >=, && || !, ( )
+//	Functions: see expressionFunctions, e.g. age(dob), be_year(dob), thai_weekday(dob),
+//	           join(" ", m_first_name, m_last_name), sum(a, b, c), if(x > 0, "yes", "no")
+//
+// Field values are text. Blank values count as 0 in arithmetic, and functions of dates return
+// blank for a blank date, so computed fields stay blank until their inputs are filled.
+
+// ErrInvalidExpression is returned for expressions that do not parse
+var ErrInvalidExpression = errors.New("invalid expression")
+
+const (
+	maxExpressionLength = 1000
+	maxExpressionDepth  = 32
+	maxExpressionText   = 1 << 20 // Longest text built by & concat and join, in bytes
+)
+
+// Expression is a parsed computed field expression
+type Expression struct {
+	source string
+	root   exprNode
+	fields []string
+}
+
+// ParseExpression parses and checks an expression: syntax, function names and argument counts
+func ParseExpression(source string) (*Expression, error) {
+	if strings.TrimSpace(source) == "" {
+		return nil, fmt.Errorf("%w: expression is empty", ErrInvalidExpression)
+	}
+	if len(source) > maxExpressionLength {
+		return nil, fmt.Errorf("%w: expression is longer than %d characters", ErrInvalidExpression, maxExpressionLength)
+	}
+
+	tokens, err := tokenizeExpression(source)
+	if err != nil {
+		return nil, err
+	}
+	p := &exprParser{tokens: tokens, fields: make(map[string]bool)}
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind != tokenEOF {
+		return nil, p.errorAt(tok, "unexpected %s", tok)
+	}
+
+	fields := make([]string, 0, len(p.fields))
+	for field := range p.fields {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	return &Expression{source: source, root: root, fields: fields}, nil
+}
+
+// String returns the expression source
+func (e *Expression) String() string {
+	return e.source
+}
+
+// Fields returns the keys of the fields the expression reads, sorted
+func (e *Expression) Fields() []string {
+	return e.fields
+}
+
+// Evaluate computes the expression from field values (keyed without braces) at time now
+func (e *Expression) Evaluate(values map[string]string, now time.Time) (string, error) {
+	result, err := e.root.eval(&exprEnv{values: values, now: now})
+	if err != nil {
+		return "", err
+	}
+	return exprString(result), nil
+}
+
+// ========== Tokens ==========
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenNumber
+	tokenString
+	tokenIdent
+	tokenField // {{key}}
+	tokenOperator
+	tokenLeftParen
+	tokenRightParen
+	tokenComma
+)
+
+type exprToken struct {
+	kind  tokenKind
+	text  string
+	value string // Unquoted string or field key
+	pos   int    // Byte offset in the source
+}
+
+func (t exprToken) String() string {
+	if t.kind == tokenEOF {
+		return "end of expression"
+	}
+	return fmt.Sprintf("'%s'", t.text)
+}
+
+var expressionOperators = []string{"&&", "||", "==", "!=", "<=", ">=", "+", "-", "*", "/", "%", "&", "<", ">", "!"}
+
+func tokenizeExpression(source string) ([]exprToken, error) {
+	var tokens []exprToken
+	for pos := 0; pos < len(source); {
+		r, size := utf8.DecodeRuneInString(source[pos:])
+		rest := source[pos:]
+		switch {
+		case unicode.IsSpace(r):
+			pos += size
+			continue
+		case r == '(':
+			tokens = append(tokens, exprToken{kind: tokenLeftParen, text: "(", pos: pos})
+			pos++
+			continue
+		case r == ')':
+			tokens = append(tokens, exprToken{kind: tokenRightParen, text: ")", pos: pos})
+			pos++
+			continue
+		case r == ',':
+			tokens = append(tokens, exprToken{kind: tokenComma, text: ",", pos: pos})
+			pos++
+			continue
+		case strings.HasPrefix(rest, "{{"):
+			end := strings.Index(rest, "}}")
+			if end < 0 {
+				return nil, fmt.Errorf("%w: unclosed '{{' at position %d", ErrInvalidExpression, pos)
+			}
+			key := strings.TrimSpace(rest[2:end])
+			if key == "" {
+				return nil, fmt.Errorf("%w: empty field reference at position %d", ErrInvalidExpression, pos)
+			}
+			tokens = append(tokens, exprToken{kind: tokenField, text: rest[:end+2], value: key, pos: pos})
+			pos += end + 2
+			continue
+		case r == '"' || r == '\'':
+			end := strings.IndexRune(rest[1:], r)
+			if end < 0 {
+				return nil, fmt.Errorf("%w: unclosed string at position %d", ErrInvalidExpression, pos)
+			}
+			tokens = append(tokens, exprToken{kind: tokenString, text: rest[:end+2], value: rest[1 : end+1], pos: pos})
+			pos += end + 2
+			continue
+		case r >= '0' && r <= '9' || r == '.':
+			end := 0
+			for end < len(rest) && (rest[end] >= '0' && rest[end] <= '9' || rest[end] == '.') {
+				end++
+			}
+			if _, err := strconv.ParseFloat(rest[:end], 64); err != nil {
+				return nil, fmt.Errorf("%w: invalid number '%s' at position %d", ErrInvalidExpression, rest[:end], pos)
+			}
+			tokens = append(tokens, exprToken{kind: tokenNumber, text: rest[:end], pos: pos})
+			pos += end
+			continue
+		case r == '_' || unicode.IsLetter(r):
+			end := 0
+			for end < len(rest) {
+				c, n := utf8.DecodeRuneInString(rest[end:])
+				if c != '_' && !unicode.IsLetter(c) && !unicode.IsDigit(c) && !unicode.Is(unicode.Mn, c) {
+					break
+				}
+				end += n
+			}
+			tokens = append(tokens, exprToken{kind: tokenIdent, text: rest[:end], value: rest[:end], pos: pos})
+			pos += end
+			continue
+		}
+
+		matched := false
+		for _, op := range expressionOperators {
+			if strings.HasPrefix(rest, op) {
+				tokens = append(tokens, exprToken{kind: tokenOperator, text: op, pos: pos})
+				pos += len(op)
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return nil, fmt.Errorf("%w: unexpected character '%c' at position %d", ErrInvalidExpression, r, pos)
+		}
+	}
+	return append(tokens, exprToken{kind: tokenEOF, pos: len(source)}), nil
+}
+
+// ========== Parser ==========
+
+type exprParser struct {
+	tokens []exprToken
+	pos    int
+	depth  int
+	fields map[string]bool
+}
+
+func (p *exprParser) peek() exprToken {
+	return p.tokens[p.pos]
+}
+
+func (p *exprParser) next() exprToken {
+	tok := p.tokens[p.pos]
+	if tok.kind != tokenEOF {
+		p.pos++
+	}
+	return tok
+}
+
+func (p *exprParser) acceptOperator(ops ...string) (string, bool) {
+	tok := p.peek()
+	if tok.kind != tokenOperator {
+		return "", false
+	}
+	for _, op := range ops {
+		if tok.text == op {
+			p.pos++
+			return op, true
+		}
+	}
+	return "", false
+}
+
+func (p *exprParser) errorAt(tok exprToken, format string, args ...interface{}) error {
+	return fmt.Errorf("%w: %s at position %d", ErrInvalidExpression, fmt.Sprintf(format, args...), tok.pos)
+}
+
+// parseBinary parses left-associative operators of one precedence level
+func (p *exprParser) parseBinary(operand func() (exprNode, error), ops ...string) (exprNode, error) {
+	left, err := operand()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		op, ok := p.acceptOperator(ops...)
+		if !ok {
+			return left, nil
+		}
+		right, err := operand()
+		if err != nil {
+			return nil, err
+		}
+		left = &binaryNode{op: op, left: left, right: right}
+	}
+}
+
+func (p *exprParser) parseOr() (exprNode, error) {
+	p.depth++
+	defer func() { p.depth-- }()
+	if p.depth > maxExpressionDepth {
+		return nil, p.errorAt(p.peek(), "expression is nested too deeply")
+	}
+	return p.parseBinary(p.parseAnd, "||")
+}
+
+func (p *exprParser) parseAnd() (exprNode, error) {
+	return p.parseBinary(p.parseComparison, "&&")
+}
+
+func (p *exprParser) parseComparison() (exprNode, error) {
+	left, err := p.parseConcat()
+	if err != nil {
+		return nil, err
+	}
+	if op, ok := p.acceptOperator("==", "!=", "<=", ">=", "<", ">"); ok {
+		right, err := p.parseConcat()
+		if err != nil {
+			return nil, err
+		}
+		return &binaryNode{op: op, left: left, right: right}, nil
+	}
+	return left, nil
+}
+
+func (p *exprParser) parseConcat() (exprNode, error) {
+	return p.parseBinary(p.parseAdditive, "&")
+}
+
+func (p *exprParser) parseAdditive() (exprNode, error) {
+	return p.parseBinary(p.parseMultiplicative, "+", "-")
+}
+
+func (p *exprParser) parseMultiplicative() (exprNode, error) {
+	return p.parseBinary(p.parseUnary, "*", "/", "%")
+}
+
+func (p *exprParser) parseUnary() (exprNode, error) {
+	if op, ok := p.acceptOperator("-", "!"); ok {
+		p.depth++
+		defer func() { p.depth-- }()
+		if p.depth > maxExpressionDepth {
+			return nil, p.errorAt(p.peek(), "expression is nested too deeply")
+		}
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &unaryNode{op: op, operand: operand}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *exprParser) parsePrimary() (exprNode, error) {
+	tok := p.next()
+	switch tok.kind {
+	case tokenNumber:
+		number, _ := strconv.ParseFloat(tok.text, 64)
+		return &literalNode{value: number}, nil
+	case tokenString:
+		return &literalNode{value: tok.value}, nil
+	case tokenField:
+		p.fields[tok.value] = true
+		return &fieldNode{key: tok.value}, nil
+	case tokenLeftParen:
+		inner, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if closing := p.next(); closing.kind != tokenRightParen {
+			return nil, p.errorAt(closing, "expected ')' but found %s", closing)
+		}
+		return inner, nil
+	case tokenIdent:
+		if p.peek().kind == tokenLeftParen {
+			return p.parseCall(tok)
+		}
+		switch tok.text {
+		case "true":
+			return &literalNode{value: true}, nil
+		case "false":
+			return &literalNode{value: false}, nil
+		}
+		p.fields[tok.value] = true
+		return &fieldNode{key: tok.value}, nil
+	}
+	return nil, p.errorAt(tok, "unexpected %s", tok)
+}
+
+func (p *exprParser) parseCall(name exprToken) (exprNode, error) {
+	function, ok := expressionFunctions[strings.ToLower(name.text)]
+	if !ok {
+		return nil, p.errorAt(name, "unknown function '%s'", name.text)
+	}
+	p.next() // (
+
+	var args []exprNode
+	if p.peek().kind == tokenRightParen {
+		p.next()
+	} else {
+		for {
+			arg, err := p.parseOr()
+			if err != nil {
+				return nil, err
+			}
+			args = append(args, arg)
+			tok := p.next()
+			if tok.kind == tokenRightParen {
+				break
+			}
+			if tok.kind != tokenComma {
+				return nil, p.errorAt(tok, "expected ',' or ')' but found %s", tok)
+			}
+		}
+	}
+
+	if len(args) < function.minArgs || (function.maxArgs >= 0 && len(args) > function.maxArgs) {
+		return nil, p.errorAt(name, "%s() takes %s", strings.ToLower(name.text), function.arity())
+	}
+	return &callNode{name: strings.ToLower(name.text), function: function, args: args}, nil
+}
+
+// ========== Evaluation ==========
+
+type exprEnv struct {
+	values map[string]string
+	now    time.Time
+}
+
+// exprCheckText fails when text being built would exceed maxExpressionText bytes,
+// so that long concatenation chains over large values stay cheap
+func exprCheckText(length int) error {
+	if length > maxExpressionText {
+		return fmt.Errorf("text result is longer than %d bytes", maxExpressionText)
+	}
+	return nil
+}
+
+type exprNode interface {
+	eval(env *exprEnv) (interface{}, error)
+}
+
+type literalNode struct {
+	value interface{} // string, float64 or bool
+}
+
+func (n *literalNode) eval(env *exprEnv) (interface{}, error) {
+	return n.value, nil
+}
+
+type fieldNode struct {
+	key string
+}
+
+func (n *fieldNode) eval(env *exprEnv) (interface{}, error) {
+	return env.values[n.key], nil
+}
+
+type unaryNode struct {
+	op      string
+	operand exprNode
+}
+
+func (n *unaryNode) eval(env *exprEnv) (interface{}, error) {
+	value, err := n.operand.eval(env)
+	if err != nil {
+		return nil, err
+	}
+	if n.op == "!" {
+		return !exprBool(value), nil
+	}
+	number, err := exprNumber(value)
+	if err != nil {
+		return nil, err
+	}
+	return -number, nil
+}
+
+type binaryNode struct {
+	op          string
+	left, right exprNode
+}
+
+func (n *binaryNode) eval(env *exprEnv) (interface{}, error) {
+	left, err := n.left.eval(env)
+	if err != nil {
+		return nil, err
+	}
+
+	// && and || only evaluate the right side when needed
+	switch n.op {
+	case "&&":
+		if !exprBool(left) {
+			return false, nil
+		}
+	case "||":
+		if exprBool(left) {
+			return true, nil
+		}
+	}
+
+	right, err := n.right.eval(env)
+	if err != nil {
+		return nil, err
+	}
+
+	switch n.op {
+	case "&&", "||":
+		return exprBool(right), nil
+	case "&":
+		a, b := exprString(left), exprString(right)
+		if err := exprCheckText(len(a) + len(b)); err != nil {
+			return nil, err
+		}
+		return a + b, nil
+	case "==", "!=", "<", "<=", ">", ">=":
+		return exprCompare(n.op, left, right), nil
+	}
+
+	a, err := exprNumber(left)
+	if err != nil {
+		return nil, err
+	}
+	b, err := exprNumber(right)
+	if err != nil {
+		return nil, err
+	}
+	switch n.op {
+	case "+":
+		return a + b, nil
+	case "-":
+		return a - b, nil
+	case "*":
+		return a * b, nil
+	case "/":
+		if b == 0 {
+			return nil, errors.New("division by zero")
+		}
+		return a / b, nil
+	default: // %
+		if b == 0 {
+			return nil, errors.New("division by zero")
+		}
+		return math.Mod(a, b), nil
+	}
+}
+
+type callNode struct {
+	name     string
+	function expressionFunction
+	args     []exprNode
+}
+
+func (n *callNode) eval(env *exprEnv) (interface{}, error) {
+	// if() only evaluates the chosen branch
+	if n.name == "if" {
+		condition, err := n.args[0].eval(env)
+		if err != nil {
+			return nil, err
+		}
+		if exprBool(condition) {
+			return n.args[1].eval(env)
+		}
+		if len(n.args) > 2 {
+			return n.args[2].eval(env)
+		}
+		return "", nil
+	}
+
+	args := make([]interface{}, len(n.args))
+	for i, arg := range n.args {
+		value, err := arg.eval(env)
+		if err != nil {
+			return nil, err
+		}
+		args[i] = value
+	}
+	result, err := n.function.call(args, env)
+	if err != nil {
+		return nil, fmt.Errorf("%s(): %w", n.name, err)
+	}
+	return result, nil
+}
+
+// exprString converts a value to text; whole numbers have no decimals
+func exprString(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	}
+	return ""
+}
+
+// exprNumber converts a value to a number; blank text is 0
+func exprNumber(value interface{}) (float64, error) {
+	switch v := value.(type) {
+	case float64:
+		return v, nil
+	case bool:
+		if v {
+			return 1, nil
+		}
+		return 0, nil
+	case string:
+		if strings.TrimSpace(v) == "" {
+			return 0, nil
+		}
+		if number, ok := ParseNumber(v); ok {
+			return number, nil
+		}
+		return 0, fmt.Errorf("'%s' is not a number", v)
+	}
+	return 0, nil
+}
+
+// exprBool converts a value to true or false; blank text, "false" and "0" are false
+func exprBool(value interface{}) bool {
+	switch v := value.(type) {
+	case bool:
+		return v
+	case float64:
+		return v != 0
+	case string:
+		v = strings.TrimSpace(v)
+		return v != "" && v != "0" && !strings.EqualFold(v, "false")
+	}
+	return false
+}
+
+// exprCompare compares numerically when both sides are numbers, otherwise as text
+func exprCompare(op string, left, right interface{}) bool {
+	cmp := 0
+	a, errA := exprNumber(left)
+	b, errB := exprNumber(right)
+	if errA == nil && errB == nil && exprString(left) != "" && exprString(right) != "" {
+		if a < b {
+			cmp = -1
+		} else if a > b {
+			cmp = 1
+		}
+	} else {
+		cmp = strings.Compare(exprString(left), exprString(right))
+	}
+
+	switch op {
+	case "==":
+		return cmp == 0
+	case "!=":
+		return cmp != 0
+	case "<":
+		return cmp < 0
+	case "<=":
+		return cmp <= 0
+	case ">":
+		return cmp > 0
+	default:
+		return cmp >= 0
+	}
+}
+
+// ========== Functions ==========
+
+type expressionFunction struct {
+	minArgs     int
+	maxArgs     int // -1 for any number
+	description string
+	call        func(args []interface{}, env *exprEnv) (interface{}, error)
+}
+
+func (f expressionFunction) arity() string {
+	switch {
+	case f.maxArgs < 0:
+		return fmt.Sprintf("at least %d arguments", f.minArgs)
+	case f.minArgs == f.maxArgs:
+		return fmt.Sprintf("%d arguments", f.minArgs)
+	default:
+		return fmt.Sprintf("%d to %d arguments", f.minArgs, f.maxArgs)
+	}
+}
+
+// ExpressionFunctions returns the names and descriptions of the functions expressions can call
+func ExpressionFunctions() map[string]string {
+	functions := make(map[string]string, len(expressionFunctions))
+	for name, function := range expressionFunctions {
+		functions[name] = function.description
+	}
+	return functions
+}
+
+// expressionFunctions are the functions expressions can call; if() is evaluated by callNode
+var expressionFunctions = map[string]expressionFunction{
+	"if": {2, 3, "if(condition, then, else): then when the condition holds, otherwise else (blank if omitted)", nil},
+	"coalesce": {1, -1, "coalesce(a, b, ...): the first value that is not blank", func(args []interface{}, env *exprEnv) (interface{}, error) {
+		for _, arg := range args {
+			if strings.TrimSpace(exprString(arg)) != "" {
+				return arg, nil
+			}
+		}
+		return "", nil
+	}},
+	"concat": {1, -1, "concat(a, b, ...): the values joined without a separator", func(args []interface{}, env *exprEnv) (interface{}, error) {
+		var b strings.Builder
+		for _, arg := range args {
+			text := exprString(arg)
+			if err := exprCheckText(b.Len() + len(text)); err != nil {
+				return nil, err
+			}
+			b.WriteString(text)
+		}
+		return b.String(), nil
+	}},
+	"join": {2, -1, "join(separator, a, b, ...): the values that are not blank, joined by separator", func(args []interface{}, env *exprEnv) (interface{}, error) {
+		var parts []string
+		separator := exprString(args[0])
+		length := 0
+		for _, arg := range args[1:] {
+			if part := strings.TrimSpace(exprString(arg)); part != "" {
+				length += len(part) + len(separator)
+				if err := exprCheckText(length); err != nil {
+					return nil, err
+				}
+				parts = append(parts, part)
+			}
+		}
+		return strings.Join(parts, separator), nil
+	}},
+	"trim": {1, 1, "trim(text): text without surrounding spaces", func(args []interface{}, env *exprEnv) (interface{}, error) {
+		return strings.TrimSpace(exprString(args[0])), nil
+	}},
+	"upper": {1, 1, "upper(text): text in upper case", func(args []interface{}, env *exprEnv) (interface{}, error) {
+		return strings.ToUpper(exprString(args[0])), nil
+	}},
+	"lower": {1, 1, "lower(text): text in lower case", func(args []interface{}, env *exprEnv) (interface{}, error) {
+		return strings.ToLower(exprString(args[0])), nil
+	}},
+	"thai_digits": {1, 1, "thai_digits(text): text with ASCII digits written as Thai digits", func(args []interface{}, env *exprEnv) (interface{}, error) {
+		return thaiDigitWriter.Replace(exprString(args[0])), nil
+	}},
+	"sum": {1, -1, "sum(a, b, ...): the total of the values that are not blank (blank if all are)", func(args []interface{}, env *exprEnv) (interface{}, error) {
+		return exprAggregate(args, func(total, number float64) float64 { return total + number })
+	}},
+	"min": {1, -1, "min(a, b, ...): the smallest value that is not blank", func(args []interface{}, env *exprEnv) (interface{}, error) {
+		return exprAggregate(args, math.Min)
+	}},
+	"max": {1, -1, "max(a, b, ...): the largest value that is not blank", func(args []interface{}, env *exprEnv) (interface{}, error) {
+		return exprAggregate(args, math.Max)
+	}},
+	"round": {1, 2, "round(number, digits): number rounded to digits decimals (default 0)", func(args []interface{}, env *exprEnv) (interface{}, error) {
+		if exprString(args[0]) == "" {
+			return "", nil
+		}
+		number, err := exprNumber(args[0])
+		if err != nil {
+			return nil, err
+		}
+		scale := 1.0
+		if len(args) > 1 {
+			digits, err := exprNumber(args[1])
+			if err != nil {
+				return nil, err
+			}
+			scale = math.Pow(10, math.Round(digits))
+		}
+		return math.Round(number*scale) / scale, nil
+	}},
+	"format_number": {1, 2, "format_number(number, decimals): number with thousands separators, e.g. 1,234.50", func(args []interface{}, env *exprEnv) (interface{}, error) {
+		if exprString(args[0]) == "" {
+			return "", nil
+		}
+		number, err := exprNumber(args[0])
+		if err != nil {
+			return nil, err
+		}
+		decimals := 0.0
+		if len(args) > 1 {
+			if decimals, err = exprNumber(args[1]); err != nil {
+				return nil, err
+			}
+		}
+		return formatThousands(strconv.FormatFloat(number, 'f', int(math.Max(0, math.Min(10, decimals))), 64)), nil
+	}},
+	"today": {0, 0, "today(): the processing date (YYYY-MM-DD)", func(args []interface{}, env *exprEnv) (interface{}, error) {
+		return env.now.Format("2006-01-02"), nil
+	}},
+	"age": {1, 2, "age(date, on): whole years from date until on (default today)", func(args []interface{}, env *exprEnv) (interface{}, error) {
+		from, ok, err := exprDate(args[0])
+		if !ok {
+			return "", err
+		}
+		on := time.Date(env.now.Year(), env.now.Month(), env.now.Day(), 0, 0, 0, 0, time.UTC)
+		if len(args) > 1 {
+			if on, ok, err = exprDate(args[1]); !ok {
+				return "", err
+			}
+		}
+		years := on.Year() - from.Year()
+		if on.Month() < from.Month() || (on.Month() == from.Month() && on.Day() < from.Day()) {
+			years--
+		}
+		return float64(years), nil
+	}},
+	"year":       {1, 1, "year(date): the Gregorian year", exprDateFunction(func(date time.Time) interface{} { return float64(date.Year()) })},
+	"be_year":    {1, 1, "be_year(date): the Buddhist Era year (Gregorian + 543)", exprDateFunction(func(date time.Time) interface{} { return float64(date.Year() + 543) })},
+	"month":      {1, 1, "month(date): the month number (1-12)", exprDateFunction(func(date time.Time) interface{} { return float64(date.Month()) })},
+	"day":        {1, 1, "day(date): the day of the month", exprDateFunction(func(date time.Time) interface{} { return float64(date.Day()) })},
+	"thai_month": {1, 1, "thai_month(date): the Thai month name, e.g. ตุลาคม", exprDateFunction(func(date time.Time) interface{} { return thaiMonthNames[date.Month()-1] })},
+	"thai_weekday": {1, 1, "thai_weekday(date): the Thai weekday, e.g. วันจันทร์", exprDateFunction(func(date time.Time) interface{} {
+		// WeekdayOptions starts on Monday
+		return WeekdayOptions[(int(date.Weekday())+6)%7]
+	})},
+	"thai_date": {1, 1, "thai_date(date): the Thai long date in the Buddhist Era, e.g. 18 ตุลาคม 2569", exprDateFunction(func(date time.Time) interface{} {
+		return fmt.Sprintf("%d %s %d", date.Day(), thaiMonthNames[date.Month()-1], date.Year()+543)
+	})},
+	"iso_date": {1, 1, "iso_date(date): the date as YYYY-MM-DD (Gregorian)", exprDateFunction(func(date time.Time) interface{} { return date.Format("2006-01-02") })},
+}
+
+// thaiDigitWriter writes ASCII digits as Thai digits (the reverse of NormalizeDigits)
+var thaiDigitWriter = strings.NewReplacer("0", "๐", "1", "๑", "2", "๒", "3", "๓", "4", "๔", "5", "๕", "6", "๖", "7", "๗", "8", "๘", "9", "๙")
+
+// exprDate parses a date value; ok is false for blank values and for errors
+func exprDate(value interface{}) (time.Time, bool, error) {
+	text := strings.TrimSpace(exprString(value))
+	if text == "" {
+		return time.Time{}, false, nil
+	}
+	date, ok := ParseDate(text)
+	if !ok {
+		return time.Time{}, false, fmt.Errorf("'%s' is not a date", text)
+	}
+	return date, true, nil
+}
+
+// exprDateFunction makes a one-argument date function that is blank for a blank date
+func exprDateFunction(fn func(date time.Time) interface{}) func(args []interface{}, env *exprEnv) (interface{}, error) {
+	return func(args []interface{}, env *exprEnv) (interface{}, error) {
+		date, ok, err := exprDate(args[0])
+		if !ok {
+			return "", err
+		}
+		return fn(date), nil
+	}
+}
+
+// exprAggregate folds the values that are not blank; blank if all are
+func exprAggregate(args []interface{}, fold func(acc, number float64) float64) (interface{}, error) {
+	var result interface{} = ""
+	for _, arg := range args {
+		if strings.TrimSpace(exprString(arg)) == "" {
+			continue
+		}
+		number, err := exprNumber(arg)
+		if err != nil {
+			return nil, err
+		}
+		if acc, ok := result.(float64); ok {
+			result = fold(acc, number)
+		} else {
+			result = number
+		}
+	}
+	return result, nil
+}
+
+// formatThousands inserts commas into the integer part of a formatted number
+func formatThousands(number string) string {
+	sign := ""
+	if strings.HasPrefix(number, "-") {
+		sign, number = "-", number[1:]
+	}
+	integer, fraction := number, ""
+	if dot := strings.IndexByte(number, '.'); dot >= 0 {
+		integer, fraction = number[:dot], number[dot:]
+	}
+	var b strings.Builder
+	for i, digit := range integer {
+		if i > 0 && (len(integer)-i)%3 == 0 {
+			b.WriteByte(',')
+		}
+		b.WriteRune(digit)
+	}
+	return sign + b.String() + fraction
+}
+
+// RenameExpressionFields rewrites the field references of an expression (renames maps old keys
+// to new keys). Expressions that do not parse are returned unchanged
+func RenameExpressionFields(source string, renames map[string]string) string {
+	tokens, err := tokenizeExpression(source)
+	if err != nil {
+		return source
+	}
+
+	var b strings.Builder
+	last := 0
+	for i, tok := range tokens {
+		var key string
+		switch {
+		case tok.kind == tokenField:
+			key = tok.value
+		case tok.kind == tokenIdent && tokens[i+1].kind != tokenLeftParen && tok.text != "true" && tok.text != "false":
+			key = tok.value
+		default:
+			continue
+		}
+		newKey, ok := renames[key]
+		if !ok {
+			continue
+		}
+		b.WriteString(source[last:tok.pos])
+		if isExpressionIdentifier(newKey) {
+			b.WriteString(newKey)
+		} else {
+			b.WriteString("{{" + newKey + "}}")
+		}
+		last = tok.pos + len(tok.text)
+	}
+	b.WriteString(source[last:])
+	return b.String()
+}
+
+// isExpressionIdentifier reports whether a field key can be written without braces
+func isExpressionIdentifier(key string) bool {
+	tokens, err := tokenizeExpression(key)
+	return err == nil && len(tokens) == 2 && tokens[0].kind == tokenIdent && tokens[0].text == key && key != "true" && key != "false"
+}
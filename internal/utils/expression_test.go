@@ -0,0 +1,106 @@
+package utils
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseExpressionRejectsMalformedInput(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+	}{
+		{"empty", ""},
+		{"blank", "   "},
+		{"too long", strings.Repeat("a + ", maxExpressionLength/4) + "a"},
+		{"unterminated string", `"abc`},
+		{"unterminated braced field", "{{name"},
+		{"missing operand", "1 +"},
+		{"unclosed parenthesis", "(1 + 2"},
+		{"stray closing parenthesis", "1 + 2)"},
+		{"unknown function", "nope(1)"},
+		{"too few arguments", "join(\" \")"},
+		{"too many arguments", "trim(a, b)"},
+		{"dangling comma", "sum(1, )"},
+		{"nested parentheses", strings.Repeat("(", maxExpressionDepth+1) + "1" + strings.Repeat(")", maxExpressionDepth+1)},
+		{"nested calls", strings.Repeat("trim(", maxExpressionDepth+1) + "a" + strings.Repeat(")", maxExpressionDepth+1)},
+		{"chained unary operators", strings.Repeat("-", maxExpressionDepth+1) + "1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ParseExpression(tt.source); !errors.Is(err, ErrInvalidExpression) {
+				t.Fatalf("ParseExpression(%q) error = %v, want ErrInvalidExpression", tt.source, err)
+			}
+		})
+	}
+}
+
+func TestParseExpressionAcceptsNestingUpToTheLimit(t *testing.T) {
+	depth := maxExpressionDepth - 1
+	source := strings.Repeat("(", depth) + "1" + strings.Repeat(")", depth)
+	expr, err := ParseExpression(source)
+	if err != nil {
+		t.Fatalf("ParseExpression: %v", err)
+	}
+	if got, err := expr.Evaluate(nil, time.Now()); err != nil || got != "1" {
+		t.Fatalf("Evaluate = %q, %v; want \"1\"", got, err)
+	}
+}
+
+func TestExpressionEvaluate(t *testing.T) {
+	now := time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC)
+	values := map[string]string{
+		"first": "Somchai",
+		"last":  "Jaidee",
+		"dob":   "1990-10-19",
+		"qty":   "3",
+		"price": "12.5",
+		"$1":    "x",
+	}
+
+	tests := []struct {
+		source string
+		want   string
+	}{
+		{`join(" ", first, last)`, "Somchai Jaidee"},
+		{`first & "-" & {{$1}}`, "Somchai-x"},
+		{"qty * price", "37.5"},
+		{"blank + 1", "1"},
+		{"age(dob)", "35"},
+		{"be_year(dob)", "2533"},
+		{`if(qty > 2, "many", "few")`, "many"},
+		{"round(10 / 3, 2)", "3.33"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.source, func(t *testing.T) {
+			expr, err := ParseExpression(tt.source)
+			if err != nil {
+				t.Fatalf("ParseExpression: %v", err)
+			}
+			got, err := expr.Evaluate(values, now)
+			if err != nil {
+				t.Fatalf("Evaluate: %v", err)
+			}
+			if got != tt.want {
+				t.Fatalf("Evaluate = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExpressionEvaluateCapsTextLength(t *testing.T) {
+	values := map[string]string{"big": strings.Repeat("x", maxExpressionText/2+1)}
+	for _, source := range []string{"big & big", `concat(big, big)`, `join("", big, big)`} {
+		expr, err := ParseExpression(source)
+		if err != nil {
+			t.Fatalf("ParseExpression(%q): %v", source, err)
+		}
+		if _, err := expr.Evaluate(values, time.Now()); err == nil {
+			t.Fatalf("Evaluate(%q) succeeded, want a text length error", source)
+		}
+	}
+}
@@ -77,6 +77,23 @@ type RadioOption struct {
 	ChildFields []string `json:"childFields,omitempty"` // Child fields to show when this option is selected (e.g., ["$3_D"])
 }
 
+// FieldPosition places a field on an overlay template page
+// Coordinates are PDF points (1/72 inch) from the top-left corner of the page
+type FieldPosition struct {
+	Page      int     `json:"page"` // 1-based page number
+	X         float64 `json:"x"`
+	Y         float64 `json:"y"`
+	Width     float64 `json:"width,omitempty"`     // Box width; text is shrunk and aligned to fit
+	Height    float64 `json:"height,omitempty"`    // Box height; single lines are vertically centered
+	FontSize  float64 `json:"fontSize,omitempty"`  // Font size in points (default 14)
+	Align     string  `json:"align,omitempty"`     // left, center or right
+	Multiline bool    `json:"multiline,omitempty"` // Wrap text inside the box
+	Comb      int     `json:"comb,omitempty"`      // Number of character boxes (e.g., 13 for an ID number)
+	Kind      string  `json:"kind,omitempty"`      // text (default), check or image
+	Mark      string  `json:"mark,omitempty"`      // check (default), cross or dot, for check fields
+	Color     string  `json:"color,omitempty"`     // Text/mark color as #RRGGBB
+}
+
 // FieldDefinition represents the complete definition of a field
 type FieldDefinition struct {
 	Placeholder  string           `json:"placeholder"`
@@ -90,6 +107,7 @@ type FieldDefinition struct {
 	GroupOrder   int              `json:"groupOrder,omitempty"`   // Order within the group
 	Order        int              `json:"order"`                  // Global display order for the field (user can reorder)
 	DefaultValue string           `json:"defaultValue,omitempty"` // Default value for the field (e.g., "/" for checkbox)
+	Expression   string           `json:"expression,omitempty"`   // Computed at process time from other fields (see ParseExpression); not an input
 	// Merged field properties
 	IsMerged     bool             `json:"isMerged,omitempty"`     // Whether this is a merged field
 	MergedFields []string         `json:"mergedFields,omitempty"` // List of original placeholder keys that are merged
@@ -99,6 +117,16 @@ type FieldDefinition struct {
 	IsRadioGroup  bool          `json:"isRadioGroup,omitempty"`  // Whether this field is a radio group master
 	RadioGroupId  string        `json:"radioGroupId,omitempty"`  // Unique identifier for the radio group
 	RadioOptions  []RadioOption `json:"radioOptions,omitempty"`  // List of radio options with their placeholders
+	// Localized label/description keyed by language code (e.g., "en"); Label/Description are the Thai defaults
+	Translations map[string]FieldTranslation `json:"translations,omitempty"`
+	// Position on the page, for overlay templates
+	Position *FieldPosition `json:"position,omitempty"`
+}
+
+// FieldTranslation holds a field's label and description in another language
+type FieldTranslation struct {
+	Label       string `json:"label,omitempty"`
+	Description string `json:"description,omitempty"`
 }
 
 // Thai name prefixes
@@ -0,0 +1,57 @@
+package utils
+
+import (
+	"fmt"
+	"time"
+)
+
+// Thai month names used for sample dates
+var thaiMonthNames = []string{
+	"มกราคม", "กุมภาพันธ์", "มีนาคม", "เมษายน", "พฤษภาคม", "มิถุนายน",
+	"กรกฎาคม", "สิงหาคม", "กันยายน", "ตุลาคม", "พฤศจิกายน", "ธันวาคม",
+}
+
+// sampleValues are synthetic values per data type for filled previews
+// The ID number has a valid checksum so previews pass Thai ID validation
+var sampleValues = map[DataType]string{
+	DataTypeText:        "ตัวอย่าง",
+	DataTypeIDNumber:    "1234567890121",
+	DataTypeTime:        "09:30",
+	DataTypeNumber:      "25",
+	DataTypeAddress:     "99/1 หมู่ 2 ถนนราชดำเนินกลาง",
+	DataTypeProvince:    "กรุงเทพมหานคร",
+	DataTypeDistrict:    "เขตพระนคร",
+	DataTypeSubdistrict: "แขวงพระบรมมหาราชวัง",
+	DataTypeCountry:     "ไทย",
+	DataTypeNamePrefix:  "นาย",
+	DataTypeName:        "สมชาย ใจดี",
+	DataTypeWeekday:     "วันจันทร์",
+	DataTypePhone:       "0812345678",
+	DataTypeEmail:       "somchai@example.com",
+	DataTypeHouseCode:   "10010001234",
+	DataTypeZodiac:      "มะโรง (งูใหญ่)",
+	DataTypeLunarMonth:  "เดือนห้า",
+	DataTypeOfficerName: "นายทะเบียน ตัวอย่าง",
+}
+
+// SampleValue returns a value to show for a field in a filled preview
+// Uses the field's default value, then its first option, then a synthetic value for its data type
+func SampleValue(def FieldDefinition, now time.Time) string {
+	if def.DefaultValue != "" {
+		return def.DefaultValue
+	}
+	if def.InputType == InputTypeCheckbox {
+		return "/"
+	}
+	if def.Validation != nil && len(def.Validation.Options) > 0 {
+		return def.Validation.Options[0]
+	}
+	if def.DataType == DataTypeDate {
+		// Thai long date in the Buddhist calendar (e.g., "18 ตุลาคม 2569")
+		return fmt.Sprintf("%d %s %d", now.Day(), thaiMonthNames[now.Month()-1], now.Year()+543)
+	}
+	if value, ok := sampleValues[def.DataType]; ok {
+		return value
+	}
+	return sampleValues[DataTypeText]
+}
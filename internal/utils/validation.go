@@ -0,0 +1,164 @@
+package utils
+
+import (
+	"net/mail"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// thaiDigits maps Thai digits (๐-๙) to ASCII digits
+var thaiDigits = strings.NewReplacer("๐", "0", "๑", "1", "๒", "2", "๓", "3", "๔", "4", "๕", "5", "๖", "6", "๗", "7", "๘", "8", "๙", "9")
+
+// NormalizeDigits replaces Thai digits with ASCII digits
+func NormalizeDigits(value string) string {
+	return thaiDigits.Replace(value)
+}
+
+// ValidThaiID reports whether a Thai national ID has 13 digits and a valid checksum
+// Spaces and dashes between digit groups (e.g., "1-2345-67890-12-1") are ignored
+func ValidThaiID(id string) bool {
+	digits := strings.NewReplacer(" ", "", "-", "").Replace(NormalizeDigits(strings.TrimSpace(id)))
+	if len(digits) != 13 {
+		return false
+	}
+
+	sum := 0
+	for i := 0; i < 13; i++ {
+		if digits[i] < '0' || digits[i] > '9' {
+			return false
+		}
+		if i < 12 {
+			sum += int(digits[i]-'0') * (13 - i)
+		}
+	}
+	return (11-(sum%11))%10 == int(digits[12]-'0')
+}
+
+// ValidEmail reports whether value is a bare email address (no display name)
+func ValidEmail(value string) bool {
+	address, err := mail.ParseAddress(value)
+	return err == nil && address.Address == value && strings.Contains(address.Address[strings.LastIndex(address.Address, "@"):], ".")
+}
+
+var phoneSeparators = strings.NewReplacer(" ", "", "-", "", "(", "", ")", "", ".", "")
+
+// ValidThaiPhone reports whether value is a Thai landline or mobile number
+// Accepts 0XXXXXXXX(X) and +66XXXXXXXX(X), with spaces, dashes, dots or parentheses
+func ValidThaiPhone(value string) bool {
+	digits := phoneSeparators.Replace(NormalizeDigits(strings.TrimSpace(value)))
+	if strings.HasPrefix(digits, "+66") {
+		digits = "0" + strings.TrimPrefix(digits, "+66")
+	}
+	if len(digits) != 9 && len(digits) != 10 || digits[0] != '0' {
+		return false
+	}
+	for _, r := range digits {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// Thai month names and abbreviations accepted by ParseDate (thaiMonthNames is in sample_values.go)
+var thaiMonthAbbreviations = []string{
+	"ม.ค.", "ก.พ.", "มี.ค.", "เม.ย.", "พ.ค.", "มิ.ย.",
+	"ก.ค.", "ส.ค.", "ก.ย.", "ต.ค.", "พ.ย.", "ธ.ค.",
+}
+
+var (
+	numericDatePattern = regexp.MustCompile(`^(\d{1,4})[/.-](\d{1,2})[/.-](\d{1,4})$`)
+	wordDatePattern    = regexp.MustCompile(`^(\d{1,2})\s*(\S+?)\s*(\d{4})$`)
+)
+
+// ParseDate parses the date formats people type into Thai forms:
+// YYYY-MM-DD, DD/MM/YYYY (also with - or .), "18 ตุลาคม 2569", "18 ต.ค. 2569" and "18 October 2026"
+// Years of 2400 and later are Buddhist Era and converted to the Gregorian calendar
+func ParseDate(value string) (time.Time, bool) {
+	value = strings.TrimSpace(NormalizeDigits(value))
+	value = strings.TrimSpace(strings.TrimPrefix(value, "วันที่"))
+
+	var day, month, year int
+	if matches := numericDatePattern.FindStringSubmatch(value); matches != nil {
+		first, _ := strconv.Atoi(matches[1])
+		second, _ := strconv.Atoi(matches[2])
+		third, _ := strconv.Atoi(matches[3])
+		if len(matches[1]) == 4 {
+			year, month, day = first, second, third
+		} else if len(matches[3]) == 4 {
+			day, month, year = first, second, third
+		} else {
+			return time.Time{}, false
+		}
+	} else if matches := wordDatePattern.FindStringSubmatch(value); matches != nil {
+		day, _ = strconv.Atoi(matches[1])
+		year, _ = strconv.Atoi(matches[3])
+		month = parseMonthName(matches[2])
+	} else if parsed, err := time.Parse("January 2, 2006", value); err == nil {
+		return parsed, true
+	} else {
+		return time.Time{}, false
+	}
+
+	if year >= 2400 {
+		year -= 543
+	}
+	if month < 1 || month > 12 || day < 1 || year < 1 {
+		return time.Time{}, false
+	}
+	date := time.Date(year, time.Month(month), day, 0, 0, 0, 0, time.UTC)
+	if date.Day() != day || int(date.Month()) != month {
+		return time.Time{}, false // e.g., 31 February
+	}
+	return date, true
+}
+
+// parseMonthName returns the month (1-12) of a Thai or English month name or abbreviation, 0 if unknown
+func parseMonthName(name string) int {
+	for i := range thaiMonthNames {
+		if name == thaiMonthNames[i] || name == thaiMonthAbbreviations[i] || name == strings.TrimSuffix(thaiMonthAbbreviations[i], ".") {
+			return i + 1
+		}
+	}
+	lower := strings.ToLower(strings.TrimSuffix(name, "."))
+	for month := time.January; month <= time.December; month++ {
+		english := strings.ToLower(month.String())
+		if lower == english || (len(lower) >= 3 && strings.HasPrefix(english, lower)) {
+			return int(month)
+		}
+	}
+	return 0
+}
+
+var timePattern = regexp.MustCompile(`^(\d{1,2})[:.](\d{2})(?:[:.](\d{2}))?$`)
+
+// ValidTime reports whether value is a time of day such as "09:30", "9.30" or "09.30 น."
+func ValidTime(value string) bool {
+	value = strings.TrimSpace(NormalizeDigits(value))
+	value = strings.TrimSpace(strings.TrimSuffix(value, "น."))
+	matches := timePattern.FindStringSubmatch(value)
+	if matches == nil {
+		return false
+	}
+	hour, _ := strconv.Atoi(matches[1])
+	minute, _ := strconv.Atoi(matches[2])
+	second := 0
+	if matches[3] != "" {
+		second, _ = strconv.Atoi(matches[3])
+	}
+	return hour < 24 && minute < 60 && second < 60
+}
+
+var numberPattern = regexp.MustCompile(`^[+-]?(\d+\.?\d*|\.\d+)$`)
+
+// ParseNumber parses a number that may use thousands separators or Thai digits
+func ParseNumber(value string) (float64, bool) {
+	value = strings.ReplaceAll(strings.TrimSpace(NormalizeDigits(value)), ",", "")
+	if !numberPattern.MatchString(value) {
+		return 0, false
+	}
+	number, err := strconv.ParseFloat(value, 64)
+	return number, err == nil
+}